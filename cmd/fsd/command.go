@@ -0,0 +1,642 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/base"
+	"github.com/half-nothing/simple-fsd/internal/database"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/config"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/global"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+	"golang.org/x/term"
+)
+
+const (
+	commandIp        = "localhost"
+	commandUserAgent = "fsd-cli"
+)
+
+var errCommandUsage = errors.New("invalid arguments")
+
+// commandContext 管理命令的运行环境, 不启动任何服务器, 直接通过数据库操作接口修改数据
+type commandContext struct {
+	logger   log.LoggerInterface
+	config   *config.Config
+	database *operation.DatabaseOperations
+	operator int
+}
+
+type commandHandler func(ctx *commandContext, flagSet *flag.FlagSet, args []string) error
+
+type command struct {
+	usage    string
+	config   bool
+	database bool
+	handler  commandHandler
+}
+
+var commands = map[string]map[string]*command{
+	"user": {
+		"create": {
+			usage:    "-username <username> -email <email> -cid <cid> [-password <password>] [-admin]",
+			config:   true,
+			database: true,
+			handler:  userCreate,
+		},
+		"passwd": {
+			usage:    "[-password <password>] <cid|username|email>",
+			config:   true,
+			database: true,
+			handler:  userPasswd,
+		},
+		"grant": {
+			usage:    "[-revoke] <cid|username|email> <permission|all>...",
+			config:   true,
+			database: true,
+			handler:  userGrant,
+		},
+		"ban": {
+			usage:    "[-unban -rating <rating>] <cid|username|email>",
+			config:   true,
+			database: true,
+			handler:  userBan,
+		},
+	},
+	"ban": {
+		"issue": {
+			usage:    "-type <cid|ip|cidr> -reason <reason> [-scope <fsd|voice|web|all>] [-duration <duration>] <subject>",
+			config:   true,
+			database: true,
+			handler:  banIssue,
+		},
+		"lift": {
+			usage:    "<ban id>",
+			config:   true,
+			database: true,
+			handler:  banLift,
+		},
+	},
+	"config": {
+		"validate": {
+			handler: configValidate,
+		},
+		"print-default": {
+			handler: configPrintDefault,
+		},
+	},
+	"db": {
+		"migrate": {
+			config:   true,
+			database: true,
+			handler:  dbMigrate,
+		},
+		"stats": {
+			config:   true,
+			database: true,
+			handler:  dbStats,
+		},
+	},
+	"plans": {
+		"purge": {
+			usage:    "[-before <duration>] [-locked] [-dry_run]",
+			config:   true,
+			database: true,
+			handler:  plansPurge,
+		},
+	},
+}
+
+func isCommand(name string) bool {
+	_, ok := commands[name]
+	return ok
+}
+
+func printCommandUsage() {
+	groups := make([]string, 0, len(commands))
+	for group := range commands {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	_, _ = fmt.Fprintln(os.Stderr, "Usage: fsd [global flags] <command> <subcommand> [flags] [args]")
+	for _, group := range groups {
+		names := make([]string, 0, len(commands[group]))
+		for name := range commands[group] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			_, _ = fmt.Fprintf(os.Stderr, "  fsd %s %s %s\n", group, name, commands[group][name].usage)
+		}
+	}
+	_, _ = fmt.Fprintln(os.Stderr, "Commands that modify data accept -operator <cid> to record the operator in audit logs")
+}
+
+// runCommand 执行管理命令, 返回进程退出码
+func runCommand(args []string) int {
+	if len(args) < 2 {
+		printCommandUsage()
+		return 2
+	}
+	cmd, ok := commands[args[0]][args[1]]
+	if !ok {
+		printCommandUsage()
+		return 2
+	}
+
+	logger := base.NewLogger()
+	logger.Init(global.MainLogPath, global.MainLogName, *global.DebugMode, *global.NoLogs)
+	defer func() { _ = logger.ShutdownCallback().Invoke(context.Background()) }()
+
+	ctx := &commandContext{logger: logger}
+
+	flagSet := flag.NewFlagSet("fsd "+args[0]+" "+args[1], flag.ContinueOnError)
+	flagSet.IntVar(&ctx.operator, "operator", 0, "cid of the operator recorded in audit logs")
+	flagSet.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage: fsd %s %s %s\n", args[0], args[1], cmd.usage)
+		flagSet.PrintDefaults()
+	}
+
+	if cmd.config {
+		c, err := base.ValidateConfig(logger)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Fail to load configuration: %v\n", err)
+			return 1
+		}
+		if err := fsd.SyncRatingConfig(c); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error occurred while handle rating addition: %v\n", err)
+			return 1
+		}
+		if err := fsd.SyncFacilityConfig(c); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error occurred while handle facility addition: %v\n", err)
+			return 1
+		}
+		ctx.config = c
+	}
+
+	if cmd.database {
+		shutdownCallback, databaseOperation, err := database.ConnectDatabase(logger, ctx.config, *global.DebugMode)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Fail to connect database: %v\n", err)
+			return 1
+		}
+		defer func() { _ = shutdownCallback.Invoke(context.Background()) }()
+		ctx.database = databaseOperation
+	}
+
+	if err := cmd.handler(ctx, flagSet, args[2:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		if errors.Is(err, errCommandUsage) {
+			flagSet.Usage()
+			return 2
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "fsd %s %s: %v\n", args[0], args[1], err)
+		return 1
+	}
+	return 0
+}
+
+// audit 写入审计日志, 未指定operator时使用subject
+func (ctx *commandContext) audit(eventType operation.AuditEventType, subject int, object string, changeDetail *operation.ChangeDetail) {
+	if ctx.operator > 0 {
+		subject = ctx.operator
+	}
+	auditLogOperation := ctx.database.AuditLogOperation()
+	auditLog := auditLogOperation.NewAuditLog(eventType, subject, object, commandIp, commandUserAgent, changeDetail)
+	if err := auditLogOperation.SaveAuditLog(auditLog); err != nil {
+		ctx.logger.ErrorF("Fail to save audit log %s for %s: %v", eventType, object, err)
+	}
+}
+
+func (ctx *commandContext) getUser(ident string) (*operation.User, error) {
+	user, err := operation.GetUserId(ident).GetUser(ctx.database.UserOperation())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ident, err)
+	}
+	return user, nil
+}
+
+// readPassword 未通过参数指定密码时从标准输入读取一行, 标准输入为终端时不回显
+func readPassword(password string) (string, error) {
+	if password != "" {
+		return password, nil
+	}
+	_, _ = fmt.Fprint(os.Stderr, "Password: ")
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		data, err := term.ReadPassword(fd)
+		_, _ = fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("fail to read password: %w", err)
+		}
+		password = string(data)
+	} else {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("fail to read password: %w", err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+	if password == "" {
+		return "", errors.New("password can not be empty")
+	}
+	return password, nil
+}
+
+func permissionNames(permission operation.Permission) []string {
+	names := make([]string, 0)
+	for name, per := range operation.PermissionMap {
+		if permission.HasPermission(per) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func userCreate(ctx *commandContext, flagSet *flag.FlagSet, args []string) error {
+	username := flagSet.String("username", "", "username")
+	email := flagSet.String("email", "", "email address")
+	cid := flagSet.Int("cid", 0, "user cid")
+	password := flagSet.String("password", "", "password, read from stdin when empty")
+	admin := flagSet.Bool("admin", false, "grant all permissions to the new user")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" || *email == "" || *cid <= 0 || flagSet.NArg() != 0 {
+		return errCommandUsage
+	}
+
+	pwd, err := readPassword(*password)
+	if err != nil {
+		return err
+	}
+
+	userOperation := ctx.database.UserOperation()
+	user, err := userOperation.NewUser(*username, *email, *cid, pwd)
+	if err != nil {
+		return err
+	}
+	if err := userOperation.AddUser(user); err != nil {
+		return err
+	}
+	ctx.audit(operation.UserCreated, user.Cid, fmt.Sprintf("%04d(%s)", user.Cid, user.Username), nil)
+	fmt.Printf("User %s(%04d) created\n", user.Username, user.Cid)
+
+	if !*admin {
+		return nil
+	}
+	return grantPermissions(ctx, user, []string{"all"}, false)
+}
+
+func userPasswd(ctx *commandContext, flagSet *flag.FlagSet, args []string) error {
+	password := flagSet.String("password", "", "new password, read from stdin when empty")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if flagSet.NArg() != 1 {
+		return errCommandUsage
+	}
+
+	user, err := ctx.getUser(flagSet.Arg(0))
+	if err != nil {
+		return err
+	}
+	pwd, err := readPassword(*password)
+	if err != nil {
+		return err
+	}
+
+	userOperation := ctx.database.UserOperation()
+	encodePassword, err := userOperation.UpdateUserPassword(user, "", pwd, true)
+	if err != nil {
+		return err
+	}
+	if err := userOperation.UpdateUserInfo(user, &operation.User{Password: string(encodePassword)}); err != nil {
+		return err
+	}
+	ctx.audit(operation.UserInformationEdit, user.Cid, fmt.Sprintf("%04d(password)", user.Cid), nil)
+	fmt.Printf("Password of %s(%04d) updated\n", user.Username, user.Cid)
+	return nil
+}
+
+func userGrant(ctx *commandContext, flagSet *flag.FlagSet, args []string) error {
+	revoke := flagSet.Bool("revoke", false, "revoke the permissions instead of granting them")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if flagSet.NArg() < 2 {
+		return errCommandUsage
+	}
+
+	user, err := ctx.getUser(flagSet.Arg(0))
+	if err != nil {
+		return err
+	}
+	return grantPermissions(ctx, user, flagSet.Args()[1:], *revoke)
+}
+
+// grantPermissions 授予或收回权限, names中的all表示全部权限
+func grantPermissions(ctx *commandContext, user *operation.User, names []string, revoke bool) error {
+	targets := make([]string, 0, len(names))
+	for _, name := range names {
+		if strings.EqualFold(name, "all") {
+			for key := range operation.PermissionMap {
+				targets = append(targets, key)
+			}
+			continue
+		}
+		if _, ok := operation.PermissionMap[name]; !ok {
+			return fmt.Errorf("unknown permission %s", name)
+		}
+		targets = append(targets, name)
+	}
+	sort.Strings(targets)
+
+	permission := operation.Permission(user.Permission)
+	changed := make([]string, 0, len(targets))
+	for _, name := range targets {
+		per := operation.PermissionMap[name]
+		if permission.HasPermission(per) != revoke {
+			continue
+		}
+		if revoke {
+			permission.Revoke(per)
+		} else {
+			permission.Grant(per)
+		}
+		changed = append(changed, name)
+	}
+
+	if len(changed) == 0 {
+		fmt.Printf("Permissions of %s(%04d) unchanged\n", user.Username, user.Cid)
+		return nil
+	}
+
+	if err := ctx.database.UserOperation().UpdateUserPermission(user, permission); err != nil {
+		return err
+	}
+
+	eventType := operation.UserPermissionGrant
+	if revoke {
+		eventType = operation.UserPermissionRevoke
+	}
+	for _, name := range changed {
+		ctx.audit(eventType, user.Cid, fmt.Sprintf("%04d(%s)", user.Cid, name), nil)
+	}
+	fmt.Printf("Permissions of %s(%04d): %s\n", user.Username, user.Cid, strings.Join(permissionNames(permission), ", "))
+	return nil
+}
+
+func userBan(ctx *commandContext, flagSet *flag.FlagSet, args []string) error {
+	unban := flagSet.Bool("unban", false, "lift the ban, requires -rating")
+	rating := flagSet.String("rating", "", "rating restored after lifting the ban, e.g. OBS or S2")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if flagSet.NArg() != 1 || (*unban && *rating == "") || (!*unban && *rating != "") {
+		return errCommandUsage
+	}
+
+	user, err := ctx.getUser(flagSet.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	oldRating := fsd.Rating(user.Rating)
+	newRating := fsd.Ban
+	if *unban {
+		if oldRating != fsd.Ban {
+			return fmt.Errorf("%s(%04d) is not banned", user.Username, user.Cid)
+		}
+		// 封禁前的权限没有保存, 解封时必须显式指定
+		if newRating, err = parseRating(*rating); err != nil {
+			return err
+		}
+	} else if oldRating == fsd.Ban {
+		return fmt.Errorf("%s(%04d) is already banned", user.Username, user.Cid)
+	}
+
+	if err := ctx.database.UserOperation().UpdateUserInfo(user, &operation.User{Rating: newRating.Index()}); err != nil {
+		return err
+	}
+	ctx.audit(operation.ControllerRatingChange, user.Cid, fmt.Sprintf("%04d", user.Cid), &operation.ChangeDetail{
+		OldValue: oldRating.String(),
+		NewValue: newRating.String(),
+	})
+	fmt.Printf("Rating of %s(%04d) changed from %s to %s\n", user.Username, user.Cid, oldRating, newRating)
+	return nil
+}
+
+// banIssue 写入封禁记录, CID封禁的对象可以是cid、用户名或邮箱
+func banIssue(ctx *commandContext, flagSet *flag.FlagSet, args []string) error {
+	subjectTypeName := flagSet.String("type", "cid", "ban subject type, cid, ip or cidr")
+	scopeName := flagSet.String("scope", "all", "ban scope, fsd, voice, web or all")
+	reason := flagSet.String("reason", "", "reason of the ban")
+	duration := flagSet.Duration("duration", 0, "duration of the ban, permanent when omitted")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	subjectType, ok := operation.ParseBanSubjectType(*subjectTypeName)
+	if !ok || flagSet.NArg() != 1 || *reason == "" || *duration < 0 {
+		return errCommandUsage
+	}
+	scope, ok := operation.ParseBanScope(*scopeName)
+	if !ok {
+		return errCommandUsage
+	}
+
+	subject, cid := "", 0
+	if subjectType == operation.BanSubjectCid {
+		user, err := ctx.getUser(flagSet.Arg(0))
+		if err != nil {
+			return err
+		}
+		subject, cid = strconv.Itoa(user.Cid), user.Cid
+	} else {
+		var err error
+		if subject, err = operation.ParseBanAddress(subjectType, flagSet.Arg(0)); err != nil {
+			return fmt.Errorf("%s: %w", flagSet.Arg(0), err)
+		}
+	}
+
+	var expiresAt *time.Time
+	if *duration > 0 {
+		expires := time.Now().Add(*duration)
+		expiresAt = &expires
+	}
+
+	banOperation := ctx.database.BanOperation()
+	ban := banOperation.NewBan(subjectType, subject, cid, scope, *reason, ctx.operator, expiresAt)
+	if err := banOperation.SaveBan(ban); err != nil {
+		return err
+	}
+	ctx.audit(operation.BanIssued, ctx.operator, fmt.Sprintf("%d(%s)", ban.ID, ban.Subject), &operation.ChangeDetail{
+		OldValue: operation.ValueNotAvailable,
+		NewValue: fmt.Sprintf("%s, %s", scope.String(), *reason),
+	})
+	fmt.Printf("Ban #%d issued on %s, scope %s\n", ban.ID, ban.Subject, scope)
+	return nil
+}
+
+func banLift(ctx *commandContext, flagSet *flag.FlagSet, args []string) error {
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if flagSet.NArg() != 1 {
+		return errCommandUsage
+	}
+	id, err := strconv.ParseUint(flagSet.Arg(0), 10, 0)
+	if err != nil || id == 0 {
+		return errCommandUsage
+	}
+
+	banOperation := ctx.database.BanOperation()
+	ban, err := banOperation.GetBan(uint(id))
+	if err != nil {
+		return err
+	}
+	if err := banOperation.LiftBan(ban, ctx.operator); err != nil {
+		return err
+	}
+	ctx.audit(operation.BanLifted, ctx.operator, fmt.Sprintf("%d(%s)", ban.ID, ban.Subject), nil)
+	fmt.Printf("Ban #%d on %s lifted\n", ban.ID, ban.Subject)
+	return nil
+}
+
+// parseRating 解析权限简称或数值, 不允许解析为封禁
+func parseRating(value string) (fsd.Rating, error) {
+	for _, model := range fsd.Ratings {
+		if strings.EqualFold(model.ShortName, value) || strconv.Itoa(model.Id) == value {
+			if model.Id == fsd.Ban.Index() {
+				break
+			}
+			return fsd.Rating(model.Id), nil
+		}
+	}
+	return fsd.Ban, fmt.Errorf("invalid rating %s", value)
+}
+
+func configValidate(ctx *commandContext, flagSet *flag.FlagSet, args []string) error {
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if _, err := base.ValidateConfig(ctx.logger); err != nil {
+		return err
+	}
+	fmt.Printf("Configuration %s is valid\n", *global.ConfigFilePath)
+	return nil
+}
+
+func configPrintDefault(_ *commandContext, flagSet *flag.FlagSet, args []string) error {
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(config.DefaultConfig(), "", "\t")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// dbMigrate 连接数据库时会自动迁移表结构, 这里只需要输出结果
+func dbMigrate(_ *commandContext, flagSet *flag.FlagSet, args []string) error {
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	fmt.Println("Database migrated")
+	return nil
+}
+
+func dbStats(ctx *commandContext, flagSet *flag.FlagSet, args []string) error {
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	type stat struct {
+		name  string
+		count func() (int64, error)
+	}
+	stats := []stat{
+		{"users", ctx.database.UserOperation().GetTotalUsers},
+		{"controllers", ctx.database.ControllerOperation().GetTotalControllers},
+		{"activities", ctx.database.ActivityOperation().GetTotalActivities},
+		{"flight_plans", func() (int64, error) {
+			_, total, err := ctx.database.FlightPlanOperation().GetFlightPlans(1, 1)
+			return total, err
+		}},
+		{"tickets", func() (int64, error) {
+			_, total, err := ctx.database.TicketOperation().GetTickets(1, 1)
+			return total, err
+		}},
+		{"announcements", func() (int64, error) {
+			_, total, err := ctx.database.AnnouncementOperation().GetDetailAnnouncements(1, 1)
+			return total, err
+		}},
+		{"audit_logs", func() (int64, error) {
+			_, total, err := ctx.database.AuditLogOperation().GetAuditLogs(1, 1)
+			return total, err
+		}},
+	}
+
+	for _, s := range stats {
+		count, err := s.count()
+		if err != nil {
+			return fmt.Errorf("fail to count %s: %w", s.name, err)
+		}
+		fmt.Printf("%-14s %d\n", s.name, count)
+	}
+	return nil
+}
+
+func plansPurge(ctx *commandContext, flagSet *flag.FlagSet, args []string) error {
+	before := flagSet.Duration("before", 30*24*time.Hour, "purge flight plans not updated within this duration")
+	locked := flagSet.Bool("locked", false, "also purge locked flight plans")
+	dryRun := flagSet.Bool("dry_run", false, "only list the flight plans to be purged")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if *before <= 0 || flagSet.NArg() != 0 {
+		return errCommandUsage
+	}
+
+	flightPlanOperation := ctx.database.FlightPlanOperation()
+	flightPlans, err := flightPlanOperation.GetStaleFlightPlans(time.Now().Add(-*before), *locked)
+	if err != nil {
+		return err
+	}
+
+	purged := 0
+	for _, flightPlan := range flightPlans {
+		if *dryRun {
+			fmt.Printf("%04d %-10s last updated %s\n", flightPlan.Cid, flightPlan.Callsign, flightPlan.UpdatedAt.Format(time.DateTime))
+			continue
+		}
+		if err := flightPlanOperation.DeleteFlightPlan(flightPlan); err != nil {
+			ctx.logger.ErrorF("Fail to delete flight plan of %04d: %v", flightPlan.Cid, err)
+			continue
+		}
+		ctx.audit(operation.FlightPlanDeleted, flightPlan.Cid, fmt.Sprintf("%04d", flightPlan.Cid), nil)
+		purged++
+	}
+
+	if *dryRun {
+		fmt.Printf("%d flight plans would be purged\n", len(flightPlans))
+		return nil
+	}
+	fmt.Printf("%d flight plans purged\n", purged)
+	return nil
+}
@@ -4,15 +4,20 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 	"time"
 
+	"github.com/half-nothing/simple-fsd/internal/ban"
 	"github.com/half-nothing/simple-fsd/internal/base"
 	"github.com/half-nothing/simple-fsd/internal/cache"
 	"github.com/half-nothing/simple-fsd/internal/database"
 	"github.com/half-nothing/simple-fsd/internal/email"
+	"github.com/half-nothing/simple-fsd/internal/federation"
 	"github.com/half-nothing/simple-fsd/internal/fsd_server"
 	"github.com/half-nothing/simple-fsd/internal/fsd_server/client"
+	"github.com/half-nothing/simple-fsd/internal/help_request"
 	"github.com/half-nothing/simple-fsd/internal/http_server"
 	"github.com/half-nothing/simple-fsd/internal/interfaces"
 	"github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
@@ -59,6 +64,25 @@ func checkDurationEnv(envKey string, target *time.Duration) {
 	}
 }
 
+// watchReloadSignal 收到SIGHUP信号时重新加载配置文件
+func watchReloadSignal(logger log.LoggerInterface, configManager *base.Manager, messageQueue queue.MessageQueueInterface) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	for range signals {
+		logger.Info("Received SIGHUP, reloading configuration")
+		if _, err := configManager.ReloadConfig(); err != nil {
+			logger.ErrorF("Fail to reload configuration, keep current configuration: %v", err)
+			continue
+		}
+		if err := messageQueue.SyncPublish(&queue.Message{
+			Type: queue.ConfigReloaded,
+			Data: configManager.Config(),
+		}); err != nil {
+			logger.ErrorF("Fail to apply reloaded configuration: %v", err)
+		}
+	}
+}
+
 func main() {
 	flag.Parse()
 
@@ -79,6 +103,7 @@ func main() {
 	checkDurationEnv(global.EnvWebsocketHeartbeatInterval, global.WebsocketHeartbeatInterval)
 	checkDurationEnv(global.EnvWebsocketTimeout, global.WebsocketTimeout)
 	checkIntEnv(global.EnvWebsocketMessageChannelSize, global.WebsocketMessageChannelSize, 128)
+	checkDurationEnv(global.EnvTrafficStreamInterval, global.TrafficStreamInterval)
 
 	if !*global.Vatsim {
 		*global.VatsimFull = false
@@ -86,6 +111,14 @@ func main() {
 
 	defer recoverFromError()
 
+	if flag.NArg() > 0 {
+		if !isCommand(flag.Arg(0)) {
+			printCommandUsage()
+			os.Exit(2)
+		}
+		os.Exit(runCommand(flag.Args()))
+	}
+
 	mainLogger := base.NewLogger()
 	mainLogger.Init(global.MainLogPath, global.MainLogName, *global.DebugMode, *global.NoLogs)
 
@@ -145,7 +178,7 @@ func main() {
 
 	cleaner.Add(messageQueue.ShutdownCallback())
 
-	connectionManager := client.NewConnectionManager(fsdLogger)
+	connectionManager := client.NewConnectionManager(fsdLogger, config.Server.FSDServer.ConnectionPolicy)
 	clientManager := client.NewClientManager(fsdLogger, config, connectionManager, messageQueue)
 
 	messageQueue.Subscribe(queue.KickClientFromServer, clientManager.HandleKickClientFromServerMessage)
@@ -153,6 +186,7 @@ func main() {
 	messageQueue.Subscribe(queue.BroadcastMessage, clientManager.HandleBroadcastMessage)
 	messageQueue.Subscribe(queue.FlushFlightPlan, clientManager.HandleFlightPlanFlushMessage)
 	messageQueue.Subscribe(queue.ChangeFlightPlanLockStatus, clientManager.HandleLockChangeMessage)
+	messageQueue.Subscribe(queue.ConfigReloaded, clientManager.HandleConfigReloadedMessage)
 
 	emailSender := email.NewEmailSender(mainLogger, config.Server.HttpServer.Email)
 	emailMessageHandler := email.NewEmailMessageHandler(emailSender)
@@ -167,24 +201,40 @@ func main() {
 	messageQueue.Subscribe(queue.SendPasswordResetEmail, emailMessageHandler.HandleSendPasswordResetEmailMessage)
 	messageQueue.Subscribe(queue.SendPermissionChangeEmail, emailMessageHandler.HandleSendPermissionChangeEmailMessage)
 	messageQueue.Subscribe(queue.SendTicketReplyEmail, emailMessageHandler.HandleSendTicketReplyEmailMessage)
+	messageQueue.Subscribe(queue.SendHelpRequestEscalatedEmail, emailMessageHandler.HandleSendHelpRequestEscalatedEmailMessage)
+	messageQueue.Subscribe(queue.SendBannedEmail, emailMessageHandler.HandleSendBannedEmailMessage)
 
 	memoryCache := cache.NewMemoryCache[*string](*global.MetarCacheCleanInterval)
 	defer memoryCache.Close()
 
 	metarManager := metar.NewMetarManager(mainLogger, config.MetarSource, memoryCache)
+	messageQueue.Subscribe(queue.ConfigReloaded, metarManager.HandleConfigReloadedMessage)
+
+	helpRequestManager := help_request.NewHelpRequestManager(fsdLogger, config.Server.FSDServer.HelpRequest,
+		clientManager, messageQueue, databaseOperation.HelpRequestOperation())
+	helpRequestManager.Start()
+	cleaner.Add(help_request.NewShutdownCallback(helpRequestManager))
+
+	banManager := ban.NewBanManager(mainLogger, clientManager, databaseOperation.BanOperation())
+	banManager.Start()
+	cleaner.Add(ban.NewShutdownCallback(banManager))
 
 	mainLogger.Info("Creating application content...")
 	applicationContent := interfaces.NewApplicationContent(
 		logger,
+		banManager,
 		cleaner,
 		configManager,
 		clientManager,
 		connectionManager,
+		helpRequestManager,
 		messageQueue,
 		metarManager,
 		databaseOperation,
 	)
 
+	go watchReloadSignal(mainLogger, configManager, messageQueue)
+
 	mainLogger.Info("Application initialized. Starting application...")
 
 	if config.Server.HttpServer.Enabled {
@@ -196,6 +246,13 @@ func main() {
 		go voiceServer.Start()
 	}
 
+	if config.Server.Federation.Enabled {
+		federationServer := federation.NewFederationServer(applicationContent)
+		if err := federationServer.Start(); err != nil {
+			mainLogger.ErrorF("Fail to start federation server: %v", err)
+		}
+	}
+
 	//if config.Server.GRPCServer.Enabled {
 	//	go grpc_server.StartGRPCServer(applicationContent)
 	//}
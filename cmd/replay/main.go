@@ -0,0 +1,134 @@
+// Package main
+// 会话回放工具, 将会话录制文件中客户端发送的数据按原始时间间隔重新发送到运行中的服务器
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/fsd_server/recorder"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+)
+
+var (
+	recordFile = flag.String("file", "", "Path to session record file")
+	server     = flag.String("server", "127.0.0.1:6809", "Address of the FSD server to replay against")
+	speed      = flag.Float64("speed", 1, "Replay speed multiplier, 0 means send without delay")
+	password   = flag.String("password", "", "Password or token used to replace the redacted login field")
+	verbose    = flag.Bool("verbose", false, "Print lines received from server")
+	waitTime   = flag.Duration("wait", 3*time.Second, "Time to wait for server response after the last line")
+)
+
+func readRecords(path string) ([]*recorder.RecordLine, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	records := make([]*recorder.RecordLine, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		record, err := recorder.ParseRecordLine(line)
+		if err != nil {
+			fmt.Printf("Skip line %d: %v\n", lineNumber, err)
+			continue
+		}
+		if record.Direction != fsd.RecordInbound {
+			continue
+		}
+		record.Data = bytes.Clone(record.Data)
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+func receive(conn net.Conn) {
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		if *verbose {
+			fmt.Printf("<- %s\n", bytes.TrimRight(scanner.Bytes(), "\r"))
+		}
+	}
+}
+
+func replay(conn net.Conn, records []*recorder.RecordLine) error {
+	start := time.Now()
+	first := records[0].Time
+	for _, record := range records {
+		if *speed > 0 {
+			offset := time.Duration(float64(record.Time.Sub(first)) / *speed)
+			if wait := time.Until(start.Add(offset)); wait > 0 {
+				time.Sleep(wait)
+			}
+		}
+
+		line := record.Data
+		if *password != "" {
+			line = recorder.ReplacePassword(line, *password)
+		}
+		if *verbose {
+			fmt.Printf("-> %s\n", line)
+		}
+		if _, err := conn.Write(append(line, fsd.SplitSign...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func main() {
+	flag.Parse()
+
+	if *recordFile == "" {
+		fmt.Println("Record file is required, use -file to specify it")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	records, err := readRecords(*recordFile)
+	if err != nil {
+		fmt.Printf("Fail to read record file: %v\n", err)
+		os.Exit(1)
+	}
+	if len(records) == 0 {
+		fmt.Println("No client lines found in record file")
+		os.Exit(1)
+	}
+	if *password == "" {
+		fmt.Println("No password specified, login packets will be sent with redacted password")
+	}
+
+	conn, err := net.Dial("tcp", *server)
+	if err != nil {
+		fmt.Printf("Fail to connect to %s: %v\n", *server, err)
+		os.Exit(1)
+	}
+	defer func() { _ = conn.Close() }()
+
+	go receive(conn)
+
+	duration := records[len(records)-1].Time.Sub(records[0].Time)
+	fmt.Printf("Replaying %d lines (%v recorded) to %s at %.2fx speed\n", len(records), duration, *server, *speed)
+
+	if err := replay(conn, records); err != nil && !errors.Is(err, net.ErrClosed) {
+		fmt.Printf("Replay interrupted: %v\n", err)
+		os.Exit(1)
+	}
+
+	time.Sleep(*waitTime)
+	fmt.Println("Replay finished")
+}
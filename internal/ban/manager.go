@@ -0,0 +1,213 @@
+// Package ban
+package ban
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+)
+
+// refreshInterval 从数据库重新加载生效中封禁的间隔, 到期的封禁会在刷新时移出缓存
+const refreshInterval = time.Minute
+
+// BanManager 缓存生效中的封禁, 检查时不访问数据库
+type BanManager struct {
+	logger        log.LoggerInterface
+	clientManager ClientManagerInterface
+	operation     operation.BanOperationInterface
+	lock          sync.RWMutex
+	cidBans       map[int][]*operation.Ban
+	addressBans   []*operation.Ban
+	callbacks     []func(ban *operation.Ban)
+	stopOnce      sync.Once
+	stop          chan struct{}
+	wg            sync.WaitGroup
+}
+
+func NewBanManager(
+	logger log.LoggerInterface,
+	clientManager ClientManagerInterface,
+	banOperation operation.BanOperationInterface,
+) *BanManager {
+	return &BanManager{
+		logger:        log.NewLoggerAdapter(logger, "BanManager"),
+		clientManager: clientManager,
+		operation:     banOperation,
+		cidBans:       make(map[int][]*operation.Ban),
+		addressBans:   make([]*operation.Ban, 0),
+		stop:          make(chan struct{}),
+	}
+}
+
+func (manager *BanManager) Start() {
+	manager.refresh()
+	manager.wg.Add(1)
+	go manager.run()
+}
+
+func (manager *BanManager) Stop() {
+	manager.stopOnce.Do(func() {
+		close(manager.stop)
+		manager.wg.Wait()
+	})
+}
+
+func (manager *BanManager) run() {
+	defer manager.wg.Done()
+
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-manager.stop:
+			return
+		case <-ticker.C:
+			manager.refresh()
+		}
+	}
+}
+
+// refresh 重新加载生效中的封禁, 数据库不可用时保留当前缓存
+func (manager *BanManager) refresh() {
+	bans, err := manager.operation.GetActiveBans()
+	if err != nil {
+		manager.logger.ErrorF("Fail to load active bans: %v", err)
+		return
+	}
+
+	cidBans := make(map[int][]*operation.Ban)
+	addressBans := make([]*operation.Ban, 0)
+	for _, ban := range bans {
+		if operation.BanSubjectType(ban.SubjectType) == operation.BanSubjectCid {
+			cidBans[ban.Cid] = append(cidBans[ban.Cid], ban)
+		} else {
+			addressBans = append(addressBans, ban)
+		}
+	}
+
+	manager.lock.Lock()
+	expired := manager.countLocked() - len(bans)
+	manager.cidBans = cidBans
+	manager.addressBans = addressBans
+	manager.lock.Unlock()
+
+	if expired > 0 {
+		manager.logger.InfoF("%d ban(s) expired or lifted, %d ban(s) active", expired, len(bans))
+	}
+}
+
+func (manager *BanManager) countLocked() int {
+	count := len(manager.addressBans)
+	for _, bans := range manager.cidBans {
+		count += len(bans)
+	}
+	return count
+}
+
+func (manager *BanManager) Check(cid int, ip string, scope operation.BanScope) *operation.Ban {
+	now := time.Now()
+	address := parseIp(ip)
+
+	manager.lock.RLock()
+	defer manager.lock.RUnlock()
+
+	if cid > 0 {
+		for _, ban := range manager.cidBans[cid] {
+			if ban.Covers(scope) && ban.Active(now) {
+				return ban
+			}
+		}
+	}
+	if address != nil {
+		for _, ban := range manager.addressBans {
+			if ban.Covers(scope) && ban.Active(now) && ban.MatchIp(address) {
+				return ban
+			}
+		}
+	}
+	return nil
+}
+
+func (manager *BanManager) Issue(ban *operation.Ban) error {
+	if err := manager.operation.SaveBan(ban); err != nil {
+		return err
+	}
+	manager.logger.InfoF("Ban #%d issued by %04d on %s, scope %s, reason: %s", ban.ID, ban.IssuerCid, ban.Subject,
+		operation.BanScope(ban.Scope).String(), ban.Reason)
+	manager.refresh()
+	if ban.Covers(operation.BanScopeFsd) {
+		manager.kickBannedClients(ban)
+	}
+	manager.lock.RLock()
+	callbacks := manager.callbacks
+	manager.lock.RUnlock()
+	for _, callback := range callbacks {
+		callback(ban)
+	}
+	return nil
+}
+
+func (manager *BanManager) AddIssueCallback(callback func(ban *operation.Ban)) {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+	manager.callbacks = append(manager.callbacks, callback)
+}
+
+func (manager *BanManager) Lift(id uint, cid int) (*operation.Ban, error) {
+	ban, err := manager.operation.GetBan(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := manager.operation.LiftBan(ban, cid); err != nil {
+		return nil, err
+	}
+	manager.logger.InfoF("Ban #%d on %s lifted by %04d", ban.ID, ban.Subject, cid)
+	manager.refresh()
+	return ban, nil
+}
+
+func (manager *BanManager) Extend(id uint, expiresAt *time.Time) (*operation.Ban, error) {
+	ban, err := manager.operation.GetBan(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := manager.operation.ExtendBan(ban, expiresAt); err != nil {
+		return nil, err
+	}
+	manager.logger.InfoF("Ban #%d on %s now expires at %v", ban.ID, ban.Subject, ban.ExpiresAt)
+	manager.refresh()
+	return ban, nil
+}
+
+// kickBannedClients 踢出命中封禁的本地FSD客户端
+func (manager *BanManager) kickBannedClients(ban *operation.Ban) {
+	clients := manager.clientManager.GetClientSnapshot()
+	defer manager.clientManager.ReleaseClientSnapshot(clients)
+	for _, client := range clients {
+		if client == nil || client.Disconnected() || client.IsRemote() || client.IsVirtual() || client.User() == nil {
+			continue
+		}
+		if !ban.MatchCid(client.User().Cid) && !ban.MatchIp(parseIp(client.RemoteAddr())) {
+			continue
+		}
+		if _, err := manager.clientManager.KickClientFromServer(client.Callsign(), "banned, "+ban.Reason); err != nil {
+			manager.logger.WarnF("Fail to kick banned client %s: %v", client.Callsign(), err)
+		}
+	}
+}
+
+// parseIp 解析IP地址, 同时支持带端口的地址
+func parseIp(address string) net.IP {
+	if address == "" {
+		return nil
+	}
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		address = host
+	}
+	return net.ParseIP(address)
+}
@@ -3,34 +3,47 @@ package base
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	. "github.com/half-nothing/simple-fsd/internal/interfaces/config"
 	"github.com/half-nothing/simple-fsd/internal/interfaces/global"
 	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
-	"github.com/half-nothing/simple-fsd/internal/utils"
 )
 
+// readConfig 读取并校验配置文件, 配置文件不存在时创建默认配置, 仅在启动时使用
 func readConfig(logger log.LoggerInterface) (*Config, *ValidResult) {
-	config := DefaultConfig()
-
-	// 读取配置文件
-	if bytes, err := os.ReadFile(*global.ConfigFilePath); err != nil {
+	if _, err := os.Stat(*global.ConfigFilePath); err != nil {
 		// 如果配置文件不存在，创建默认配置
-		if err := saveConfig(config); err != nil {
+		if err := saveConfig(DefaultConfig()); err != nil {
 			return nil, ValidFailWith(errors.New("fail to save configuration file while creating configuration file"), err)
 		}
 		return nil, ValidFail(errors.New("the configuration file does not exist and has been created. Please try again after editing the configuration file"))
+	}
+	return loadConfig(logger, *global.UpdateConfig)
+}
+
+// loadConfig 读取并校验已存在的配置文件, 不会创建默认配置文件
+// updateVersion 为 true 时会把版本不匹配的配置文件更新为当前版本
+func loadConfig(logger log.LoggerInterface, updateVersion bool) (*Config, *ValidResult) {
+	config := DefaultConfig()
+
+	// 读取配置文件
+	if bytes, err := os.ReadFile(*global.ConfigFilePath); err != nil {
+		return nil, ValidFailWith(fmt.Errorf("fail to read configuration file %s", *global.ConfigFilePath), err)
 	} else if err := json.Unmarshal(bytes, config); err != nil {
 		// 解析JSON配置
 		return nil, ValidFailWith(errors.New("the configuration file does not contain valid JSON"), err)
 	} else if result := config.CheckValid(logger); result.IsFail() {
-		if result.OriginErr() != nil && errors.Is(result.OriginErr(), ErrVersionUnmatch) && *global.UpdateConfig {
+		if result.OriginErr() != nil && errors.Is(result.OriginErr(), ErrVersionUnmatch) && updateVersion {
 			config.ConfigVersion = global.ConfigVersion
 			if err := saveConfig(config); err != nil {
 				return nil, ValidFailWith(errors.New("fail to save configuration file while creating configuration file"), err)
 			}
-			return readConfig(logger)
+			return loadConfig(logger, false)
 		} else {
 			return nil, result
 		}
@@ -38,6 +51,14 @@ func readConfig(logger log.LoggerInterface) (*Config, *ValidResult) {
 	return config, ValidPass()
 }
 
+// resultError 将校验结果转换为错误, 保留原始错误
+func resultError(result *ValidResult) error {
+	if result.OriginErr() != nil {
+		return fmt.Errorf("%s: %w", result.Err(), result.OriginErr())
+	}
+	return result.Err()
+}
+
 func saveConfig(config *Config) error {
 	if writer, err := os.OpenFile(*global.ConfigFilePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, global.DefaultFilePermissions); err != nil {
 		return err
@@ -51,16 +72,26 @@ func saveConfig(config *Config) error {
 	return nil
 }
 
+// ValidateConfig 读取并校验配置文件, 校验失败时返回错误而不是退出程序, 不会创建或修改配置文件
+func ValidateConfig(logger log.LoggerInterface) (*Config, error) {
+	config, result := loadConfig(logger, false)
+	if result != nil && result.IsFail() {
+		return nil, resultError(result)
+	}
+	return config, nil
+}
+
 type Manager struct {
-	config *utils.CachedValue[Config]
-	logger log.LoggerInterface
+	config     atomic.Pointer[Config]
+	logger     log.LoggerInterface
+	reloadLock sync.Mutex
 }
 
 func NewManager(logger log.LoggerInterface) *Manager {
 	manager := &Manager{
 		logger: logger,
 	}
-	manager.config = utils.NewCachedValue(0, manager.getConfig)
+	manager.config.Store(manager.getConfig())
 	return manager
 }
 
@@ -74,9 +105,33 @@ func (manager *Manager) getConfig() *Config {
 }
 
 func (manager *Manager) Config() *Config {
-	return manager.config.GetValue()
+	return manager.config.Load()
 }
 
 func (manager *Manager) SaveConfig() error {
 	return saveConfig(manager.Config())
 }
+
+// ReloadConfig 重新读取并校验配置文件, 校验失败或配置文件不存在时保持当前配置不变
+func (manager *Manager) ReloadConfig() (*ReloadResult, error) {
+	manager.reloadLock.Lock()
+	defer manager.reloadLock.Unlock()
+
+	newConfig, result := loadConfig(manager.logger, false)
+	if result != nil && result.IsFail() {
+		return nil, resultError(result)
+	}
+
+	snapshot, reloadResult := manager.Config().ApplyReload(newConfig)
+	manager.config.Store(snapshot)
+	if len(reloadResult.Applied) == 0 && len(reloadResult.RequireRestart) == 0 {
+		manager.logger.Info("Configuration reloaded, nothing changed")
+	}
+	if len(reloadResult.Applied) > 0 {
+		manager.logger.InfoF("Configuration reloaded, applied: %s", strings.Join(reloadResult.Applied, ", "))
+	}
+	if len(reloadResult.RequireRestart) > 0 {
+		manager.logger.WarnF("Configuration changed but require restart to take effect: %s", strings.Join(reloadResult.RequireRestart, ", "))
+	}
+	return reloadResult, nil
+}
@@ -0,0 +1,92 @@
+package base
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/config"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/global"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+)
+
+// nopLogger 丢弃全部日志
+type nopLogger struct {
+	log.LoggerInterface
+}
+
+func (nopLogger) Debug(string)                  {}
+func (nopLogger) DebugF(string, ...interface{}) {}
+func (nopLogger) Info(string)                   {}
+func (nopLogger) InfoF(string, ...interface{})  {}
+func (nopLogger) Warn(string)                   {}
+func (nopLogger) WarnF(string, ...interface{})  {}
+func (nopLogger) Error(string)                  {}
+func (nopLogger) ErrorF(string, ...interface{}) {}
+
+func TestManagerReloadConfig(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	oldPath := *global.ConfigFilePath
+	*global.ConfigFilePath = configFile
+	t.Cleanup(func() { *global.ConfigFilePath = oldPath })
+
+	if err := saveConfig(config.DefaultConfig()); err != nil {
+		t.Fatalf("saveConfig: %v", err)
+	}
+	initial, result := loadConfig(nopLogger{}, false)
+	if result.IsFail() {
+		t.Fatalf("loadConfig: %v", resultError(result))
+	}
+	manager := &Manager{logger: nopLogger{}}
+	manager.config.Store(initial)
+
+	tests := []struct {
+		name            string
+		action          func()
+		expectedOk      bool
+		expectedApplied string
+		expectedFile    bool
+		expectedFsdName string
+	}{
+		{"unchanged", func() {}, true, "", true, initial.Server.FSDServer.FSDName},
+		{"hot reload", func() {
+			changed := config.DefaultConfig()
+			changed.Server.FSDServer.FSDName = "Reloaded"
+			_ = saveConfig(changed)
+		}, true, "server.fsd_server.fsd_name", true, "Reloaded"},
+		{"invalid json", func() { _ = os.WriteFile(configFile, []byte("{"), global.DefaultFilePermissions) }, false, "", true, "Reloaded"},
+		// 配置文件被移走时不能写入默认配置
+		{"missing file", func() { _ = os.Remove(configFile) }, false, "", false, "Reloaded"},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		test.action()
+		reloadResult, err := manager.ReloadConfig()
+		_, statErr := os.Stat(configFile)
+		fsdName := manager.Config().Server.FSDServer.FSDName
+		if (err == nil) != test.expectedOk || (statErr == nil) != test.expectedFile || fsdName != test.expectedFsdName {
+			fail++
+			t.Errorf("ReloadConfig(%s) = %v, file exists %v, fsd name %q; expected ok %v, file exists %v, fsd name %q",
+				test.name, err, statErr == nil, fsdName, test.expectedOk, test.expectedFile, test.expectedFsdName)
+			continue
+		}
+		if test.expectedApplied != "" && !slices.Contains(reloadResult.Applied, test.expectedApplied) {
+			fail++
+			t.Errorf("ReloadConfig(%s) applied %v; expected %s", test.name, reloadResult.Applied, test.expectedApplied)
+			continue
+		}
+		pass++
+	}
+
+	if _, err := ValidateConfig(nopLogger{}); err == nil {
+		fail++
+		t.Errorf("ValidateConfig(missing file) = nil; expected error")
+	}
+	if _, err := os.Stat(configFile); err == nil {
+		fail++
+		t.Errorf("ValidateConfig(missing file) created %s; expected no file", configFile)
+	}
+	t.Logf("TestManagerReloadConfig: %d pass, %d fail", pass, fail)
+}
@@ -0,0 +1,126 @@
+// Package database
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+	"gorm.io/gorm"
+)
+
+type BanOperation struct {
+	logger       log.LoggerInterface
+	db           *gorm.DB
+	queryTimeout time.Duration
+}
+
+func NewBanOperation(logger log.LoggerInterface, db *gorm.DB, queryTimeout time.Duration) *BanOperation {
+	return &BanOperation{
+		logger:       logger,
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+func (banOperation *BanOperation) NewBan(subjectType BanSubjectType, subject string, cid int, scope BanScope, reason string, issuerCid int, expiresAt *time.Time) (ban *Ban) {
+	return &Ban{
+		SubjectType: int(subjectType),
+		Subject:     subject,
+		Cid:         cid,
+		Scope:       int(scope),
+		Reason:      reason,
+		IssuerCid:   issuerCid,
+		StartAt:     time.Now(),
+		ExpiresAt:   expiresAt,
+	}
+}
+
+func (banOperation *BanOperation) SaveBan(ban *Ban) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), banOperation.queryTimeout)
+	defer cancel()
+	return banOperation.db.WithContext(ctx).Save(ban).Error
+}
+
+func (banOperation *BanOperation) GetBan(id uint) (ban *Ban, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), banOperation.queryTimeout)
+	defer cancel()
+	ban = &Ban{}
+	err = banOperation.db.WithContext(ctx).First(ban, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = ErrBanNotFound
+	}
+	return
+}
+
+// activeScope 生效中的封禁: 未解除, 已开始且未到期
+func activeScope(now time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("lifted = ? AND start_at <= ? AND (expires_at IS NULL OR expires_at > ?)", false, now, now)
+	}
+}
+
+func (banOperation *BanOperation) GetBans(page, pageSize int, activeOnly bool) (bans []*Ban, total int64, err error) {
+	bans = make([]*Ban, 0, pageSize)
+	ctx, cancel := context.WithTimeout(context.Background(), banOperation.queryTimeout)
+	defer cancel()
+	query := banOperation.db.WithContext(ctx).Model(&Ban{})
+	if activeOnly {
+		query = query.Scopes(activeScope(time.Now()))
+	}
+	query.Count(&total)
+	err = query.Offset((page - 1) * pageSize).Order("created_at desc").Limit(pageSize).Find(&bans).Error
+	return
+}
+
+func (banOperation *BanOperation) GetActiveBans() (bans []*Ban, err error) {
+	bans = make([]*Ban, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), banOperation.queryTimeout)
+	defer cancel()
+	err = banOperation.db.WithContext(ctx).Scopes(activeScope(time.Now())).Find(&bans).Error
+	return
+}
+
+func (banOperation *BanOperation) LiftBan(ban *Ban, cid int) (err error) {
+	now := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), banOperation.queryTimeout)
+	defer cancel()
+	result := banOperation.db.WithContext(ctx).Model(ban).
+		Where("lifted = ?", false).
+		Updates(map[string]interface{}{
+			"lifted":    true,
+			"lifted_by": cid,
+			"lifted_at": now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrBanAlreadyLifted
+	}
+	ban.Lifted = true
+	ban.LiftedBy = cid
+	ban.LiftedAt = &now
+	return nil
+}
+
+func (banOperation *BanOperation) ExtendBan(ban *Ban, expiresAt *time.Time) (err error) {
+	if ban.Lifted {
+		return ErrBanAlreadyLifted
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), banOperation.queryTimeout)
+	defer cancel()
+	result := banOperation.db.WithContext(ctx).Model(ban).
+		Where("lifted = ?", false).
+		Update("expires_at", expiresAt)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrBanAlreadyLifted
+	}
+	ban.ExpiresAt = expiresAt
+	return nil
+}
@@ -58,7 +58,7 @@ func ConnectDatabase(lg log.LoggerInterface, config *config.Config, debug bool)
 	}
 
 	if err = db.Migrator().AutoMigrate(&User{}, &FlightPlan{}, &History{}, &Activity{}, &ActivityATC{},
-		&ActivityPilot{}, &ActivityFacility{}, &AuditLog{}, &ControllerRecord{}, &Ticket{}, &ControllerApplication{}, &Announcement{}); err != nil {
+		&ActivityPilot{}, &ActivityFacility{}, &AuditLog{}, &ControllerRecord{}, &Ticket{}, &ControllerApplication{}, &Announcement{}, &FlightPlanRevision{}, &FlightTrack{}, &LogbookEntry{}, &HelpRequest{}, &Ban{}); err != nil {
 		return nil, nil, Errorf("error occured while migrating operation: %v", err)
 	}
 
@@ -92,6 +92,11 @@ func ConnectDatabase(lg log.LoggerInterface, config *config.Config, debug bool)
 			NewControllerApplicationOperation(lg, db, queryTimeout),
 			NewTicketOperation(lg, db, queryTimeout),
 			NewAnnouncementOperation(lg, db, queryTimeout),
+			NewFlightPlanRevisionOperation(lg, db, queryTimeout, config.Server.General),
+			NewFlightTrackOperation(lg, db, queryTimeout),
+			NewLogbookOperation(lg, db, queryTimeout),
+			NewHelpRequestOperation(lg, db, queryTimeout),
+			NewBanOperation(lg, db, queryTimeout),
 		),
 		nil
 }
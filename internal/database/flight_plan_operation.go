@@ -130,6 +130,18 @@ func (flightPlanOperation *FlightPlanOperation) GetFlightPlans(page, pageSize in
 	return
 }
 
+func (flightPlanOperation *FlightPlanOperation) GetStaleFlightPlans(before time.Time, includeLocked bool) (flightPlans []*FlightPlan, err error) {
+	flightPlans = make([]*FlightPlan, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), flightPlanOperation.queryTimeout)
+	defer cancel()
+	query := flightPlanOperation.db.WithContext(ctx).Where("updated_at < ?", before)
+	if !includeLocked {
+		query = query.Where("locked = ?", false)
+	}
+	err = query.Order("cid").Find(&flightPlans).Error
+	return
+}
+
 func (flightPlanOperation *FlightPlanOperation) LockFlightPlan(flightPlan *FlightPlan) (err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), flightPlanOperation.queryTimeout)
 	defer cancel()
@@ -0,0 +1,79 @@
+// Package database
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/config"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+	"gorm.io/gorm"
+)
+
+// revisionSaveAttempts 修订号冲突时的最大尝试次数
+const revisionSaveAttempts = 5
+
+type FlightPlanRevisionOperation struct {
+	logger       log.LoggerInterface
+	config       *config.GeneralConfig
+	db           *gorm.DB
+	queryTimeout time.Duration
+}
+
+func NewFlightPlanRevisionOperation(logger log.LoggerInterface, db *gorm.DB, queryTimeout time.Duration, config *config.GeneralConfig) *FlightPlanRevisionOperation {
+	return &FlightPlanRevisionOperation{logger: logger, config: config, db: db, queryTimeout: queryTimeout}
+}
+
+func (revisionOperation *FlightPlanRevisionOperation) NewRevision(oldPlan, newPlan *FlightPlan, source FlightPlanRevisionSource, editor string, editorCid int) (revision *FlightPlanRevision) {
+	// 模拟机服务器不保存飞行计划, 也就不需要修订记录
+	if revisionOperation.config.SimulatorServer {
+		return nil
+	}
+	changes := DiffFlightPlan(oldPlan, newPlan)
+	if len(changes) == 0 {
+		return nil
+	}
+	return &FlightPlanRevision{
+		Cid:       newPlan.Cid,
+		Callsign:  newPlan.Callsign,
+		Source:    source,
+		Editor:    editor,
+		EditorCid: editorCid,
+		Changes:   changes,
+	}
+}
+
+// SaveRevision 保存修订记录, 修订号为该用户已有的最大修订号加一
+// 并发保存时修订号可能冲突, 由(cid, revision)唯一索引拦截后重新分配
+func (revisionOperation *FlightPlanRevisionOperation) SaveRevision(revision *FlightPlanRevision) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), revisionOperation.queryTimeout)
+	defer cancel()
+	for attempt := 1; attempt <= revisionSaveAttempts; attempt++ {
+		err = revisionOperation.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var latest int64
+			if err := tx.Model(&FlightPlanRevision{}).Where("cid = ?", revision.Cid).
+				Select("COALESCE(MAX(revision), 0)").Scan(&latest).Error; err != nil {
+				return err
+			}
+			revision.Revision = int(latest) + 1
+			return tx.Create(revision).Error
+		})
+		if !errors.Is(err, gorm.ErrDuplicatedKey) {
+			return err
+		}
+		revisionOperation.logger.DebugF("Revision %d of cid %d already exists, retrying", revision.Revision, revision.Cid)
+	}
+	return err
+}
+
+func (revisionOperation *FlightPlanRevisionOperation) GetRevisions(cid, page, pageSize int) (revisions []*FlightPlanRevision, total int64, err error) {
+	revisions = make([]*FlightPlanRevision, 0, pageSize)
+	ctx, cancel := context.WithTimeout(context.Background(), revisionOperation.queryTimeout)
+	defer cancel()
+	revisionOperation.db.WithContext(ctx).Model(&FlightPlanRevision{}).Where("cid = ?", cid).Count(&total)
+	err = revisionOperation.db.WithContext(ctx).Where("cid = ?", cid).Order("revision desc").
+		Offset((page - 1) * pageSize).Limit(pageSize).Find(&revisions).Error
+	return
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/config"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// nopLogger 丢弃全部日志
+type nopLogger struct {
+	log.LoggerInterface
+}
+
+func (nopLogger) Debug(string)                  {}
+func (nopLogger) DebugF(string, ...interface{}) {}
+func (nopLogger) Info(string)                   {}
+func (nopLogger) InfoF(string, ...interface{})  {}
+func (nopLogger) Warn(string)                   {}
+func (nopLogger) WarnF(string, ...interface{})  {}
+func (nopLogger) Error(string)                  {}
+func (nopLogger) ErrorF(string, ...interface{}) {}
+
+func newTestRevisionOperation(t *testing.T) (*FlightPlanRevisionOperation, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{TranslateError: true, Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&FlightPlanRevision{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return NewFlightPlanRevisionOperation(nopLogger{}, db, time.Second, &config.GeneralConfig{}), db
+}
+
+func TestSaveRevision(t *testing.T) {
+	operation, db := newTestRevisionOperation(t)
+
+	// conflicts 在对应次数的保存中把修订号改为已存在的值, 模拟并发保存时读到过期的最大修订号
+	conflicts := 0
+	if err := db.Callback().Create().Before("gorm:create").Register("test:conflict", func(tx *gorm.DB) {
+		revision, ok := tx.Statement.Dest.(*FlightPlanRevision)
+		if !ok || conflicts == 0 || revision.Revision <= 1 {
+			return
+		}
+		conflicts--
+		revision.Revision--
+		tx.Statement.SetColumn("Revision", revision.Revision)
+	}); err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+
+	tests := []struct {
+		name             string
+		cid              int
+		conflicts        int
+		expectedErr      error
+		expectedRevision int
+	}{
+		{"first", 2352, 0, nil, 1},
+		{"second", 2352, 0, nil, 2},
+		{"other cid", 2353, 0, nil, 1},
+		{"one conflict", 2352, 1, nil, 3},
+		{"several conflicts", 2352, revisionSaveAttempts - 1, nil, 4},
+		{"too many conflicts", 2352, revisionSaveAttempts, gorm.ErrDuplicatedKey, 0},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		conflicts = test.conflicts
+		revision := &FlightPlanRevision{Cid: test.cid, Callsign: "CES101", Source: FlightPlanEditByAtc, Editor: "CES101", EditorCid: test.cid}
+		err := operation.SaveRevision(revision)
+		if !errors.Is(err, test.expectedErr) || (err == nil && revision.Revision != test.expectedRevision) {
+			fail++
+			t.Errorf("SaveRevision(%s) = %v, revision %d; expected %v, revision %d", test.name, err, revision.Revision, test.expectedErr, test.expectedRevision)
+			continue
+		}
+		pass++
+	}
+
+	// 每个用户的修订号不能重复
+	var duplicated int64
+	db.Raw("SELECT COUNT(*) FROM (SELECT cid, revision FROM flight_plan_revisions GROUP BY cid, revision HAVING COUNT(*) > 1)").Scan(&duplicated)
+	if duplicated != 0 {
+		fail++
+		t.Errorf("found %d duplicated revisions; expected 0", duplicated)
+	}
+	t.Logf("TestSaveRevision: %d pass, %d fail", pass, fail)
+}
@@ -0,0 +1,71 @@
+// Package database
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+	"gorm.io/gorm"
+)
+
+type FlightTrackOperation struct {
+	logger       log.LoggerInterface
+	db           *gorm.DB
+	queryTimeout time.Duration
+}
+
+func NewFlightTrackOperation(logger log.LoggerInterface, db *gorm.DB, queryTimeout time.Duration) *FlightTrackOperation {
+	return &FlightTrackOperation{logger: logger, db: db, queryTimeout: queryTimeout}
+}
+
+func (trackOperation *FlightTrackOperation) NewFlightTrack(history *History, departure, arrival string, points []*TrackPoint) (track *FlightTrack, err error) {
+	data, err := EncodeTrackPoints(points)
+	if err != nil {
+		return nil, err
+	}
+	return &FlightTrack{
+		HistoryId:        history.ID,
+		Cid:              history.Cid,
+		Callsign:         history.Callsign,
+		DepartureAirport: departure,
+		ArrivalAirport:   arrival,
+		PointCount:       len(points),
+		StartTime:        history.StartTime,
+		EndTime:          history.EndTime,
+		Data:             data,
+	}, nil
+}
+
+func (trackOperation *FlightTrackOperation) SaveFlightTrack(track *FlightTrack) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), trackOperation.queryTimeout)
+	defer cancel()
+	if track.ID == 0 {
+		return trackOperation.db.WithContext(ctx).Create(track).Error
+	}
+	return trackOperation.db.WithContext(ctx).Save(track).Error
+}
+
+func (trackOperation *FlightTrackOperation) GetFlightTracks(cid, page, pageSize int) (tracks []*FlightTrack, total int64, err error) {
+	tracks = make([]*FlightTrack, 0, pageSize)
+	ctx, cancel := context.WithTimeout(context.Background(), trackOperation.queryTimeout)
+	defer cancel()
+	trackOperation.db.WithContext(ctx).Model(&FlightTrack{}).Where("cid = ?", cid).Select("id").Count(&total)
+	// 列表不需要航迹数据
+	err = trackOperation.db.WithContext(ctx).Omit("data").Where("cid = ?", cid).Order("id desc").
+		Offset((page - 1) * pageSize).Limit(pageSize).Find(&tracks).Error
+	return
+}
+
+func (trackOperation *FlightTrackOperation) GetFlightTrack(id uint) (track *FlightTrack, err error) {
+	track = &FlightTrack{}
+	ctx, cancel := context.WithTimeout(context.Background(), trackOperation.queryTimeout)
+	defer cancel()
+	err = trackOperation.db.WithContext(ctx).First(track, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = ErrFlightTrackNotFound
+	}
+	return
+}
@@ -0,0 +1,201 @@
+// Package database
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+	"gorm.io/gorm"
+)
+
+type HelpRequestOperation struct {
+	logger       log.LoggerInterface
+	db           *gorm.DB
+	queryTimeout time.Duration
+}
+
+func NewHelpRequestOperation(logger log.LoggerInterface, db *gorm.DB, queryTimeout time.Duration) *HelpRequestOperation {
+	return &HelpRequestOperation{
+		logger:       logger,
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+func (helpRequestOperation *HelpRequestOperation) NewHelpRequest(cid int, callsign string, latitude, longitude float64, message string) (request *HelpRequest) {
+	return &HelpRequest{
+		Cid:       cid,
+		Callsign:  callsign,
+		Latitude:  latitude,
+		Longitude: longitude,
+		Message:   message,
+		Status:    int(HelpRequestStatusPending),
+	}
+}
+
+func (helpRequestOperation *HelpRequestOperation) SaveHelpRequest(request *HelpRequest) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), helpRequestOperation.queryTimeout)
+	defer cancel()
+	return helpRequestOperation.db.WithContext(ctx).Save(request).Error
+}
+
+func (helpRequestOperation *HelpRequestOperation) GetHelpRequest(id uint) (request *HelpRequest, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), helpRequestOperation.queryTimeout)
+	defer cancel()
+	request = &HelpRequest{}
+	err = helpRequestOperation.db.WithContext(ctx).First(request, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = ErrHelpRequestNotFound
+	}
+	return
+}
+
+func (helpRequestOperation *HelpRequestOperation) GetHelpRequests(page, pageSize int, status int) (requests []*HelpRequest, total int64, err error) {
+	requests = make([]*HelpRequest, 0, pageSize)
+	ctx, cancel := context.WithTimeout(context.Background(), helpRequestOperation.queryTimeout)
+	defer cancel()
+	query := helpRequestOperation.db.WithContext(ctx).Model(&HelpRequest{})
+	if status >= 0 {
+		query = query.Where("status = ?", status)
+	}
+	query.Count(&total)
+	err = query.Offset((page - 1) * pageSize).Order("created_at desc").Limit(pageSize).Find(&requests).Error
+	return
+}
+
+func (helpRequestOperation *HelpRequestOperation) GetOpenHelpRequests() (requests []*HelpRequest, err error) {
+	requests = make([]*HelpRequest, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), helpRequestOperation.queryTimeout)
+	defer cancel()
+	err = helpRequestOperation.db.WithContext(ctx).
+		Where("status <> ?", HelpRequestStatusResolved).
+		Order("created_at").
+		Find(&requests).Error
+	return
+}
+
+func (helpRequestOperation *HelpRequestOperation) GetOpenHelpRequestByCid(cid int) (request *HelpRequest, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), helpRequestOperation.queryTimeout)
+	defer cancel()
+	request = &HelpRequest{}
+	err = helpRequestOperation.db.WithContext(ctx).
+		Where("cid = ? AND status <> ?", cid, HelpRequestStatusResolved).
+		Order("created_at").
+		First(request).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = ErrHelpRequestNotFound
+	}
+	return
+}
+
+func (helpRequestOperation *HelpRequestOperation) GetHelpRequestsSince(since time.Time) (requests []*HelpRequest, err error) {
+	requests = make([]*HelpRequest, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), helpRequestOperation.queryTimeout)
+	defer cancel()
+	err = helpRequestOperation.db.WithContext(ctx).
+		Where("created_at >= ?", since).
+		Order("created_at").
+		Find(&requests).Error
+	return
+}
+
+func (helpRequestOperation *HelpRequestOperation) GetHelpRequestsToEscalate(before time.Time) (requests []*HelpRequest, err error) {
+	requests = make([]*HelpRequest, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), helpRequestOperation.queryTimeout)
+	defer cancel()
+	err = helpRequestOperation.db.WithContext(ctx).
+		Where("status = ? AND escalated = ? AND created_at < ?", HelpRequestStatusPending, false, before).
+		Order("created_at").
+		Find(&requests).Error
+	return
+}
+
+func (helpRequestOperation *HelpRequestOperation) ClaimHelpRequest(request *HelpRequest, cid int, callsign string) (err error) {
+	if request.Status == int(HelpRequestStatusResolved) {
+		return ErrHelpRequestResolved
+	}
+	now := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), helpRequestOperation.queryTimeout)
+	defer cancel()
+	// 只允许认领未被认领的求助, 或由当前处理人重复认领
+	result := helpRequestOperation.db.WithContext(ctx).Model(request).
+		Where("status = ? OR (status = ? AND handler_cid = ?)", HelpRequestStatusPending, HelpRequestStatusClaimed, cid).
+		Updates(map[string]interface{}{
+			"status":           HelpRequestStatusClaimed,
+			"handler_cid":      cid,
+			"handler_callsign": callsign,
+			"claimed_at":       now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrHelpRequestClaimed
+	}
+	request.Status = int(HelpRequestStatusClaimed)
+	request.HandlerCid = cid
+	request.HandlerCallsign = callsign
+	request.ClaimedAt = &now
+	return nil
+}
+
+func (helpRequestOperation *HelpRequestOperation) ResolveHelpRequest(request *HelpRequest, cid int, callsign string, resolution string) (err error) {
+	if request.Status == int(HelpRequestStatusResolved) {
+		return ErrHelpRequestResolved
+	}
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":      HelpRequestStatusResolved,
+		"resolution":  resolution,
+		"resolved_at": now,
+	}
+	if request.Status == int(HelpRequestStatusPending) {
+		updates["handler_cid"] = cid
+		updates["handler_callsign"] = callsign
+		updates["claimed_at"] = now
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), helpRequestOperation.queryTimeout)
+	defer cancel()
+	result := helpRequestOperation.db.WithContext(ctx).Model(request).
+		Where("status = ?", request.Status).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrHelpRequestResolved
+	}
+	if request.Status == int(HelpRequestStatusPending) {
+		request.HandlerCid = cid
+		request.HandlerCallsign = callsign
+		request.ClaimedAt = &now
+	}
+	request.Status = int(HelpRequestStatusResolved)
+	request.Resolution = resolution
+	request.ResolvedAt = &now
+	return nil
+}
+
+func (helpRequestOperation *HelpRequestOperation) EscalateHelpRequest(request *HelpRequest) (err error) {
+	now := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), helpRequestOperation.queryTimeout)
+	defer cancel()
+	result := helpRequestOperation.db.WithContext(ctx).Model(request).
+		Where("status = ? AND escalated = ?", HelpRequestStatusPending, false).
+		Updates(map[string]interface{}{
+			"escalated":    true,
+			"escalated_at": now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrHelpRequestClaimed
+	}
+	request.Escalated = true
+	request.EscalatedAt = &now
+	return nil
+}
@@ -0,0 +1,40 @@
+// Package database
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+	"gorm.io/gorm"
+)
+
+type LogbookOperation struct {
+	logger       log.LoggerInterface
+	db           *gorm.DB
+	queryTimeout time.Duration
+}
+
+func NewLogbookOperation(logger log.LoggerInterface, db *gorm.DB, queryTimeout time.Duration) *LogbookOperation {
+	return &LogbookOperation{logger: logger, db: db, queryTimeout: queryTimeout}
+}
+
+func (logbookOperation *LogbookOperation) SaveLogbookEntry(entry *LogbookEntry) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), logbookOperation.queryTimeout)
+	defer cancel()
+	if entry.ID == 0 {
+		return logbookOperation.db.WithContext(ctx).Create(entry).Error
+	}
+	return logbookOperation.db.WithContext(ctx).Save(entry).Error
+}
+
+func (logbookOperation *LogbookOperation) GetLogbookEntries(cid, page, pageSize int) (entries []*LogbookEntry, total int64, err error) {
+	entries = make([]*LogbookEntry, 0, pageSize)
+	ctx, cancel := context.WithTimeout(context.Background(), logbookOperation.queryTimeout)
+	defer cancel()
+	logbookOperation.db.WithContext(ctx).Model(&LogbookEntry{}).Where("cid = ?", cid).Select("id").Count(&total)
+	err = logbookOperation.db.WithContext(ctx).Where("cid = ?", cid).Order("id desc").
+		Offset((page - 1) * pageSize).Limit(pageSize).Find(&entries).Error
+	return
+}
@@ -87,3 +87,17 @@ func (handler *EmailMessageHandler) HandleSendTicketReplyEmailMessage(message *q
 	}
 	return queue.ErrMessageDataType
 }
+
+func (handler *EmailMessageHandler) HandleSendHelpRequestEscalatedEmailMessage(message *queue.Message) error {
+	if val, ok := message.Data.(*HelpRequestEscalatedEmailData); ok {
+		return handler.sender.SendHelpRequestEscalatedEmail(val)
+	}
+	return queue.ErrMessageDataType
+}
+
+func (handler *EmailMessageHandler) HandleSendBannedEmailMessage(message *queue.Message) error {
+	if val, ok := message.Data.(*BannedEmailData); ok {
+		return handler.sender.SendBannedEmail(val)
+	}
+	return queue.ErrMessageDataType
+}
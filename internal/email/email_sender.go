@@ -9,6 +9,7 @@ import (
 	. "github.com/half-nothing/simple-fsd/internal/interfaces"
 	"github.com/half-nothing/simple-fsd/internal/interfaces/config"
 	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
 	"github.com/half-nothing/simple-fsd/internal/utils"
 	"gopkg.in/gomail.v2"
 )
@@ -317,3 +318,70 @@ func (sender *EmailSender) SendTicketReplyEmail(data *TicketReplyEmailData) erro
 
 	return sender.config.EmailServer.DialAndSend(m)
 }
+
+func (sender *EmailSender) SendHelpRequestEscalatedEmail(data *HelpRequestEscalatedEmailData) error {
+	if sender.config.EmailServer == nil {
+		return nil
+	}
+	if !sender.templateConfig.HelpRequestEscalatedEmail.Enable {
+		return nil
+	}
+
+	request := data.Request
+	for _, email := range data.Emails {
+		m, err := sender.generateEmail(strings.ToLower(email), sender.templateConfig.HelpRequestEscalatedEmail, &HelpRequestEscalatedEmail{
+			Id:       request.ID,
+			Cid:      utils.FormatCid(request.Cid),
+			Callsign: request.Callsign,
+			Position: fmt.Sprintf("%.4f, %.4f", request.Latitude, request.Longitude),
+			Message:  request.Message,
+			Time:     request.CreatedAt.Format(time.DateTime),
+			Timeout:  data.Timeout.String(),
+		})
+		if err != nil {
+			sender.logger.WarnF("Error rendering help request escalated email template: %v", err)
+			return ErrRenderingTemplate
+		}
+
+		sender.logger.InfoF("Sending help request #%d escalated email to %s", request.ID, email)
+
+		if err := sender.config.EmailServer.DialAndSend(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sender *EmailSender) SendBannedEmail(data *BannedEmailData) error {
+	if sender.config.EmailServer == nil {
+		return nil
+	}
+	if !sender.templateConfig.BannedEmail.Enable {
+		return nil
+	}
+
+	email := strings.ToLower(data.User.Email)
+
+	expires := "永久"
+	if data.Ban.ExpiresAt != nil {
+		expires = data.Ban.ExpiresAt.Format(time.DateTime)
+	}
+
+	m, err := sender.generateEmail(email, sender.templateConfig.BannedEmail, &BannedEmail{
+		Cid:      fmt.Sprintf("%04d", data.User.Cid),
+		Time:     data.Ban.StartAt.Format(time.DateTime),
+		Operator: fmt.Sprintf("%04d", data.Operator.Cid),
+		Scope:    operation.BanScope(data.Ban.Scope).String(),
+		Expires:  expires,
+		Reason:   data.Ban.Reason,
+		Contact:  data.Operator.Email,
+	})
+	if err != nil {
+		sender.logger.WarnF("Error rendering banned email template: %v", err)
+		return ErrRenderingTemplate
+	}
+
+	sender.logger.InfoF("Sending banned email to %s(%d)", email, data.User.Cid)
+
+	return sender.config.EmailServer.DialAndSend(m)
+}
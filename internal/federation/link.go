@@ -0,0 +1,228 @@
+// Package federation
+package federation
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+)
+
+var (
+	ErrLinkClosed        = errors.New("federation link closed")
+	ErrHandshakeFailed   = errors.New("federation handshake failed")
+	ErrUnexpectedPeer    = errors.New("unexpected federation peer")
+	ErrDuplicateNodeName = errors.New("peer has the same node name")
+	ErrUnexpectedPacket  = errors.New("unexpected federation packet")
+)
+
+// linkQueueSize 链路待转发数据包队列长度, 队列满时丢弃新的数据包
+const linkQueueSize = 1024
+
+// link 两个联邦节点之间的一条已认证链路
+type link struct {
+	logger    log.LoggerInterface
+	conn      net.Conn
+	peer      string
+	outbound  bool
+	timeout   time.Duration
+	decoder   *json.Decoder
+	encoder   *json.Encoder
+	writeLock sync.Mutex
+	closed    atomic.Bool
+	done      chan struct{}
+	// outbox 转发给远程客户端的数据包, 由 writeLoop 写入链路, 避免广播时阻塞在慢速链路上
+	outbox chan *LinkMessage
+	// sent 已同步给对端的本地客户端状态, 仅由同步协程访问
+	sent map[string]*ClientState
+}
+
+func newLink(logger log.LoggerInterface, conn net.Conn, outbound bool, timeout time.Duration) *link {
+	return &link{
+		logger:   logger,
+		conn:     conn,
+		outbound: outbound,
+		timeout:  timeout,
+		decoder:  json.NewDecoder(bufio.NewReader(conn)),
+		encoder:  json.NewEncoder(conn),
+		done:     make(chan struct{}),
+		outbox:   make(chan *LinkMessage, linkQueueSize),
+		sent:     make(map[string]*ClientState),
+	}
+}
+
+// handshake 双向挑战应答认证, 双方都需要证明自己持有相同的共享密钥
+// 发起方先发送签名, 接收方验证通过后才发送自己的签名, 未认证的连接拿不到任何签名
+func (l *link) handshake(nodeName string, secret []byte, expectedPeer string) error {
+	nonce, err := newNonce()
+	if err != nil {
+		return err
+	}
+	if err := l.send(&LinkMessage{Type: LinkHello, Node: nodeName, Nonce: nonce}); err != nil {
+		return err
+	}
+
+	hello, err := l.receive()
+	if err != nil {
+		return err
+	}
+	if hello.Type != LinkHello || hello.Node == "" || hello.Nonce == "" {
+		return ErrHandshakeFailed
+	}
+	if hello.Node == nodeName {
+		return ErrDuplicateNodeName
+	}
+	if expectedPeer != "" && hello.Node != expectedPeer {
+		return fmt.Errorf("%w, expect %s but got %s", ErrUnexpectedPeer, expectedPeer, hello.Node)
+	}
+
+	if l.outbound {
+		transcript := &handshakeTranscript{initiator: nodeName, initiatorNonce: nonce, responder: hello.Node, responderNonce: hello.Nonce}
+		if err := l.send(&LinkMessage{Type: LinkAuth, Node: nodeName, Mac: transcript.sign(secret, roleInitiator)}); err != nil {
+			return err
+		}
+		if err := l.receiveAuth(transcript, secret, roleResponder, hello.Node); err != nil {
+			return err
+		}
+	} else {
+		transcript := &handshakeTranscript{initiator: hello.Node, initiatorNonce: hello.Nonce, responder: nodeName, responderNonce: nonce}
+		if err := l.receiveAuth(transcript, secret, roleInitiator, hello.Node); err != nil {
+			return err
+		}
+		if err := l.send(&LinkMessage{Type: LinkAuth, Node: nodeName, Mac: transcript.sign(secret, roleResponder)}); err != nil {
+			return err
+		}
+	}
+
+	l.peer = hello.Node
+	l.logger = log.NewLoggerAdapter(l.logger, fmt.Sprintf("Link(%s)", l.peer))
+	return nil
+}
+
+// receiveAuth 接收并验证对端的握手签名
+func (l *link) receiveAuth(transcript *handshakeTranscript, secret []byte, role handshakeRole, peer string) error {
+	auth, err := l.receive()
+	if err != nil {
+		return err
+	}
+	if auth.Type != LinkAuth || auth.Node != peer || !transcript.verify(secret, role, auth.Mac) {
+		return ErrHandshakeFailed
+	}
+	return nil
+}
+
+// dialer 返回发起该链路的节点名称
+func (l *link) dialer(nodeName string) string {
+	if l.outbound {
+		return nodeName
+	}
+	return l.peer
+}
+
+func (l *link) send(message *LinkMessage) error {
+	if l.closed.Load() {
+		return ErrLinkClosed
+	}
+
+	l.writeLock.Lock()
+	defer l.writeLock.Unlock()
+
+	_ = l.conn.SetWriteDeadline(time.Now().Add(l.timeout))
+	if err := l.encoder.Encode(message); err != nil {
+		l.close()
+		return err
+	}
+	return nil
+}
+
+// enqueue 将数据包放入发送队列, 不会阻塞调用方
+func (l *link) enqueue(message *LinkMessage) error {
+	if l.closed.Load() {
+		return ErrLinkClosed
+	}
+	select {
+	case l.outbox <- message:
+		return nil
+	default:
+		return ErrClientQueueFull
+	}
+}
+
+// writeLoop 将发送队列中的数据包写入链路, 链路关闭时退出
+func (l *link) writeLoop() {
+	for {
+		select {
+		case <-l.done:
+			return
+		case message := <-l.outbox:
+			if err := l.send(message); err != nil {
+				if !l.closed.Load() {
+					l.logger.WarnF("Federation link write error: %v", err)
+				}
+				return
+			}
+		}
+	}
+}
+
+func (l *link) receive() (*LinkMessage, error) {
+	_ = l.conn.SetReadDeadline(time.Now().Add(l.timeout))
+	message := &LinkMessage{}
+	if err := l.decoder.Decode(message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+func (l *link) close() {
+	if !l.closed.CompareAndSwap(false, true) {
+		return
+	}
+	close(l.done)
+	_ = l.conn.Close()
+}
+
+// syncStates 将本地客户端状态与上次发送的状态对比, 只发送有变化的部分
+func (l *link) syncStates(states map[string]*ClientState) error {
+	changed := make([]*ClientState, 0)
+	for callsign, state := range states {
+		if sent, ok := l.sent[callsign]; ok && reflect.DeepEqual(sent, state) {
+			continue
+		}
+		changed = append(changed, state)
+	}
+
+	removed := make([]string, 0)
+	for callsign := range l.sent {
+		if _, ok := states[callsign]; !ok {
+			removed = append(removed, callsign)
+		}
+	}
+
+	if len(changed) == 0 && len(removed) == 0 {
+		return l.send(&LinkMessage{Type: LinkPing})
+	}
+
+	if len(changed) > 0 {
+		if err := l.send(&LinkMessage{Type: LinkState, Clients: changed}); err != nil {
+			return err
+		}
+	}
+
+	if len(removed) > 0 {
+		if err := l.send(&LinkMessage{Type: LinkRemove, Callsigns: removed}); err != nil {
+			return err
+		}
+	}
+
+	l.sent = states
+	return nil
+}
@@ -0,0 +1,228 @@
+// Package federation
+package federation
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+)
+
+const testLinkTimeout = 500 * time.Millisecond
+
+// nopLogger 丢弃全部日志
+type nopLogger struct {
+	log.LoggerInterface
+}
+
+func (nopLogger) Debug(string)                  {}
+func (nopLogger) DebugF(string, ...interface{}) {}
+func (nopLogger) Info(string)                   {}
+func (nopLogger) InfoF(string, ...interface{})  {}
+func (nopLogger) Warn(string)                   {}
+func (nopLogger) WarnF(string, ...interface{})  {}
+func (nopLogger) Error(string)                  {}
+func (nopLogger) ErrorF(string, ...interface{}) {}
+
+// newTestLinkPair 通过本地回环建立一对链路, 第一个为发起方, 第二个为接收方
+func newTestLinkPair(t *testing.T) (*link, *link) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer func() { _ = listener.Close() }()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			close(accepted)
+			return
+		}
+		accepted <- conn
+	}()
+
+	dialConn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	acceptConn, ok := <-accepted
+	if !ok {
+		t.Fatalf("accept failed")
+	}
+	dialer := newLink(nopLogger{}, dialConn, true, testLinkTimeout)
+	acceptor := newLink(nopLogger{}, acceptConn, false, testLinkTimeout)
+	t.Cleanup(func() {
+		dialer.close()
+		acceptor.close()
+	})
+	return dialer, acceptor
+}
+
+// runHandshake 在后台执行握手, 失败时与 serveLink 一样关闭链路
+func runHandshake(l *link, nodeName string, secret []byte, expectedPeer string) <-chan error {
+	result := make(chan error, 1)
+	go func() {
+		err := l.handshake(nodeName, secret, expectedPeer)
+		if err != nil {
+			l.close()
+		}
+		result <- err
+	}()
+	return result
+}
+
+func TestLinkHandshake(t *testing.T) {
+	tests := []struct {
+		name             string
+		dialerName       string
+		dialerSecret     string
+		expectedPeer     string
+		acceptorName     string
+		acceptorSecret   string
+		expectedDialer   error
+		expectedAcceptor error
+	}{
+		{"success", "ZSHA", "secret", "ZBPE", "ZBPE", "secret", nil, nil},
+		{"any peer", "ZSHA", "secret", "", "ZBPE", "secret", nil, nil},
+		{"wrong secret", "ZSHA", "secret", "ZBPE", "ZBPE", "other", errLinkAny, ErrHandshakeFailed},
+		{"unexpected peer", "ZSHA", "secret", "ZGZU", "ZBPE", "secret", ErrUnexpectedPeer, errLinkAny},
+		{"same node name", "ZSHA", "secret", "", "ZSHA", "secret", ErrDuplicateNodeName, ErrDuplicateNodeName},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		dialer, acceptor := newTestLinkPair(t)
+		dialerResult := runHandshake(dialer, test.dialerName, []byte(test.dialerSecret), test.expectedPeer)
+		acceptorResult := runHandshake(acceptor, test.acceptorName, []byte(test.acceptorSecret), "")
+		dialerErr, acceptorErr := <-dialerResult, <-acceptorResult
+		if !matchLinkErr(dialerErr, test.expectedDialer) || !matchLinkErr(acceptorErr, test.expectedAcceptor) {
+			fail++
+			t.Errorf("handshake(%s) = %v, %v; expected %v, %v", test.name, dialerErr, acceptorErr, test.expectedDialer, test.expectedAcceptor)
+			continue
+		}
+		if test.expectedDialer == nil && (dialer.peer != test.acceptorName || acceptor.peer != test.dialerName) {
+			fail++
+			t.Errorf("handshake(%s) peers = %s, %s; expected %s, %s", test.name, dialer.peer, acceptor.peer, test.acceptorName, test.dialerName)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestLinkHandshake: %d pass, %d fail", pass, fail)
+}
+
+// TestLinkHandshakeRelay 攻击者同时连接两个节点, 把A的随机数交给B签名后再把签名转交给A
+func TestLinkHandshakeRelay(t *testing.T) {
+	secret := []byte("secret")
+	pass := 0
+	fail := 0
+
+	attackerToA, nodeA := newTestLinkPair(t)
+	attackerToB, nodeB := newTestLinkPair(t)
+	attackerToB.timeout = testLinkTimeout / 5
+	resultA := runHandshake(nodeA, "ZSHA", secret, "")
+	resultB := runHandshake(nodeB, "ZBPE", secret, "")
+
+	helloA, errA := attackerToA.receive()
+	helloB, errB := attackerToB.receive()
+	if errA != nil || errB != nil {
+		t.Fatalf("receive hello: %v, %v", errA, errB)
+	}
+	_ = attackerToA.send(&LinkMessage{Type: LinkHello, Node: helloB.Node, Nonce: helloA.Nonce})
+	_ = attackerToB.send(&LinkMessage{Type: LinkHello, Node: "ZGZU", Nonce: helloA.Nonce})
+
+	// 接收方在验证发起方之前不会发送签名
+	if auth, err := attackerToB.receive(); err == nil {
+		fail++
+		t.Errorf("responder sent %s before the initiator authenticated; expected nothing", auth.Type)
+		_ = attackerToA.send(&LinkMessage{Type: LinkAuth, Node: helloB.Node, Mac: auth.Mac})
+	} else {
+		pass++
+		_ = attackerToA.send(&LinkMessage{Type: LinkAuth, Node: helloB.Node, Mac: helloA.Nonce})
+	}
+
+	if err := <-resultA; !errors.Is(err, ErrHandshakeFailed) {
+		fail++
+		t.Errorf("relayed handshake = %v, peer %q; expected %v", err, nodeA.peer, ErrHandshakeFailed)
+	} else {
+		pass++
+	}
+	attackerToB.close()
+	<-resultB
+	t.Logf("TestLinkHandshakeRelay: %d pass, %d fail", pass, fail)
+}
+
+// TestLinkHandshakeReflection 攻击者把发起方的随机数和签名原样返回
+func TestLinkHandshakeReflection(t *testing.T) {
+	secret := []byte("secret")
+	pass := 0
+	fail := 0
+
+	nodeA, attacker := newTestLinkPair(t)
+	resultA := runHandshake(nodeA, "ZSHA", secret, "")
+
+	hello, err := attacker.receive()
+	if err != nil {
+		t.Fatalf("receive hello: %v", err)
+	}
+	_ = attacker.send(&LinkMessage{Type: LinkHello, Node: "ZBPE", Nonce: hello.Nonce})
+	auth, err := attacker.receive()
+	if err != nil {
+		t.Fatalf("receive auth: %v", err)
+	}
+	_ = attacker.send(&LinkMessage{Type: LinkAuth, Node: "ZBPE", Mac: auth.Mac})
+
+	if err := <-resultA; !errors.Is(err, ErrHandshakeFailed) {
+		fail++
+		t.Errorf("reflected handshake = %v, peer %q; expected %v", err, nodeA.peer, ErrHandshakeFailed)
+	} else {
+		pass++
+	}
+	t.Logf("TestLinkHandshakeReflection: %d pass, %d fail", pass, fail)
+}
+
+func TestHandshakeTranscript(t *testing.T) {
+	secret := []byte("secret")
+	transcript := &handshakeTranscript{initiator: "ZSHA", initiatorNonce: "01", responder: "ZBPE", responderNonce: "02"}
+	signature := transcript.sign(secret, roleInitiator)
+
+	tests := []struct {
+		name       string
+		transcript *handshakeTranscript
+		secret     []byte
+		role       handshakeRole
+		expected   bool
+	}{
+		{"same", transcript, secret, roleInitiator, true},
+		{"other role", transcript, secret, roleResponder, false},
+		{"other secret", transcript, []byte("other"), roleInitiator, false},
+		{"swapped nonces", &handshakeTranscript{"ZSHA", "02", "ZBPE", "01"}, secret, roleInitiator, false},
+		{"swapped nodes", &handshakeTranscript{"ZBPE", "01", "ZSHA", "02"}, secret, roleInitiator, false},
+		{"shifted fields", &handshakeTranscript{"ZSHA0", "1", "ZBPE", "02"}, secret, roleInitiator, false},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		result := test.transcript.verify(test.secret, test.role, signature)
+		if result != test.expected {
+			fail++
+			t.Errorf("verify(%s) = %v; expected %v", test.name, result, test.expected)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestHandshakeTranscript: %d pass, %d fail", pass, fail)
+}
+
+// errLinkAny 表示任意非空错误, 例如对端断开后的读取错误
+var errLinkAny = errors.New("any error")
+
+func matchLinkErr(err, expected error) bool {
+	if expected == errLinkAny {
+		return err != nil
+	}
+	return errors.Is(err, expected)
+}
@@ -0,0 +1,144 @@
+// Package federation
+package federation
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+)
+
+type LinkMessageType string
+
+const (
+	LinkHello  LinkMessageType = "hello"  // 握手, 携带节点名称与随机数
+	LinkAuth   LinkMessageType = "auth"   // 握手应答, 携带握手过程的HMAC
+	LinkState  LinkMessageType = "state"  // 客户端状态增量同步
+	LinkRemove LinkMessageType = "remove" // 客户端下线
+	LinkPacket LinkMessageType = "packet" // 转发给指定客户端的FSD数据包
+	LinkError  LinkMessageType = "error"  // 转发给指定客户端的错误(例如踢出)
+	LinkPing   LinkMessageType = "ping"   // 心跳
+)
+
+// ClientState 节点间同步的客户端状态
+type ClientState struct {
+	Callsign     string                `json:"callsign"`
+	Cid          int                   `json:"cid"`
+	RealName     string                `json:"real_name"`
+	IsAtc        bool                  `json:"is_atc"`
+	Rating       int                   `json:"rating"`
+	Facility     uint                  `json:"facility"`
+	Frequency    int                   `json:"frequency"`
+	VisualRange  float64               `json:"visual_range"`
+	Position     [4]fsd.Position       `json:"position"`
+	Transponder  string                `json:"transponder"`
+	Altitude     int                   `json:"altitude"`
+	GroundSpeed  int                   `json:"ground_speed"`
+	FlightPhase  fsd.FlightPhase       `json:"flight_phase,omitempty"`
+	Heading      int                   `json:"heading"`
+	FlightPlan   *operation.FlightPlan `json:"flight_plan"`
+	AtisInfo     []string              `json:"atis_info"`
+	IsBreak      bool                  `json:"is_break"`
+	LogoffTime   string                `json:"logoff_time"`
+	LogonTime    time.Time             `json:"logon_time"`
+	Disconnected bool                  `json:"disconnected"`
+}
+
+type LinkMessage struct {
+	Type      LinkMessageType `json:"type"`
+	Node      string          `json:"node,omitempty"`
+	Nonce     string          `json:"nonce,omitempty"`
+	Mac       string          `json:"mac,omitempty"`
+	Clients   []*ClientState  `json:"clients,omitempty"`
+	Callsigns []string        `json:"callsigns,omitempty"`
+	To        string          `json:"to,omitempty"`
+	Data      string          `json:"data,omitempty"`
+	Errno     int             `json:"errno,omitempty"`
+	Fatal     bool            `json:"fatal,omitempty"`
+	Env       string          `json:"env,omitempty"`
+}
+
+func newClientState(client fsd.ClientInterface) *ClientState {
+	state := &ClientState{
+		Callsign:     client.Callsign(),
+		RealName:     client.RealName(),
+		IsAtc:        client.IsAtc(),
+		Rating:       client.Rating().Index(),
+		Facility:     uint(client.Facility()),
+		Frequency:    client.Frequency(),
+		VisualRange:  client.VisualRange(),
+		Position:     client.Position(),
+		Transponder:  client.Transponder(),
+		Altitude:     client.Altitude(),
+		GroundSpeed:  client.GroundSpeed(),
+		FlightPhase:  client.FlightPhase(),
+		Heading:      client.Heading(),
+		AtisInfo:     append([]string(nil), client.AtisInfo()...),
+		IsBreak:      client.IsBreak(),
+		LogoffTime:   client.LogoffTime(),
+		Disconnected: client.Disconnected(),
+	}
+	if user := client.User(); user != nil {
+		state.Cid = user.Cid
+	}
+	if history := client.History(); history != nil {
+		state.LogonTime = history.StartTime
+	}
+	if flightPlan := client.FlightPlan(); flightPlan != nil {
+		fp := *flightPlan
+		state.FlightPlan = &fp
+	}
+	return state
+}
+
+func newNonce() (string, error) {
+	buffer := make([]byte, 32)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buffer), nil
+}
+
+// handshakeRole 握手签名方的角色, 签名中包含角色防止发起方和接收方的签名互相冒用
+type handshakeRole string
+
+const (
+	roleInitiator handshakeRole = "initiator"
+	roleResponder handshakeRole = "responder"
+)
+
+// handshakeTranscript 握手过程中双方交换的节点名称与随机数
+type handshakeTranscript struct {
+	initiator      string
+	initiatorNonce string
+	responder      string
+	responderNonce string
+}
+
+// sign 计算握手签名, 签名内容包含签名方角色、双方节点名称与双方随机数
+// 签名只对本次握手有效, 无法被反射回对端或中继给其他节点
+func (transcript *handshakeTranscript) sign(secret []byte, role handshakeRole) string {
+	mac := hmac.New(sha256.New, secret)
+	for _, field := range []string{string(role), transcript.initiator, transcript.initiatorNonce, transcript.responder, transcript.responderNonce} {
+		_ = binary.Write(mac, binary.BigEndian, uint32(len(field)))
+		mac.Write([]byte(field))
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (transcript *handshakeTranscript) verify(secret []byte, role handshakeRole, signature string) bool {
+	expected, err := hex.DecodeString(transcript.sign(secret, role))
+	if err != nil {
+		return false
+	}
+	actual, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(expected, actual)
+}
@@ -0,0 +1,224 @@
+// Package federation
+package federation
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+)
+
+var ErrRemoteClient = errors.New("operation not supported on remote client")
+
+// RemoteClient 联邦节点上客户端在本地的镜像
+// 所有发送给该客户端的数据都会通过节点间链路转发, 修改状态的方法均为空操作
+type RemoteClient struct {
+	lock    sync.RWMutex
+	link    *link
+	state   *ClientState
+	user    *operation.User
+	history *operation.History
+}
+
+func newRemoteClient(link *link, state *ClientState) *RemoteClient {
+	client := &RemoteClient{link: link}
+	client.update(link, state)
+	return client
+}
+
+func (client *RemoteClient) update(link *link, state *ClientState) {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+	client.link = link
+	client.state = state
+	client.user = &operation.User{Cid: state.Cid}
+	client.history = &operation.History{Cid: state.Cid, Callsign: state.Callsign, StartTime: state.LogonTime, IsAtc: state.IsAtc}
+}
+
+func (client *RemoteClient) getLink() *link {
+	client.lock.RLock()
+	defer client.lock.RUnlock()
+	return client.link
+}
+
+func (client *RemoteClient) getState() *ClientState {
+	client.lock.RLock()
+	defer client.lock.RUnlock()
+	return client.state
+}
+
+func (client *RemoteClient) Disconnected() bool { return client.getState().Disconnected }
+
+func (client *RemoteClient) Delete() {}
+
+func (client *RemoteClient) Reconnect(_ SessionInterface) bool { return false }
+
+func (client *RemoteClient) IssueResumeToken() string { return "" }
+
+func (client *RemoteClient) CheckResumeToken(_ string) bool { return false }
+
+func (client *RemoteClient) ResumeToken() string { return "" }
+
+func (client *RemoteClient) MarkedDisconnect(_ bool) {}
+
+func (client *RemoteClient) Discard() {}
+
+func (client *RemoteClient) UpsertFlightPlan(_ []string) error { return ErrRemoteClient }
+
+func (client *RemoteClient) SetPosition(_ int, _ float64, _ float64) error { return ErrRemoteClient }
+
+func (client *RemoteClient) UpdatePilotPos(_ int, _ float64, _ float64, _ int, _ int, _ uint32) {}
+
+func (client *RemoteClient) UpdateVisualPos(_ *VisualPosition) {}
+
+func (client *RemoteClient) VisualPosition() *VisualPosition { return nil }
+
+func (client *RemoteClient) SetFastUpdate(_ bool) bool { return false }
+
+func (client *RemoteClient) UpdateAtcPos(_ int, _ Facility, _ float64, _ float64, _ float64) {}
+
+func (client *RemoteClient) UpdateAtcVisPoint(_ int, _ float64, _ float64) error {
+	return ErrRemoteClient
+}
+
+func (client *RemoteClient) ClearAtcAtisInfo() {}
+
+func (client *RemoteClient) AddAtcAtisInfo(_ string) {}
+
+func (client *RemoteClient) SendError(result *Result) {
+	if result.Success {
+		return
+	}
+	message := &LinkMessage{
+		Type:  LinkError,
+		To:    client.Callsign(),
+		Errno: result.Errno.Index(),
+		Fatal: result.Fatal,
+		Env:   result.Env,
+	}
+	if result.Err != nil {
+		message.Data = result.Err.Error()
+	}
+	if err := client.getLink().enqueue(message); err != nil {
+		client.getLink().logger.WarnF("Fail to forward error to %s: %v", client.Callsign(), err)
+	}
+}
+
+func (client *RemoteClient) SendLineWithoutLog(line []byte) error {
+	line = bytes.TrimSuffix(line, SplitSign)
+	if err := client.getLink().enqueue(&LinkMessage{Type: LinkPacket, To: client.Callsign(), Data: string(line)}); err != nil {
+		if errors.Is(err, ErrClientQueueFull) {
+			return err
+		}
+		return ErrClientSocketWrite
+	}
+	return nil
+}
+
+func (client *RemoteClient) SendLine(line []byte) {
+	if err := client.SendLineWithoutLog(line); err != nil {
+		client.getLink().logger.WarnF("Fail to forward packet to %s: %v", client.Callsign(), err)
+	}
+}
+
+func (client *RemoteClient) SendMotd() {}
+
+func (client *RemoteClient) ResetMotd() {}
+
+func (client *RemoteClient) UpdateCapacities(_ []string) {}
+
+func (client *RemoteClient) CheckCapacity(_ string) bool { return false }
+
+func (client *RemoteClient) CheckFacility(facility Facility) bool {
+	return facility.CheckFacility(client.Facility())
+}
+
+func (client *RemoteClient) CheckRating(rating []Rating) bool {
+	return slices.Contains(rating, client.Rating())
+}
+
+func (client *RemoteClient) IsAtc() bool { return client.getState().IsAtc }
+
+func (client *RemoteClient) IsAtis() bool { return strings.HasSuffix(client.Callsign(), "ATIS") }
+
+func (client *RemoteClient) IsRemote() bool { return true }
+
+func (client *RemoteClient) IsVirtual() bool { return false }
+
+func (client *RemoteClient) Callsign() string { return client.getState().Callsign }
+
+func (client *RemoteClient) RemoteAddr() string { return "federation:" + client.getLink().peer }
+
+func (client *RemoteClient) ClientSoftware() string { return "" }
+
+func (client *RemoteClient) Rating() Rating { return Rating(client.getState().Rating) }
+
+func (client *RemoteClient) Facility() Facility { return Facility(client.getState().Facility) }
+
+func (client *RemoteClient) RealName() string { return client.getState().RealName }
+
+func (client *RemoteClient) Position() [4]Position { return client.getState().Position }
+
+func (client *RemoteClient) VisualRange() float64 { return client.getState().VisualRange }
+
+func (client *RemoteClient) SetUser(_ *operation.User) {}
+
+func (client *RemoteClient) SetSimType(_ int) {}
+
+func (client *RemoteClient) FlightPlan() *operation.FlightPlan { return client.getState().FlightPlan }
+
+func (client *RemoteClient) User() *operation.User {
+	client.lock.RLock()
+	defer client.lock.RUnlock()
+	return client.user
+}
+
+func (client *RemoteClient) Frequency() int { return client.getState().Frequency }
+
+func (client *RemoteClient) AtisInfo() []string { return client.getState().AtisInfo }
+
+func (client *RemoteClient) History() *operation.History {
+	client.lock.RLock()
+	defer client.lock.RUnlock()
+	return client.history
+}
+
+func (client *RemoteClient) Transponder() string { return client.getState().Transponder }
+
+func (client *RemoteClient) Altitude() int { return client.getState().Altitude }
+
+func (client *RemoteClient) FlightPhase() FlightPhase { return client.getState().FlightPhase }
+
+func (client *RemoteClient) GroundSpeed() int { return client.getState().GroundSpeed }
+
+func (client *RemoteClient) Heading() int { return client.getState().Heading }
+
+func (client *RemoteClient) Paths() []*PilotPath { return make([]*PilotPath, 0) }
+
+func (client *RemoteClient) LogoffTime() string { return client.getState().LogoffTime }
+
+func (client *RemoteClient) SetLogoffTime(_ string) {}
+
+func (client *RemoteClient) IsBreak() bool { return client.getState().IsBreak }
+
+func (client *RemoteClient) SetBreak(_ bool) {}
+
+func (client *RemoteClient) SetRating(_ Rating) {}
+
+func (client *RemoteClient) SetRealName(_ string) {}
+
+func (client *RemoteClient) ClearFlightPlan() {}
+
+func (client *RemoteClient) SetFlightPlan(_ *operation.FlightPlan) {}
+
+func (client *RemoteClient) SetDeleteCallback(_ Callback) {}
+
+func (client *RemoteClient) SetDisconnectCallback(_ Callback) {}
+
+func (client *RemoteClient) SetReconnectCallback(_ Callback) {}
+
+func (client *RemoteClient) SetMessageReceivedCallback(_ func([]byte)) {}
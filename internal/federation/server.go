@@ -0,0 +1,328 @@
+// Package federation
+package federation
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/config"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+)
+
+// FederationServer 联邦服务, 负责与其他SimpleFSD节点建立链路并同步客户端
+type FederationServer struct {
+	logger        log.LoggerInterface
+	config        *config.FederationConfig
+	secret        []byte
+	clientManager fsd.ClientManagerInterface
+	listener      net.Listener
+
+	linksLock sync.Mutex
+	links     map[string]*link
+
+	remotesLock sync.Mutex
+	remotes     map[string]*RemoteClient
+
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewFederationServer(application *interfaces.ApplicationContent) *FederationServer {
+	c := application.ConfigManager().Config().Server.Federation
+	server := &FederationServer{
+		logger:        log.NewLoggerAdapter(application.Logger().FsdLogger(), "Federation"),
+		config:        c,
+		secret:        []byte(c.Secret),
+		clientManager: application.ClientManager(),
+		links:         make(map[string]*link),
+		remotes:       make(map[string]*RemoteClient),
+	}
+	server.ctx, server.cancel = context.WithCancel(context.Background())
+	application.Cleaner().Add(NewShutdownCallback(server))
+	return server
+}
+
+func (s *FederationServer) Start() error {
+	listener, err := net.Listen("tcp", s.config.Address)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+	s.logger.InfoF("Federation node %s listening on %s", s.config.NodeName, listener.Addr())
+
+	s.wg.Add(1)
+	go s.acceptLinks()
+
+	for _, peer := range s.config.Peers {
+		s.wg.Add(1)
+		go s.dialPeer(peer)
+	}
+	return nil
+}
+
+func (s *FederationServer) Stop() {
+	s.logger.Debug("Stopping federation server")
+	s.cancel()
+
+	if s.listener != nil {
+		_ = s.listener.Close()
+	}
+
+	s.linksLock.Lock()
+	for _, l := range s.links {
+		l.close()
+	}
+	s.linksLock.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *FederationServer) acceptLinks() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			s.logger.ErrorF("Accept federation link error: %v", err)
+			continue
+		}
+		s.logger.InfoF("Accepted federation link from %s", conn.RemoteAddr())
+		s.wg.Add(1)
+		go func(l *link) {
+			defer s.wg.Done()
+			s.serveLink(l, "")
+		}(newLink(s.logger, conn, false, s.config.TimeoutDuration))
+	}
+}
+
+func (s *FederationServer) dialPeer(peer *config.FederationPeer) {
+	defer s.wg.Done()
+	dialer := net.Dialer{Timeout: s.config.TimeoutDuration}
+	for {
+		if peer.Name == "" || !s.hasLink(peer.Name) {
+			conn, err := dialer.DialContext(s.ctx, "tcp", peer.Address)
+			if err != nil {
+				s.logger.WarnF("Fail to connect to federation peer %s: %v", peer.Address, err)
+			} else {
+				s.logger.InfoF("Connected to federation peer %s", peer.Address)
+				s.serveLink(newLink(s.logger, conn, true, s.config.TimeoutDuration), peer.Name)
+			}
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-time.After(s.config.ReconnectDuration):
+		}
+	}
+}
+
+func (s *FederationServer) hasLink(peer string) bool {
+	s.linksLock.Lock()
+	defer s.linksLock.Unlock()
+	_, ok := s.links[peer]
+	return ok
+}
+
+// registerLink 注册链路, 如果两个节点之间同时存在两条链路
+// 则保留由节点名称较小的一方发起的链路, 双方会得出相同的结论
+func (s *FederationServer) registerLink(l *link) bool {
+	s.linksLock.Lock()
+	defer s.linksLock.Unlock()
+
+	if s.ctx.Err() != nil {
+		return false
+	}
+
+	if existing, ok := s.links[l.peer]; ok {
+		if existing.dialer(s.config.NodeName) <= l.dialer(s.config.NodeName) {
+			return false
+		}
+		existing.close()
+	}
+	s.links[l.peer] = l
+	return true
+}
+
+func (s *FederationServer) unregisterLink(l *link) {
+	s.linksLock.Lock()
+	defer s.linksLock.Unlock()
+	if existing, ok := s.links[l.peer]; ok && existing == l {
+		delete(s.links, l.peer)
+	}
+}
+
+func (s *FederationServer) serveLink(l *link, expectedPeer string) {
+	defer l.close()
+
+	if err := l.handshake(s.config.NodeName, s.secret, expectedPeer); err != nil {
+		s.logger.WarnF("Federation handshake with %s failed: %v", l.conn.RemoteAddr(), err)
+		return
+	}
+
+	if !s.registerLink(l) {
+		l.logger.Info("Duplicate federation link, closing")
+		return
+	}
+	l.logger.InfoF("Federation link established with %s(%s)", l.peer, l.conn.RemoteAddr())
+
+	defer func() {
+		s.unregisterLink(l)
+		s.removeLinkClients(l)
+		l.logger.Info("Federation link closed")
+	}()
+
+	go s.syncLoop(l)
+	go l.writeLoop()
+
+	for {
+		message, err := l.receive()
+		if err != nil {
+			if !l.closed.Load() {
+				l.logger.WarnF("Federation link read error: %v", err)
+			}
+			return
+		}
+		if err := s.handleMessage(l, message); err != nil {
+			l.logger.WarnF("Fail to handle federation message %s: %v", message.Type, err)
+		}
+	}
+}
+
+func (s *FederationServer) syncLoop(l *link) {
+	ticker := time.NewTicker(s.config.SyncDuration)
+	defer ticker.Stop()
+
+	for {
+		if err := l.syncStates(s.localStates()); err != nil {
+			if !errors.Is(err, ErrLinkClosed) {
+				l.logger.WarnF("Fail to sync client states: %v", err)
+			}
+			l.close()
+			return
+		}
+
+		select {
+		case <-l.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// localStates 收集本节点直接连接的客户端状态, 不包含其他节点的远程客户端和本节点的虚拟客户端
+func (s *FederationServer) localStates() map[string]*ClientState {
+	clients := s.clientManager.GetClientSnapshot()
+	defer s.clientManager.ReleaseClientSnapshot(clients)
+	states := make(map[string]*ClientState, len(clients))
+	for _, client := range clients {
+		if client == nil || client.IsRemote() || client.IsVirtual() {
+			continue
+		}
+		states[client.Callsign()] = newClientState(client)
+	}
+	return states
+}
+
+func (s *FederationServer) handleMessage(l *link, message *LinkMessage) error {
+	switch message.Type {
+	case LinkPing:
+		return nil
+	case LinkState:
+		for _, state := range message.Clients {
+			s.upsertRemoteClient(l, state)
+		}
+		return nil
+	case LinkRemove:
+		for _, callsign := range message.Callsigns {
+			s.removeRemoteClient(l, callsign)
+		}
+		return nil
+	case LinkPacket:
+		return s.deliverPacket(message)
+	case LinkError:
+		return s.deliverError(message)
+	default:
+		return ErrUnexpectedPacket
+	}
+}
+
+func (s *FederationServer) upsertRemoteClient(l *link, state *ClientState) {
+	s.remotesLock.Lock()
+	defer s.remotesLock.Unlock()
+
+	if remote, ok := s.remotes[state.Callsign]; ok {
+		remote.update(l, state)
+		s.clientManager.UpdateClientPosition(remote)
+		return
+	}
+
+	remote := newRemoteClient(l, state)
+	if err := s.clientManager.AddClient(remote); err != nil {
+		l.logger.WarnF("Fail to add remote client %s: %v", state.Callsign, err)
+		return
+	}
+	s.remotes[state.Callsign] = remote
+	l.logger.InfoF("Remote client %s(%d) online", state.Callsign, state.Cid)
+}
+
+func (s *FederationServer) removeRemoteClient(l *link, callsign string) {
+	s.remotesLock.Lock()
+	defer s.remotesLock.Unlock()
+
+	remote, ok := s.remotes[callsign]
+	if !ok || remote.getLink() != l {
+		return
+	}
+	s.deleteRemoteClient(remote)
+	l.logger.InfoF("Remote client %s offline", callsign)
+}
+
+func (s *FederationServer) removeLinkClients(l *link) {
+	s.remotesLock.Lock()
+	defer s.remotesLock.Unlock()
+
+	for _, remote := range s.remotes {
+		if remote.getLink() == l {
+			s.deleteRemoteClient(remote)
+		}
+	}
+}
+
+// deleteRemoteClient 从客户端管理器中删除远程客户端, 调用方需持有remotesLock
+func (s *FederationServer) deleteRemoteClient(remote *RemoteClient) {
+	callsign := remote.Callsign()
+	delete(s.remotes, callsign)
+	if client, ok := s.clientManager.GetClient(callsign); ok && client == fsd.ClientInterface(remote) {
+		s.clientManager.DeleteClient(callsign)
+	}
+}
+
+// deliverPacket 将其他节点转发过来的数据包发送给本地客户端
+func (s *FederationServer) deliverPacket(message *LinkMessage) error {
+	if client, ok := s.clientManager.GetClient(message.To); ok && client.IsRemote() {
+		// 只投递给本节点客户端, 防止数据包在节点间循环
+		return nil
+	}
+	return s.clientManager.SendMessageTo(message.To, []byte(message.Data))
+}
+
+func (s *FederationServer) deliverError(message *LinkMessage) error {
+	client, ok := s.clientManager.GetClient(message.To)
+	if !ok {
+		return fsd.ErrCallsignNotFound
+	}
+	if client.IsRemote() {
+		return nil
+	}
+	client.SendError(fsd.ResultError(fsd.ClientError(message.Errno), message.Fatal, message.Env, errors.New(message.Data)))
+	return nil
+}
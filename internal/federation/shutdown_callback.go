@@ -0,0 +1,35 @@
+// Package federation
+package federation
+
+import (
+	"context"
+	"time"
+)
+
+type ShutdownCallback struct {
+	server *FederationServer
+}
+
+func NewShutdownCallback(server *FederationServer) *ShutdownCallback {
+	return &ShutdownCallback{
+		server: server,
+	}
+}
+
+func (callback *ShutdownCallback) Invoke(ctx context.Context) error {
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		callback.server.Stop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-timeoutCtx.Done():
+		return timeoutCtx.Err()
+	}
+}
@@ -0,0 +1,250 @@
+// Package atis
+package atis
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/global"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+)
+
+var ErrVirtualClient = errors.New("operation not supported on virtual atis client")
+
+const (
+	atisFacility = TWR
+	atisRating   = STU2
+	atisRealName = "Automated ATIS"
+)
+
+// AtisClient 服务器生成的虚拟ATIS客户端
+// 只响应发给自己的ATIS、CAPS和RN查询, 修改状态的方法均为空操作
+type AtisClient struct {
+	lock          sync.RWMutex
+	logger        log.LoggerInterface
+	clientManager ClientManagerInterface
+	callsign      string
+	frequency     int
+	visualRange   float64
+	position      [4]Position
+	atisInfo      []string
+	user          *operation.User
+	history       *operation.History
+}
+
+func newAtisClient(
+	logger log.LoggerInterface,
+	clientManager ClientManagerInterface,
+	callsign string,
+	frequency int,
+	visualRange float64,
+	position Position,
+) *AtisClient {
+	return &AtisClient{
+		logger:        logger,
+		clientManager: clientManager,
+		callsign:      callsign,
+		frequency:     frequency,
+		visualRange:   visualRange,
+		position:      [4]Position{position},
+		atisInfo:      make([]string, 0),
+		user:          &operation.User{Cid: 0},
+		history:       &operation.History{Callsign: callsign, StartTime: time.Now(), IsAtc: true},
+	}
+}
+
+func (client *AtisClient) setAtisInfo(lines []string) {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+	client.atisInfo = lines
+}
+
+// resetHistory 重新上线时刷新上线时间
+func (client *AtisClient) resetHistory() {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+	client.history = &operation.History{Callsign: client.callsign, StartTime: time.Now(), IsAtc: true}
+}
+
+// handleQuery 响应其他客户端发给虚拟ATIS的查询
+func (client *AtisClient) handleQuery(data []string) {
+	if len(data) < 3 || data[1] != client.callsign {
+		return
+	}
+	from := strings.TrimPrefix(data[0], string(ClientQuery))
+	switch data[2] {
+	case AtcAtis:
+		lines := client.AtisInfo()
+		for _, line := range lines {
+			client.reply(from, AtcAtis, "T", line)
+		}
+		client.reply(from, AtcAtis, "E", strconv.Itoa(len(lines)))
+	case ClientCapacity:
+		client.reply(from, ClientCapacity, "ATCINFO=1")
+	case ClientRealName:
+		client.reply(from, ClientRealName, atisRealName, "", strconv.Itoa(atisRating.Index()))
+	}
+}
+
+func (client *AtisClient) reply(to string, parts ...string) {
+	packet := MakePacket(ClientResponse, append([]string{client.callsign, to}, parts...)...)
+	if err := client.clientManager.SendMessageTo(to, packet); err != nil {
+		client.logger.DebugF("Fail to reply %s to %s: %v", parts[0], to, err)
+	}
+}
+
+func (client *AtisClient) Disconnected() bool { return false }
+
+func (client *AtisClient) Delete() {}
+
+func (client *AtisClient) Reconnect(_ SessionInterface) bool { return false }
+
+func (client *AtisClient) IssueResumeToken() string { return "" }
+
+func (client *AtisClient) CheckResumeToken(_ string) bool { return false }
+
+func (client *AtisClient) ResumeToken() string { return "" }
+
+func (client *AtisClient) MarkedDisconnect(_ bool) {}
+
+func (client *AtisClient) Discard() {}
+
+func (client *AtisClient) UpsertFlightPlan(_ []string) error { return ErrVirtualClient }
+
+func (client *AtisClient) SetPosition(_ int, _ float64, _ float64) error { return ErrVirtualClient }
+
+func (client *AtisClient) UpdatePilotPos(_ int, _ float64, _ float64, _ int, _ int, _ uint32) {}
+
+func (client *AtisClient) UpdateVisualPos(_ *VisualPosition) {}
+
+func (client *AtisClient) VisualPosition() *VisualPosition { return nil }
+
+func (client *AtisClient) SetFastUpdate(_ bool) bool { return false }
+
+func (client *AtisClient) UpdateAtcPos(_ int, _ Facility, _ float64, _ float64, _ float64) {}
+
+func (client *AtisClient) UpdateAtcVisPoint(_ int, _ float64, _ float64) error {
+	return ErrVirtualClient
+}
+
+func (client *AtisClient) ClearAtcAtisInfo() {}
+
+func (client *AtisClient) AddAtcAtisInfo(_ string) {}
+
+func (client *AtisClient) SendError(_ *Result) {}
+
+func (client *AtisClient) SendLineWithoutLog(line []byte) error {
+	line = bytes.TrimSuffix(line, SplitSign)
+	if bytes.HasPrefix(line, []byte(ClientQuery)) {
+		client.handleQuery(strings.Split(string(line), ":"))
+	}
+	return nil
+}
+
+func (client *AtisClient) SendLine(line []byte) { _ = client.SendLineWithoutLog(line) }
+
+func (client *AtisClient) SendMotd() {}
+
+func (client *AtisClient) ResetMotd() {}
+
+func (client *AtisClient) UpdateCapacities(_ []string) {}
+
+func (client *AtisClient) CheckCapacity(_ string) bool { return false }
+
+func (client *AtisClient) CheckFacility(facility Facility) bool {
+	return facility.CheckFacility(atisFacility)
+}
+
+func (client *AtisClient) CheckRating(rating []Rating) bool {
+	return slices.Contains(rating, atisRating)
+}
+
+func (client *AtisClient) IsAtc() bool { return true }
+
+func (client *AtisClient) IsAtis() bool { return true }
+
+func (client *AtisClient) IsRemote() bool { return false }
+
+// IsVirtual 虚拟客户端没有连接, 不占用本节点的连接数, 也不同步到其他联邦节点
+func (client *AtisClient) IsVirtual() bool { return true }
+
+func (client *AtisClient) Callsign() string { return client.callsign }
+
+func (client *AtisClient) RemoteAddr() string { return global.FSDServerName }
+
+func (client *AtisClient) ClientSoftware() string { return "ATIS generator" }
+
+func (client *AtisClient) Rating() Rating { return atisRating }
+
+func (client *AtisClient) Facility() Facility { return atisFacility }
+
+func (client *AtisClient) RealName() string { return atisRealName }
+
+func (client *AtisClient) Position() [4]Position { return client.position }
+
+func (client *AtisClient) VisualRange() float64 { return client.visualRange }
+
+func (client *AtisClient) SetUser(_ *operation.User) {}
+
+func (client *AtisClient) SetSimType(_ int) {}
+
+func (client *AtisClient) FlightPlan() *operation.FlightPlan { return nil }
+
+func (client *AtisClient) User() *operation.User { return client.user }
+
+func (client *AtisClient) Frequency() int { return client.frequency }
+
+func (client *AtisClient) AtisInfo() []string {
+	client.lock.RLock()
+	defer client.lock.RUnlock()
+	return client.atisInfo
+}
+
+func (client *AtisClient) History() *operation.History {
+	client.lock.RLock()
+	defer client.lock.RUnlock()
+	return client.history
+}
+
+func (client *AtisClient) Transponder() string { return "" }
+
+func (client *AtisClient) Altitude() int { return 0 }
+
+func (client *AtisClient) FlightPhase() FlightPhase { return "" }
+
+func (client *AtisClient) GroundSpeed() int { return 0 }
+
+func (client *AtisClient) Heading() int { return 0 }
+
+func (client *AtisClient) Paths() []*PilotPath { return make([]*PilotPath, 0) }
+
+func (client *AtisClient) LogoffTime() string { return "" }
+
+func (client *AtisClient) SetLogoffTime(_ string) {}
+
+func (client *AtisClient) IsBreak() bool { return false }
+
+func (client *AtisClient) SetBreak(_ bool) {}
+
+func (client *AtisClient) SetRating(_ Rating) {}
+
+func (client *AtisClient) SetRealName(_ string) {}
+
+func (client *AtisClient) ClearFlightPlan() {}
+
+func (client *AtisClient) SetFlightPlan(_ *operation.FlightPlan) {}
+
+func (client *AtisClient) SetDeleteCallback(_ Callback) {}
+
+func (client *AtisClient) SetDisconnectCallback(_ Callback) {}
+
+func (client *AtisClient) SetReconnectCallback(_ Callback) {}
+
+func (client *AtisClient) SetMessageReceivedCallback(_ func([]byte)) {}
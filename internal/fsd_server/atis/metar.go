@@ -0,0 +1,91 @@
+// Package atis
+package atis
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// notAvailable METAR中缺少对应字段时的占位文本
+const notAvailable = "N/A"
+
+var (
+	timeRegex        = regexp.MustCompile(`^\d{2}(\d{4})Z$`)
+	windRegex        = regexp.MustCompile(`^(\d{3}|VRB)(\d{2,3})(G(\d{2,3}))?(KT|MPS)$`)
+	visibilityRegex  = regexp.MustCompile(`^\d{4}$`)
+	statuteMileRegex = regexp.MustCompile(`^[PM]?\d+(/\d+)?SM$`)
+	temperatureRegex = regexp.MustCompile(`^(M?\d{2})/(M?\d{2})?$`)
+	qnhRegex         = regexp.MustCompile(`^([QA])(\d{4})$`)
+)
+
+// metarInfo 从METAR中解析出的ATIS需要的字段
+type metarInfo struct {
+	time        string
+	wind        string
+	visibility  string
+	temperature string
+	dewpoint    string
+	qnh         string
+}
+
+func formatTemperature(value string) string {
+	return strings.Replace(value, "M", "-", 1)
+}
+
+// parseMetar 解析METAR报文, 无法识别的字段保持为N/A
+func parseMetar(metar string) *metarInfo {
+	info := &metarInfo{
+		time:        notAvailable,
+		wind:        notAvailable,
+		visibility:  notAvailable,
+		temperature: notAvailable,
+		dewpoint:    notAvailable,
+		qnh:         notAvailable,
+	}
+	for _, field := range strings.Fields(metar) {
+		switch {
+		case info.time == notAvailable && timeRegex.MatchString(field):
+			info.time = timeRegex.FindStringSubmatch(field)[1] + "Z"
+		case info.wind == notAvailable && windRegex.MatchString(field):
+			match := windRegex.FindStringSubmatch(field)
+			speed, _ := strconv.Atoi(match[2])
+			if speed == 0 {
+				info.wind = "CALM"
+				continue
+			}
+			info.wind = fmt.Sprintf("%s/%d%s", match[1], speed, match[5])
+			if match[4] != "" {
+				gust, _ := strconv.Atoi(match[4])
+				info.wind = fmt.Sprintf("%s/%dG%d%s", match[1], speed, gust, match[5])
+			}
+		case info.visibility == notAvailable && field == "CAVOK":
+			info.visibility = "CAVOK"
+		case info.visibility == notAvailable && visibilityRegex.MatchString(field):
+			if field == "9999" {
+				info.visibility = "10KM"
+			} else {
+				meters, _ := strconv.Atoi(field)
+				info.visibility = fmt.Sprintf("%dM", meters)
+			}
+		case info.visibility == notAvailable && statuteMileRegex.MatchString(field):
+			info.visibility = field
+		case info.temperature == notAvailable && temperatureRegex.MatchString(field):
+			match := temperatureRegex.FindStringSubmatch(field)
+			info.temperature = formatTemperature(match[1])
+			if match[2] != "" {
+				info.dewpoint = formatTemperature(match[2])
+			}
+		case info.qnh == notAvailable && qnhRegex.MatchString(field):
+			match := qnhRegex.FindStringSubmatch(field)
+			if match[1] == "Q" {
+				value, _ := strconv.Atoi(match[2])
+				info.qnh = strconv.Itoa(value)
+			} else {
+				info.qnh = match[2][:2] + "." + match[2][2:]
+			}
+		}
+	}
+	return info
+}
@@ -0,0 +1,56 @@
+// Package atis
+package atis
+
+import "testing"
+
+func TestParseMetar(t *testing.T) {
+	tests := []struct {
+		metar    string
+		expected metarInfo
+	}{
+		{
+			"ZSSS 011200Z 09005MPS 9999 FEW030 25/18 Q1012 NOSIG",
+			metarInfo{"1200Z", "090/5MPS", "10KM", "25", "18", "1012"},
+		},
+		{
+			"METAR ZBAA 010600Z VRB02MPS CAVOK M05/M15 Q1030 NOSIG=",
+			metarInfo{"0600Z", "VRB/2MPS", "CAVOK", "-05", "-15", "1030"},
+		},
+		{
+			"KJFK 011751Z 31015G25KT 10SM FEW050 12/M03 A2992 RMK AO2",
+			metarInfo{"1751Z", "310/15G25KT", "10SM", "12", "-03", "29.92"},
+		},
+		{
+			"EGLL 010920Z 00000KT 0800 R27L/1000 FG VV002 08/08 Q0998",
+			metarInfo{"0920Z", "CALM", "800M", "08", "08", "998"},
+		},
+		{
+			"KSFO 011456Z 28008KT 1/2SM FG OVC002 11/ A3001",
+			metarInfo{"1456Z", "280/8KT", "1/2SM", "11", notAvailable, "30.01"},
+		},
+		{
+			"ZGGG 010300Z 160105KT 3000 TSRA 30/26 Q1004 Q1005",
+			metarInfo{"0300Z", "160/105KT", "3000M", "30", "26", "1004"},
+		},
+		{
+			"ZUUU NIL",
+			metarInfo{notAvailable, notAvailable, notAvailable, notAvailable, notAvailable, notAvailable},
+		},
+		{
+			"",
+			metarInfo{notAvailable, notAvailable, notAvailable, notAvailable, notAvailable, notAvailable},
+		},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		result := parseMetar(test.metar)
+		if *result != test.expected {
+			fail++
+			t.Errorf("parseMetar(%q) = %+v; expected %+v", test.metar, *result, test.expected)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestParseMetar: %d pass, %d fail", pass, fail)
+}
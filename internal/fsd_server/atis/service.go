@@ -0,0 +1,240 @@
+// Package atis
+package atis
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/config"
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/global"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+)
+
+// positionInterval 虚拟ATIS广播位置的间隔
+const positionInterval = 5 * time.Second
+
+// lineReplacer 冒号是FSD协议的字段分隔符, 不能出现在ATIS文本中
+var lineReplacer = strings.NewReplacer(":", " ", "\r", "", "\n", "")
+
+// atisStation 单个机场的虚拟ATIS
+type atisStation struct {
+	config   *config.AtisAirportConfig
+	template []string
+	client   *AtisClient
+	metar    string
+	letter   int
+	online   bool
+}
+
+func (station *atisStation) letterString() string {
+	return string(rune('A' + station.letter))
+}
+
+// render 使用当前METAR和信息代码生成ATIS文本
+func (station *atisStation) render() []string {
+	info := parseMetar(station.metar)
+	replacer := strings.NewReplacer(
+		"{icao}", station.config.Icao,
+		"{letter}", station.letterString(),
+		"{time}", info.time,
+		"{approach}", station.config.Approach,
+		"{arrival_runways}", strings.Join(station.config.ArrivalRunways, ","),
+		"{departure_runways}", strings.Join(station.config.DepartureRunways, ","),
+		"{wind}", info.wind,
+		"{visibility}", info.visibility,
+		"{temperature}", info.temperature,
+		"{dewpoint}", info.dewpoint,
+		"{qnh}", info.qnh,
+		"{transition_level}", station.config.TransitionLevel,
+	)
+	lines := make([]string, 0, len(station.template))
+	for _, line := range station.template {
+		lines = append(lines, lineReplacer.Replace(replacer.Replace(line)))
+	}
+	return lines
+}
+
+// AtisService 根据METAR自动生成机场ATIS, 并以虚拟客户端的形式上线
+// 机场有真实管制员在线时虚拟ATIS自动下线, 管制员下线后重新上线
+type AtisService struct {
+	logger        log.LoggerInterface
+	config        *config.FsdAtisConfig
+	clientManager ClientManagerInterface
+	metarManager  interfaces.MetarManagerInterface
+	stations      []*atisStation
+	stopOnce      sync.Once
+	stop          chan struct{}
+	wg            sync.WaitGroup
+}
+
+func NewAtisService(application *interfaces.ApplicationContent) *AtisService {
+	c := application.ConfigManager().Config()
+	service := &AtisService{
+		logger:        log.NewLoggerAdapter(application.Logger().FsdLogger(), "AtisService"),
+		config:        c.Server.FSDServer.Atis,
+		clientManager: application.ClientManager(),
+		metarManager:  application.MetarManager(),
+		stations:      make([]*atisStation, 0, len(c.Server.FSDServer.Atis.Airports)),
+		stop:          make(chan struct{}),
+	}
+	for _, airport := range service.config.Airports {
+		airportData := c.GetAirportData(airport.Icao)
+		if airportData == nil {
+			service.logger.WarnF("Airport %s not found in airport data, skip its ATIS", airport.Icao)
+			continue
+		}
+		template := airport.Template
+		if len(template) == 0 {
+			template = service.config.Template
+		}
+		client := newAtisClient(service.logger, service.clientManager, airport.Icao+"_ATIS", airport.FrequencyValue,
+			float64(service.config.VisualRange), Position{Latitude: airportData.Lat, Longitude: airportData.Lon})
+		service.stations = append(service.stations, &atisStation{config: airport, template: template, client: client})
+	}
+	application.Cleaner().Add(NewShutdownCallback(service))
+	return service
+}
+
+func (service *AtisService) Start() {
+	service.logger.InfoF("ATIS generator started with %d airport(s)", len(service.stations))
+	service.wg.Add(1)
+	go service.run()
+}
+
+func (service *AtisService) Stop() {
+	service.stopOnce.Do(func() {
+		close(service.stop)
+		service.wg.Wait()
+		for _, station := range service.stations {
+			service.withdraw(station)
+		}
+	})
+}
+
+func (service *AtisService) run() {
+	defer service.wg.Done()
+
+	service.updateAll()
+
+	updateTicker := time.NewTicker(service.config.UpdateIntervalDuration)
+	defer updateTicker.Stop()
+	positionTicker := time.NewTicker(positionInterval)
+	defer positionTicker.Stop()
+
+	for {
+		select {
+		case <-service.stop:
+			return
+		case <-updateTicker.C:
+			service.updateAll()
+		case <-positionTicker.C:
+			service.broadcastPositions()
+		}
+	}
+}
+
+func (service *AtisService) updateAll() {
+	for _, station := range service.stations {
+		service.update(station)
+	}
+}
+
+// update 检查METAR是否变化, 变化时推进信息代码并重新生成ATIS
+func (service *AtisService) update(station *atisStation) {
+	metar, err := service.metarManager.QueryMetar(station.config.Icao)
+	if err != nil {
+		service.logger.WarnF("Fail to query metar of %s: %v", station.config.Icao, err)
+		if station.metar == "" {
+			return
+		}
+	} else if metar != station.metar {
+		if station.metar != "" {
+			station.letter = (station.letter + 1) % 26
+		}
+		station.metar = metar
+		station.client.setAtisInfo(station.render())
+		if station.online {
+			service.clientManager.TrafficHub().Publish(TrafficAtis, station.client)
+		}
+		service.logger.InfoF("%s information %s: %s", station.client.Callsign(), station.letterString(), metar)
+	}
+	service.refreshOnline(station)
+}
+
+// controllerOnline 机场是否有真实管制员在线
+func (service *AtisService) controllerOnline(station *atisStation) bool {
+	clients := service.clientManager.GetClientSnapshot()
+	defer service.clientManager.ReleaseClientSnapshot(clients)
+	prefix := station.config.Icao + "_"
+	for _, client := range clients {
+		if client == nil || client == ClientInterface(station.client) || client.Disconnected() {
+			continue
+		}
+		if client.IsAtc() && strings.HasPrefix(client.Callsign(), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (service *AtisService) refreshOnline(station *atisStation) {
+	if station.metar == "" {
+		return
+	}
+	if service.controllerOnline(station) {
+		if station.online {
+			service.logger.InfoF("Controller online at %s, withdraw %s", station.config.Icao, station.client.Callsign())
+			service.withdraw(station)
+		}
+		return
+	}
+	if !station.online {
+		service.publish(station)
+	}
+}
+
+func (service *AtisService) publish(station *atisStation) {
+	station.client.resetHistory()
+	if err := service.clientManager.AddClient(station.client); err != nil {
+		service.logger.WarnF("Fail to add %s: %v", station.client.Callsign(), err)
+		return
+	}
+	station.online = true
+	service.clientManager.BroadcastMessageInRange(MakePacket(AddAtc, station.client.Callsign(), global.FSDServerName,
+		atisRealName, strconv.Itoa(station.client.User().Cid), "", strconv.Itoa(atisRating.Index())), station.client, nil)
+	service.broadcastPosition(station)
+}
+
+func (service *AtisService) withdraw(station *atisStation) {
+	if !station.online {
+		return
+	}
+	station.online = false
+	if client, ok := service.clientManager.GetClient(station.client.Callsign()); ok && client == ClientInterface(station.client) {
+		service.clientManager.DeleteClient(station.client.Callsign())
+	}
+	service.clientManager.BroadcastMessageInRange(MakePacket(RemoveAtc, station.client.Callsign(),
+		fmt.Sprintf("%04d", station.client.User().Cid)), station.client, nil)
+}
+
+func (service *AtisService) broadcastPositions() {
+	for _, station := range service.stations {
+		if station.online {
+			service.broadcastPosition(station)
+		}
+	}
+}
+
+func (service *AtisService) broadcastPosition(station *atisStation) {
+	client := station.client
+	position := client.Position()[0]
+	service.clientManager.BroadcastMessageInRange(MakePacket(AtcPosition, client.Callsign(),
+		strconv.Itoa(client.Frequency()), strconv.Itoa(atisFacility.Index()), strconv.Itoa(int(client.VisualRange())),
+		strconv.Itoa(atisRating.Index()), strconv.FormatFloat(position.Latitude, 'f', 5, 64),
+		strconv.FormatFloat(position.Longitude, 'f', 5, 64), "0"), client, nil)
+}
@@ -0,0 +1,35 @@
+// Package atis
+package atis
+
+import (
+	"context"
+	"time"
+)
+
+type ShutdownCallback struct {
+	service *AtisService
+}
+
+func NewShutdownCallback(service *AtisService) *ShutdownCallback {
+	return &ShutdownCallback{
+		service: service,
+	}
+}
+
+func (callback *ShutdownCallback) Invoke(ctx context.Context) error {
+	timeoutCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		callback.service.Stop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-timeoutCtx.Done():
+		return timeoutCtx.Err()
+	}
+}
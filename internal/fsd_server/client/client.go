@@ -2,6 +2,9 @@ package client
 
 import (
 	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"slices"
@@ -16,15 +19,21 @@ import (
 	"github.com/half-nothing/simple-fsd/internal/interfaces/global"
 	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
 	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+	"github.com/half-nothing/simple-fsd/internal/metrics"
 	"github.com/half-nothing/simple-fsd/internal/utils"
 )
 
 type Client struct {
 	socket                  SessionInterface
+	outbound                *outboundQueue
 	logger                  log.LoggerInterface
 	config                  *config.Config
+	configManager           interfaces.ConfigManagerInterface
 	userOperation           operation.UserOperationInterface
 	flightPlanOperation     operation.FlightPlanOperationInterface
+	revisionOperation       operation.FlightPlanRevisionOperationInterface
+	trackOperation          operation.FlightTrackOperationInterface
+	logbookOperation        operation.LogbookOperationInterface
 	historyOperation        operation.HistoryOperationInterface
 	capacities              map[string]bool
 	isAtc                   bool
@@ -44,6 +53,8 @@ type Client struct {
 	groundSpeed             int
 	frequency               int
 	pbh                     uint32
+	visualPosition          *VisualPosition
+	fastUpdate              atomic.Bool
 	visualRange             float64
 	flightPlan              *operation.FlightPlan
 	atisInfo                []string
@@ -53,8 +64,10 @@ type Client struct {
 	disconnect              atomic.Bool
 	motdBytes               []byte
 	reconnectTimer          *time.Timer
+	resumeToken             string
 	lock                    sync.RWMutex
 	pathTrigger             *utils.OverflowTrigger
+	lifecycle               *flightLifecycle
 	deleteCallback          Callback
 	disconnectCallback      Callback
 	reconnectCallback       Callback
@@ -97,8 +110,12 @@ func NewClient(
 	client := &Client{
 		logger:              log.NewLoggerAdapter(logger, fmt.Sprintf("%s(%d)[%s]", callsign, session.User().Cid, session.ConnId())),
 		config:              c,
+		configManager:       applicationContent.ConfigManager(),
 		userOperation:       userOperation,
 		flightPlanOperation: flightPlanOperation,
+		revisionOperation:   applicationContent.Operations().FlightPlanRevisionOperation(),
+		trackOperation:      applicationContent.Operations().FlightTrackOperation(),
+		logbookOperation:    applicationContent.Operations().LogbookOperation(),
 		historyOperation:    historyOperation,
 		capacities:          make(map[string]bool),
 		isAtc:               isAtc,
@@ -130,7 +147,11 @@ func NewClient(
 		lock:                sync.RWMutex{},
 		arrivalAirportData:  nil,
 	}
+	client.outbound = client.newOutboundQueue(session)
 	client.pathTrigger = utils.NewOverflowTrigger(c.Server.FSDServer.PosUpdatePoints, client.recordPathPoint)
+	if !isAtc {
+		client.lifecycle = newFlightLifecycle(c.Server.FSDServer.AirportData, session.User().Cid, callsign)
+	}
 	return client
 }
 
@@ -139,6 +160,49 @@ func (client *Client) recordPathPoint() {
 		Latitude:  client.position[0].Latitude,
 		Longitude: client.position[0].Longitude,
 		Altitude:  client.altitude,
+		Time:      time.Now(),
+	})
+}
+
+// saveTrack 保存本次连线的航迹, 需要在联飞记录保存之后调用
+func (client *Client) saveTrack() {
+	if len(client.paths) == 0 || client.history.ID == 0 {
+		return
+	}
+	points := make([]*operation.TrackPoint, 0, len(client.paths))
+	for _, path := range client.paths {
+		points = append(points, &operation.TrackPoint{
+			Latitude:  path.Latitude,
+			Longitude: path.Longitude,
+			Altitude:  path.Altitude,
+			Time:      path.Time,
+		})
+	}
+	departure, arrival := "", ""
+	if client.flightPlan != nil {
+		departure, arrival = client.flightPlan.DepartureAirport, client.flightPlan.ArrivalAirport
+	}
+	track, err := client.trackOperation.NewFlightTrack(client.history, departure, arrival, points)
+	if err != nil {
+		client.logger.ErrorF("Failed to encode flight track: %v", err)
+		return
+	}
+	if err := client.trackOperation.SaveFlightTrack(track); err != nil {
+		client.logger.ErrorF("Failed to save flight track: %v", err)
+	}
+}
+
+func (client *Client) newOutboundQueue(session SessionInterface) *outboundQueue {
+	return newOutboundQueue(session, client.config.Server.FSDServer.OutboundQueue, func(err error) {
+		client.lock.RLock()
+		current := client.socket == session
+		client.lock.RUnlock()
+		// 旧连接的写入错误不影响重连后的会话
+		if !current {
+			return
+		}
+		client.logger.WarnF("Failed to send data: %v", err)
+		client.MarkedDisconnect(false)
 	})
 }
 
@@ -159,6 +223,8 @@ func (client *Client) Delete() {
 		client.reconnectTimer = nil
 	}
 
+	client.outbound.close()
+
 	defer func() {
 		client.logger.Info("Client session deleted")
 		if !client.clientManager.DeleteClient(client.callsign) {
@@ -182,6 +248,13 @@ func (client *Client) Delete() {
 		}
 	}
 
+	// 保存已经起飞但尚未到达停机位的飞行日志
+	if client.lifecycle != nil {
+		if entry := client.lifecycle.close(client.flightPlan); entry != nil {
+			client.saveLogbook(entry)
+		}
+	}
+
 	// 如果判断飞机已在目的机场内，则删除计划
 	if client.flightPlan != nil && client.checkArrival() {
 		err := client.flightPlanOperation.DeleteFlightPlan(client.flightPlan)
@@ -223,6 +296,7 @@ func (client *Client) Delete() {
 		if err := client.userOperation.UpdateUserPilotTime(client.user, client.history.OnlineTime); err != nil {
 			client.logger.Error("Failed to add pilot time: %v")
 		}
+		client.saveTrack()
 	}
 }
 
@@ -244,6 +318,8 @@ func (client *Client) Reconnect(socket SessionInterface) bool {
 	client.ClearAtcAtisInfo()
 	client.capacities = make(map[string]bool)
 	client.disconnect.Store(false)
+	client.outbound.close()
+	client.outbound = client.newOutboundQueue(socket)
 	client.socket = socket
 	socket.SetCallsign(client.callsign)
 	if client.reconnectCallback != nil {
@@ -252,6 +328,37 @@ func (client *Client) Reconnect(socket SessionInterface) bool {
 	return true
 }
 
+func (client *Client) IssueResumeToken() string {
+	data := make([]byte, 16)
+	_, _ = rand.Read(data)
+	token := hex.EncodeToString(data)
+
+	client.lock.Lock()
+	defer client.lock.Unlock()
+	client.resumeToken = token
+	return token
+}
+
+func (client *Client) CheckResumeToken(token string) bool {
+	client.lock.RLock()
+	defer client.lock.RUnlock()
+	if client.resumeToken == "" || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(client.resumeToken), []byte(token)) == 1
+}
+
+func (client *Client) ResumeToken() string {
+	client.lock.RLock()
+	defer client.lock.RUnlock()
+	return client.resumeToken
+}
+
+func (client *Client) Discard() {
+	client.disconnect.Store(true)
+	client.outbound.close()
+}
+
 func (client *Client) MarkedDisconnect(immediate bool) {
 	client.lock.Lock()
 	defer func() {
@@ -265,10 +372,8 @@ func (client *Client) MarkedDisconnect(immediate bool) {
 		return
 	}
 
-	// 关闭连接
-	if client.socket.Conn() != nil {
-		_ = client.socket.Conn().Close()
-	}
+	// 关闭发送队列, 写协程发出剩余数据后关闭连接
+	client.outbound.closeWithConn()
 
 	// 取消之前的定时器
 	if client.reconnectTimer != nil {
@@ -304,6 +409,8 @@ func (client *Client) UpsertFlightPlan(flightPlanData []string) error {
 			return err
 		}
 		client.flightPlan = flightPlan
+		client.saveRevision(nil, flightPlan)
+		client.clientManager.TrafficHub().Publish(TrafficFlightPlan, client)
 		return nil
 	}
 	// 如果是模拟机服务器, 只创建就行
@@ -317,8 +424,24 @@ func (client *Client) UpsertFlightPlan(flightPlanData []string) error {
 			client.flightPlan.Locked = false
 		}
 	}
-	err := client.flightPlanOperation.UpdateFlightPlan(client.flightPlan, flightPlanData, false)
-	return err
+	oldFlightPlan := *client.flightPlan
+	if err := client.flightPlanOperation.UpdateFlightPlan(client.flightPlan, flightPlanData, false); err != nil {
+		return err
+	}
+	client.saveRevision(&oldFlightPlan, client.flightPlan)
+	client.clientManager.TrafficHub().Publish(TrafficFlightPlan, client)
+	return nil
+}
+
+// saveRevision 记录机组对飞行计划的修改
+func (client *Client) saveRevision(oldFlightPlan, newFlightPlan *operation.FlightPlan) {
+	revision := client.revisionOperation.NewRevision(oldFlightPlan, newFlightPlan, operation.FlightPlanEditByPilot, client.callsign, client.user.Cid)
+	if revision == nil {
+		return
+	}
+	if err := client.revisionOperation.SaveRevision(revision); err != nil {
+		client.logger.ErrorF("Fail to save flight plan revision: %v", err)
+	}
 }
 
 func (client *Client) SetPosition(index int, lat float64, lon float64) error {
@@ -343,7 +466,42 @@ func (client *Client) UpdatePilotPos(transponder int, lat float64, lon float64,
 	client.altitude = alt
 	client.groundSpeed = groundSpeed
 	client.pbh = pbh
+	client.clientManager.UpdateClientPosition(client)
 	go client.pathTrigger.Tick()
+	if client.lifecycle != nil {
+		_, _, _, onGround := utils.UnpackPBH(pbh)
+		if entry := client.lifecycle.update(client.position[0], alt, groundSpeed, onGround, client.flightPlan); entry != nil {
+			go client.saveLogbook(entry)
+		}
+	}
+}
+
+// saveLogbook 保存飞行日志
+func (client *Client) saveLogbook(entry *operation.LogbookEntry) {
+	if client.config.Server.General.SimulatorServer {
+		return
+	}
+	if err := client.logbookOperation.SaveLogbookEntry(entry); err != nil {
+		client.logger.ErrorF("Failed to save logbook entry: %v", err)
+	}
+}
+
+// UpdateVisualPos 处理快速位置更新, 只更新位置与姿态, 不记录飞行路径
+func (client *Client) UpdateVisualPos(position *VisualPosition) {
+	_ = client.SetPosition(0, position.Latitude, position.Longitude)
+	client.altitude = int(position.AltitudeTrue)
+	client.pbh = position.Pbh
+	client.visualPosition = position
+	client.clientManager.UpdateClientPosition(client)
+	if client.lifecycle != nil {
+		client.lifecycle.updateVisual(position)
+	}
+}
+
+func (client *Client) VisualPosition() *VisualPosition { return client.visualPosition }
+
+func (client *Client) SetFastUpdate(enabled bool) bool {
+	return client.fastUpdate.Swap(enabled) != enabled
 }
 
 func (client *Client) UpdateAtcPos(frequency int, facility Facility, visualRange float64, lat float64, lon float64) {
@@ -351,13 +509,18 @@ func (client *Client) UpdateAtcPos(frequency int, facility Facility, visualRange
 	client.frequency = frequency
 	client.facility = facility
 	client.visualRange = visualRange
+	client.clientManager.UpdateClientPosition(client)
 }
 
 func (client *Client) UpdateAtcVisPoint(visIndex int, lat float64, lon float64) error {
 	if visIndex < 0 || visIndex > 2 {
 		return errors.New("visIndex out of range [0,2]")
 	}
-	return client.SetPosition(visIndex+1, lat, lon)
+	if err := client.SetPosition(visIndex+1, lat, lon); err != nil {
+		return err
+	}
+	client.clientManager.UpdateClientPosition(client)
+	return nil
 }
 
 func (client *Client) ClearAtcAtisInfo() {
@@ -390,6 +553,24 @@ func (client *Client) SendError(result *Result) {
 	}
 }
 
+// send 将数据包放入发送队列, 调用方需持有读锁
+func (client *Client) send(line []byte) error {
+	if err := client.outbound.push(line); err != nil {
+		if errors.Is(err, ErrClientQueueFull) && client.config.Server.FSDServer.OutboundQueue.OverflowPolicy == config.OverflowDisconnect {
+			client.logger.Warn("Outbound queue overflow, disconnecting client")
+			go client.MarkedDisconnect(false)
+		}
+		return err
+	}
+	metrics.CountFsdOutbound(line)
+
+	if client.messageReceivedCallback != nil && bytes.HasPrefix(line, []byte(Message)) {
+		_, result, _ := bytes.Cut(bytes.TrimSuffix(line, SplitSign), []byte(Message))
+		go client.messageReceivedCallback(append(bytes.Clone(result), SplitSign...))
+	}
+	return nil
+}
+
 func (client *Client) SendLineWithoutLog(line []byte) error {
 	client.lock.RLock()
 	defer client.lock.RUnlock()
@@ -399,20 +580,7 @@ func (client *Client) SendLineWithoutLog(line []byte) error {
 		return ErrClientDisconnected
 	}
 
-	if !bytes.HasSuffix(line, SplitSign) {
-		line = append(line, SplitSign...)
-	}
-
-	if _, err := client.socket.Conn().Write(line); err != nil {
-		client.logger.ErrorF("Failed to send data: %v", err)
-		return ErrClientSocketWrite
-	}
-
-	if client.messageReceivedCallback != nil && bytes.HasPrefix(line, []byte(Message)) {
-		_, result, _ := bytes.Cut(line, []byte(Message))
-		go client.messageReceivedCallback(result)
-	}
-	return nil
+	return client.send(line)
 }
 
 func (client *Client) SendLine(line []byte) {
@@ -424,36 +592,32 @@ func (client *Client) SendLine(line []byte) {
 	client.lock.RLock()
 	defer client.lock.RUnlock()
 
-	if !bytes.HasSuffix(line, SplitSign) {
-		client.logger.DebugF("<- %s", line)
-		line = append(line, SplitSign...)
-	} else {
-		client.logger.DebugF("<- %s", line[:len(line)-SplitSignLen])
-	}
-
-	if _, err := client.socket.Conn().Write(line); err != nil {
-		client.logger.WarnF("Failed to send data: %v", client.callsign, err)
-	}
+	client.logger.DebugF("<- %s", bytes.TrimSuffix(line, SplitSign))
 
-	if client.messageReceivedCallback != nil && bytes.HasPrefix(line, []byte(Message)) {
-		_, result, _ := bytes.Cut(line, []byte(Message))
-		go client.messageReceivedCallback(result)
+	if err := client.send(line); err != nil {
+		client.logger.WarnF("Failed to send data: %v", err)
 	}
 }
 
 func (client *Client) SendMotd() {
-	if client.motdBytes != nil {
-		client.SendLine(client.motdBytes)
-		return
+	client.lock.Lock()
+	if client.motdBytes == nil {
+		buffer := bytes.Buffer{}
+		for _, message := range client.configManager.Config().Server.FSDServer.CurrentMotd {
+			buffer.Write(MakePacket(Message, global.FSDServerName, client.callsign, message))
+		}
+		client.motdBytes = buffer.Bytes()
 	}
+	motd := client.motdBytes
+	client.lock.Unlock()
 
-	buffer := bytes.Buffer{}
-	for _, message := range client.config.Server.FSDServer.CurrentMotd {
-		buffer.Write(MakePacket(Message, global.FSDServerName, client.callsign, message))
-	}
+	client.SendLine(motd)
+}
 
-	client.motdBytes = buffer.Bytes()
-	client.SendLine(client.motdBytes)
+func (client *Client) ResetMotd() {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+	client.motdBytes = nil
 }
 
 func (client *Client) UpdateCapacities(capacities []string) {
@@ -483,8 +647,27 @@ func (client *Client) IsAtc() bool { return client.isAtc }
 
 func (client *Client) IsAtis() bool { return client.isAtis }
 
+func (client *Client) IsRemote() bool { return false }
+
+func (client *Client) IsVirtual() bool { return false }
+
 func (client *Client) Callsign() string { return client.callsign }
 
+func (client *Client) RemoteAddr() string {
+	client.lock.RLock()
+	defer client.lock.RUnlock()
+	return client.socket.ConnId()
+}
+
+func (client *Client) ClientSoftware() string {
+	client.lock.RLock()
+	defer client.lock.RUnlock()
+	if software := client.socket.ClientSoftware(); software != "" {
+		return software
+	}
+	return fmt.Sprintf("protocol %d, sim type %d", client.protocol, client.simType)
+}
+
 func (client *Client) Rating() Rating { return client.rating }
 
 func (client *Client) Facility() Facility { return client.facility }
@@ -513,6 +696,13 @@ func (client *Client) Transponder() string { return client.transponder }
 
 func (client *Client) Altitude() int { return client.altitude }
 
+func (client *Client) FlightPhase() FlightPhase {
+	if client.lifecycle == nil {
+		return ""
+	}
+	return client.lifecycle.currentPhase()
+}
+
 func (client *Client) GroundSpeed() int { return client.groundSpeed }
 
 func (client *Client) Heading() int {
@@ -540,10 +730,12 @@ func (client *Client) SetRealName(realName string) { client.realName = realName
 
 func (client *Client) ClearFlightPlan() {
 	client.flightPlan = nil
+	client.clientManager.TrafficHub().Publish(TrafficFlightPlan, client)
 }
 
 func (client *Client) SetFlightPlan(flightPlan *operation.FlightPlan) {
 	client.flightPlan = flightPlan
+	client.clientManager.TrafficHub().Publish(TrafficFlightPlan, client)
 	go client.clientManager.BroadcastMessage([]byte(client.flightPlanOperation.ToString(flightPlan)), client, BroadcastToAtc)
 }
 
@@ -14,6 +14,7 @@ import (
 	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
 	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
 	"github.com/half-nothing/simple-fsd/internal/interfaces/queue"
+	"github.com/half-nothing/simple-fsd/internal/metrics"
 	"github.com/half-nothing/simple-fsd/internal/utils"
 )
 
@@ -27,6 +28,13 @@ type ClientManager struct {
 	clientSlicePool   sync.Pool
 	messageQueue      queue.MessageQueueInterface
 	whazzupContent    *utils.CachedValue[OnlineClients]
+	spatialIndex      *spatialIndex
+	flightDataStore   *FlightDataStore
+	squawkAllocator   *SquawkAllocator
+	sectorManager     *SectorManager
+	trafficHub        *TrafficHub
+	// broadcastWorkers 广播并发数, 配置重载时更新
+	broadcastWorkers atomic.Int32
 }
 
 func NewClientManager(
@@ -42,16 +50,44 @@ func NewClientManager(
 		config:            config,
 		connectionManager: connectionManager,
 		messageQueue:      messageQueue,
+		spatialIndex:      newSpatialIndex(),
+		trafficHub:        NewTrafficHub(),
 		clientSlicePool: sync.Pool{
 			New: func() interface{} {
 				return make([]ClientInterface, 0, 128)
 			},
 		},
 	}
+	clientManager.broadcastWorkers.Store(int32(config.Server.FSDServer.MaxBroadcastWorkers))
+	clientManager.flightDataStore = NewFlightDataStore(logger, clientManager)
+	clientManager.squawkAllocator = NewSquawkAllocator(logger, config.Server.FSDServer.Squawk, clientManager)
+	clientManager.sectorManager = NewSectorManager(logger, config.Server.FSDServer.Sector, clientManager)
 	clientManager.whazzupContent = utils.NewCachedValue[OnlineClients](config.Server.FSDServer.CacheDuration, func() *OnlineClients { return clientManager.getWhazzupContent() })
+	metrics.OnlineClients.SetCollector(clientManager.collectOnlineClients)
 	return clientManager
 }
 
+// collectOnlineClients 按客户端类型和席位统计在线人数
+func (cm *ClientManager) collectOnlineClients(emit func(value float64, labelValues ...string)) {
+	clients := cm.GetClientSnapshot()
+	defer cm.putSlice(clients)
+
+	counts := make(map[[2]string]int)
+	for _, client := range clients {
+		if client == nil || client.Disconnected() {
+			continue
+		}
+		if client.IsAtc() {
+			counts[[2]string{"atc", client.Facility().String()}]++
+		} else {
+			counts[[2]string{"pilot", Pilot.String()}]++
+		}
+	}
+	for labels, count := range counts {
+		emit(float64(count), labels[0], labels[1])
+	}
+}
+
 func (cm *ClientManager) sendRawMessageTo(from string, to string, message string) error {
 	client, exists := cm.GetClient(to)
 	if !exists {
@@ -120,9 +156,6 @@ func (cm *ClientManager) broadcastMessage(message *BroadcastMessageData) error {
 		return nil
 	}
 
-	var wg sync.WaitGroup
-	sem := make(chan struct{}, cm.config.Server.FSDServer.MaxBroadcastWorkers)
-
 	var filter ClientFilter
 
 	switch message.Target {
@@ -149,23 +182,11 @@ func (cm *ClientManager) broadcastMessage(message *BroadcastMessageData) error {
 			continue
 		}
 
-		wg.Add(1)
-		sem <- struct{}{}
-		go func(cl ClientInterface) {
-			defer func() {
-				<-sem
-				wg.Done()
-			}()
-
-			cm.logger.DebugF("[Broadcast] -> [%s] %s", cl.Callsign(), string(bytes.TrimRight(packet, "\r\n")))
-			err := cl.SendLineWithoutLog(packet)
-			if err != nil && errors.Is(err, ErrClientSocketWrite) {
-				cl.MarkedDisconnect(false)
-			}
-		}(client)
+		cm.logger.DebugF("[Broadcast] -> [%s] %s", client.Callsign(), bytes.TrimRight(packet, "\r\n"))
+		if err := client.SendLineWithoutLog(packet); err != nil && errors.Is(err, ErrClientSocketWrite) {
+			client.MarkedDisconnect(false)
+		}
 	}
-
-	wg.Wait()
 	return nil
 }
 
@@ -193,6 +214,28 @@ func (cm *ClientManager) HandleKickClientFromServerMessage(message *queue.Messag
 	return queue.ErrMessageDataType
 }
 
+func (cm *ClientManager) HandleConfigReloadedMessage(message *queue.Message) error {
+	if val, ok := message.Data.(*config.Config); ok {
+		if err := SyncRatingConfig(val); err != nil {
+			return err
+		}
+		if err := SyncFacilityConfig(val); err != nil {
+			return err
+		}
+		SyncRangeLimit(val.Server.FSDServer.RangeLimit)
+		cm.broadcastWorkers.Store(int32(val.Server.FSDServer.MaxBroadcastWorkers))
+
+		// MOTD可能已经修改, 清除客户端缓存的MOTD数据
+		clients := cm.GetClientSnapshot()
+		defer cm.putSlice(clients)
+		for _, client := range clients {
+			client.ResetMotd()
+		}
+		return nil
+	}
+	return queue.ErrMessageDataType
+}
+
 func (cm *ClientManager) GetWhazzupContent() *OnlineClients {
 	return cm.whazzupContent.GetValue()
 }
@@ -247,9 +290,11 @@ func (cm *ClientManager) getWhazzupContent() *OnlineClients {
 				Heading:     client.Heading(),
 				Altitude:    client.Altitude(),
 				GroundSpeed: client.GroundSpeed(),
+				FlightPhase: client.FlightPhase(),
 				FlightPlan:  client.FlightPlan(),
 				LogonTime:   client.History().StartTime.Format(time.DateTime),
 			}
+			pilot.Assigned, _ = cm.squawkAllocator.GetReservation(client.Callsign())
 			data.Pilots = append(data.Pilots, pilot)
 		}
 	}
@@ -260,7 +305,13 @@ func (cm *ClientManager) getWhazzupContent() *OnlineClients {
 }
 
 func (cm *ClientManager) putSlice(clients []ClientInterface) {
-	cm.clientSlicePool.Put(clients)
+	// 清空引用, 避免池中的切片持有已经下线的客户端
+	clear(clients)
+	cm.clientSlicePool.Put(clients[:0])
+}
+
+func (cm *ClientManager) ReleaseClientSnapshot(clients []ClientInterface) {
+	cm.putSlice(clients)
 }
 
 func (cm *ClientManager) Shutdown(ctx context.Context) error {
@@ -271,11 +322,12 @@ func (cm *ClientManager) Shutdown(ctx context.Context) error {
 	defer cancel()
 
 	clients := cm.GetClientSnapshot()
-	defer cm.putSlice(clients)
 
 	done := make(chan struct{})
 	go func() {
 		defer close(done)
+		// 超时返回后协程仍在使用快照, 由协程负责放回池中
+		defer cm.putSlice(clients)
 		cm.disconnectClients(clients)
 	}()
 
@@ -307,7 +359,7 @@ func (cm *ClientManager) disconnectClients(clients []ClientInterface) {
 		return
 	}
 
-	sem := make(chan struct{}, cm.config.Server.FSDServer.MaxBroadcastWorkers)
+	sem := make(chan struct{}, cm.broadcastWorkers.Load())
 	var wg sync.WaitGroup
 
 	for _, client := range clients {
@@ -332,13 +384,24 @@ func (cm *ClientManager) AddClient(client ClientInterface) error {
 		return fmt.Errorf("server shutting down")
 	}
 	cm.lock.Lock()
-	defer cm.lock.Unlock()
 
 	if _, exists := cm.clients[client.Callsign()]; exists {
+		cm.lock.Unlock()
 		return fmt.Errorf("client already registered: %s", client.Callsign())
 	}
+	// 远程客户端和虚拟客户端不占用本节点的连接
+	if !client.IsRemote() && !client.IsVirtual() {
+		if err := cm.connectionManager.AddConnection(client); err != nil {
+			cm.lock.Unlock()
+			return err
+		}
+	}
 	cm.clients[client.Callsign()] = client
-	cm.connectionManager.AddConnection(client)
+	cm.spatialIndex.update(client)
+	cm.lock.Unlock()
+
+	// 交通事件订阅者可能反向查询客户端, 需要在释放锁之后发布
+	cm.trafficHub.Publish(TrafficConnect, client)
 	return nil
 }
 
@@ -356,17 +419,33 @@ func (cm *ClientManager) GetClient(callsign string) (ClientInterface, bool) {
 
 func (cm *ClientManager) DeleteClient(callsign string) bool {
 	cm.lock.Lock()
-	defer cm.lock.Unlock()
 
 	client, exists := cm.clients[callsign]
 	if !exists {
+		cm.lock.Unlock()
 		return false
 	}
 
 	delete(cm.clients, callsign)
-	return cm.connectionManager.RemoveConnection(client) == nil
+	cm.spatialIndex.remove(client)
+	result := client.IsRemote() || client.IsVirtual() || cm.connectionManager.RemoveConnection(client) == nil
+	cm.lock.Unlock()
+
+	// 协调数据存储会反向查询客户端, 需要在释放锁之后清理
+	cm.flightDataStore.RemoveClient(client)
+	cm.squawkAllocator.Release(callsign)
+	cm.trafficHub.Publish(TrafficDisconnect, client)
+	return result
 }
 
+func (cm *ClientManager) FlightDataStore() FlightDataStoreInterface { return cm.flightDataStore }
+
+func (cm *ClientManager) SquawkAllocator() SquawkAllocatorInterface { return cm.squawkAllocator }
+
+func (cm *ClientManager) SectorManager() SectorManagerInterface { return cm.sectorManager }
+
+func (cm *ClientManager) TrafficHub() TrafficHubInterface { return cm.trafficHub }
+
 func (cm *ClientManager) SendMessageTo(callsign string, message []byte) error {
 	if cm.shuttingDown.Load() {
 		return errors.New("server is shutting down")
@@ -389,6 +468,51 @@ func (cm *ClientManager) SendMessageTo(callsign string, message []byte) error {
 	return nil
 }
 
+// UpdateClientPosition 客户端位置或视程变化后更新空间索引, 并发布位置事件
+func (cm *ClientManager) UpdateClientPosition(client ClientInterface) {
+	cm.lock.RLock()
+	// 只索引已注册的客户端, 避免已删除的客户端被重新加入索引
+	if registered, ok := cm.clients[client.Callsign()]; !ok || registered != client {
+		cm.lock.RUnlock()
+		return
+	}
+	cm.spatialIndex.update(client)
+	cm.lock.RUnlock()
+
+	cm.trafficHub.Publish(TrafficPosition, client)
+}
+
+// getClientsInRange 获取可能在fromClient范围内的客户端, 无法使用空间索引时返回全部客户端
+func (cm *ClientManager) getClientsInRange(fromClient ClientInterface) []ClientInterface {
+	clients := cm.clientSlicePool.Get().([]ClientInterface)
+	clients, ok := cm.spatialIndex.candidates(fromClient, clients[:0])
+	if ok {
+		return clients
+	}
+	cm.putSlice(clients)
+	return cm.GetClientSnapshot()
+}
+
+func (cm *ClientManager) HasClientInRange(fromClient ClientInterface, filter BroadcastFilter) bool {
+	if cm.shuttingDown.Load() {
+		return false
+	}
+
+	clients := cm.getClientsInRange(fromClient)
+	defer cm.putSlice(clients)
+
+	filter = CombineBroadcastFilter(filter, BroadcastToClientInRange)
+	for _, client := range clients {
+		if client == fromClient || client.Disconnected() {
+			continue
+		}
+		if filter(client, fromClient) {
+			return true
+		}
+	}
+	return false
+}
+
 func (cm *ClientManager) BroadcastMessage(message []byte, fromClient ClientInterface, filter BroadcastFilter) {
 	if cm.shuttingDown.Load() || len(message) == 0 {
 		return
@@ -397,21 +521,29 @@ func (cm *ClientManager) BroadcastMessage(message []byte, fromClient ClientInter
 	clients := cm.GetClientSnapshot()
 	defer cm.putSlice(clients) // 重置并放回池中
 
-	if len(clients) == 0 {
+	cm.broadcastTo(clients, message, fromClient, filter)
+}
+
+func (cm *ClientManager) BroadcastMessageInRange(message []byte, fromClient ClientInterface, filter BroadcastFilter) {
+	if cm.shuttingDown.Load() || len(message) == 0 {
 		return
 	}
 
-	messageLen := len(message)
-	fullMsg := make([]byte, messageLen)
-	copy(fullMsg, message)
-	if !bytes.HasSuffix(message, SplitSign) {
-		fullMsg = append(fullMsg, SplitSign...)
-	}
+	clients := cm.getClientsInRange(fromClient)
+	defer cm.putSlice(clients)
 
-	// 并发广播
-	var wg sync.WaitGroup
-	sem := make(chan struct{}, cm.config.Server.FSDServer.MaxBroadcastWorkers)
+	cm.broadcastTo(clients, message, fromClient, CombineBroadcastFilter(filter, BroadcastToClientInRange))
+}
+
+// broadcastTo 向满足过滤条件的客户端发送数据包
+// 发送只是放入客户端各自的发送队列, 不会被慢速客户端阻塞, 因此无需并发
+func (cm *ClientManager) broadcastTo(clients []ClientInterface, message []byte, fromClient ClientInterface, filter BroadcastFilter) {
+	if len(clients) == 0 {
+		return
+	}
+	defer metrics.ObserveSince(metrics.BroadcastDuration, time.Now())
 
+	logMessage := bytes.TrimSuffix(message, SplitSign)
 	for _, client := range clients {
 		if client == fromClient || client.Disconnected() {
 			continue
@@ -421,21 +553,9 @@ func (cm *ClientManager) BroadcastMessage(message []byte, fromClient ClientInter
 			continue
 		}
 
-		wg.Add(1)
-		sem <- struct{}{}
-		go func(cl ClientInterface) {
-			defer func() {
-				<-sem
-				wg.Done()
-			}()
-
-			cm.logger.DebugF("[Broadcast] -> [%s] %s", cl.Callsign(), fullMsg[:messageLen-len(SplitSign)])
-			err := cl.SendLineWithoutLog(fullMsg)
-			if err != nil && errors.Is(err, ErrClientSocketWrite) {
-				cl.MarkedDisconnect(false)
-			}
-		}(client)
+		cm.logger.DebugF("[Broadcast] -> [%s] %s", client.Callsign(), logMessage)
+		if err := client.SendLineWithoutLog(message); err != nil && errors.Is(err, ErrClientSocketWrite) {
+			client.MarkedDisconnect(false)
+		}
 	}
-
-	wg.Wait()
 }
@@ -2,32 +2,45 @@
 package client
 
 import (
+	"fmt"
 	"slices"
+	"strings"
 	"sync"
 
+	"github.com/half-nothing/simple-fsd/internal/interfaces/config"
 	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
 	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
 )
 
 type ConnectionManager struct {
 	logger      log.LoggerInterface
+	policy      *config.FsdConnectionPolicyConfig
 	connections map[int][]ClientInterface
+	resuming    map[ClientInterface]int // 正在恢复的连接, 恢复期间计入连接数
 	lock        sync.RWMutex
 }
 
 func NewConnectionManager(
 	logger log.LoggerInterface,
+	policy *config.FsdConnectionPolicyConfig,
 ) *ConnectionManager {
 	return &ConnectionManager{
 		logger:      log.NewLoggerAdapter(logger, "ConnectionManager"),
+		policy:      policy,
 		connections: make(map[int][]ClientInterface),
+		resuming:    make(map[ClientInterface]int),
 		lock:        sync.RWMutex{},
 	}
 }
 
-func (cm *ConnectionManager) AddConnection(client ClientInterface) {
+func (cm *ConnectionManager) AddConnection(client ClientInterface) error {
 	cm.lock.Lock()
 	defer cm.lock.Unlock()
+	// 检查与记录在同一把锁内完成, 同一CID并发登录时不会同时通过检查
+	if err := cm.checkPolicyLocked(client.User(), client.Callsign(), client.IsAtc(), nil); err != nil {
+		return err
+	}
 	cid := client.User().Cid
 	cm.logger.DebugF("New connection: %d(%s)", cid, client.Callsign())
 	if val, ok := cm.connections[cid]; ok {
@@ -35,6 +48,7 @@ func (cm *ConnectionManager) AddConnection(client ClientInterface) {
 	} else {
 		cm.connections[cid] = []ClientInterface{client}
 	}
+	return nil
 }
 
 func (cm *ConnectionManager) RemoveConnection(client ClientInterface) error {
@@ -68,3 +82,105 @@ func (cm *ConnectionManager) GetConnections(cid int) ([]ClientInterface, error)
 	}
 	return val, nil
 }
+
+func (cm *ConnectionManager) CheckConnectionPolicy(user *operation.User, callsign string, isAtc bool) error {
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+	return cm.checkPolicyLocked(user, callsign, isAtc, nil)
+}
+
+func (cm *ConnectionManager) ResumeConnection(user *operation.User, client ClientInterface, resume func() bool) (bool, error) {
+	cm.lock.Lock()
+	if err := cm.checkPolicyLocked(user, client.Callsign(), client.IsAtc(), client); err != nil {
+		cm.lock.Unlock()
+		return false, err
+	}
+	cm.resuming[client]++
+	cm.lock.Unlock()
+
+	// 删除客户端时会在持有客户端锁的情况下移除连接, 所以恢复时不能持有连接管理器的锁
+	resumed := resume()
+
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+	if cm.resuming[client]--; cm.resuming[client] <= 0 {
+		delete(cm.resuming, client)
+	}
+	return resumed, nil
+}
+
+// checkPolicyLocked 检查连接数限制, exclude 为正在恢复的连接本身, 不计入连接数, 调用方需要持有锁
+func (cm *ConnectionManager) checkPolicyLocked(user *operation.User, callsign string, isAtc bool, exclude ClientInterface) error {
+	if !cm.policy.Enabled {
+		return nil
+	}
+
+	limit := cm.matchLimit(user.Rating, callsignFacility(callsign, isAtc))
+	if limit == nil {
+		return nil
+	}
+
+	kind := GetConnectionKind(callsign, isAtc)
+	maxConnections := connectionLimitOf(limit, kind)
+	if maxConnections < 0 {
+		return nil
+	}
+
+	// 已断开等待恢复的连接不计入, 正在恢复的连接计入
+	online := make([]string, 0)
+	for _, client := range cm.connections[user.Cid] {
+		if client == exclude || GetConnectionKind(client.Callsign(), client.IsAtc()) != kind {
+			continue
+		}
+		if _, ok := cm.resuming[client]; !ok && client.Disconnected() {
+			continue
+		}
+		online = append(online, client.Callsign())
+	}
+	if len(online) < maxConnections {
+		return nil
+	}
+	if maxConnections == 0 {
+		return fmt.Errorf("%w, %s connection is not allowed", ErrConnectionLimitReached, kind)
+	}
+	return fmt.Errorf("%w, at most %d %s connection(s) allowed, already online as %s",
+		ErrConnectionLimitReached, maxConnections, kind, strings.Join(online, ", "))
+}
+
+// matchLimit 返回第一条匹配规则的限制, 规则豁免时返回nil
+func (cm *ConnectionManager) matchLimit(rating int, facility string) *config.FsdConnectionLimit {
+	for _, rule := range cm.policy.Rules {
+		if !slices.Contains(rule.Ratings, rating) && !slices.Contains(rule.Facilities, facility) {
+			continue
+		}
+		if rule.Exempt {
+			return nil
+		}
+		return rule.Limit
+	}
+	return cm.policy.Default
+}
+
+// callsignFacility 登录席位, 管制员为呼号的席位后缀, 机组为PILOT
+func callsignFacility(callsign string, isAtc bool) string {
+	if !isAtc {
+		return "PILOT"
+	}
+	if index := strings.LastIndex(callsign, "_"); index != -1 {
+		return strings.ToUpper(callsign[index+1:])
+	}
+	return ""
+}
+
+func connectionLimitOf(limit *config.FsdConnectionLimit, kind ConnectionKind) int {
+	switch kind {
+	case ConnectionPilot:
+		return limit.Pilot
+	case ConnectionAtis:
+		return limit.Atis
+	case ConnectionObserver:
+		return limit.Observer
+	default:
+		return limit.Atc
+	}
+}
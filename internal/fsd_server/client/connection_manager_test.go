@@ -0,0 +1,164 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	c "github.com/half-nothing/simple-fsd/internal/interfaces/config"
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+)
+
+func newTestConnectionManager() *ConnectionManager {
+	return NewConnectionManager(nopLogger{}, &c.FsdConnectionPolicyConfig{
+		Enabled: true,
+		Default: &c.FsdConnectionLimit{Pilot: 1, Atc: 1, Atis: 1, Observer: -1},
+		Rules: []*c.FsdConnectionPolicyRule{
+			{Ratings: []int{11, 12}, Exempt: true},
+			{Facilities: []string{"CTR"}, Limit: &c.FsdConnectionLimit{Pilot: 1, Atc: 2, Atis: 1, Observer: -1}},
+		},
+	})
+}
+
+func newTestConnection(callsign string, isAtc bool, cid int, rating int) *fakeClient {
+	client := newFakeClient(callsign, 0, 0, 0)
+	client.isAtc = isAtc
+	client.user = &operation.User{Cid: cid, Rating: rating}
+	return client
+}
+
+func TestConnectionManagerAddConnection(t *testing.T) {
+	manager := newTestConnectionManager()
+
+	tests := []struct {
+		client      *fakeClient
+		expectedErr error
+	}{
+		{newTestConnection("CES101", false, 1001, 1), nil},
+		{newTestConnection("CES102", false, 1001, 1), ErrConnectionLimitReached},
+		{newTestConnection("ZSSS_APP", true, 1001, 5), nil},
+		{newTestConnection("ZSSS_ATIS", true, 1001, 5), nil},
+		{newTestConnection("ZSSS_OBS", true, 1001, 5), nil},
+		{newTestConnection("ZSPD_OBS", true, 1001, 5), nil},
+		{newTestConnection("ZSHA_CTR", true, 1001, 5), nil},
+		{newTestConnection("ZSHA_E_CTR", true, 1001, 5), ErrConnectionLimitReached},
+		{newTestConnection("CES201", false, 1002, 1), nil},
+		{newTestConnection("CES301", false, 1003, 11), nil},
+		{newTestConnection("CES302", false, 1003, 11), nil},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		err := manager.AddConnection(test.client)
+		if !errors.Is(err, test.expectedErr) {
+			fail++
+			t.Errorf("AddConnection(%s) = %v; expected %v", test.client.Callsign(), err, test.expectedErr)
+			continue
+		}
+		pass++
+	}
+
+	connections, _ := manager.GetConnections(1001)
+	if len(connections) != 6 {
+		fail++
+		t.Errorf("GetConnections(1001) = %d connections; expected 6", len(connections))
+	}
+	t.Logf("TestConnectionManagerAddConnection: %d pass, %d fail", pass, fail)
+}
+
+func TestConnectionManagerResumeConnection(t *testing.T) {
+	manager := newTestConnectionManager()
+
+	// 断线后用其他呼号登录, 再恢复原呼号时不能超过限制
+	first := newTestConnection("CES101", false, 1001, 1)
+	second := newTestConnection("CES102", false, 1001, 1)
+	_ = manager.AddConnection(first)
+	first.disconnected = true
+	_ = manager.AddConnection(second)
+	atc := newTestConnection("ZSSS_APP", true, 1001, 5)
+	_ = manager.AddConnection(atc)
+	atc.disconnected = true
+	failed := newTestConnection("CES201", false, 1002, 1)
+	_ = manager.AddConnection(failed)
+	failed.disconnected = true
+
+	tests := []struct {
+		name            string
+		client          *fakeClient
+		action          func()
+		expectedResumed bool
+		expectedErr     error
+	}{
+		{"other pilot online", first, func() {}, false, ErrConnectionLimitReached},
+		{"other kind online", atc, func() {}, true, nil},
+		{"other pilot disconnected", first, func() { second.disconnected = true }, true, nil},
+		{"resume failed", failed, func() {}, false, nil},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		test.action()
+		client := test.client
+		resumed, err := manager.ResumeConnection(client.User(), client, func() bool {
+			if client == failed {
+				return false
+			}
+			client.disconnected = false
+			return true
+		})
+		if resumed != test.expectedResumed || !errors.Is(err, test.expectedErr) {
+			fail++
+			t.Errorf("ResumeConnection(%s) = %v, %v; expected %v, %v", test.name, resumed, err, test.expectedResumed, test.expectedErr)
+			continue
+		}
+		pass++
+	}
+
+	// 恢复期间占用名额, 其他连接不能同时通过检查
+	third := newTestConnection("CES103", false, 1003, 1)
+	_ = manager.AddConnection(third)
+	third.disconnected = true
+	var addErr error
+	_, _ = manager.ResumeConnection(third.User(), third, func() bool {
+		addErr = manager.CheckConnectionPolicy(third.User(), "CES104", false)
+		third.disconnected = false
+		return true
+	})
+	if !errors.Is(addErr, ErrConnectionLimitReached) {
+		fail++
+		t.Errorf("CheckConnectionPolicy(during resume) = %v; expected %v", addErr, ErrConnectionLimitReached)
+	}
+	t.Logf("TestConnectionManagerResumeConnection: %d pass, %d fail", pass, fail)
+}
+
+func TestConnectionManagerConcurrentAdd(t *testing.T) {
+	manager := newTestConnectionManager()
+
+	// 同一CID并发登录时只有一个连接能通过检查
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	added := 0
+	for i := range 32 {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			client := newTestConnection(fmt.Sprintf("CES%03d", index), false, 1001, 1)
+			if manager.CheckConnectionPolicy(client.User(), client.Callsign(), false) != nil {
+				return
+			}
+			if manager.AddConnection(client) == nil {
+				lock.Lock()
+				added++
+				lock.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	connections, _ := manager.GetConnections(1001)
+	if added != 1 || len(connections) != 1 {
+		t.Errorf("concurrent AddConnection added %d, %d connections; expected 1, 1", added, len(connections))
+	}
+}
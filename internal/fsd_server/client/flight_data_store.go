@@ -0,0 +1,220 @@
+package client
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/global"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+)
+
+// ownershipQueries 改变标牌归属的协调子命令
+var ownershipQueries = []string{IHoleTag, ITakeTag, DropTag, HandoffTransfer}
+
+// assignedQueries 需要保存的指定数据协调子命令, 其他子命令只转发不保存
+var assignedQueries = []string{ScratchPad, TempAltitude, BeaconCode, AssignSquawk}
+
+// FlightDataStore EuroScope协调数据存储
+// 保存每架航空器的标牌归属, 指定数据与移交状态, 供新登录的管制员同步
+type FlightDataStore struct {
+	logger        log.LoggerInterface
+	clientManager ClientManagerInterface
+	lock          sync.RWMutex
+	records       map[string]*FlightData
+}
+
+func NewFlightDataStore(logger log.LoggerInterface, clientManager ClientManagerInterface) *FlightDataStore {
+	return &FlightDataStore{
+		logger:        log.NewLoggerAdapter(logger, "FlightDataStore"),
+		clientManager: clientManager,
+		records:       make(map[string]*FlightData),
+	}
+}
+
+// getRecord 获取或创建指定航空器的协调数据, 只为在线的机组创建记录, 调用方需持有lock
+func (store *FlightDataStore) getRecord(callsign string) *FlightData {
+	if record, ok := store.records[callsign]; ok {
+		return record
+	}
+	client, ok := store.clientManager.GetClient(callsign)
+	if !ok || client.IsAtc() {
+		return nil
+	}
+	record := &FlightData{
+		Callsign: callsign,
+		Assigned: make(map[string]*AssignedValue),
+	}
+	store.records[callsign] = record
+	return record
+}
+
+func (store *FlightDataStore) HandleCoordination(from string, subQuery string, args []string) {
+	if len(args) == 0 || (!slices.Contains(ownershipQueries, subQuery) && !slices.Contains(assignedQueries, subQuery)) {
+		return
+	}
+
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	record := store.getRecord(args[0])
+	if record == nil {
+		return
+	}
+
+	now := time.Now()
+	switch subQuery {
+	case IHoleTag, ITakeTag:
+		record.Owner = from
+		record.HandoffFrom = ""
+		record.HandoffTarget = ""
+	case DropTag:
+		if record.Owner != from {
+			return
+		}
+		record.Owner = ""
+		record.HandoffFrom = ""
+		record.HandoffTarget = ""
+	case HandoffTransfer:
+		if len(args) < 2 {
+			return
+		}
+		record.Owner = args[1]
+		record.HandoffFrom = ""
+		record.HandoffTarget = ""
+	case ScratchPad, TempAltitude, BeaconCode, AssignSquawk:
+		if len(args) < 2 {
+			return
+		}
+		value := strings.Join(args[1:], ":")
+		if value == "" {
+			delete(record.Assigned, subQuery)
+		} else {
+			record.Assigned[subQuery] = &AssignedValue{Value: value, SetBy: from, UpdatedAt: now}
+		}
+	}
+	record.UpdatedAt = now
+}
+
+func (store *FlightDataStore) RequestHandoff(from string, to string, callsign string) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	record := store.getRecord(callsign)
+	if record == nil {
+		return
+	}
+	record.HandoffFrom = from
+	record.HandoffTarget = to
+	record.UpdatedAt = time.Now()
+}
+
+func (store *FlightDataStore) AcceptHandoff(from string, _ string, callsign string) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	record := store.getRecord(callsign)
+	if record == nil {
+		return
+	}
+	record.Owner = from
+	record.HandoffFrom = ""
+	record.HandoffTarget = ""
+	record.UpdatedAt = time.Now()
+}
+
+func (store *FlightDataStore) ReplayTo(client ClientInterface) {
+	if !client.IsAtc() {
+		return
+	}
+
+	callsign := client.Callsign()
+	packets := make([][]byte, 0)
+
+	store.lock.RLock()
+	for _, record := range store.records {
+		// 管制员自己持有的标牌由服务器代为通知, 重连后才能重新获得标牌
+		if record.Owner == callsign {
+			packets = append(packets, MakePacket(ClientQuery, global.FSDServerName, EuroscopeFrequency, IHoleTag, record.Callsign))
+		} else if record.Owner != "" {
+			packets = append(packets, MakePacket(ClientQuery, record.Owner, EuroscopeFrequency, IHoleTag, record.Callsign))
+		}
+		for _, subQuery := range slices.Sorted(maps.Keys(record.Assigned)) {
+			assigned := record.Assigned[subQuery]
+			source := assigned.SetBy
+			if source == callsign {
+				source = global.FSDServerName
+			}
+			packets = append(packets, MakePacket(ClientQuery, source, EuroscopeFrequency, subQuery, record.Callsign, assigned.Value))
+		}
+		if record.HandoffTarget == callsign {
+			packets = append(packets, MakePacket(RequestHandoff, record.HandoffFrom, callsign, record.Callsign))
+		}
+	}
+	store.lock.RUnlock()
+
+	if len(packets) == 0 {
+		return
+	}
+	store.logger.DebugF("Replay %d flight data packets to %s", len(packets), callsign)
+	for _, packet := range packets {
+		client.SendLine(packet)
+	}
+}
+
+func (store *FlightDataStore) RemoveClient(client ClientInterface) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	callsign := client.Callsign()
+	if !client.IsAtc() {
+		delete(store.records, callsign)
+		return
+	}
+
+	for _, record := range store.records {
+		if record.Owner == callsign {
+			record.Owner = ""
+		}
+		if record.HandoffFrom == callsign || record.HandoffTarget == callsign {
+			record.HandoffFrom = ""
+			record.HandoffTarget = ""
+		}
+	}
+}
+
+func copyFlightData(record *FlightData) *FlightData {
+	data := *record
+	data.Assigned = make(map[string]*AssignedValue, len(record.Assigned))
+	for key, value := range record.Assigned {
+		assigned := *value
+		data.Assigned[key] = &assigned
+	}
+	return &data
+}
+
+func (store *FlightDataStore) GetFlightData(callsign string) (*FlightData, bool) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+
+	record, ok := store.records[callsign]
+	if !ok {
+		return nil, false
+	}
+	return copyFlightData(record), true
+}
+
+func (store *FlightDataStore) GetFlightDataList() []*FlightData {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+
+	data := make([]*FlightData, 0, len(store.records))
+	for _, record := range store.records {
+		data = append(data, copyFlightData(record))
+	}
+	slices.SortFunc(data, func(a, b *FlightData) int { return strings.Compare(a.Callsign, b.Callsign) })
+	return data
+}
@@ -0,0 +1,181 @@
+package client
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+)
+
+type fakeClientManager struct {
+	ClientManagerInterface
+	clients map[string]ClientInterface
+}
+
+func (manager *fakeClientManager) GetClient(callsign string) (ClientInterface, bool) {
+	client, ok := manager.clients[callsign]
+	return client, ok
+}
+
+func newTestFlightDataStore(clients ...*fakeClient) *FlightDataStore {
+	manager := &fakeClientManager{clients: make(map[string]ClientInterface)}
+	for _, client := range clients {
+		manager.clients[client.callsign] = client
+	}
+	return NewFlightDataStore(nopLogger{}, manager)
+}
+
+func assignedOf(data *FlightData) map[string]string {
+	result := make(map[string]string, len(data.Assigned))
+	for subQuery, assigned := range data.Assigned {
+		result[subQuery] = assigned.Value + "@" + assigned.SetBy
+	}
+	return result
+}
+
+func TestFlightDataStoreHandleCoordination(t *testing.T) {
+	store := newTestFlightDataStore(&fakeClient{callsign: "CES101"}, &fakeClient{callsign: "ZSHA_CTR", isAtc: true})
+
+	tests := []struct {
+		name             string
+		from             string
+		subQuery         string
+		args             []string
+		expectedExists   bool
+		expectedOwner    string
+		expectedAssigned map[string]string
+	}{
+		{"unknown aircraft", "ZSSS_APP", IHoleTag, []string{"CES999"}, false, "", nil},
+		{"atc target", "ZSSS_APP", IHoleTag, []string{"ZSHA_CTR"}, false, "", nil},
+		{"relay only", "ZSSS_APP", WhoHoldTag, []string{"CES101"}, false, "", nil},
+		{"unknown sub query", "ZSSS_APP", "XX", []string{"CES101", "value"}, false, "", nil},
+		{"no args", "ZSSS_APP", ScratchPad, []string{}, false, "", nil},
+		{"hold tag", "ZSSS_APP", IHoleTag, []string{"CES101"}, true, "ZSSS_APP", map[string]string{}},
+		{"scratch pad", "ZSSS_APP", ScratchPad, []string{"CES101", "DCT", "PIMOL"}, true, "ZSSS_APP", map[string]string{ScratchPad: "DCT:PIMOL@ZSSS_APP"}},
+		{"temp altitude", "ZSSS_APP", TempAltitude, []string{"CES101", "6000"}, true, "ZSSS_APP", map[string]string{ScratchPad: "DCT:PIMOL@ZSSS_APP", TempAltitude: "6000@ZSSS_APP"}},
+		{"unknown sub query ignored", "ZSSS_APP", "XX", []string{"CES101", "value"}, true, "ZSSS_APP", map[string]string{ScratchPad: "DCT:PIMOL@ZSSS_APP", TempAltitude: "6000@ZSSS_APP"}},
+		{"assigned without value", "ZSSS_APP", BeaconCode, []string{"CES101"}, true, "ZSSS_APP", map[string]string{ScratchPad: "DCT:PIMOL@ZSSS_APP", TempAltitude: "6000@ZSSS_APP"}},
+		{"clear scratch pad", "ZSSS_APP", ScratchPad, []string{"CES101", ""}, true, "ZSSS_APP", map[string]string{TempAltitude: "6000@ZSSS_APP"}},
+		{"drop by other", "ZSHA_CTR", DropTag, []string{"CES101"}, true, "ZSSS_APP", map[string]string{TempAltitude: "6000@ZSSS_APP"}},
+		{"transfer", "ZSSS_APP", HandoffTransfer, []string{"CES101", "ZSHA_CTR"}, true, "ZSHA_CTR", map[string]string{TempAltitude: "6000@ZSSS_APP"}},
+		{"take tag", "ZSSS_APP", ITakeTag, []string{"CES101"}, true, "ZSSS_APP", map[string]string{TempAltitude: "6000@ZSSS_APP"}},
+		{"drop by owner", "ZSSS_APP", DropTag, []string{"CES101"}, true, "", map[string]string{TempAltitude: "6000@ZSSS_APP"}},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		store.HandleCoordination(test.from, test.subQuery, test.args)
+		data, ok := store.GetFlightData("CES101")
+		if ok != test.expectedExists {
+			fail++
+			t.Errorf("HandleCoordination(%s) record exists = %v; expected %v", test.name, ok, test.expectedExists)
+			continue
+		}
+		if !ok {
+			if len(store.GetFlightDataList()) != 0 {
+				fail++
+				t.Errorf("HandleCoordination(%s) created records %v; expected none", test.name, store.GetFlightDataList())
+				continue
+			}
+			pass++
+			continue
+		}
+		if assigned := assignedOf(data); data.Owner != test.expectedOwner || !maps.Equal(assigned, test.expectedAssigned) {
+			fail++
+			t.Errorf("HandleCoordination(%s) = owner %q, assigned %v; expected owner %q, assigned %v",
+				test.name, data.Owner, assigned, test.expectedOwner, test.expectedAssigned)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestFlightDataStoreHandleCoordination: %d pass, %d fail", pass, fail)
+}
+
+func TestFlightDataStoreHandoff(t *testing.T) {
+	app := &fakeClient{callsign: "ZSSS_APP", isAtc: true}
+	ctr := &fakeClient{callsign: "ZSHA_CTR", isAtc: true}
+	store := newTestFlightDataStore(&fakeClient{callsign: "CES101"}, app, ctr)
+
+	tests := []struct {
+		name            string
+		action          func()
+		expectedOwner   string
+		expectedFrom    string
+		expectedTarget  string
+		expectedRecords int
+	}{
+		{"hold tag", func() { store.HandleCoordination("ZSSS_APP", IHoleTag, []string{"CES101"}) }, "ZSSS_APP", "", "", 1},
+		{"request", func() { store.RequestHandoff("ZSSS_APP", "ZSHA_CTR", "CES101") }, "ZSSS_APP", "ZSSS_APP", "ZSHA_CTR", 1},
+		{"request unknown", func() { store.RequestHandoff("ZSSS_APP", "ZSHA_CTR", "CES999") }, "ZSSS_APP", "ZSSS_APP", "ZSHA_CTR", 1},
+		{"accept", func() { store.AcceptHandoff("ZSHA_CTR", "ZSSS_APP", "CES101") }, "ZSHA_CTR", "", "", 1},
+		{"request again", func() { store.RequestHandoff("ZSHA_CTR", "ZSSS_APP", "CES101") }, "ZSHA_CTR", "ZSHA_CTR", "ZSSS_APP", 1},
+		{"target disconnected", func() { store.RemoveClient(app) }, "ZSHA_CTR", "", "", 1},
+		{"owner disconnected", func() { store.RemoveClient(ctr) }, "", "", "", 1},
+		{"pilot disconnected", func() { store.RemoveClient(&fakeClient{callsign: "CES101"}) }, "", "", "", 0},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		test.action()
+		records := store.GetFlightDataList()
+		data := &FlightData{}
+		if len(records) > 0 {
+			data = records[0]
+		}
+		if len(records) != test.expectedRecords || data.Owner != test.expectedOwner || data.HandoffFrom != test.expectedFrom || data.HandoffTarget != test.expectedTarget {
+			fail++
+			t.Errorf("%s = %d records, owner %q, handoff %q -> %q; expected %d records, owner %q, handoff %q -> %q", test.name,
+				len(records), data.Owner, data.HandoffFrom, data.HandoffTarget, test.expectedRecords, test.expectedOwner, test.expectedFrom, test.expectedTarget)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestFlightDataStoreHandoff: %d pass, %d fail", pass, fail)
+}
+
+func TestFlightDataStoreReplayTo(t *testing.T) {
+	store := newTestFlightDataStore(&fakeClient{callsign: "CES101"}, &fakeClient{callsign: "CES102"})
+	store.HandleCoordination("ZSSS_APP", IHoleTag, []string{"CES101"})
+	store.HandleCoordination("ZSSS_APP", TempAltitude, []string{"CES101", "6000"})
+	store.HandleCoordination("ZSHA_CTR", ScratchPad, []string{"CES101", "PIMOL"})
+	store.HandleCoordination("ZSHA_CTR", IHoleTag, []string{"CES102"})
+	store.RequestHandoff("ZSHA_CTR", "ZSSS_APP", "CES102")
+
+	tests := []struct {
+		name     string
+		client   *fakeClient
+		expected []string
+	}{
+		{"pilot", &fakeClient{callsign: "CES103"}, []string{}},
+		{"other controller", &fakeClient{callsign: "ZSPD_TWR", isAtc: true}, []string{
+			"$CQZSSS_APP:@94835:IH:CES101\r\n",
+			"$CQZSHA_CTR:@94835:SC:CES101:PIMOL\r\n",
+			"$CQZSSS_APP:@94835:TA:CES101:6000\r\n",
+			"$CQZSHA_CTR:@94835:IH:CES102\r\n",
+		}},
+		// 管制员重连后自己持有的标牌与指定数据由服务器代为发送
+		{"owner reconnected", &fakeClient{callsign: "ZSSS_APP", isAtc: true}, []string{
+			"$CQSERVER:@94835:IH:CES101\r\n",
+			"$CQZSHA_CTR:@94835:SC:CES101:PIMOL\r\n",
+			"$CQSERVER:@94835:TA:CES101:6000\r\n",
+			"$CQZSHA_CTR:@94835:IH:CES102\r\n",
+			"$HOZSHA_CTR:ZSSS_APP:CES102\r\n",
+		}},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		store.ReplayTo(test.client)
+		// 记录之间的顺序不固定, 只比较排序后的结果
+		result := slices.Sorted(slices.Values(test.client.lines))
+		expected := slices.Sorted(slices.Values(test.expected))
+		if !slices.Equal(result, expected) {
+			fail++
+			t.Errorf("ReplayTo(%s) = %q; expected %q", test.name, result, expected)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestFlightDataStoreReplayTo: %d pass, %d fail", pass, fail)
+}
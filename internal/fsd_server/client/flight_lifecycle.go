@@ -0,0 +1,291 @@
+package client
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/config"
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+)
+
+const (
+	// taxiSpeed 地面上超过该地速(节)视为开始滑行
+	taxiSpeed = 5
+	// stoppedSpeed 地面上低于该地速(节)视为停止
+	stoppedSpeed = 1
+	// taxiInSpeed 落地后低于该地速(节)视为脱离跑道
+	taxiInSpeed = 30
+	// takeoffSpeed 离地判定的最低地速(节)
+	takeoffSpeed = 40
+	// groundHeight 客户端未上报离地标志时, 距机场标高在该值(英尺)以内且低速视为在地面
+	groundHeight = 300
+	// climbHeight 离场机场标高以上该高度(英尺)视为进入航路阶段
+	climbHeight = 10000
+	// approachDistance 距计划目的地机场该距离(海里)以内视为进入进近阶段
+	approachDistance = 40
+	// approachHeight 进近阶段的最大高度(英尺, 相对机场标高)
+	approachHeight = 10000
+	// visualSampleExpire 快速位置更新中的垂直速度在该时间内有效
+	visualSampleExpire = 2 * time.Second
+	// meterPerSecondToFpm 米每秒到英尺每分钟的换算系数
+	meterPerSecondToFpm = 196.85
+	// taxiOutRevertTime 滑行阶段在离场机场停止超过该时间视为回到停机位准备, 例如推出后取消航班
+	taxiOutRevertTime = 10 * time.Minute
+)
+
+type lifecycleAirport struct {
+	icao string
+	data *config.AirportData
+}
+
+func (airport *lifecycleAirport) position() Position {
+	return Position{Latitude: airport.data.Lat, Longitude: airport.data.Lon}
+}
+
+// flightLifecycle 机组飞行阶段状态机
+// 根据地速、高度和与机场的距离推断飞行阶段, 并记录轮挡与起降时间
+type flightLifecycle struct {
+	lock          sync.Mutex
+	airports      map[string]*config.AirportData
+	cid           int
+	callsign      string
+	phase         FlightPhase
+	entry         *operation.LogbookEntry
+	departure     *lifecycleAirport
+	lastAltitude  int
+	lastTime      time.Time
+	verticalSpeed int
+	visualTime    time.Time
+	stoppedTime   time.Time
+}
+
+func newFlightLifecycle(airports map[string]*config.AirportData, cid int, callsign string) *flightLifecycle {
+	return &flightLifecycle{
+		airports: airports,
+		cid:      cid,
+		callsign: callsign,
+		phase:    PhasePreflight,
+		entry:    &operation.LogbookEntry{Cid: cid, Callsign: callsign},
+	}
+}
+
+func (lifecycle *flightLifecycle) currentPhase() FlightPhase {
+	lifecycle.lock.Lock()
+	defer lifecycle.lock.Unlock()
+	return lifecycle.phase
+}
+
+// nearestAirport 查找位置所在的机场, 不在任何机场范围内时返回nil
+func (lifecycle *flightLifecycle) nearestAirport(position Position) *lifecycleAirport {
+	var nearest *lifecycleAirport
+	minDistance := math.MaxFloat64
+	for icao, data := range lifecycle.airports {
+		distance := DistanceInNauticalMiles(position, Position{Latitude: data.Lat, Longitude: data.Lon})
+		if distance <= data.AirportRange && distance < minDistance {
+			minDistance = distance
+			nearest = &lifecycleAirport{icao: icao, data: data}
+		}
+	}
+	return nearest
+}
+
+func (lifecycle *flightLifecycle) plannedAirport(icao string) *lifecycleAirport {
+	if data, ok := lifecycle.airports[icao]; ok {
+		return &lifecycleAirport{icao: icao, data: data}
+	}
+	return nil
+}
+
+// onGround 客户端上报的离地标志不可靠时, 使用已知机场的标高辅助判断
+// 尚未确定离场机场时使用计划中的起飞机场
+func (lifecycle *flightLifecycle) onGround(position Position, altitude, groundSpeed int, onGroundFlag bool, planned, arrival *lifecycleAirport) bool {
+	if onGroundFlag {
+		return true
+	}
+	if groundSpeed >= takeoffSpeed {
+		return false
+	}
+	for _, airport := range []*lifecycleAirport{lifecycle.departure, planned, arrival} {
+		if airport == nil {
+			continue
+		}
+		if float64(altitude)-airport.data.Alt <= groundHeight &&
+			DistanceInNauticalMiles(position, airport.position()) <= airport.data.AirportRange {
+			return true
+		}
+	}
+	return false
+}
+
+// updateVisual 使用快速位置更新中的垂直速度, 比位置差分更准确
+func (lifecycle *flightLifecycle) updateVisual(position *VisualPosition) {
+	lifecycle.lock.Lock()
+	defer lifecycle.lock.Unlock()
+	lifecycle.verticalSpeed = int(position.VelocityY * meterPerSecondToFpm)
+	lifecycle.visualTime = time.Now()
+}
+
+// update 处理一次位置更新, 到达停机位时返回本段飞行的日志
+func (lifecycle *flightLifecycle) update(
+	position Position,
+	altitude, groundSpeed int,
+	onGroundFlag bool,
+	flightPlan *operation.FlightPlan,
+) *operation.LogbookEntry {
+	return lifecycle.updateAt(time.Now(), position, altitude, groundSpeed, onGroundFlag, flightPlan)
+}
+
+func (lifecycle *flightLifecycle) updateAt(
+	now time.Time,
+	position Position,
+	altitude, groundSpeed int,
+	onGroundFlag bool,
+	flightPlan *operation.FlightPlan,
+) (finished *operation.LogbookEntry) {
+	lifecycle.lock.Lock()
+	defer lifecycle.lock.Unlock()
+
+	if elapsed := now.Sub(lifecycle.lastTime); elapsed >= time.Second {
+		// 没有可用的快速位置更新时, 使用两次位置更新的高度差估算垂直速度
+		if !lifecycle.lastTime.IsZero() && now.Sub(lifecycle.visualTime) > visualSampleExpire {
+			lifecycle.verticalSpeed = int(float64(altitude-lifecycle.lastAltitude) / elapsed.Minutes())
+		}
+		lifecycle.lastAltitude = altitude
+		lifecycle.lastTime = now
+	}
+
+	var planned, arrival *lifecycleAirport
+	if flightPlan != nil {
+		arrival = lifecycle.plannedAirport(flightPlan.ArrivalAirport)
+		if lifecycle.departure == nil {
+			planned = lifecycle.plannedAirport(flightPlan.DepartureAirport)
+		}
+	}
+	onGround := lifecycle.onGround(position, altitude, groundSpeed, onGroundFlag, planned, arrival)
+	airborne := !onGround && groundSpeed >= takeoffSpeed
+
+	switch lifecycle.phase {
+	case PhasePreflight, PhaseArrived:
+		if onGround && groundSpeed >= taxiSpeed {
+			if lifecycle.phase == PhaseArrived {
+				lifecycle.entry = &operation.LogbookEntry{Cid: lifecycle.cid, Callsign: lifecycle.callsign}
+			}
+			lifecycle.entry.OffBlockTime = &now
+			lifecycle.setDeparture(lifecycle.nearestAirport(position))
+			lifecycle.stoppedTime = time.Time{}
+			lifecycle.phase = PhaseTaxiOut
+		} else if airborne {
+			// 连线时已经在空中, 无法得知轮挡和起飞时间
+			lifecycle.phase = PhaseEnRoute
+		}
+	case PhaseTaxiOut:
+		if airborne {
+			lifecycle.entry.TakeoffTime = &now
+			if lifecycle.departure == nil {
+				lifecycle.setDeparture(lifecycle.nearestAirport(position))
+			}
+			lifecycle.phase = PhaseAirborne
+		} else if onGround && groundSpeed < stoppedSpeed && lifecycle.atDeparture(position) {
+			if lifecycle.stoppedTime.IsZero() {
+				lifecycle.stoppedTime = now
+			} else if now.Sub(lifecycle.stoppedTime) >= taxiOutRevertTime {
+				lifecycle.entry = &operation.LogbookEntry{Cid: lifecycle.cid, Callsign: lifecycle.callsign}
+				lifecycle.departure = nil
+				lifecycle.phase = PhasePreflight
+			}
+		} else {
+			lifecycle.stoppedTime = time.Time{}
+		}
+	case PhaseAirborne:
+		if onGround {
+			lifecycle.land(position, now)
+		} else if lifecycle.departure == nil || float64(altitude)-lifecycle.departure.data.Alt >= climbHeight ||
+			DistanceInNauticalMiles(position, lifecycle.departure.position()) > approachDistance {
+			lifecycle.phase = PhaseEnRoute
+		}
+	case PhaseEnRoute:
+		if onGround {
+			lifecycle.land(position, now)
+		} else if arrival != nil && float64(altitude)-arrival.data.Alt <= approachHeight &&
+			DistanceInNauticalMiles(position, arrival.position()) <= approachDistance {
+			lifecycle.phase = PhaseApproach
+		}
+	case PhaseApproach:
+		if onGround {
+			lifecycle.land(position, now)
+		}
+	case PhaseLanded, PhaseTaxiIn:
+		if airborne {
+			// 连续起降或复飞
+			lifecycle.entry.LandingTime = nil
+			lifecycle.entry.ArrivalAirport = ""
+			lifecycle.phase = PhaseAirborne
+		} else if lifecycle.phase == PhaseLanded && groundSpeed < taxiInSpeed {
+			lifecycle.phase = PhaseTaxiIn
+		} else if lifecycle.phase == PhaseTaxiIn && groundSpeed < stoppedSpeed {
+			lifecycle.entry.OnBlockTime = &now
+			lifecycle.entry.Completed = true
+			lifecycle.phase = PhaseArrived
+			finished = lifecycle.finish(flightPlan)
+		}
+	}
+
+	if lifecycle.entry.AircraftType == "" && flightPlan != nil {
+		lifecycle.fillPlan(flightPlan)
+	}
+	return
+}
+
+// atDeparture 位置是否在离场机场范围内, 离场机场未知时视为在机场内
+func (lifecycle *flightLifecycle) atDeparture(position Position) bool {
+	if lifecycle.departure == nil {
+		return true
+	}
+	return DistanceInNauticalMiles(position, lifecycle.departure.position()) <= lifecycle.departure.data.AirportRange
+}
+
+func (lifecycle *flightLifecycle) setDeparture(airport *lifecycleAirport) {
+	lifecycle.departure = airport
+	if airport != nil {
+		lifecycle.entry.DepartureAirport = airport.icao
+	}
+}
+
+// land 记录落地时间、落地机场和接地率, 调用方需持有lock
+func (lifecycle *flightLifecycle) land(position Position, now time.Time) {
+	lifecycle.entry.LandingTime = &now
+	lifecycle.entry.TouchdownRate = min(lifecycle.verticalSpeed, 0)
+	if airport := lifecycle.nearestAirport(position); airport != nil {
+		lifecycle.entry.ArrivalAirport = airport.icao
+	}
+	lifecycle.phase = PhaseLanded
+}
+
+func (lifecycle *flightLifecycle) fillPlan(flightPlan *operation.FlightPlan) {
+	lifecycle.entry.AircraftType = flightPlan.AircraftType
+	lifecycle.entry.PlannedDeparture = flightPlan.DepartureAirport
+	lifecycle.entry.PlannedArrival = flightPlan.ArrivalAirport
+}
+
+// finish 结束当前日志并返回, 调用方需持有lock
+func (lifecycle *flightLifecycle) finish(flightPlan *operation.FlightPlan) *operation.LogbookEntry {
+	entry := lifecycle.entry
+	if flightPlan != nil {
+		lifecycle.fillPlan(flightPlan)
+	}
+	lifecycle.entry = &operation.LogbookEntry{Cid: lifecycle.cid, Callsign: lifecycle.callsign}
+	lifecycle.departure = nil
+	return entry
+}
+
+// close 客户端断开时调用, 返回尚未保存且已经起飞的日志
+func (lifecycle *flightLifecycle) close(flightPlan *operation.FlightPlan) *operation.LogbookEntry {
+	lifecycle.lock.Lock()
+	defer lifecycle.lock.Unlock()
+	if lifecycle.entry.TakeoffTime == nil {
+		return nil
+	}
+	return lifecycle.finish(flightPlan)
+}
@@ -0,0 +1,110 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/config"
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+)
+
+type lifecycleStep struct {
+	elapsed     time.Duration
+	latitude    float64
+	longitude   float64
+	altitude    int
+	groundSpeed int
+	onGround    bool
+	expected    FlightPhase
+	finished    bool
+}
+
+func TestFlightLifecycle(t *testing.T) {
+	airports := map[string]*config.AirportData{
+		"ZSSS": {Lat: 31.198, Lon: 121.336, Alt: 10, AirportRange: 5},
+		"ZBAA": {Lat: 40.080, Lon: 116.584, Alt: 116, AirportRange: 5},
+	}
+	flightPlan := &operation.FlightPlan{DepartureAirport: "ZSSS", ArrivalAirport: "ZBAA", AircraftType: "A320"}
+
+	tests := []struct {
+		name  string
+		steps []lifecycleStep
+	}{
+		{"full flight", []lifecycleStep{
+			{0, 31.198, 121.336, 10, 0, true, PhasePreflight, false},
+			{10 * time.Second, 31.199, 121.336, 10, 12, true, PhaseTaxiOut, false},
+			{5 * time.Minute, 31.205, 121.340, 600, 160, false, PhaseAirborne, false},
+			{15 * time.Minute, 32.000, 120.900, 15000, 300, false, PhaseEnRoute, false},
+			{90 * time.Minute, 40.500, 116.584, 8000, 250, false, PhaseApproach, false},
+			{100 * time.Minute, 40.080, 116.584, 116, 130, true, PhaseLanded, false},
+			{101 * time.Minute, 40.081, 116.584, 116, 20, true, PhaseTaxiIn, false},
+			{106 * time.Minute, 40.082, 116.584, 116, 0, true, PhaseArrived, true},
+			{120 * time.Minute, 40.082, 116.584, 116, 8, true, PhaseTaxiOut, false},
+		}},
+		{"connect airborne", []lifecycleStep{
+			{0, 35.000, 118.000, 33000, 450, false, PhaseEnRoute, false},
+			{time.Minute, 35.100, 118.000, 33000, 450, false, PhaseEnRoute, false},
+		}},
+		{"taxi out revert", []lifecycleStep{
+			{0, 31.198, 121.336, 10, 0, true, PhasePreflight, false},
+			{10 * time.Second, 31.199, 121.336, 10, 8, true, PhaseTaxiOut, false},
+			{time.Minute, 31.199, 121.336, 10, 0, true, PhaseTaxiOut, false},
+			{6 * time.Minute, 31.199, 121.336, 10, 0, true, PhaseTaxiOut, false},
+			{11 * time.Minute, 31.199, 121.336, 10, 0, true, PhasePreflight, false},
+			{12 * time.Minute, 31.199, 121.336, 10, 6, true, PhaseTaxiOut, false},
+		}},
+		{"taxi out moving resets timer", []lifecycleStep{
+			{0, 31.198, 121.336, 10, 6, true, PhaseTaxiOut, false},
+			{time.Minute, 31.199, 121.336, 10, 0, true, PhaseTaxiOut, false},
+			{9 * time.Minute, 31.200, 121.336, 10, 10, true, PhaseTaxiOut, false},
+			{10 * time.Minute, 31.201, 121.336, 10, 0, true, PhaseTaxiOut, false},
+			{19 * time.Minute, 31.201, 121.336, 10, 0, true, PhaseTaxiOut, false},
+			{21 * time.Minute, 31.201, 121.336, 10, 0, true, PhasePreflight, false},
+		}},
+		{"touch and go", []lifecycleStep{
+			{0, 31.198, 121.336, 10, 6, true, PhaseTaxiOut, false},
+			{time.Minute, 31.205, 121.340, 600, 160, false, PhaseAirborne, false},
+			{8 * time.Minute, 31.198, 121.336, 10, 120, true, PhaseLanded, false},
+			{9 * time.Minute, 31.205, 121.340, 500, 140, false, PhaseAirborne, false},
+		}},
+		{"ground without flag", []lifecycleStep{
+			{0, 31.198, 121.336, 10, 0, false, PhasePreflight, false},
+			{10 * time.Second, 31.199, 121.336, 12, 15, false, PhaseTaxiOut, false},
+			{time.Minute, 31.205, 121.340, 900, 150, false, PhaseAirborne, false},
+		}},
+	}
+	pass := 0
+	fail := 0
+	start := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	for _, test := range tests {
+		lifecycle := newFlightLifecycle(airports, 1000, "CES101")
+		matched := true
+		for index, step := range test.steps {
+			position := Position{Latitude: step.latitude, Longitude: step.longitude}
+			entry := lifecycle.updateAt(start.Add(step.elapsed), position, step.altitude, step.groundSpeed, step.onGround, flightPlan)
+			if phase := lifecycle.currentPhase(); phase != step.expected || (entry != nil) != step.finished {
+				matched = false
+				t.Errorf("%s step %d = %s, finished %v; expected %s, finished %v", test.name, index, phase, entry != nil, step.expected, step.finished)
+				break
+			}
+			if entry != nil && (!entry.Completed || entry.DepartureAirport != "ZSSS" || entry.ArrivalAirport != "ZBAA" ||
+				entry.OffBlockTime == nil || entry.TakeoffTime == nil || entry.LandingTime == nil || entry.OnBlockTime == nil) {
+				matched = false
+				t.Errorf("%s step %d finished entry %+v is incomplete", test.name, index, entry)
+				break
+			}
+			if step.expected == PhasePreflight && lifecycle.entry.OffBlockTime != nil {
+				matched = false
+				t.Errorf("%s step %d keeps off block time after returning to preflight", test.name, index)
+				break
+			}
+		}
+		if !matched {
+			fail++
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestFlightLifecycle: %d pass, %d fail", pass, fail)
+}
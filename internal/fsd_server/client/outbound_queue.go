@@ -0,0 +1,208 @@
+package client
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/config"
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+)
+
+// coalesceCommands 可以合并的位置数据包, 同一发送者未发出的旧数据包会被新数据包替换
+var coalesceCommands = []ClientCommand{
+	PilotPosition,
+	AtcPosition,
+	VisualPilotPosUpdate,
+	VisualPilotPeriodic,
+	VisualPilotStop,
+}
+
+type outboundPacket struct {
+	line []byte
+	key  string
+}
+
+// outboundQueue 客户端发送队列
+// 所有发往客户端的数据包先进入有界队列, 由单独的写协程按顺序写入连接, 避免慢速客户端阻塞广播
+type outboundQueue struct {
+	lock         sync.Mutex
+	session      SessionInterface
+	config       *config.FsdOutboundQueueConfig
+	pending      []*outboundPacket
+	positions    map[string]*outboundPacket
+	notify       chan struct{}
+	done         chan struct{}
+	closeOnce    sync.Once
+	closed       bool
+	closeConn    bool // 写协程退出时关闭连接
+	exited       bool // 写协程已经退出
+	onWriteError func(err error)
+}
+
+func newOutboundQueue(session SessionInterface, config *config.FsdOutboundQueueConfig, onWriteError func(err error)) *outboundQueue {
+	queue := &outboundQueue{
+		session:      session,
+		config:       config,
+		pending:      make([]*outboundPacket, 0, 16),
+		positions:    make(map[string]*outboundPacket),
+		notify:       make(chan struct{}, 1),
+		done:         make(chan struct{}),
+		onWriteError: onWriteError,
+	}
+	go queue.run()
+	return queue
+}
+
+// coalesceKey 获取位置数据包的合并键, 非位置数据包返回空字符串
+func coalesceKey(line []byte) string {
+	for _, command := range coalesceCommands {
+		if !bytes.HasPrefix(line, []byte(command)) {
+			continue
+		}
+		fields := bytes.SplitN(line[len(command):], []byte{':'}, 3)
+		// 机组位置数据包第一个字段为应答机模式, 第二个字段才是呼号
+		index := 0
+		if command == PilotPosition {
+			index = 1
+		}
+		if len(fields) <= index+1 {
+			return ""
+		}
+		return string(command) + string(fields[index])
+	}
+	return ""
+}
+
+// push 将数据包加入队列, 队列已满时返回ErrClientQueueFull
+// 数据包会被复制, 调用方可以在返回后继续复用line
+func (queue *outboundQueue) push(line []byte) error {
+	key := coalesceKey(line)
+	data := make([]byte, 0, len(line)+SplitSignLen)
+	data = append(data, line...)
+	if !bytes.HasSuffix(data, SplitSign) {
+		data = append(data, SplitSign...)
+	}
+
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+
+	if queue.closed {
+		return ErrClientDisconnected
+	}
+
+	if key != "" {
+		if packet, ok := queue.positions[key]; ok {
+			packet.line = data
+			return nil
+		}
+	}
+
+	if len(queue.pending) >= queue.config.QueueSize {
+		return ErrClientQueueFull
+	}
+
+	packet := &outboundPacket{line: data, key: key}
+	queue.pending = append(queue.pending, packet)
+	if key != "" {
+		queue.positions[key] = packet
+	}
+
+	select {
+	case queue.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// take 取出当前队列中的全部数据包
+func (queue *outboundQueue) take() []*outboundPacket {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+
+	packets := queue.pending
+	queue.pending = make([]*outboundPacket, 0, len(packets))
+	clear(queue.positions)
+	return packets
+}
+
+func (queue *outboundQueue) run() {
+	defer queue.exit()
+	buffer := bytes.Buffer{}
+	for {
+		select {
+		case <-queue.notify:
+		case <-queue.done:
+			// 关闭前尽量发出剩余数据, 例如致命错误的提示
+			_ = queue.flush(&buffer)
+			return
+		}
+		if err := queue.flush(&buffer); err != nil {
+			queue.onWriteError(err)
+			return
+		}
+	}
+}
+
+func (queue *outboundQueue) flush(buffer *bytes.Buffer) error {
+	packets := queue.take()
+	if len(packets) == 0 {
+		return nil
+	}
+
+	buffer.Reset()
+	for _, packet := range packets {
+		queue.session.Record(RecordOutbound, packet.line)
+		buffer.Write(packet.line)
+	}
+
+	conn := queue.session.Conn()
+	if conn == nil {
+		return nil
+	}
+	_ = conn.SetWriteDeadline(time.Now().Add(queue.config.WriteTimeoutDuration))
+	if _, err := conn.Write(buffer.Bytes()); err != nil {
+		return err
+	}
+	return nil
+}
+
+// exit 写协程退出, 如果已经请求关闭连接则在最后一次写入之后关闭
+func (queue *outboundQueue) exit() {
+	queue.lock.Lock()
+	queue.exited = true
+	closeConn := queue.closeConn
+	queue.lock.Unlock()
+	if closeConn {
+		queue.closeSessionConn()
+	}
+}
+
+func (queue *outboundQueue) closeSessionConn() {
+	if conn := queue.session.Conn(); conn != nil {
+		_ = conn.Close()
+	}
+}
+
+// closeWithConn 停止接收新数据包, 写协程发出剩余数据后关闭连接
+// 由写协程关闭连接, 保证最后的错误提示等数据不会因为连接提前关闭而丢失
+func (queue *outboundQueue) closeWithConn() {
+	queue.lock.Lock()
+	queue.closeConn = true
+	exited := queue.exited
+	queue.lock.Unlock()
+	if exited {
+		queue.closeSessionConn()
+	}
+	queue.close()
+}
+
+// close 停止接收新数据包, 写协程发出剩余数据后退出
+func (queue *outboundQueue) close() {
+	queue.closeOnce.Do(func() {
+		queue.lock.Lock()
+		queue.closed = true
+		queue.lock.Unlock()
+		close(queue.done)
+	})
+}
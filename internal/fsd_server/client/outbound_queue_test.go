@@ -0,0 +1,205 @@
+package client
+
+import (
+	"errors"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/config"
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+)
+
+func TestCoalesceKey(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected string
+	}{
+		{"@N:CES101:2000:1:31.0:121.0:3000:250:0:0", "@CES101"},
+		{"@S:CES101:2000:1:31.0:121.0:3000:250:0:0\r\n", "@CES101"},
+		{"%ZSSS_APP:0:5:150:12:31.0:121.0:0", "%ZSSS_APP"},
+		{"^CES101:31.0:121.0:3000:0:0:0:0:0:0:0:0:0", "^CES101"},
+		{"#SLCES101:31.0:121.0:3000:0:0:0:0:0:0:0:0:0", "#SLCES101"},
+		{"#STCES101:31.0:121.0", "#STCES101"},
+		{"#TMCES101:ZSSS_APP:hello", ""},
+		{"$CQSERVER:CES101:CAPS", ""},
+		{"@N:CES101", ""},
+		{"%ZSSS_APP", ""},
+		{"", ""},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		result := coalesceKey([]byte(test.line))
+		if result != test.expected {
+			fail++
+			t.Errorf("coalesceKey(%q) = %q; expected %q", test.line, result, test.expected)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestCoalesceKey: %d pass, %d fail", pass, fail)
+}
+
+func TestOutboundQueuePush(t *testing.T) {
+	// 不启动写协程, 只检查入队与合并的结果
+	queue := &outboundQueue{
+		config:    &config.FsdOutboundQueueConfig{QueueSize: 3},
+		pending:   make([]*outboundPacket, 0),
+		positions: make(map[string]*outboundPacket),
+		notify:    make(chan struct{}, 1),
+	}
+
+	tests := []struct {
+		line          string
+		expectedErr   error
+		expectedQueue []string
+	}{
+		{"@N:CES101:2000:1:31.0:121.0:3000:250:0:0", nil, []string{"@N:CES101:2000:1:31.0:121.0:3000:250:0:0\r\n"}},
+		{"#TMCES102:CES101:hello", nil, []string{"@N:CES101:2000:1:31.0:121.0:3000:250:0:0\r\n", "#TMCES102:CES101:hello\r\n"}},
+		{"@N:CES101:2000:1:31.1:121.1:3100:250:0:0\r\n", nil, []string{"@N:CES101:2000:1:31.1:121.1:3100:250:0:0\r\n", "#TMCES102:CES101:hello\r\n"}},
+		{"@N:CES102:2000:1:31.5:121.5:3000:250:0:0", nil, []string{"@N:CES101:2000:1:31.1:121.1:3100:250:0:0\r\n", "#TMCES102:CES101:hello\r\n", "@N:CES102:2000:1:31.5:121.5:3000:250:0:0\r\n"}},
+		{"#TMCES102:CES101:full", ErrClientQueueFull, []string{"@N:CES101:2000:1:31.1:121.1:3100:250:0:0\r\n", "#TMCES102:CES101:hello\r\n", "@N:CES102:2000:1:31.5:121.5:3000:250:0:0\r\n"}},
+		{"@N:CES102:2000:1:31.6:121.6:3000:250:0:0", nil, []string{"@N:CES101:2000:1:31.1:121.1:3100:250:0:0\r\n", "#TMCES102:CES101:hello\r\n", "@N:CES102:2000:1:31.6:121.6:3000:250:0:0\r\n"}},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		err := queue.push([]byte(test.line))
+		result := make([]string, 0, len(queue.pending))
+		for _, packet := range queue.pending {
+			result = append(result, string(packet.line))
+		}
+		if !errors.Is(err, test.expectedErr) || !slices.Equal(result, test.expectedQueue) {
+			fail++
+			t.Errorf("push(%q) = %v, queue %q; expected %v, queue %q", test.line, err, result, test.expectedErr, test.expectedQueue)
+			continue
+		}
+		pass++
+	}
+
+	queue.take()
+	queue.closed = true
+	if err := queue.push([]byte("#TMCES102:CES101:closed")); !errors.Is(err, ErrClientDisconnected) {
+		fail++
+		t.Errorf("push after close = %v; expected %v", err, ErrClientDisconnected)
+	}
+	t.Logf("TestOutboundQueuePush: %d pass, %d fail", pass, fail)
+}
+
+// fakeConn 测试用连接, 记录关闭前写入的数据
+type fakeConn struct {
+	net.Conn
+	lock      sync.Mutex
+	written   []byte
+	writeErr  error
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newFakeConn(writeErr error) *fakeConn {
+	return &fakeConn{writeErr: writeErr, closed: make(chan struct{})}
+}
+
+func (conn *fakeConn) Write(data []byte) (int, error) {
+	conn.lock.Lock()
+	defer conn.lock.Unlock()
+	select {
+	case <-conn.closed:
+		return 0, net.ErrClosed
+	default:
+	}
+	if conn.writeErr != nil {
+		return 0, conn.writeErr
+	}
+	conn.written = append(conn.written, data...)
+	return len(data), nil
+}
+
+func (conn *fakeConn) Close() error {
+	conn.lock.Lock()
+	defer conn.lock.Unlock()
+	conn.closeOnce.Do(func() { close(conn.closed) })
+	return nil
+}
+
+func (conn *fakeConn) SetWriteDeadline(time.Time) error { return nil }
+
+func (conn *fakeConn) waitClosed() bool {
+	select {
+	case <-conn.closed:
+		return true
+	case <-time.After(time.Second):
+		return false
+	}
+}
+
+func (conn *fakeConn) String() string {
+	conn.lock.Lock()
+	defer conn.lock.Unlock()
+	return string(conn.written)
+}
+
+type fakeSession struct {
+	SessionInterface
+	conn net.Conn
+}
+
+func (session *fakeSession) Conn() net.Conn { return session.conn }
+
+func (session *fakeSession) Record(RecordDirection, []byte) {}
+
+func TestOutboundQueueCloseWithConn(t *testing.T) {
+	queueConfig := &config.FsdOutboundQueueConfig{QueueSize: 16, WriteTimeoutDuration: time.Second}
+
+	tests := []struct {
+		name            string
+		writeErr        error
+		run             func(queue *outboundQueue)
+		expectedWritten string
+	}{
+		{"final error is flushed before close", nil, func(queue *outboundQueue) {
+			_ = queue.push([]byte("#TMSERVER:CES101:hello"))
+			_ = queue.push([]byte("$ERSERVER:CES101:013::Client queue full"))
+			queue.closeWithConn()
+		}, "#TMSERVER:CES101:hello\r\n$ERSERVER:CES101:013::Client queue full\r\n"},
+		{"close requested on write error", errors.New("broken pipe"), func(queue *outboundQueue) {
+			queue.onWriteError = func(error) { queue.closeWithConn() }
+			_ = queue.push([]byte("#TMSERVER:CES101:hello"))
+		}, ""},
+		{"close after writer exited", nil, func(queue *outboundQueue) {
+			queue.close()
+			for {
+				queue.lock.Lock()
+				exited := queue.exited
+				queue.lock.Unlock()
+				if exited {
+					break
+				}
+				time.Sleep(time.Millisecond)
+			}
+			queue.closeWithConn()
+		}, ""},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		conn := newFakeConn(test.writeErr)
+		queue := newOutboundQueue(&fakeSession{conn: conn}, queueConfig, func(error) {})
+		test.run(queue)
+		if !conn.waitClosed() {
+			fail++
+			t.Errorf("closeWithConn(%s) did not close the connection", test.name)
+			continue
+		}
+		if written := conn.String(); written != test.expectedWritten {
+			fail++
+			t.Errorf("closeWithConn(%s) wrote %q; expected %q", test.name, written, test.expectedWritten)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestOutboundQueueCloseWithConn: %d pass, %d fail", pass, fail)
+}
@@ -0,0 +1,220 @@
+package client
+
+import (
+	"math"
+
+	c "github.com/half-nothing/simple-fsd/internal/interfaces/config"
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+)
+
+// sectorArea 扇区定义及预先计算的边界框
+type sectorArea struct {
+	data     *c.SectorData
+	boundary []Position
+	minLat   float64
+	maxLat   float64
+	minLon   float64
+	maxLon   float64
+}
+
+func newSectorArea(data *c.SectorData) *sectorArea {
+	area := &sectorArea{
+		data:     data,
+		boundary: make([]Position, 0, len(data.Polygon)),
+		minLat:   math.MaxFloat64,
+		maxLat:   -math.MaxFloat64,
+		minLon:   math.MaxFloat64,
+		maxLon:   -math.MaxFloat64,
+	}
+	for _, point := range data.Polygon {
+		area.boundary = append(area.boundary, Position{Latitude: point[0], Longitude: point[1]})
+	}
+	area.boundary = UnwrapLongitudes(area.boundary)
+	for _, point := range area.boundary {
+		area.minLat = min(area.minLat, point.Latitude)
+		area.maxLat = max(area.maxLat, point.Latitude)
+		area.minLon = min(area.minLon, point.Longitude)
+		area.maxLon = max(area.maxLon, point.Longitude)
+	}
+	return area
+}
+
+func (area *sectorArea) contains(position Position, altitude int) bool {
+	return area.data.InVerticalLimits(altitude) && area.containsPoint(position)
+}
+
+// containsPoint 只判断水平位置, 不考虑扇区的垂直范围
+func (area *sectorArea) containsPoint(position Position) bool {
+	// 跨越180度经线的扇区展开后经度可能超出[-180, 180], 将点平移到边界框所在的经度范围
+	if position.Longitude < area.minLon {
+		position.Longitude += 360
+	} else if position.Longitude > area.maxLon {
+		position.Longitude -= 360
+	}
+	if position.Latitude < area.minLat || position.Latitude > area.maxLat ||
+		position.Longitude < area.minLon || position.Longitude > area.maxLon {
+		return false
+	}
+	return PointInPolygon(position, area.boundary)
+}
+
+// SectorManager 扇区归属管理
+// 扇区按定义文件中的顺序匹配, 位置所在的扇区无人值守时继续向后查找包含该位置的扇区
+type SectorManager struct {
+	logger        log.LoggerInterface
+	config        *c.FsdSectorConfig
+	clientManager *ClientManager
+	sectors       []*sectorArea
+}
+
+func NewSectorManager(logger log.LoggerInterface, config *c.FsdSectorConfig, clientManager *ClientManager) *SectorManager {
+	sectors := make([]*sectorArea, 0, len(config.Sectors))
+	for _, sector := range config.Sectors {
+		sectors = append(sectors, newSectorArea(sector))
+	}
+	return &SectorManager{
+		logger:        log.NewLoggerAdapter(logger, "SectorManager"),
+		config:        config,
+		clientManager: clientManager,
+		sectors:       sectors,
+	}
+}
+
+func (manager *SectorManager) Enabled() bool {
+	return manager.config.Enabled && len(manager.sectors) > 0
+}
+
+// owners 计算每个扇区当前负责的在线管制员, 与sectors下标一一对应
+// 同一扇区有多个负责席位在线时, 取回退顺序中最靠前的席位
+func (manager *SectorManager) owners() []ClientInterface {
+	clients := manager.clientManager.GetClientSnapshot()
+	defer manager.clientManager.putSlice(clients)
+
+	owners := make([]ClientInterface, len(manager.sectors))
+	for index, sector := range manager.sectors {
+		bestOrder := math.MaxInt
+		for _, client := range clients {
+			if !client.IsAtc() || client.IsAtis() || client.Disconnected() || !AllowAtcFacility.CheckFacility(client.Facility()) {
+				continue
+			}
+			if order, ok := sector.data.MatchOwner(client.Callsign()); ok && order < bestOrder {
+				bestOrder = order
+				owners[index] = client
+			}
+		}
+	}
+	return owners
+}
+
+func (manager *SectorManager) resolve(position Position, altitude int, owners []ClientInterface) (*sectorArea, ClientInterface) {
+	var first *sectorArea
+	for index, sector := range manager.sectors {
+		if !sector.contains(position, altitude) {
+			continue
+		}
+		if owners[index] != nil {
+			return sector, owners[index]
+		}
+		if first == nil {
+			first = sector
+		}
+	}
+	return first, nil
+}
+
+func (manager *SectorManager) Responsible(position Position, altitude int) (string, ClientInterface) {
+	if !manager.Enabled() {
+		return "", nil
+	}
+	sector, controller := manager.resolve(position, altitude, manager.owners())
+	if sector == nil {
+		return "", nil
+	}
+	return sector.data.Name, controller
+}
+
+// SectorsAt 返回水平范围包含该位置的全部扇区名称, 按定义文件中的顺序排列
+func (manager *SectorManager) SectorsAt(position Position) []string {
+	if !manager.Enabled() || !position.PositionValid() {
+		return nil
+	}
+	sectors := make([]string, 0)
+	for _, sector := range manager.sectors {
+		if sector.containsPoint(position) {
+			sectors = append(sectors, sector.data.Name)
+		}
+	}
+	return sectors
+}
+
+func (manager *SectorManager) GetSectors() []*SectorStatus {
+	owners := manager.owners()
+	result := make([]*SectorStatus, 0, len(manager.sectors))
+	for index, sector := range manager.sectors {
+		status := &SectorStatus{
+			Name:    sector.data.Name,
+			Floor:   sector.data.Floor,
+			Ceiling: sector.data.Ceiling,
+			Owners:  sector.data.Owners,
+			Polygon: sector.data.Polygon,
+		}
+		if owners[index] != nil {
+			status.Controller = owners[index].Callsign()
+		}
+		result = append(result, status)
+	}
+	return result
+}
+
+func (manager *SectorManager) GetAircraftOwnership() []*AircraftOwnership {
+	owners := manager.owners()
+	clients := manager.clientManager.GetClientSnapshot()
+	defer manager.clientManager.putSlice(clients)
+
+	result := make([]*AircraftOwnership, 0)
+	for _, client := range clients {
+		if client.IsAtc() || client.Disconnected() {
+			continue
+		}
+		ownership := &AircraftOwnership{Callsign: client.Callsign(), Altitude: client.Altitude()}
+		sector, controller := manager.resolve(client.Position()[0], client.Altitude(), owners)
+		if sector != nil {
+			ownership.Sector = sector.data.Name
+		}
+		if controller != nil {
+			ownership.Controller = controller.Callsign()
+		}
+		result = append(result, ownership)
+	}
+	return result
+}
+
+// FrequencyFilter 频率消息只发送给管制员和由该频率上的管制员负责的机组
+// 频率上没有负责任何扇区的管制员时(例如空对空频率)不做过滤
+func (manager *SectorManager) FrequencyFilter(frequency int) BroadcastFilter {
+	if !manager.Enabled() || !manager.config.FrequencyFilter {
+		return nil
+	}
+	owners := manager.owners()
+	tuned := make(map[ClientInterface]struct{})
+	for _, owner := range owners {
+		if owner != nil && owner.Frequency() == frequency {
+			tuned[owner] = struct{}{}
+		}
+	}
+	if len(tuned) == 0 {
+		return nil
+	}
+	return func(toClient, _ ClientInterface) bool {
+		if toClient.IsAtc() {
+			return true
+		}
+		_, controller := manager.resolve(toClient.Position()[0], toClient.Altitude(), owners)
+		if controller == nil {
+			return false
+		}
+		_, ok := tuned[controller]
+		return ok
+	}
+}
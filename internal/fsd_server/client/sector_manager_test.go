@@ -0,0 +1,172 @@
+package client
+
+import (
+	"slices"
+	"testing"
+
+	c "github.com/half-nothing/simple-fsd/internal/interfaces/config"
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+)
+
+func newFakeController(callsign string, facility Facility) *fakeClient {
+	client := newFakeClient(callsign, 31.2, 121.3, 150)
+	client.isAtc = true
+	client.facility = facility
+	return client
+}
+
+func TestSectorManagerResponsible(t *testing.T) {
+	atis := newFakeController("ZSHA_ATIS", TWR)
+	atis.isAtis = true
+	manager := newTestClientManager(
+		newFakeController("ZSHA_1_CTR", CTR),
+		newFakeController("ZSSS_APP", OBS),
+		newFakeController("ZSPD_APP", APP),
+		newFakeController("PAZA_CTR", CTR),
+		atis,
+	)
+	manager.sectorManager = NewSectorManager(nopLogger{}, &c.FsdSectorConfig{
+		Enabled: true,
+		Sectors: []*c.SectorData{
+			{
+				Name:    "ZSSS_APP",
+				Ceiling: 10000,
+				Owners:  []string{"ZSSS_APP", "ZSHA_ATIS"},
+				Polygon: [][2]float64{{30.8, 120.8}, {30.8, 121.8}, {31.6, 121.8}, {31.6, 120.8}},
+			},
+			{
+				Name:    "ZSPD_APP",
+				Ceiling: 10000,
+				Owners:  []string{"ZSPD_APP"},
+				Polygon: [][2]float64{{30.8, 121.8}, {30.8, 122.5}, {31.6, 122.5}, {31.6, 121.8}},
+			},
+			{
+				Name:    "ZSHA",
+				Owners:  []string{"ZSHA_CTR", "ZSHA_*_CTR"},
+				Polygon: [][2]float64{{28, 118}, {28, 124}, {34, 124}, {34, 118}},
+			},
+			{
+				Name:    "PAZA",
+				Owners:  []string{"PAZA_CTR"},
+				Polygon: [][2]float64{{50, 170}, {50, -170}, {60, -170}, {60, 170}},
+			},
+			{
+				Name:    "ZYSH",
+				Owners:  []string{"ZYSH_CTR"},
+				Polygon: [][2]float64{{40, 120}, {40, 125}, {45, 125}, {45, 120}},
+			},
+		},
+	}, manager)
+
+	tests := []struct {
+		name               string
+		position           Position
+		altitude           int
+		expectedSector     string
+		expectedController string
+	}{
+		// 观察员席位与ATIS不能负责扇区, 回退到包含该位置的下一个扇区
+		{"unattended approach", Position{Latitude: 31.2, Longitude: 121.3}, 3000, "ZSHA", "ZSHA_1_CTR"},
+		{"attended approach", Position{Latitude: 31.2, Longitude: 122.0}, 3000, "ZSPD_APP", "ZSPD_APP"},
+		// 公共边只属于其中一个扇区
+		{"shared edge", Position{Latitude: 31.2, Longitude: 121.8}, 3000, "ZSPD_APP", "ZSPD_APP"},
+		{"above approach", Position{Latitude: 31.2, Longitude: 122.0}, 10000, "ZSHA", "ZSHA_1_CTR"},
+		{"antimeridian east", Position{Latitude: 55, Longitude: 175}, 30000, "PAZA", "PAZA_CTR"},
+		{"antimeridian west", Position{Latitude: 55, Longitude: -175}, 30000, "PAZA", "PAZA_CTR"},
+		// 扇区无人值守时返回第一个包含该位置的扇区
+		{"no controller", Position{Latitude: 42, Longitude: 122}, 30000, "ZYSH", ""},
+		{"outside", Position{Latitude: 0, Longitude: 10}, 30000, "", ""},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		sector, controller := manager.sectorManager.Responsible(test.position, test.altitude)
+		callsign := ""
+		if controller != nil {
+			callsign = controller.Callsign()
+		}
+		if sector != test.expectedSector || callsign != test.expectedController {
+			fail++
+			t.Errorf("Responsible(%s) = %q, %q; expected %q, %q", test.name, sector, callsign, test.expectedSector, test.expectedController)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestSectorManagerResponsible: %d pass, %d fail", pass, fail)
+}
+
+func TestSectorManagerOwnerFallback(t *testing.T) {
+	sectors := &c.FsdSectorConfig{
+		Enabled: true,
+		Sectors: []*c.SectorData{{
+			Name:    "ZSHA",
+			Owners:  []string{"ZSHA_CTR", "ZSHA_*_CTR", "ZSSS_APP"},
+			Polygon: [][2]float64{{28, 118}, {28, 124}, {34, 124}, {34, 118}},
+		}},
+	}
+
+	tests := []struct {
+		name     string
+		online   []*fakeClient
+		expected string
+	}{
+		{"primary", []*fakeClient{newFakeController("ZSSS_APP", APP), newFakeController("ZSHA_CTR", CTR), newFakeController("ZSHA_1_CTR", CTR)}, "ZSHA_CTR"},
+		{"wildcard", []*fakeClient{newFakeController("ZSSS_APP", APP), newFakeController("ZSHA_1_CTR", CTR)}, "ZSHA_1_CTR"},
+		{"last fallback", []*fakeClient{newFakeController("ZSSS_APP", APP), newFakeController("ZSPD_APP", APP)}, "ZSSS_APP"},
+		{"pilot callsign", []*fakeClient{newFakeClient("ZSHA_CTR", 31.2, 121.3, 20)}, ""},
+		{"none", []*fakeClient{}, ""},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		manager := newTestClientManager(test.online...)
+		manager.sectorManager = NewSectorManager(nopLogger{}, sectors, manager)
+		owners := manager.sectorManager.owners()
+		callsign := ""
+		if owners[0] != nil {
+			callsign = owners[0].Callsign()
+		}
+		if callsign != test.expected {
+			fail++
+			t.Errorf("owners(%s) = %q; expected %q", test.name, callsign, test.expected)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestSectorManagerOwnerFallback: %d pass, %d fail", pass, fail)
+}
+
+func TestSectorManagerSectorsAt(t *testing.T) {
+	manager := newTestClientManager()
+	manager.sectorManager = NewSectorManager(nopLogger{}, &c.FsdSectorConfig{
+		Enabled: true,
+		Sectors: []*c.SectorData{
+			{Name: "ZSSS_APP", Ceiling: 10000, Owners: []string{"ZSSS_APP"}, Polygon: [][2]float64{{30.8, 120.8}, {30.8, 121.8}, {31.6, 121.8}, {31.6, 120.8}}},
+			{Name: "ZSHA", Owners: []string{"ZSHA_CTR"}, Polygon: [][2]float64{{28, 118}, {28, 124}, {34, 124}, {34, 118}}},
+			{Name: "PAZA", Owners: []string{"PAZA_CTR"}, Polygon: [][2]float64{{50, 170}, {50, -170}, {60, -170}, {60, 170}}},
+		},
+	}, manager)
+
+	tests := []struct {
+		position Position
+		expected []string
+	}{
+		{Position{Latitude: 31.2, Longitude: 121.3}, []string{"ZSSS_APP", "ZSHA"}},
+		{Position{Latitude: 33, Longitude: 119}, []string{"ZSHA"}},
+		{Position{Latitude: 55, Longitude: -179.5}, []string{"PAZA"}},
+		{Position{Latitude: 55, Longitude: 179.5}, []string{"PAZA"}},
+		{Position{Latitude: 45, Longitude: 179.5}, []string{}},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		result := manager.sectorManager.SectorsAt(test.position)
+		if !slices.Equal(result, test.expected) {
+			fail++
+			t.Errorf("SectorsAt(%+v) = %v; expected %v", test.position, result, test.expected)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestSectorManagerSectorsAt: %d pass, %d fail", pass, fail)
+}
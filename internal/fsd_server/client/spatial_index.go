@@ -0,0 +1,201 @@
+package client
+
+import (
+	"math"
+	"sync"
+
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+)
+
+const (
+	// gridCellSize 网格大小, 单位为度
+	gridCellSize = 1.0
+	// nauticalMilesPerDegree 每纬度对应的海里数
+	nauticalMilesPerDegree = 60.0
+	// wideRangeThreshold 视程超过该值(海里)的客户端不进入网格, 每次查询都作为候选
+	wideRangeThreshold = 100.0
+	// maxQueryRadius 查询半径超过该值(海里)时直接退化为全量遍历
+	maxQueryRadius = 600.0
+	// maxQueryLatitude 查询范围接近极点时经度跨度过大, 直接退化为全量遍历
+	maxQueryLatitude = 85.0
+)
+
+type gridCell struct {
+	lat int
+	lon int
+}
+
+type indexEntry struct {
+	cells []gridCell
+	wide  bool
+}
+
+// spatialIndex 客户端经纬度网格索引
+// 用于在范围广播前快速筛选出可能在范围内的客户端, 精确的距离判断仍由广播过滤器完成
+type spatialIndex struct {
+	lock    sync.RWMutex
+	cells   map[gridCell]map[ClientInterface]struct{}
+	wide    map[ClientInterface]struct{}
+	entries map[ClientInterface]*indexEntry
+}
+
+func newSpatialIndex() *spatialIndex {
+	return &spatialIndex{
+		cells:   make(map[gridCell]map[ClientInterface]struct{}),
+		wide:    make(map[ClientInterface]struct{}),
+		entries: make(map[ClientInterface]*indexEntry),
+	}
+}
+
+func cellOf(latitude, longitude float64) gridCell {
+	return gridCell{
+		lat: int(math.Floor(latitude / gridCellSize)),
+		lon: normalizeLonCell(int(math.Floor(longitude / gridCellSize))),
+	}
+}
+
+func normalizeLonCell(lon int) int {
+	cells := int(360 / gridCellSize)
+	return ((lon+cells/2)%cells+cells)%cells - cells/2
+}
+
+// update 根据客户端当前的位置与视程更新索引
+func (index *spatialIndex) update(client ClientInterface) {
+	entry := &indexEntry{wide: client.VisualRange() > wideRangeThreshold}
+	if !entry.wide {
+		for _, position := range client.Position() {
+			if !position.PositionValid() {
+				continue
+			}
+			cell := cellOf(position.Latitude, position.Longitude)
+			if !containsCell(entry.cells, cell) {
+				entry.cells = append(entry.cells, cell)
+			}
+		}
+	}
+
+	index.lock.Lock()
+	defer index.lock.Unlock()
+
+	if old, ok := index.entries[client]; ok && old.wide == entry.wide && equalCells(old.cells, entry.cells) {
+		return
+	}
+	index.removeLocked(client)
+	index.entries[client] = entry
+	if entry.wide {
+		index.wide[client] = struct{}{}
+		return
+	}
+	for _, cell := range entry.cells {
+		clients, ok := index.cells[cell]
+		if !ok {
+			clients = make(map[ClientInterface]struct{})
+			index.cells[cell] = clients
+		}
+		clients[client] = struct{}{}
+	}
+}
+
+func (index *spatialIndex) remove(client ClientInterface) {
+	index.lock.Lock()
+	defer index.lock.Unlock()
+	index.removeLocked(client)
+}
+
+// removeLocked 从索引中移除客户端, 调用方需持有lock
+func (index *spatialIndex) removeLocked(client ClientInterface) {
+	entry, ok := index.entries[client]
+	if !ok {
+		return
+	}
+	delete(index.entries, client)
+	if entry.wide {
+		delete(index.wide, client)
+		return
+	}
+	for _, cell := range entry.cells {
+		if clients, ok := index.cells[cell]; ok {
+			delete(clients, client)
+			if len(clients) == 0 {
+				delete(index.cells, cell)
+			}
+		}
+	}
+}
+
+// candidates 将可能在fromClient范围内的客户端追加到clients中
+// 返回false表示无法使用索引(例如查询范围过大), 调用方需要遍历全部客户端
+func (index *spatialIndex) candidates(fromClient ClientInterface, clients []ClientInterface) ([]ClientInterface, bool) {
+	if fromClient == nil {
+		return clients, false
+	}
+
+	// 所有阈值都不超过双方视程之和, 网格内客户端的视程不超过wideRangeThreshold
+	radius := fromClient.VisualRange() + wideRangeThreshold
+	if radius > maxQueryRadius {
+		return clients, false
+	}
+
+	ranges := make([][4]int, 0, 4)
+	for _, position := range fromClient.Position() {
+		if !position.PositionValid() {
+			continue
+		}
+		deltaLat := radius / nauticalMilesPerDegree
+		maxLat := math.Abs(position.Latitude) + deltaLat
+		if maxLat >= maxQueryLatitude {
+			return clients, false
+		}
+		deltaLon := deltaLat / math.Cos(maxLat*math.Pi/180)
+		ranges = append(ranges, [4]int{
+			int(math.Floor((position.Latitude - deltaLat) / gridCellSize)),
+			int(math.Floor((position.Latitude + deltaLat) / gridCellSize)),
+			int(math.Floor((position.Longitude - deltaLon) / gridCellSize)),
+			int(math.Floor((position.Longitude + deltaLon) / gridCellSize)),
+		})
+	}
+
+	index.lock.RLock()
+	defer index.lock.RUnlock()
+
+	seen := make(map[ClientInterface]struct{})
+	for client := range index.wide {
+		seen[client] = struct{}{}
+		clients = append(clients, client)
+	}
+	for _, r := range ranges {
+		for lat := r[0]; lat <= r[1]; lat++ {
+			for lon := r[2]; lon <= r[3]; lon++ {
+				for client := range index.cells[gridCell{lat: lat, lon: normalizeLonCell(lon)}] {
+					if _, ok := seen[client]; ok {
+						continue
+					}
+					seen[client] = struct{}{}
+					clients = append(clients, client)
+				}
+			}
+		}
+	}
+	return clients, true
+}
+
+func containsCell(cells []gridCell, cell gridCell) bool {
+	for _, c := range cells {
+		if c == cell {
+			return true
+		}
+	}
+	return false
+}
+
+func equalCells(a, b []gridCell) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,177 @@
+package client
+
+import (
+	"slices"
+	"testing"
+
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+)
+
+// fakeClient 测试用客户端, 只实现索引、分配器与扇区管理需要的方法
+type fakeClient struct {
+	ClientInterface
+	callsign     string
+	positions    [4]Position
+	visualRange  float64
+	isAtc        bool
+	isAtis       bool
+	facility     Facility
+	altitude     int
+	transponder  string
+	flightPlan   *operation.FlightPlan
+	user         *operation.User
+	lines        []string
+	disconnected bool
+}
+
+func newFakeClient(callsign string, latitude, longitude, visualRange float64) *fakeClient {
+	return &fakeClient{
+		callsign:    callsign,
+		positions:   [4]Position{{Latitude: latitude, Longitude: longitude}},
+		visualRange: visualRange,
+	}
+}
+
+func (client *fakeClient) Callsign() string { return client.callsign }
+
+func (client *fakeClient) Position() [4]Position { return client.positions }
+
+func (client *fakeClient) VisualRange() float64 { return client.visualRange }
+
+func (client *fakeClient) IsAtc() bool { return client.isAtc }
+
+func (client *fakeClient) IsAtis() bool { return client.isAtis }
+
+func (client *fakeClient) Facility() Facility { return client.facility }
+
+func (client *fakeClient) Altitude() int { return client.altitude }
+
+func (client *fakeClient) Disconnected() bool { return client.disconnected }
+
+func (client *fakeClient) Transponder() string { return client.transponder }
+
+func (client *fakeClient) FlightPlan() *operation.FlightPlan { return client.flightPlan }
+
+func (client *fakeClient) User() *operation.User { return client.user }
+
+func (client *fakeClient) SendLine(line []byte) { client.lines = append(client.lines, string(line)) }
+
+func callsignsOf(clients []ClientInterface) []string {
+	result := make([]string, 0, len(clients))
+	for _, client := range clients {
+		result = append(result, client.Callsign())
+	}
+	slices.Sort(result)
+	return result
+}
+
+func TestCellOf(t *testing.T) {
+	tests := []struct {
+		latitude  float64
+		longitude float64
+		expected  gridCell
+	}{
+		{0.5, 0.5, gridCell{0, 0}},
+		{-0.5, -0.5, gridCell{-1, -1}},
+		{31.2, 121.8, gridCell{31, 121}},
+		{10, 179.5, gridCell{10, 179}},
+		{10, 180, gridCell{10, -180}},
+		{10, -180.5, gridCell{10, 179}},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		result := cellOf(test.latitude, test.longitude)
+		if result != test.expected {
+			fail++
+			t.Errorf("cellOf(%v, %v) = %v; expected %v", test.latitude, test.longitude, result, test.expected)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestCellOf: %d pass, %d fail", pass, fail)
+}
+
+func TestSpatialIndexCandidates(t *testing.T) {
+	index := newSpatialIndex()
+	for _, client := range []*fakeClient{
+		newFakeClient("CES101", 31.0, 121.0, 50),
+		newFakeClient("CES102", 31.5, 121.5, 50),
+		newFakeClient("CCA201", 40.0, 116.0, 50),
+		newFakeClient("EGTT_CTR", 51.0, -0.5, 300),
+		newFakeClient("UAL301", 10.0, 179.8, 20),
+		newFakeClient("UAL302", 10.0, -179.8, 20),
+	} {
+		index.update(client)
+	}
+
+	tests := []struct {
+		name        string
+		from        *fakeClient
+		expectedOk  bool
+		expectedHit []string
+	}{
+		{"nearby", newFakeClient("FROM", 31.2, 121.2, 50), true, []string{"CES101", "CES102", "EGTT_CTR"}},
+		{"antimeridian", newFakeClient("FROM", 10.0, 179.9, 20), true, []string{"EGTT_CTR", "UAL301", "UAL302"}},
+		{"invalid position", newFakeClient("FROM", 0, 0, 50), true, []string{"EGTT_CTR"}},
+		{"radius too large", newFakeClient("FROM", 31.2, 121.2, 600), false, []string{}},
+		{"near pole", newFakeClient("FROM", 84.5, 10.0, 20), false, []string{}},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		clients, ok := index.candidates(test.from, make([]ClientInterface, 0))
+		result := callsignsOf(clients)
+		if ok != test.expectedOk || !slices.Equal(result, test.expectedHit) {
+			fail++
+			t.Errorf("candidates(%s) = %v, %v; expected %v, %v", test.name, result, ok, test.expectedHit, test.expectedOk)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestSpatialIndexCandidates: %d pass, %d fail", pass, fail)
+}
+
+func TestSpatialIndexUpdate(t *testing.T) {
+	index := newSpatialIndex()
+	moving := newFakeClient("CES101", 31.0, 121.0, 50)
+	removed := newFakeClient("CES102", 31.5, 121.5, 50)
+	index.update(moving)
+	index.update(removed)
+	from := newFakeClient("FROM", 31.2, 121.2, 50)
+
+	tests := []struct {
+		name     string
+		action   func()
+		expected []string
+	}{
+		{"initial", func() {}, []string{"CES101", "CES102"}},
+		{"move away", func() { moving.positions[0] = Position{Latitude: 40, Longitude: 116}; index.update(moving) }, []string{"CES102"}},
+		{"remove", func() { index.remove(removed) }, []string{}},
+		{"become wide", func() { moving.visualRange = 300; index.update(moving) }, []string{"CES101"}},
+		{"move back", func() {
+			moving.visualRange = 50
+			moving.positions[0] = Position{Latitude: 31.0, Longitude: 121.0}
+			index.update(moving)
+		}, []string{"CES101"}},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		test.action()
+		clients, _ := index.candidates(from, make([]ClientInterface, 0))
+		result := callsignsOf(clients)
+		if !slices.Equal(result, test.expected) {
+			fail++
+			t.Errorf("candidates after %s = %v; expected %v", test.name, result, test.expected)
+			continue
+		}
+		pass++
+	}
+	if len(index.wide) != 0 || len(index.cells) != 1 {
+		fail++
+		t.Errorf("index has %d wide clients and %d cells; expected 0 and 1", len(index.wide), len(index.cells))
+	}
+	t.Logf("TestSpatialIndexUpdate: %d pass, %d fail", pass, fail)
+}
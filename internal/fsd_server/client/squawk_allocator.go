@@ -0,0 +1,144 @@
+package client
+
+import (
+	"sync"
+
+	c "github.com/half-nothing/simple-fsd/internal/interfaces/config"
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+)
+
+type squawkReservation struct {
+	code      int
+	rangeName string
+}
+
+// SquawkAllocator 应答机编码分配器
+// 按起飞机场或机组所在扇区匹配编码范围, 分配时跳过已预留的编码和在线机组正在使用的编码
+type SquawkAllocator struct {
+	logger        log.LoggerInterface
+	config        *c.FsdSquawkConfig
+	clientManager *ClientManager
+	lock          sync.Mutex
+	reserved      map[int]struct{}
+	reservations  map[string]*squawkReservation
+	codes         map[int]string
+}
+
+func NewSquawkAllocator(logger log.LoggerInterface, config *c.FsdSquawkConfig, clientManager *ClientManager) *SquawkAllocator {
+	reserved := make(map[int]struct{}, len(config.ReservedCodes))
+	for _, code := range config.ReservedCodes {
+		if value, err := c.ParseSquawk(code); err == nil {
+			reserved[value] = struct{}{}
+		}
+	}
+	return &SquawkAllocator{
+		logger:        log.NewLoggerAdapter(logger, "SquawkAllocator"),
+		config:        config,
+		clientManager: clientManager,
+		reserved:      reserved,
+		reservations:  make(map[string]*squawkReservation),
+		codes:         make(map[int]string),
+	}
+}
+
+// liveCodes 获取可能与目标机组冲突的在线机组正在使用的编码
+func (allocator *SquawkAllocator) liveCodes(target ClientInterface) map[int]struct{} {
+	clients := allocator.clientManager.GetClientSnapshot()
+	defer allocator.clientManager.putSlice(clients)
+
+	codes := make(map[int]struct{})
+	for _, client := range clients {
+		if client == target || client.IsAtc() || client.Disconnected() {
+			continue
+		}
+		if allocator.config.ConflictRange > 0 &&
+			FindNearestDistance(client.Position(), target.Position()) > allocator.config.ConflictRange {
+			continue
+		}
+		if code, err := c.ParseSquawk(client.Transponder()); err == nil {
+			codes[code] = struct{}{}
+		}
+	}
+	return codes
+}
+
+func (allocator *SquawkAllocator) Allocate(callsign string) (string, error) {
+	if !allocator.config.Enabled {
+		return "", ErrSquawkDisabled
+	}
+
+	client, ok := allocator.clientManager.GetClient(callsign)
+	if !ok || client.IsAtc() {
+		return "", ErrCallsignNotFound
+	}
+
+	departure := ""
+	if flightPlan := client.FlightPlan(); flightPlan != nil {
+		departure = flightPlan.DepartureAirport
+	}
+	var sectors []string
+	if allocator.clientManager.sectorManager != nil {
+		sectors = allocator.clientManager.sectorManager.SectorsAt(client.Position()[0])
+	}
+	squawkRange := allocator.config.MatchRange(departure, sectors)
+	if squawkRange == nil {
+		return "", ErrSquawkNoRange
+	}
+
+	live := allocator.liveCodes(client)
+
+	allocator.lock.Lock()
+	defer allocator.lock.Unlock()
+
+	// 已有预留且编码仍属于同一区域, 没有被其他机组占用时直接复用
+	if reservation, ok := allocator.reservations[callsign]; ok {
+		if _, conflict := live[reservation.code]; reservation.rangeName == squawkRange.Name && !conflict {
+			return c.FormatSquawk(reservation.code), nil
+		}
+		delete(allocator.codes, reservation.code)
+		delete(allocator.reservations, callsign)
+	}
+
+	for code := squawkRange.StartCode; code <= squawkRange.EndCode; code++ {
+		if _, ok := allocator.reserved[code]; ok {
+			continue
+		}
+		if _, ok := allocator.codes[code]; ok {
+			continue
+		}
+		if _, ok := live[code]; ok {
+			continue
+		}
+		allocator.codes[code] = callsign
+		allocator.reservations[callsign] = &squawkReservation{code: code, rangeName: squawkRange.Name}
+		allocator.logger.InfoF("Allocate squawk %s to %s from range %s", c.FormatSquawk(code), callsign, squawkRange.Name)
+		return c.FormatSquawk(code), nil
+	}
+
+	allocator.logger.WarnF("Squawk range %s exhausted while allocating for %s", squawkRange.Name, callsign)
+	return "", ErrSquawkExhausted
+}
+
+func (allocator *SquawkAllocator) Release(callsign string) {
+	allocator.lock.Lock()
+	defer allocator.lock.Unlock()
+
+	reservation, ok := allocator.reservations[callsign]
+	if !ok {
+		return
+	}
+	delete(allocator.codes, reservation.code)
+	delete(allocator.reservations, callsign)
+}
+
+func (allocator *SquawkAllocator) GetReservation(callsign string) (string, bool) {
+	allocator.lock.Lock()
+	defer allocator.lock.Unlock()
+
+	reservation, ok := allocator.reservations[callsign]
+	if !ok {
+		return "", false
+	}
+	return c.FormatSquawk(reservation.code), true
+}
@@ -0,0 +1,171 @@
+package client
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	c "github.com/half-nothing/simple-fsd/internal/interfaces/config"
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+)
+
+// nopLogger 丢弃全部日志
+type nopLogger struct {
+	log.LoggerInterface
+}
+
+func (nopLogger) Debug(string)                  {}
+func (nopLogger) DebugF(string, ...interface{}) {}
+func (nopLogger) Info(string)                   {}
+func (nopLogger) InfoF(string, ...interface{})  {}
+func (nopLogger) Warn(string)                   {}
+func (nopLogger) WarnF(string, ...interface{})  {}
+func (nopLogger) Error(string)                  {}
+func (nopLogger) ErrorF(string, ...interface{}) {}
+
+func newTestClientManager(clients ...*fakeClient) *ClientManager {
+	manager := &ClientManager{
+		logger:  nopLogger{},
+		clients: make(map[string]ClientInterface),
+		clientSlicePool: sync.Pool{
+			New: func() interface{} {
+				return make([]ClientInterface, 0, 16)
+			},
+		},
+	}
+	for _, client := range clients {
+		manager.clients[client.Callsign()] = client
+	}
+	return manager
+}
+
+func newTestSquawkRange(name string, prefixes []string, sectors []string, start, end int) *c.SquawkRange {
+	return &c.SquawkRange{
+		Name:      name,
+		Prefixes:  prefixes,
+		Sectors:   sectors,
+		Start:     c.FormatSquawk(start),
+		End:       c.FormatSquawk(end),
+		StartCode: start,
+		EndCode:   end,
+	}
+}
+
+func TestSquawkAllocatorAllocate(t *testing.T) {
+	withFlightPlan := func(client *fakeClient, departure string) *fakeClient {
+		client.flightPlan = &operation.FlightPlan{DepartureAirport: departure}
+		return client
+	}
+	squawking := newFakeClient("CES900", 39.5, 116.5, 20)
+	squawking.transponder = "0103"
+	tower := newFakeClient("ZBAA_TWR", 40.0, 116.5, 50)
+	tower.isAtc = true
+
+	manager := newTestClientManager(
+		withFlightPlan(newFakeClient("CCA101", 40.0, 116.5, 20), "ZBAA"),
+		withFlightPlan(newFakeClient("CCA102", 39.1, 117.3, 20), "ZBTJ"),
+		withFlightPlan(newFakeClient("CCA103", 38.2, 114.7, 20), "ZBSJ"),
+		withFlightPlan(newFakeClient("CSN301", 31.1, 121.8, 20), "ZSPD"),
+		newFakeClient("NOFPL1", 40.5, 116.0, 20),
+		newFakeClient("NOFPL2", 22.3, 113.9, 20),
+		squawking,
+		tower,
+	)
+	manager.sectorManager = NewSectorManager(nopLogger{}, &c.FsdSectorConfig{
+		Enabled: true,
+		Sectors: []*c.SectorData{{
+			Name:    "ZBPE",
+			Owners:  []string{"ZBPE_CTR"},
+			Polygon: [][2]float64{{38, 114}, {38, 119}, {42, 119}, {42, 114}},
+		}},
+	}, manager)
+	manager.squawkAllocator = NewSquawkAllocator(nopLogger{}, &c.FsdSquawkConfig{
+		Enabled:       true,
+		ReservedCodes: []string{"0000", "0102"},
+		Ranges: []*c.SquawkRange{
+			newTestSquawkRange("ZBPE", []string{"ZB"}, []string{"ZBPE"}, 0o101, 0o105),
+			newTestSquawkRange("ZSHA", []string{"ZS"}, nil, 0o201, 0o201),
+		},
+	}, manager)
+
+	tests := []struct {
+		callsign     string
+		release      bool
+		expectedCode string
+		expectedErr  error
+	}{
+		{"CCA101", false, "0101", nil},
+		// 同一机组重复申请时复用已预留的编码
+		{"CCA101", false, "0101", nil},
+		// 0102为保留编码, 0103正在被在线机组使用
+		{"CCA102", false, "0104", nil},
+		// 没有飞行计划的机组按所在扇区匹配
+		{"NOFPL1", false, "0105", nil},
+		{"CCA103", false, "", ErrSquawkExhausted},
+		{"CCA101", true, "", nil},
+		{"CCA103", false, "0101", nil},
+		{"CSN301", false, "0201", nil},
+		// 不在任何扇区内且没有默认范围
+		{"NOFPL2", false, "", ErrSquawkNoRange},
+		{"ZBAA_TWR", false, "", ErrCallsignNotFound},
+		{"UNKNOWN", false, "", ErrCallsignNotFound},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		if test.release {
+			manager.squawkAllocator.Release(test.callsign)
+			if _, ok := manager.squawkAllocator.GetReservation(test.callsign); ok {
+				fail++
+				t.Errorf("Release(%s) still has reservation", test.callsign)
+				continue
+			}
+			pass++
+			continue
+		}
+		code, err := manager.squawkAllocator.Allocate(test.callsign)
+		if code != test.expectedCode || !errors.Is(err, test.expectedErr) {
+			fail++
+			t.Errorf("Allocate(%s) = %q, %v; expected %q, %v", test.callsign, code, err, test.expectedCode, test.expectedErr)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestSquawkAllocatorAllocate: %d pass, %d fail", pass, fail)
+}
+
+func TestSquawkAllocatorConflictRange(t *testing.T) {
+	near := newFakeClient("CES901", 40.1, 116.6, 20)
+	near.transponder = "0101"
+	far := newFakeClient("CES902", 31.1, 121.8, 20)
+	far.transponder = "0102"
+	target := newFakeClient("CCA101", 40.0, 116.5, 20)
+	target.flightPlan = &operation.FlightPlan{DepartureAirport: "ZBAA"}
+
+	manager := newTestClientManager(near, far, target)
+	manager.squawkAllocator = NewSquawkAllocator(nopLogger{}, &c.FsdSquawkConfig{
+		Enabled:       true,
+		ConflictRange: 100,
+		Ranges:        []*c.SquawkRange{newTestSquawkRange("ZBPE", []string{"ZB"}, nil, 0o101, 0o103)},
+	}, manager)
+
+	// 附近机组使用的0101需要跳过, 范围外机组使用的0102可以分配
+	code, err := manager.squawkAllocator.Allocate("CCA101")
+	if err != nil || code != "0102" {
+		t.Errorf("Allocate(CCA101) = %q, %v; expected %q, nil", code, err, "0102")
+	}
+
+	// 预留的编码之后被附近机组占用时重新分配
+	near.transponder = "0102"
+	code, err = manager.squawkAllocator.Allocate("CCA101")
+	if err != nil || code != "0101" {
+		t.Errorf("Allocate(CCA101) after conflict = %q, %v; expected %q, nil", code, err, "0101")
+	}
+
+	manager.squawkAllocator.config.Enabled = false
+	if _, err := manager.squawkAllocator.Allocate("CCA101"); !errors.Is(err, ErrSquawkDisabled) {
+		t.Errorf("Allocate(CCA101) while disabled = %v; expected %v", err, ErrSquawkDisabled)
+	}
+}
@@ -0,0 +1,47 @@
+package client
+
+import (
+	"sync"
+
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+)
+
+// TrafficHub 实时交通事件分发
+type TrafficHub struct {
+	lock     sync.RWMutex
+	nextId   int
+	handlers map[int]TrafficHandler
+}
+
+func NewTrafficHub() *TrafficHub {
+	return &TrafficHub{
+		handlers: make(map[int]TrafficHandler),
+	}
+}
+
+func (hub *TrafficHub) Publish(eventType TrafficEventType, client ClientInterface) {
+	hub.lock.RLock()
+	defer hub.lock.RUnlock()
+
+	if len(hub.handlers) == 0 {
+		return
+	}
+	event := NewTrafficEvent(eventType, client)
+	for _, handler := range hub.handlers {
+		handler(event)
+	}
+}
+
+func (hub *TrafficHub) Subscribe(handler TrafficHandler) func() {
+	hub.lock.Lock()
+	defer hub.lock.Unlock()
+
+	id := hub.nextId
+	hub.nextId++
+	hub.handlers[id] = handler
+	return func() {
+		hub.lock.Lock()
+		defer hub.lock.Unlock()
+		delete(hub.handlers, id)
+	}
+}
@@ -13,13 +13,17 @@ type CommandContent struct {
 	logger              log.LoggerInterface
 	application         *interfaces.ApplicationContent
 	isSimulatorServer   bool
-	refuseOutRange      bool
+	resumeToken         bool
 	jwtToken            string
 	metarManager        interfaces.MetarManagerInterface
+	helpRequestManager  interfaces.HelpRequestManagerInterface
+	banManager          interfaces.BanManagerInterface
 	clientManager       fsd.ClientManagerInterface
+	connectionManager   fsd.ConnectionManagerInterface
 	messageQueue        queue.MessageQueueInterface
 	userOperation       operation.UserOperationInterface
 	flightPlanOperation operation.FlightPlanOperationInterface
+	revisionOperation   operation.FlightPlanRevisionOperationInterface
 	auditLogOperation   operation.AuditLogOperationInterface
 }
 
@@ -32,13 +36,17 @@ func NewCommandContent(
 		logger:              log.NewLoggerAdapter(logger, "CommandHandler"),
 		application:         application,
 		isSimulatorServer:   config.Server.General.SimulatorServer,
-		refuseOutRange:      config.Server.FSDServer.RangeLimit.RefuseOutRange,
+		resumeToken:         config.Server.FSDServer.ResumeToken,
 		jwtToken:            config.Server.HttpServer.JWT.Secret,
 		metarManager:        application.MetarManager(),
+		helpRequestManager:  application.HelpRequestManager(),
+		banManager:          application.BanManager(),
 		clientManager:       application.ClientManager(),
+		connectionManager:   application.ConnectionManager(),
 		messageQueue:        application.MessageQueue(),
 		userOperation:       application.Operations().UserOperation(),
 		flightPlanOperation: application.Operations().FlightPlanOperation(),
+		revisionOperation:   application.Operations().FlightPlanRevisionOperation(),
 		auditLogOperation:   application.Operations().AuditLogOperation(),
 	}
 }
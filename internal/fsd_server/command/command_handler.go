@@ -2,6 +2,7 @@
 package command
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"strconv"
@@ -28,61 +29,42 @@ func (content *CommandContent) verifyFsdUserInfo(session SessionInterface, calls
 		return ResultError(InvalidProtocolVision, true, callsign, nil)
 	}
 
-	client, ok := content.clientManager.GetClient(callsign)
-
-	// 客户端存在且标记为断开连接
-	if ok {
-		if client.Reconnect(session) {
-			// 客户端重连
-			session.SetClient(client)
-		} else {
-			// 呼号已被使用
-			return ResultError(CallsignInUse, true, callsign, nil)
-		}
+	client, result := content.findResumableClient(callsign)
+	if result != nil {
+		return result
 	}
 
-	user, err := cid.GetUser(content.userOperation)
-	if err != nil {
-		return ResultError(InvalidCidPassword, true, callsign, err)
-	}
-	if user.Rating <= Ban.Index() {
-		return ResultError(CidSuspended, true, callsign, nil)
-	}
-	if !content.userOperation.VerifyUserPassword(user, password) {
-		return ResultError(InvalidCidPassword, true, callsign, nil)
+	user, result := content.loadLoginUser(session, callsign, cid)
+	if result != nil {
+		return result
 	}
 
-	// 重设重连客户端的User
-	if client != nil {
-		client.SetUser(user)
+	// 恢复会话时可以使用恢复令牌代替密码, 令牌不匹配时仍按密码验证
+	if !content.checkResumeToken(client, password) && !content.userOperation.VerifyUserPassword(user, password) {
+		return ResultError(InvalidCidPassword, true, callsign, nil)
 	}
-	session.SetUser(user)
 
-	return nil
+	return content.resumeSession(session, client, user)
 }
 
 func (content *CommandContent) verifyVatsimUserInfo(session SessionInterface, callsign string, cid operation.UserId, token string) *Result {
 	if !callsignValid(callsign) {
 		return ResultError(CallsignInvalid, true, callsign, nil)
 	}
-	client, ok := content.clientManager.GetClient(callsign)
-	// 客户端存在且标记为断开连接
-	if ok {
-		if client.Reconnect(session) {
-			// 客户端重连
-			session.SetClient(client)
-		} else {
-			// 呼号已被使用
-			return ResultError(CallsignInUse, true, callsign, nil)
-		}
+
+	client, result := content.findResumableClient(callsign)
+	if result != nil {
+		return result
 	}
 
-	user, err := cid.GetUser(content.userOperation)
-	if err != nil {
-		return ResultError(InvalidCidPassword, true, callsign, err)
+	user, result := content.loadLoginUser(session, callsign, cid)
+	if result != nil {
+		return result
 	}
-	if user.Rating <= Ban.Index() {
-		return ResultError(CidSuspended, true, callsign, nil)
+
+	// 恢复会话时可以使用恢复令牌代替JWT令牌, 令牌不匹配时仍按JWT令牌验证
+	if content.checkResumeToken(client, token) {
+		return content.resumeSession(session, client, user)
 	}
 
 	claims, err := jwt.ParseWithClaims(token, &service.FsdClaims{}, content.defaultKeyFunc)
@@ -90,23 +72,116 @@ func (content *CommandContent) verifyVatsimUserInfo(session SessionInterface, ca
 		return ResultError(InvalidCidPassword, true, callsign, err)
 	}
 
-	if _, ok := claims.Claims.(*service.FsdClaims); !ok {
+	fsdClaims, ok := claims.Claims.(*service.FsdClaims)
+	if !ok {
 		return ResultError(InvalidCidPassword, true, callsign, errors.New("invalid claims type"))
 	}
 
-	if client != nil {
-		client.SetUser(user)
+	// 令牌必须属于登录时提交的用户
+	if fsdClaims.Subject != user.Username {
+		return ResultError(InvalidCidPassword, true, callsign, fmt.Errorf("token issued for %s but login as %04d", fsdClaims.Subject, user.Cid))
+	}
+
+	return content.resumeSession(session, client, user)
+}
+
+// findResumableClient 查找呼号对应的客户端, 客户端在线时呼号已被占用, 已断开的客户端在验证身份后可以恢复
+func (content *CommandContent) findResumableClient(callsign string) (ClientInterface, *Result) {
+	client, ok := content.clientManager.GetClient(callsign)
+	if !ok {
+		return nil, nil
 	}
+	if !client.Disconnected() {
+		return nil, ResultError(CallsignInUse, true, callsign, nil)
+	}
+	return client, nil
+}
+
+func (content *CommandContent) loadLoginUser(session SessionInterface, callsign string, cid operation.UserId) (*operation.User, *Result) {
+	user, err := cid.GetUser(content.userOperation)
+	if err != nil {
+		return nil, ResultError(InvalidCidPassword, true, callsign, err)
+	}
+	if user.Rating <= Ban.Index() {
+		return nil, ResultError(CidSuspended, true, callsign, nil)
+	}
+	if ban := content.banManager.Check(user.Cid, session.ConnId(), operation.BanScopeFsd); ban != nil {
+		return nil, ResultError(CidSuspended, true, callsign, fmt.Errorf("matched ban #%d, reason: %s", ban.ID, ban.Reason))
+	}
+	return user, nil
+}
+
+// resumeSession 身份验证通过后调用, 只有同一CID才能恢复已断开的客户端
+func (content *CommandContent) resumeSession(session SessionInterface, client ClientInterface, user *operation.User) *Result {
+	if client == nil {
+		session.SetUser(user)
+		return nil
+	}
+
+	callsign := client.Callsign()
+	if client.User() == nil || client.User().Cid != user.Cid {
+		return ResultError(CallsignInUse, true, callsign, fmt.Errorf("callsign owned by another user, login as %04d", user.Cid))
+	}
+
+	// 断开期间可能已经用其他呼号登录, 恢复前需要重新检查连接数限制
+	oldAddr := client.RemoteAddr()
+	resumed, err := content.connectionManager.ResumeConnection(user, client, func() bool { return client.Reconnect(session) })
+	if err != nil {
+		content.logger.InfoF("[%s] Login refused for %04d, %v", callsign, user.Cid, err)
+		return ResultError(Custom, true, callsign, err)
+	}
+	if !resumed {
+		return ResultError(CallsignInUse, true, callsign, nil)
+	}
+	session.SetClient(client)
+	client.SetUser(user)
 	session.SetUser(user)
 
+	content.logger.InfoF("[%s] Session resumed by %04d, remote address %s -> %s", callsign, user.Cid, oldAddr, session.ConnId())
 	return nil
 }
 
+func (content *CommandContent) checkResumeToken(client ClientInterface, token string) bool {
+	return content.resumeToken && client != nil && client.CheckResumeToken(token)
+}
+
+// issueResumeToken 登录成功后生成新的恢复令牌, 令牌只能通过Http接口查询, 不会在FSD连接上明文发送
+func (content *CommandContent) issueResumeToken(session SessionInterface) {
+	if !content.resumeToken {
+		return
+	}
+	session.Client().IssueResumeToken()
+}
+
 func (content *CommandContent) checkRangeLimit(_ SessionInterface, realFacility Facility, realRange int) *Result {
 	rangeLimit := realFacility.GetRangeLimit()
 	if rangeLimit > -1 && realRange > rangeLimit {
-		return ResultError(Custom, content.refuseOutRange, strconv.Itoa(realRange), fmt.Errorf("visual range out of limit, your visual range is %d but limit is %d", realRange, rangeLimit))
+		return ResultError(Custom, content.application.ConfigManager().Config().Server.FSDServer.RangeLimit.RefuseOutRange, strconv.Itoa(realRange), fmt.Errorf("visual range out of limit, your visual range is %d but limit is %d", realRange, rangeLimit))
+	}
+	return nil
+}
+
+// checkConnectionPolicy 新建连接前检查同一CID的连接数限制, 恢复会话在 resumeSession 中检查
+// 这里只是提前拒绝以免创建客户端, 并发登录时以 addClient 中的检查为准
+func (content *CommandContent) checkConnectionPolicy(session SessionInterface, callsign string, isAtc bool) *Result {
+	if session.Client() != nil {
+		return nil
+	}
+	if err := content.connectionManager.CheckConnectionPolicy(session.User(), callsign, isAtc); err != nil {
+		content.logger.InfoF("[%s] Login refused for %04d, %v", callsign, session.User().Cid, err)
+		return ResultError(Custom, true, callsign, err)
+	}
+	return nil
+}
+
+// addClient 将新建的客户端加入客户端管理器并绑定到会话, 加入失败时丢弃客户端并断开连接
+func (content *CommandContent) addClient(session SessionInterface, client ClientInterface) *Result {
+	if err := content.clientManager.AddClient(client); err != nil {
+		client.Discard()
+		content.logger.InfoF("[%s] Login refused for %04d, %v", client.Callsign(), session.User().Cid, err)
+		return ResultError(Custom, true, client.Callsign(), err)
 	}
+	session.SetClient(client)
 	return nil
 }
 
@@ -126,7 +201,7 @@ func (content *CommandContent) checkRatingAndFacility(session SessionInterface,
 		return ResultError(Custom, true, callsign, fmt.Errorf("invalid callsign %s", callsign))
 	}
 	ident := facilityIdent[len(facilityIdent)-1]
-	if facility, exist := FacilityMap[ident]; !exist {
+	if facility, exist := LookupFacility(ident); !exist {
 		return ResultError(Custom, true, callsign, fmt.Errorf("invalid callsign %s", callsign))
 	} else {
 		session.SetFacilityIdent(facility)
@@ -144,11 +219,15 @@ func (content *CommandContent) HandleVatsimAddAtc(session SessionInterface, data
 	if result := content.checkRatingAndFacility(session, reqRating, callsign); result != nil {
 		return result
 	}
+	if result := content.checkConnectionPolicy(session, callsign, true); result != nil {
+		return result
+	}
 	realName := data[2]
 	if session.Client() == nil {
 		client := c.NewClient(content.application, callsign, Rating(reqRating), 0, realName, session, true)
-		_ = content.clientManager.AddClient(client)
-		session.SetClient(client)
+		if result := content.addClient(session, client); result != nil {
+			return result
+		}
 	} else {
 		session.Client().SetRating(Rating(reqRating))
 		session.Client().SetRealName(realName)
@@ -156,9 +235,11 @@ func (content *CommandContent) HandleVatsimAddAtc(session SessionInterface, data
 	content.logger.InfoF("[%s] ATC login successfully", callsign)
 	broadcastData := data[:6]
 	broadcastData[4] = ""
-	go content.clientManager.BroadcastMessage(MakePacket(AddAtc, broadcastData...), session.Client(), BroadcastToClientInRange)
+	go content.clientManager.BroadcastMessageInRange(MakePacket(AddAtc, broadcastData...), session.Client(), nil)
 	session.Client().SendMotd()
+	content.issueResumeToken(session)
 	session.Client().SendLine(MakePacket(ClientQuery, global.FSDServerName, callsign, "ATIS"))
+	content.clientManager.FlightDataStore().ReplayTo(session.Client())
 	return ResultSuccess()
 }
 
@@ -174,14 +255,18 @@ func (content *CommandContent) HandleFsdAddAtc(session SessionInterface, data []
 	if result := content.checkRatingAndFacility(session, reqRating, callsign); result != nil {
 		return result
 	}
+	if result := content.checkConnectionPolicy(session, callsign, true); result != nil {
+		return result
+	}
 	realName := data[2]
 	latitude := utils.StrToFloat(data[9], 0)
 	longitude := utils.StrToFloat(data[10], 0)
 	if session.Client() == nil {
 		client := c.NewClient(content.application, callsign, Rating(reqRating), protocol, realName, session, true)
 		_ = client.SetPosition(0, latitude, longitude)
-		_ = content.clientManager.AddClient(client)
-		session.SetClient(client)
+		if result := content.addClient(session, client); result != nil {
+			return result
+		}
 	} else {
 		session.Client().SetRating(Rating(reqRating))
 		session.Client().SetRealName(realName)
@@ -189,9 +274,11 @@ func (content *CommandContent) HandleFsdAddAtc(session SessionInterface, data []
 	content.logger.InfoF("[%s] ATC login successfully", callsign)
 	broadcastData := data[:6]
 	broadcastData[4] = ""
-	go content.clientManager.BroadcastMessage(MakePacket(AddAtc, broadcastData...), session.Client(), BroadcastToClientInRange)
+	go content.clientManager.BroadcastMessageInRange(MakePacket(AddAtc, broadcastData...), session.Client(), nil)
 	session.Client().SendMotd()
+	content.issueResumeToken(session)
 	session.Client().SendLine(MakePacket(ClientQuery, global.FSDServerName, callsign, AtcAtis))
+	content.clientManager.FlightDataStore().ReplayTo(session.Client())
 	return ResultSuccess()
 }
 
@@ -220,14 +307,18 @@ func (content *CommandContent) handleClientLogin(session SessionInterface, data
 	simType := utils.StrToInt(data[6], 0)
 	realName := data[7]
 	reqRating := Rating(utils.StrToInt(data[4], 0) - 1)
-	if reqRating != Normal || !RatingFacilityMap[reqRating].CheckFacility(Pilot) {
+	if reqRating != Normal || !reqRating.CheckRatingFacility(Pilot) {
 		return ResultError(RequestLevelTooHigh, true, callsign, nil)
 	}
+	if result := content.checkConnectionPolicy(session, callsign, false); result != nil {
+		return result
+	}
 	if session.Client() == nil {
 		client := c.NewClient(content.application, callsign, reqRating, protocol, realName, session, false)
 		client.SetSimType(simType)
-		session.SetClient(client)
-		_ = content.clientManager.AddClient(client)
+		if result := content.addClient(session, client); result != nil {
+			return result
+		}
 	} else {
 		session.Client().SetRating(reqRating)
 		session.Client().SetRealName(realName)
@@ -235,8 +326,9 @@ func (content *CommandContent) handleClientLogin(session SessionInterface, data
 	content.logger.InfoF("[%s] Client login successfully", callsign)
 	broadcastData := data[:6]
 	broadcastData[4] = ""
-	go content.clientManager.BroadcastMessage(MakePacket(AddPilot, broadcastData...), session.Client(), BroadcastToClientInRange)
+	go content.clientManager.BroadcastMessageInRange(MakePacket(AddPilot, broadcastData...), session.Client(), nil)
 	session.Client().SendMotd()
+	content.issueResumeToken(session)
 	session.Client().SendLine(MakePacket(ClientQuery, global.FSDServerName, callsign, ClientCapacity))
 	if !content.isSimulatorServer {
 		flightPlan := session.Client().FlightPlan()
@@ -273,10 +365,7 @@ func (content *CommandContent) HandleAtcPosUpdate(session SessionInterface, data
 	visualRange := utils.StrToFloat(data[3], 0)
 	latitude := utils.StrToFloat(data[5], 0)
 	longitude := utils.StrToFloat(data[6], 0)
-	if session.Client() == nil {
-		return ResultError(Syntax, false, "", fmt.Errorf("client not register"))
-	}
-	go content.clientManager.BroadcastMessage(rawLine, session.Client(), BroadcastToClientInRange)
+	go content.clientManager.BroadcastMessageInRange(rawLine, session.Client(), nil)
 	session.Client().UpdateAtcPos(frequency, facility, visualRange, latitude, longitude)
 	return ResultSuccess()
 }
@@ -288,48 +377,79 @@ func (content *CommandContent) HandlePilotPosUpdate(session SessionInterface, da
 	altitude := utils.StrToInt(data[6], 0)
 	groundSpeed := utils.StrToInt(data[7], 0)
 	pbh := uint32(utils.StrToInt(data[8], 0))
-	if session.Client() == nil {
+	go content.clientManager.BroadcastMessageInRange(rawLine, session.Client(), nil)
+	session.Client().UpdatePilotPos(transponder, latitude, longitude, altitude, groundSpeed, pbh)
+	if *global.VisualPilot {
+		content.updateFastUpdateState(session.Client())
+	}
+	return ResultSuccess()
+}
+
+// HandleVisualPilotPosUpdate 处理VisualPilot快速位置更新(^, #SL, #ST)
+// 快速位置只转发给视程范围内支持VISUPDATE的客户端
+func (content *CommandContent) HandleVisualPilotPosUpdate(session SessionInterface, data []string, rawLine []byte) *Result {
+	client := session.Client()
+	if client == nil {
 		return ResultError(Syntax, false, "", fmt.Errorf("client not register"))
 	}
-	go content.clientManager.BroadcastMessage(rawLine, session.Client(), BroadcastToClientInRange)
-	session.Client().UpdatePilotPos(transponder, latitude, longitude, altitude, groundSpeed, pbh)
+	if client.IsAtc() {
+		return ResultError(Syntax, false, session.Callsign(), fmt.Errorf("fast position update is only available for pilot"))
+	}
+	if data[0] != session.Callsign() {
+		return ResultError(InvalidSrcCallsign, false, data[0], nil)
+	}
+	stopped := bytes.HasPrefix(rawLine, []byte(VisualPilotStop))
+	if !stopped && len(data) < 12 {
+		return ResultError(Syntax, false, session.Callsign(), fmt.Errorf("datapack length too short, require 12 but got %d", len(data)))
+	}
+	client.UpdateVisualPos(parseVisualPosition(data, stopped))
+	go content.clientManager.BroadcastMessageInRange(rawLine, client, BroadcastToVisualClient)
 	return ResultSuccess()
 }
 
+// updateFastUpdateState 根据附近是否存在支持VISUPDATE的客户端, 通知飞行员开始或停止发送快速位置
+// 没有客户端需要快速位置时只发送常规位置, 避免无意义的流量
+func (content *CommandContent) updateFastUpdateState(client ClientInterface) {
+	if client == nil || client.IsAtc() || !client.CheckCapacity(VisualPilot) {
+		return
+	}
+	interested := content.clientManager.HasClientInRange(client, BroadcastToVisualClient)
+	if !client.SetFastUpdate(interested) {
+		return
+	}
+	enabled := "0"
+	if interested {
+		enabled = "1"
+	}
+	client.SendLine(MakePacket(SwitchVisualPilot, global.FSDServerName, client.Callsign(), enabled))
+}
+
 func (content *CommandContent) HandleAtcVisPointUpdate(session SessionInterface, data []string, _ []byte) *Result {
 	visPos := utils.StrToInt(data[1], 0)
 	latitude := utils.StrToFloat(data[2], 0)
 	longitude := utils.StrToFloat(data[3], 0)
-	if session.Client() == nil {
-		return ResultError(Syntax, false, "", fmt.Errorf("client not register"))
-	}
 	_ = session.Client().UpdateAtcVisPoint(visPos, latitude, longitude)
 	return ResultSuccess()
 }
 
 // sendFrequencyMessage 发送频率消息
 func (content *CommandContent) sendFrequencyMessage(session SessionInterface, targetStation string, rawLine []byte) *Result {
-	if session.Client() == nil {
-		return ResultError(Syntax, false, "", fmt.Errorf("client not register"))
-	}
 	frequency := utils.StrToInt(fmt.Sprintf("%d%s", 1, targetStation[1:]), -1)
 	if frequency == -1 {
 		return ResultError(Syntax, true, targetStation, fmt.Errorf("illegal frequency %s", targetStation))
 	}
 	if FrequencyValid(frequency) {
-		// 合法频率, 发给所有客户端
-		go content.clientManager.BroadcastMessage(rawLine, session.Client(), BroadcastToClientInRange)
+		// 合法频率, 发给所有客户端, 启用扇区接收过滤时只发给该频率管制员负责的机组
+		filter := content.clientManager.SectorManager().FrequencyFilter(frequency - 100000)
+		go content.clientManager.BroadcastMessageInRange(rawLine, session.Client(), filter)
 	} else {
 		// 非法频率, 大概率是管制使用, 只发给管制
-		go content.clientManager.BroadcastMessage(rawLine, session.Client(), CombineBroadcastFilter(BroadcastToAtc, BroadcastToClientInRange))
+		go content.clientManager.BroadcastMessageInRange(rawLine, session.Client(), BroadcastToAtc)
 	}
 	return nil
 }
 
 func (content *CommandContent) HandleClientQuery(session SessionInterface, data []string, rawLine []byte) *Result {
-	if session.Client() == nil {
-		return ResultError(Syntax, false, "", fmt.Errorf("client not register"))
-	}
 	commandLength := len(data)
 	if commandLength < 3 {
 		return ResultError(Syntax, false, "", fmt.Errorf("illegal command length %d", commandLength))
@@ -363,6 +483,8 @@ func (content *CommandContent) HandleClientQuery(session SessionInterface, data
 			}
 		case IpAddress:
 			session.Client().SendLine(MakePacket(ClientResponse, global.FSDServerName, data[0], "IP", session.Conn().RemoteAddr().String()[0:strings.LastIndex(session.Conn().RemoteAddr().String(), ":")]))
+		case AssignSquawk:
+			return content.handleAssignSquawk(session, data)
 		}
 		return ResultSuccess()
 	}
@@ -393,12 +515,18 @@ func (content *CommandContent) HandleClientQuery(session SessionInterface, data
 						content.logger.ErrorF("UpdateCruiseAltitude error: illegal cruise altitude %s, %s", data[4], rawLine)
 						return ResultError(Syntax, false, session.Client().Callsign(), nil)
 					}
+					oldFlightPlan := *client.FlightPlan()
 					if err := content.flightPlanOperation.UpdateCruiseAltitude(client.FlightPlan(), fmt.Sprintf("FL%03d", cruiseAltitude/100)); err != nil {
 						// 这里并不是发给服务器的, 所以如果出错, 直接返回就行
 						return ResultSuccess()
 					}
+					content.handleFlightPlanAmended(session.Client(), client, &oldFlightPlan)
 				}
 			}
+			// 保存协调数据, 供之后登录的管制员同步
+			if commandLength >= 4 && session.Client().IsAtc() {
+				content.clientManager.FlightDataStore().HandleCoordination(session.Client().Callsign(), subQuery, data[3:])
+			}
 			// ATIS信息更新, 需要更新服务器存储的ATIS信息
 			if subQuery == InfoUpdate {
 				session.Client().ClearAtcAtisInfo()
@@ -421,10 +549,28 @@ func (content *CommandContent) HandleClientQuery(session SessionInterface, data
 	return ResultSuccess()
 }
 
-func (content *CommandContent) HandleClientResponse(session SessionInterface, data []string, rawLine []byte) *Result {
-	if session.Client() == nil {
-		return ResultError(Syntax, false, "", fmt.Errorf("client not register"))
+// handleAssignSquawk 处理管制员向服务器申请应答机编码
+func (content *CommandContent) handleAssignSquawk(session SessionInterface, data []string) *Result {
+	if len(data) < 4 {
+		return ResultError(Syntax, false, "", fmt.Errorf("illegal command length %d", len(data)))
 	}
+	if !content.isSimulatorServer && !session.Client().CheckFacility(AllowAtcFacility) {
+		return ResultError(InvalidCtrl, false, session.Client().Callsign(), nil)
+	}
+	targetCallsign := data[3]
+	code, err := content.clientManager.SquawkAllocator().Allocate(targetCallsign)
+	if err != nil {
+		if errors.Is(err, ErrCallsignNotFound) {
+			return ResultError(NoCallsignFound, false, targetCallsign, err)
+		}
+		return ResultError(Custom, false, targetCallsign, err)
+	}
+	content.clientManager.FlightDataStore().HandleCoordination(session.Client().Callsign(), BeaconCode, []string{targetCallsign, code})
+	session.Client().SendLine(MakePacket(ClientResponse, global.FSDServerName, data[0], AssignSquawk, targetCallsign, code))
+	return ResultSuccess()
+}
+
+func (content *CommandContent) HandleClientResponse(session SessionInterface, data []string, rawLine []byte) *Result {
 	commandLength := len(data)
 	targetStation := data[1]
 	if targetStation == global.FSDServerName {
@@ -433,6 +579,10 @@ func (content *CommandContent) HandleClientResponse(session SessionInterface, da
 			if data[3] == "T" {
 				session.Client().AddAtcAtisInfo(data[4])
 			}
+			// 管制员发送完全部ATIS后发送E结束
+			if data[3] == "E" {
+				content.clientManager.TrafficHub().Publish(TrafficAtis, session.Client())
+			}
 			if data[3] == "Z" {
 				session.Client().SetLogoffTime(data[4])
 			}
@@ -440,8 +590,8 @@ func (content *CommandContent) HandleClientResponse(session SessionInterface, da
 		}
 		if subQuery == ClientCapacity && commandLength >= 4 {
 			session.Client().UpdateCapacities(data[3:])
-			if *global.VisualPilot && session.Client().CheckCapacity(VisualPilot) {
-				session.Client().SendLine(MakePacket(SwitchVisualPilot, global.FSDServerName, session.Callsign(), "1"))
+			if *global.VisualPilot {
+				content.updateFastUpdateState(session.Client())
 			}
 			return ResultSuccess()
 		}
@@ -469,7 +619,12 @@ func (content *CommandContent) HandleMessage(session SessionInterface, data []st
 	if strings.HasPrefix(targetStation, "*") {
 		// 广播消息
 		if targetStation == string(AllSup) {
-			go content.clientManager.BroadcastMessage(rawLine, session.Client(), BroadcastToSup)
+			// 监管之间的消息照常广播, 其他用户的消息作为求助进入求助队列
+			if BroadcastToSupClient(session.Client()) {
+				go content.clientManager.BroadcastMessage(rawLine, session.Client(), BroadcastToSup)
+				return ResultSuccess()
+			}
+			content.submitHelpRequest(session, strings.Join(data[2:], ":"))
 			return ResultSuccess()
 		}
 		if targetStation == "*" && session.Client().IsAtc() && session.Client().CheckRating(AllowKillRating) {
@@ -477,14 +632,15 @@ func (content *CommandContent) HandleMessage(session SessionInterface, data []st
 		}
 		return ResultSuccess()
 	}
+	if targetStation == global.FSDServerName {
+		content.handleConsoleMessage(session, strings.Join(data[2:], ":"))
+		return ResultSuccess()
+	}
 	_ = content.clientManager.SendMessageTo(targetStation, rawLine)
 	return ResultSuccess()
 }
 
 func (content *CommandContent) HandlePlan(session SessionInterface, data []string, rawLine []byte) *Result {
-	if session.Client() == nil {
-		return ResultError(Syntax, false, "", fmt.Errorf("client not register"))
-	}
 	if session.Client().IsAtc() {
 		return ResultError(Syntax, false, "FLIGHT_PLAN", fmt.Errorf("atc can not submit fligth plan"))
 	}
@@ -492,15 +648,12 @@ func (content *CommandContent) HandlePlan(session SessionInterface, data []strin
 		return ResultError(Custom, false, "FLIGHT_PLAN", err)
 	}
 	if !session.Client().FlightPlan().Locked {
-		go content.clientManager.BroadcastMessage(rawLine, session.Client(), CombineBroadcastFilter(BroadcastToAtc, BroadcastToClientInRange))
+		go content.clientManager.BroadcastMessageInRange(rawLine, session.Client(), BroadcastToAtc)
 	}
 	return ResultSuccess()
 }
 
 func (content *CommandContent) HandleAtcEditPlan(session SessionInterface, data []string, _ []byte) *Result {
-	if session.Client() == nil {
-		return ResultError(Syntax, false, "", fmt.Errorf("client not register"))
-	}
 	if !session.Client().IsAtc() {
 		return ResultError(Syntax, false, session.Client().Callsign(), fmt.Errorf("only atc can edit flight plan"))
 	}
@@ -512,36 +665,69 @@ func (content *CommandContent) HandleAtcEditPlan(session SessionInterface, data
 	if !ok {
 		return ResultError(NoCallsignFound, false, session.Client().Callsign(), fmt.Errorf("%s not exists", targetCallsign))
 	}
-	if client.FlightPlan == nil {
+	if client.FlightPlan() == nil {
 		return ResultError(NoFlightPlan, false, session.Client().Callsign(), fmt.Errorf("%s do not have filght plan", session.Client().Callsign()))
 	}
+	oldFlightPlan := *client.FlightPlan()
 	client.FlightPlan().Locked = !content.isSimulatorServer
 	if err := content.flightPlanOperation.UpdateFlightPlan(client.FlightPlan(), data[1:], true); err != nil {
 		return ResultError(Syntax, false, session.Client().Callsign(), err)
 	}
-	go content.clientManager.BroadcastMessage([]byte(content.flightPlanOperation.ToString(client.FlightPlan())),
-		session.Client(), CombineBroadcastFilter(BroadcastToAtc, BroadcastToClientInRange))
+	content.handleFlightPlanAmended(session.Client(), client, &oldFlightPlan)
+	go content.clientManager.BroadcastMessageInRange([]byte(content.flightPlanOperation.ToString(client.FlightPlan())),
+		session.Client(), BroadcastToAtc)
 	return ResultSuccess()
 }
 
-func (content *CommandContent) HandleKillClient(session SessionInterface, data []string, _ []byte) *Result {
-	if session.Client() == nil {
-		return ResultError(Custom, false, "", fmt.Errorf("client not register"))
+// handleFlightPlanAmended 记录管制员对飞行计划的修改, 并通知机组修改内容
+func (content *CommandContent) handleFlightPlanAmended(editor ClientInterface, target ClientInterface, oldFlightPlan *operation.FlightPlan) {
+	changes := operation.DiffFlightPlan(oldFlightPlan, target.FlightPlan())
+	if len(changes) == 0 {
+		return
+	}
+	content.clientManager.TrafficHub().Publish(TrafficFlightPlan, target)
+
+	if revision := content.revisionOperation.NewRevision(oldFlightPlan, target.FlightPlan(), operation.FlightPlanEditByAtc,
+		editor.Callsign(), editor.User().Cid); revision != nil {
+		if err := content.revisionOperation.SaveRevision(revision); err != nil {
+			content.logger.ErrorF("Fail to save flight plan revision of %s: %v", target.Callsign(), err)
+		}
+	}
+
+	if target.IsAtc() {
+		return
+	}
+	summary := make([]string, 0, len(changes))
+	for _, change := range changes {
+		summary = append(summary, fmt.Sprintf("%s %s -> %s", change.Field, change.OldValue, change.NewValue))
 	}
+	target.SendLine(MakePacket(Message, "FPlanManager", target.Callsign(),
+		fmt.Sprintf("Your flight plan has been amended by %s, %s", editor.Callsign(), strings.Join(summary, "; "))))
+}
+
+func (content *CommandContent) HandleKillClient(session SessionInterface, data []string, _ []byte) *Result {
 	if !(session.Client().IsAtc() && session.Client().CheckRating(AllowKillRating)) {
 		return ResultError(Custom, false, session.Client().Callsign(), fmt.Errorf("%s rating not allowed to kill client", session.Client().Rating().String()))
 	}
 	targetStation := data[1]
-	client, err := content.clientManager.KickClientFromServer(targetStation, data[2])
-	if err != nil {
+	if _, err := content.kickClient(session, targetStation, data[2]); err != nil {
 		return ResultError(NoCallsignFound, false, session.Client().Callsign(), fmt.Errorf("%s not exists", targetStation))
 	}
+	return ResultSuccess()
+}
+
+// kickClient 将客户端踢出服务器, 并发送邮件通知和记录审计日志
+func (content *CommandContent) kickClient(session SessionInterface, callsign string, reason string) (ClientInterface, error) {
+	client, err := content.clientManager.KickClientFromServer(callsign, reason)
+	if err != nil {
+		return nil, err
+	}
 	content.messageQueue.Publish(&queue.Message{
 		Type: queue.SendKickedFromServerEmail,
 		Data: &interfaces.KickedFromServerEmailData{
 			User:     client.User(),
 			Operator: session.User(),
-			Reason:   data[2],
+			Reason:   reason,
 		},
 	})
 	content.messageQueue.Publish(&queue.Message{
@@ -555,7 +741,7 @@ func (content *CommandContent) HandleKillClient(session SessionInterface, data [
 			nil,
 		),
 	})
-	return ResultSuccess()
+	return client, nil
 }
 
 func (content *CommandContent) HandleRequest(_ SessionInterface, data []string, rawLine []byte) *Result {
@@ -564,18 +750,22 @@ func (content *CommandContent) HandleRequest(_ SessionInterface, data []string,
 	return ResultSuccess()
 }
 
+func (content *CommandContent) HandleRequestHandoff(session SessionInterface, data []string, rawLine []byte) *Result {
+	content.clientManager.FlightDataStore().RequestHandoff(session.Client().Callsign(), data[1], data[2])
+	return content.HandleRequest(session, data, rawLine)
+}
+
+func (content *CommandContent) HandleAcceptHandoff(session SessionInterface, data []string, rawLine []byte) *Result {
+	content.clientManager.FlightDataStore().AcceptHandoff(session.Client().Callsign(), data[1], data[2])
+	return content.HandleRequest(session, data, rawLine)
+}
+
 func (content *CommandContent) RemoveClient(session SessionInterface, _ []string, _ []byte) *Result {
-	if session.Client() == nil {
-		return ResultError(Custom, false, "", fmt.Errorf("client not register"))
-	}
 	content.logger.InfoF("[%s] Offline", session.Client().Callsign())
 	return ResultSuccess()
 }
 
 func (content *CommandContent) HandleSquawkBox(session SessionInterface, data []string, rawLine []byte) *Result {
-	if session.Client() == nil {
-		return ResultError(Syntax, false, "", fmt.Errorf("client not register"))
-	}
 	targetStation := data[1]
 	client, ok := content.clientManager.GetClient(targetStation)
 	if !ok {
@@ -586,9 +776,6 @@ func (content *CommandContent) HandleSquawkBox(session SessionInterface, data []
 }
 
 func (content *CommandContent) HandleWeatherQuery(session SessionInterface, data []string, _ []byte) *Result {
-	if session.Client() == nil {
-		return ResultError(Syntax, false, "", fmt.Errorf("client not register"))
-	}
 	targetStation := data[3]
 	if len(targetStation) != 4 {
 		return ResultError(Syntax, false, targetStation, fmt.Errorf("invalid target station"))
@@ -604,10 +791,14 @@ func (content *CommandContent) HandleWeatherQuery(session SessionInterface, data
 
 func (content *CommandContent) HandleClientIdent(session SessionInterface, data []string, _ []byte) *Result {
 	session.SetCallsign(data[0])
+	// $IDcallsign:SERVER:client id:client name:major version:minor version:...
+	if len(data) >= 6 {
+		session.SetClientSoftware(fmt.Sprintf("%s %s.%s", data[3], data[4], data[5]))
+	}
 	return ResultSuccess()
 }
 
 func (content *CommandContent) HandleBroadcastToClient(session SessionInterface, _ []string, rawLine []byte) *Result {
-	go content.clientManager.BroadcastMessage(rawLine, session.Client(), CombineBroadcastFilter(BroadcastToPilot, BroadcastToClientInRange))
+	go content.clientManager.BroadcastMessageInRange(rawLine, session.Client(), BroadcastToPilot)
 	return ResultSuccess()
 }
@@ -0,0 +1,319 @@
+package command
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/http/service"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+)
+
+// nopLogger 丢弃全部日志
+type nopLogger struct {
+	log.LoggerInterface
+}
+
+func (nopLogger) Debug(string)                  {}
+func (nopLogger) DebugF(string, ...interface{}) {}
+func (nopLogger) Info(string)                   {}
+func (nopLogger) InfoF(string, ...interface{})  {}
+func (nopLogger) Warn(string)                   {}
+func (nopLogger) WarnF(string, ...interface{})  {}
+func (nopLogger) Error(string)                  {}
+func (nopLogger) ErrorF(string, ...interface{}) {}
+
+type fakeUserOperation struct {
+	operation.UserOperationInterface
+	users     map[int]*operation.User
+	passwords map[int]string
+}
+
+func (userOperation *fakeUserOperation) GetUserByCid(cid int) (*operation.User, error) {
+	if user, ok := userOperation.users[cid]; ok {
+		return user, nil
+	}
+	return nil, operation.ErrUserNotFound
+}
+
+func (userOperation *fakeUserOperation) VerifyUserPassword(user *operation.User, password string) bool {
+	return userOperation.passwords[user.Cid] == password
+}
+
+type fakeBanManager struct {
+	banned map[int]bool
+}
+
+func (manager *fakeBanManager) Check(cid int, _ string, _ operation.BanScope) *operation.Ban {
+	if manager.banned[cid] {
+		return &operation.Ban{ID: 1, Reason: "test"}
+	}
+	return nil
+}
+
+func (*fakeBanManager) Issue(*operation.Ban) error { return nil }
+
+func (*fakeBanManager) Lift(uint, int) (*operation.Ban, error) { return nil, nil }
+
+func (*fakeBanManager) Extend(uint, *time.Time) (*operation.Ban, error) { return nil, nil }
+
+func (*fakeBanManager) AddIssueCallback(func(*operation.Ban)) {}
+
+type fakeClientManager struct {
+	ClientManagerInterface
+	clients map[string]ClientInterface
+}
+
+func (manager *fakeClientManager) GetClient(callsign string) (ClientInterface, bool) {
+	client, ok := manager.clients[callsign]
+	return client, ok
+}
+
+// fakeConnectionManager limited 中的用户已经达到连接数限制
+type fakeConnectionManager struct {
+	ConnectionManagerInterface
+	limited map[int]bool
+}
+
+func (manager *fakeConnectionManager) ResumeConnection(user *operation.User, _ ClientInterface, resume func() bool) (bool, error) {
+	if manager.limited[user.Cid] {
+		return false, ErrConnectionLimitReached
+	}
+	return resume(), nil
+}
+
+// fakeResumeClient 已登录或已断开的客户端
+type fakeResumeClient struct {
+	ClientInterface
+	callsign     string
+	user         *operation.User
+	disconnected bool
+	resumeToken  string
+	socket       SessionInterface
+}
+
+func (client *fakeResumeClient) Callsign() string { return client.callsign }
+
+func (client *fakeResumeClient) User() *operation.User { return client.user }
+
+func (client *fakeResumeClient) SetUser(user *operation.User) { client.user = user }
+
+func (client *fakeResumeClient) Disconnected() bool { return client.disconnected }
+
+func (client *fakeResumeClient) RemoteAddr() string { return "10.0.0.1:50000" }
+
+func (client *fakeResumeClient) CheckResumeToken(token string) bool {
+	return client.resumeToken != "" && client.resumeToken == token
+}
+
+func (client *fakeResumeClient) Reconnect(socket SessionInterface) bool {
+	if !client.disconnected {
+		return false
+	}
+	client.disconnected = false
+	client.socket = socket
+	return true
+}
+
+type fakeSession struct {
+	SessionInterface
+	user   *operation.User
+	client ClientInterface
+}
+
+func (session *fakeSession) ConnId() string { return "10.0.0.2:50001" }
+
+func (session *fakeSession) SetUser(user *operation.User) { session.user = user }
+
+func (session *fakeSession) SetClient(client ClientInterface) { session.client = client }
+
+const testJwtSecret = "test-secret"
+
+func newTestCommandContent(resumeToken bool, clients ...*fakeResumeClient) *CommandContent {
+	users := map[int]*operation.User{
+		1001: {Cid: 1001, Username: "pilot1", Rating: Normal.Index()},
+		1002: {Cid: 1002, Username: "pilot2", Rating: Normal.Index()},
+		1003: {Cid: 1003, Username: "suspended", Rating: Ban.Index()},
+		1004: {Cid: 1004, Username: "banned", Rating: Normal.Index()},
+		1005: {Cid: 1005, Username: "limited", Rating: Normal.Index()},
+	}
+	clientManager := &fakeClientManager{clients: make(map[string]ClientInterface)}
+	for _, client := range clients {
+		clientManager.clients[client.callsign] = client
+	}
+	return &CommandContent{
+		logger:        nopLogger{},
+		resumeToken:   resumeToken,
+		jwtToken:      testJwtSecret,
+		banManager:    &fakeBanManager{banned: map[int]bool{1004: true}},
+		clientManager: clientManager,
+		// 1005 断线后已用其他呼号登录
+		connectionManager: &fakeConnectionManager{limited: map[int]bool{1005: true}},
+		userOperation: &fakeUserOperation{
+			users:     users,
+			passwords: map[int]string{1001: "pw1", 1002: "pw2", 1003: "pw3", 1004: "pw4", 1005: "pw5"},
+		},
+	}
+}
+
+func newTestFsdToken(t *testing.T, username string, secret string) string {
+	claims := &service.FsdClaims{RegisteredClaims: jwt.RegisteredClaims{
+		Subject:   username,
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}}
+	token, err := jwt.NewWithClaims(jwt.GetSigningMethod("HS512"), claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("fail to sign token: %v", err)
+	}
+	return token
+}
+
+type resumeCase struct {
+	name          string
+	resumeToken   bool
+	online        *fakeResumeClient
+	callsign      string
+	cid           int
+	credential    string
+	expectedErrno ClientError
+	expectedOk    bool
+	expectResume  bool
+}
+
+func checkResumeCase(t *testing.T, test resumeCase, result *Result, session *fakeSession) bool {
+	if test.expectedOk != (result == nil) || (result != nil && result.Errno != test.expectedErrno) {
+		t.Errorf("%s = %+v; expected ok %v, errno %v", test.name, result, test.expectedOk, test.expectedErrno)
+		return false
+	}
+	resumed := session.client != nil
+	if resumed != test.expectResume {
+		t.Errorf("%s resumed = %v; expected %v", test.name, resumed, test.expectResume)
+		return false
+	}
+	if test.online != nil && !test.expectResume && test.online.socket != nil {
+		t.Errorf("%s reconnected a client owned by %04d", test.name, test.online.user.Cid)
+		return false
+	}
+	if test.expectedOk && (session.user == nil || session.user.Cid != test.cid) {
+		t.Errorf("%s session user = %+v; expected cid %04d", test.name, session.user, test.cid)
+		return false
+	}
+	return true
+}
+
+func TestVerifyFsdUserInfo(t *testing.T) {
+	disconnected := func(cid int, token string) *fakeResumeClient {
+		return &fakeResumeClient{callsign: "CES101", user: &operation.User{Cid: cid}, disconnected: true, resumeToken: token}
+	}
+	tests := []resumeCase{
+		{"new login", false, nil, "CES101", 1001, "pw1", 0, true, false},
+		{"wrong password", false, nil, "CES101", 1001, "wrong", InvalidCidPassword, false, false},
+		{"unknown user", false, nil, "CES101", 9999, "pw1", InvalidCidPassword, false, false},
+		{"invalid callsign", false, nil, "C", 1001, "pw1", CallsignInvalid, false, false},
+		{"suspended", false, nil, "CES101", 1003, "pw3", CidSuspended, false, false},
+		{"banned", false, nil, "CES101", 1004, "pw4", CidSuspended, false, false},
+		{"callsign online", false, &fakeResumeClient{callsign: "CES101", user: &operation.User{Cid: 1001}}, "CES101", 1001, "pw1", CallsignInUse, false, false},
+		{"resume same cid", false, disconnected(1001, ""), "CES101", 1001, "pw1", 0, true, true},
+		{"resume other cid", false, disconnected(1001, ""), "CES101", 1002, "pw2", CallsignInUse, false, false},
+		{"resume wrong password", false, disconnected(1001, ""), "CES101", 1001, "wrong", InvalidCidPassword, false, false},
+		// 未启用恢复令牌时令牌不能代替密码
+		{"token disabled", false, disconnected(1001, "token1"), "CES101", 1001, "token1", InvalidCidPassword, false, false},
+		{"resume with token", true, disconnected(1001, "token1"), "CES101", 1001, "token1", 0, true, true},
+		{"resume with password", true, disconnected(1001, "token1"), "CES101", 1001, "pw1", 0, true, true},
+		{"resume wrong token", true, disconnected(1001, "token1"), "CES101", 1001, "token2", InvalidCidPassword, false, false},
+		{"token of other cid", true, disconnected(1001, "token1"), "CES101", 1002, "token1", CallsignInUse, false, false},
+		{"password of other cid", true, disconnected(1001, "token1"), "CES101", 1002, "pw2", CallsignInUse, false, false},
+		// 恢复会话同样受连接数限制
+		{"resume over limit", false, disconnected(1005, ""), "CES101", 1005, "pw5", Custom, false, false},
+		// 令牌只对断开的客户端有效, 不能用于新的登录
+		{"token without client", true, nil, "CES101", 1001, "token1", InvalidCidPassword, false, false},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		var content *CommandContent
+		if test.online != nil {
+			content = newTestCommandContent(test.resumeToken, test.online)
+		} else {
+			content = newTestCommandContent(test.resumeToken)
+		}
+		session := &fakeSession{}
+		result := content.verifyFsdUserInfo(session, test.callsign, 9, operation.IntUserId(test.cid), test.credential)
+		if !checkResumeCase(t, test, result, session) {
+			fail++
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestVerifyFsdUserInfo: %d pass, %d fail", pass, fail)
+}
+
+func TestVerifyVatsimUserInfo(t *testing.T) {
+	disconnected := func(cid int, token string) *fakeResumeClient {
+		return &fakeResumeClient{callsign: "CES101", user: &operation.User{Cid: cid}, disconnected: true, resumeToken: token}
+	}
+	jwt1 := newTestFsdToken(t, "pilot1", testJwtSecret)
+	jwt2 := newTestFsdToken(t, "pilot2", testJwtSecret)
+	forged := newTestFsdToken(t, "pilot1", "other-secret")
+	tests := []resumeCase{
+		{"new login", false, nil, "CES101", 1001, jwt1, 0, true, false},
+		{"forged token", false, nil, "CES101", 1001, forged, InvalidCidPassword, false, false},
+		// 令牌必须属于登录时提交的用户
+		{"token of other user", false, nil, "CES101", 1001, jwt2, InvalidCidPassword, false, false},
+		{"resume same cid", false, disconnected(1001, ""), "CES101", 1001, jwt1, 0, true, true},
+		{"resume other cid", false, disconnected(1001, ""), "CES101", 1002, jwt2, CallsignInUse, false, false},
+		{"resume with token", true, disconnected(1001, "token1"), "CES101", 1001, "token1", 0, true, true},
+		{"resume with jwt", true, disconnected(1001, "token1"), "CES101", 1001, jwt1, 0, true, true},
+		{"resume wrong token", true, disconnected(1001, "token1"), "CES101", 1001, "token2", InvalidCidPassword, false, false},
+		{"token of other cid", true, disconnected(1001, "token1"), "CES101", 1002, "token1", CallsignInUse, false, false},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		var content *CommandContent
+		if test.online != nil {
+			content = newTestCommandContent(test.resumeToken, test.online)
+		} else {
+			content = newTestCommandContent(test.resumeToken)
+		}
+		session := &fakeSession{}
+		result := content.verifyVatsimUserInfo(session, test.callsign, operation.IntUserId(test.cid), test.credential)
+		if !checkResumeCase(t, test, result, session) {
+			fail++
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestVerifyVatsimUserInfo: %d pass, %d fail", pass, fail)
+}
+
+func TestCheckResumeToken(t *testing.T) {
+	client := &fakeResumeClient{callsign: "CES101", resumeToken: "token1"}
+	tests := []struct {
+		enabled  bool
+		client   ClientInterface
+		token    string
+		expected bool
+	}{
+		{true, client, "token1", true},
+		{true, client, "token2", false},
+		{true, client, "", false},
+		{false, client, "token1", false},
+		{true, nil, "token1", false},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		content := &CommandContent{resumeToken: test.enabled}
+		result := content.checkResumeToken(test.client, test.token)
+		if result != test.expected {
+			fail++
+			t.Errorf("checkResumeToken(%v, %q) = %v; expected %v", test.enabled, test.token, result, test.expected)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestCheckResumeToken: %d pass, %d fail", pass, fail)
+}
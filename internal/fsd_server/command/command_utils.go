@@ -1,7 +1,12 @@
 // Package command
 package command
 
-import "strings"
+import (
+	"strings"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+	"github.com/half-nothing/simple-fsd/internal/utils"
+)
 
 const (
 	CallsignMinLen = 3
@@ -40,3 +45,33 @@ func callsignValid(callsign string) bool {
 
 	return true
 }
+
+// parseVisualPosition 解析VisualPilot快速位置数据包
+// ^与#SL: callsign:lat:lon:altTrue:altAgl:pbh:vx:vy:vz:vPitch:vHeading:vBank[:noseGear]
+// #ST: callsign:lat:lon:altTrue:altAgl:pbh[:noseGear]
+func parseVisualPosition(data []string, stopped bool) *fsd.VisualPosition {
+	position := &fsd.VisualPosition{
+		Latitude:     utils.StrToFloat(data[1], 0),
+		Longitude:    utils.StrToFloat(data[2], 0),
+		AltitudeTrue: utils.StrToFloat(data[3], 0),
+		AltitudeAgl:  utils.StrToFloat(data[4], 0),
+		Pbh:          uint32(utils.StrToInt(data[5], 0)),
+		Stopped:      stopped,
+	}
+	if stopped {
+		if len(data) > 6 {
+			position.NoseGearAngle = utils.StrToFloat(data[6], 0)
+		}
+		return position
+	}
+	position.VelocityX = utils.StrToFloat(data[6], 0)
+	position.VelocityY = utils.StrToFloat(data[7], 0)
+	position.VelocityZ = utils.StrToFloat(data[8], 0)
+	position.VelocityPitch = utils.StrToFloat(data[9], 0)
+	position.VelocityHeading = utils.StrToFloat(data[10], 0)
+	position.VelocityBank = utils.StrToFloat(data[11], 0)
+	if len(data) > 12 {
+		position.NoseGearAngle = utils.StrToFloat(data[12], 0)
+	}
+	return position
+}
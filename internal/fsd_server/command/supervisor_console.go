@@ -0,0 +1,325 @@
+// Package command
+// File supervisor_console.go
+package command
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/global"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/queue"
+)
+
+const (
+	consoleCommandPrefix = "."
+	consoleLineMaxItems  = 8
+)
+
+var (
+	errConsoleUsage        = errors.New("invalid arguments")
+	errConsoleNoPermission = errors.New("permission denied")
+)
+
+// consoleHandler 控制台命令处理函数, 返回值为回复给发送者的文本行
+type consoleHandler func(content *CommandContent, session SessionInterface, args []string) ([]string, error)
+
+type consoleCommand struct {
+	permission operation.Permission // 为0时不需要权限
+	minArgs    int
+	usage      string
+	handler    consoleHandler
+}
+
+// consoleCommands 通过私聊SERVER使用的管理命令
+var consoleCommands = map[string]*consoleCommand{
+	"kick":     {permission: operation.ClientKill, minArgs: 1, usage: "<callsign> [reason]", handler: (*CommandContent).consoleKick},
+	"msg":      {permission: operation.ClientSendMessage, minArgs: 2, usage: "<callsign> <text>", handler: (*CommandContent).consoleMessage},
+	"info":     {permission: operation.ClientManagerEntry, minArgs: 1, usage: "<callsign>", handler: (*CommandContent).consoleInfo},
+	"online":   {permission: operation.ClientManagerEntry, usage: "", handler: (*CommandContent).consoleOnline},
+	"metar":    {minArgs: 1, usage: "<icao>", handler: (*CommandContent).consoleMetar},
+	"wallop":   {minArgs: 1, usage: "<text>", handler: (*CommandContent).consoleWallop},
+	"requests": {permission: operation.HelpRequestShowList, usage: "", handler: (*CommandContent).consoleHelpRequests},
+	"claim":    {permission: operation.HelpRequestHandle, minArgs: 1, usage: "<id>", handler: (*CommandContent).consoleClaim},
+	"resolve":  {permission: operation.HelpRequestHandle, minArgs: 1, usage: "<id> [note]", handler: (*CommandContent).consoleResolve},
+}
+
+// handleConsoleMessage 处理发送给SERVER的私聊消息, 以.开头的消息按命令处理, 回复以SERVER的私聊发回
+func (content *CommandContent) handleConsoleMessage(session SessionInterface, text string) {
+	if session.Client() == nil || session.User() == nil {
+		return
+	}
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, consoleCommandPrefix) {
+		content.consoleReply(session, "Send .help to list available commands")
+		return
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(text, consoleCommandPrefix))
+	if len(fields) == 0 {
+		return
+	}
+	name, args := strings.ToLower(fields[0]), fields[1:]
+
+	if name == "help" {
+		content.consoleReply(session, content.consoleHelp(session)...)
+		return
+	}
+
+	command, ok := consoleCommands[name]
+	if !ok {
+		content.consoleReply(session, fmt.Sprintf("Unknown command .%s, send .help to list available commands", name))
+		return
+	}
+
+	lines, err := content.runConsoleCommand(session, command, args)
+	switch {
+	case errors.Is(err, errConsoleUsage):
+		content.consoleReply(session, fmt.Sprintf("Usage: .%s %s", name, command.usage))
+	case err != nil:
+		content.consoleReply(session, fmt.Sprintf(".%s failed: %v", name, err))
+	default:
+		content.consoleReply(session, lines...)
+	}
+	content.logger.InfoF("[%s] Console command %s, result: %v", session.Callsign(), text, err)
+
+	content.messageQueue.Publish(&queue.Message{
+		Type: queue.AuditLog,
+		Data: content.auditLogOperation.NewAuditLog(
+			operation.SupervisorCommand,
+			session.User().Cid,
+			session.Callsign(),
+			session.ConnId(),
+			"NOT AVAILABLE",
+			&operation.ChangeDetail{
+				OldValue: operation.ValueNotAvailable,
+				NewValue: text,
+			},
+		),
+	})
+}
+
+func (content *CommandContent) runConsoleCommand(session SessionInterface, command *consoleCommand, args []string) ([]string, error) {
+	if !hasConsolePermission(session, command.permission) {
+		return nil, errConsoleNoPermission
+	}
+	if len(args) < command.minArgs {
+		return nil, errConsoleUsage
+	}
+	return command.handler(content, session, args)
+}
+
+func hasConsolePermission(session SessionInterface, permission operation.Permission) bool {
+	if permission == 0 {
+		return true
+	}
+	userPermission := operation.Permission(session.User().Permission)
+	return userPermission.HasPermission(permission)
+}
+
+func (content *CommandContent) consoleReply(session SessionInterface, lines ...string) {
+	for _, line := range lines {
+		session.Client().SendLine(MakePacket(Message, global.FSDServerName, session.Callsign(), line))
+	}
+}
+
+// consoleHelp 只列出发送者有权限使用的命令
+func (content *CommandContent) consoleHelp(session SessionInterface) []string {
+	names := make([]string, 0, len(consoleCommands))
+	for name, command := range consoleCommands {
+		if hasConsolePermission(session, command.permission) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	lines := make([]string, 0, len(names)+1)
+	lines = append(lines, "Available commands:")
+	for _, name := range names {
+		lines = append(lines, strings.TrimSpace(fmt.Sprintf(".%s %s", name, consoleCommands[name].usage)))
+	}
+	return lines
+}
+
+func (content *CommandContent) consoleKick(session SessionInterface, args []string) ([]string, error) {
+	callsign := strings.ToUpper(args[0])
+	reason := "Kicked by supervisor"
+	if len(args) > 1 {
+		reason = strings.Join(args[1:], " ")
+	}
+	client, err := content.kickClient(session, callsign, reason)
+	if err != nil {
+		return nil, fmt.Errorf("%s not exists", callsign)
+	}
+	return []string{fmt.Sprintf("%s(%04d) kicked: %s", client.Callsign(), client.User().Cid, reason)}, nil
+}
+
+func (content *CommandContent) consoleMessage(session SessionInterface, args []string) ([]string, error) {
+	callsign := strings.ToUpper(args[0])
+	client, ok := content.clientManager.GetClient(callsign)
+	if !ok {
+		return nil, fmt.Errorf("%s not exists", callsign)
+	}
+	message := strings.Join(args[1:], " ")
+	client.SendLine(MakePacket(Message, global.FSDServerName, client.Callsign(), message))
+	content.messageQueue.Publish(&queue.Message{
+		Type: queue.AuditLog,
+		Data: content.auditLogOperation.NewAuditLog(
+			operation.ClientMessage,
+			session.User().Cid,
+			fmt.Sprintf("%s(%s)", client.Callsign(), message),
+			session.ConnId(),
+			"NOT AVAILABLE",
+			nil,
+		),
+	})
+	return []string{fmt.Sprintf("Message sent to %s", client.Callsign())}, nil
+}
+
+func (content *CommandContent) consoleInfo(_ SessionInterface, args []string) ([]string, error) {
+	callsign := strings.ToUpper(args[0])
+	client, ok := content.clientManager.GetClient(callsign)
+	if !ok || client.Disconnected() {
+		return nil, fmt.Errorf("%s not exists", callsign)
+	}
+	connectTime := "unknown"
+	if history := client.History(); history != nil && !history.StartTime.IsZero() {
+		connectTime = fmt.Sprintf("%s UTC (%s ago)", history.StartTime.UTC().Format(time.DateTime),
+			time.Since(history.StartTime).Truncate(time.Second))
+	}
+	kind := "Pilot"
+	if client.IsAtc() {
+		kind = fmt.Sprintf("ATC %s %s", client.Facility(), formatFrequency(client.Frequency()))
+	}
+	return []string{
+		fmt.Sprintf("%s: CID %04d, %s, rating %s, name %s", client.Callsign(), client.User().Cid, kind, client.Rating(), client.RealName()),
+		fmt.Sprintf("IP %s, software %s", client.RemoteAddr(), client.ClientSoftware()),
+		fmt.Sprintf("Connected since %s", connectTime),
+	}, nil
+}
+
+func formatFrequency(frequency int) string {
+	if frequency <= 0 {
+		return "---"
+	}
+	return fmt.Sprintf("1%02d.%03d", frequency/1000, frequency%1000)
+}
+
+func (content *CommandContent) consoleOnline(_ SessionInterface, _ []string) ([]string, error) {
+	pilots := make([]string, 0)
+	controllers := make([]string, 0)
+	clients := content.clientManager.GetClientSnapshot()
+	defer content.clientManager.ReleaseClientSnapshot(clients)
+	for _, client := range clients {
+		if client == nil || client.Disconnected() {
+			continue
+		}
+		if client.IsAtc() {
+			controllers = append(controllers, client.Callsign())
+		} else {
+			pilots = append(pilots, client.Callsign())
+		}
+	}
+	sort.Strings(pilots)
+	sort.Strings(controllers)
+
+	lines := []string{fmt.Sprintf("Online: %d pilots, %d controllers", len(pilots), len(controllers))}
+	lines = append(lines, joinConsoleItems("ATC", controllers)...)
+	lines = append(lines, joinConsoleItems("PILOT", pilots)...)
+	return lines, nil
+}
+
+// joinConsoleItems 每行最多consoleLineMaxItems项, 避免单条消息过长被客户端截断
+func joinConsoleItems(title string, items []string) []string {
+	lines := make([]string, 0, len(items)/consoleLineMaxItems+1)
+	for start := 0; start < len(items); start += consoleLineMaxItems {
+		end := min(start+consoleLineMaxItems, len(items))
+		lines = append(lines, fmt.Sprintf("%s: %s", title, strings.Join(items[start:end], " ")))
+	}
+	return lines
+}
+
+func (content *CommandContent) consoleMetar(_ SessionInterface, args []string) ([]string, error) {
+	icao := strings.ToUpper(args[0])
+	if len(icao) != 4 {
+		return nil, errConsoleUsage
+	}
+	metar, err := content.metarManager.QueryMetar(icao)
+	if err != nil {
+		return nil, fmt.Errorf("cant fetch metar for %s", icao)
+	}
+	return []string{metar}, nil
+}
+
+// consoleWallop 通过控制台发起求助, 与向*S发送消息相同
+func (content *CommandContent) consoleWallop(session SessionInterface, args []string) ([]string, error) {
+	if _, err := content.helpRequestManager.Submit(session.Client(), strings.Join(args, " ")); err != nil && !errors.Is(err, operation.ErrHelpRequestOpen) {
+		return nil, errors.New("fail to submit help request")
+	}
+	return nil, nil
+}
+
+// submitHelpRequest 保存求助, 保存失败时直接提醒求助者
+func (content *CommandContent) submitHelpRequest(session SessionInterface, message string) {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return
+	}
+	if _, err := content.helpRequestManager.Submit(session.Client(), message); err != nil && !errors.Is(err, operation.ErrHelpRequestOpen) {
+		content.logger.ErrorF("[%s] Fail to submit help request: %v", session.Callsign(), err)
+		content.consoleReply(session, "Fail to submit help request, please try again later")
+	}
+}
+
+func (content *CommandContent) consoleHelpRequests(_ SessionInterface, _ []string) ([]string, error) {
+	requests, err := content.helpRequestManager.OpenRequests()
+	if err != nil {
+		return nil, errors.New("fail to query help requests")
+	}
+	lines := []string{fmt.Sprintf("Open help requests: %d", len(requests))}
+	for _, request := range requests {
+		state := "pending"
+		if request.Status == int(operation.HelpRequestStatusClaimed) {
+			state = "claimed by " + request.HandlerCallsign
+		}
+		lines = append(lines, fmt.Sprintf("#%d %s %s ago, %s: %s", request.ID, request.Callsign,
+			time.Since(request.CreatedAt).Truncate(time.Second), state, request.Message))
+	}
+	return lines, nil
+}
+
+func parseHelpRequestId(arg string) (uint, error) {
+	id, err := strconv.ParseUint(strings.TrimPrefix(arg, "#"), 10, 64)
+	if err != nil || id == 0 {
+		return 0, errConsoleUsage
+	}
+	return uint(id), nil
+}
+
+func (content *CommandContent) consoleClaim(session SessionInterface, args []string) ([]string, error) {
+	id, err := parseHelpRequestId(args[0])
+	if err != nil {
+		return nil, err
+	}
+	request, err := content.helpRequestManager.Claim(id, session.User().Cid, session.Callsign())
+	if err != nil {
+		return nil, err
+	}
+	return []string{fmt.Sprintf("Help request #%d from %s claimed: %s", request.ID, request.Callsign, request.Message)}, nil
+}
+
+func (content *CommandContent) consoleResolve(session SessionInterface, args []string) ([]string, error) {
+	id, err := parseHelpRequestId(args[0])
+	if err != nil {
+		return nil, err
+	}
+	request, err := content.helpRequestManager.Resolve(id, session.User().Cid, session.Callsign(), strings.Join(args[1:], " "))
+	if err != nil {
+		return nil, err
+	}
+	return []string{fmt.Sprintf("Help request #%d from %s resolved", request.ID, request.Callsign)}, nil
+}
@@ -0,0 +1,202 @@
+// Package packet
+package packet
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/config"
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/global"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	"github.com/half-nothing/simple-fsd/internal/metrics"
+	"github.com/half-nothing/simple-fsd/internal/utils"
+)
+
+type packetClass int
+
+const (
+	packetPosition packetClass = iota // 位置数据包
+	packetText                        // 文本消息
+	packetOther                       // 其他数据包
+	packetClassCount
+)
+
+var packetClassNames = [packetClassCount]string{"position", "text", "other"}
+
+func classifyCommand(command ClientCommand) packetClass {
+	switch command {
+	case PilotPosition, AtcPosition, AtcSubVisPoint, VisualPilotPeriodic, VisualPilotPosUpdate, VisualPilotStop:
+		return packetPosition
+	case Message:
+		return packetText
+	default:
+		return packetOther
+	}
+}
+
+var (
+	ErrTooManyConnections = errors.New("too many connections from this address")
+	ErrConnectTooFrequent = errors.New("connecting too frequently")
+)
+
+// floodState 会话的超限状态
+type floodState struct {
+	lock          sync.Mutex
+	limited       [packetClassCount]bool
+	mutedUntil    time.Time
+	violations    int
+	lastViolation time.Time
+}
+
+// FloodGuard 限制单个IP的连接数与建连频率, 以及单个会话各类数据包的频率
+type FloodGuard struct {
+	logger         log.LoggerInterface
+	config         *config.FsdFloodProtectionConfig
+	clientManager  ClientManagerInterface
+	lock           sync.Mutex
+	connections    map[string]int
+	connectLimiter *utils.SlidingWindowLimiter
+	packetLimiters [packetClassCount]*utils.SlidingWindowLimiter
+	reportLimiter  *utils.SlidingWindowLimiter
+}
+
+func NewFloodGuard(
+	logger log.LoggerInterface,
+	config *config.FsdFloodProtectionConfig,
+	clientManager ClientManagerInterface,
+) *FloodGuard {
+	guard := &FloodGuard{
+		logger:         log.NewLoggerAdapter(logger, "FloodGuard"),
+		config:         config,
+		clientManager:  clientManager,
+		connections:    make(map[string]int),
+		connectLimiter: utils.NewSlidingWindowLimiter(time.Minute, config.ConnectRateLimit),
+		// 同一IP被拒绝连接时每分钟最多提醒监管一次
+		reportLimiter: utils.NewSlidingWindowLimiter(time.Minute, 1),
+	}
+	limits := [packetClassCount]int{config.PositionPacketLimit, config.TextPacketLimit, config.OtherPacketLimit}
+	for class, limit := range limits {
+		guard.packetLimiters[class] = utils.NewSlidingWindowLimiter(config.PacketWindowDuration, limit)
+		guard.packetLimiters[class].StartCleanup(time.Minute)
+	}
+	guard.connectLimiter.StartCleanup(time.Minute)
+	guard.reportLimiter.StartCleanup(time.Minute)
+	return guard
+}
+
+// AcquireConnection 为新连接占用一个名额, 返回nil时连接关闭后必须调用 ReleaseConnection
+func (guard *FloodGuard) AcquireConnection(remoteAddr string) error {
+	ip := hostOf(remoteAddr)
+
+	guard.lock.Lock()
+	var err error
+	if guard.config.MaxConnectionsPerIp > 0 && guard.connections[ip] >= guard.config.MaxConnectionsPerIp {
+		err = ErrTooManyConnections
+	} else if !guard.connectLimiter.Allow(ip) {
+		err = ErrConnectTooFrequent
+	} else {
+		guard.connections[ip]++
+	}
+	guard.lock.Unlock()
+
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, ErrTooManyConnections) {
+		metrics.FsdFloodViolations.Inc("connections")
+	} else {
+		metrics.FsdFloodViolations.Inc("connect_rate")
+	}
+	if guard.reportLimiter.Allow(ip) {
+		guard.notifySupervisors(fmt.Sprintf("[FLOOD] Refused connection from %s, %v", ip, err))
+	}
+	return err
+}
+
+func (guard *FloodGuard) ReleaseConnection(remoteAddr string) {
+	ip := hostOf(remoteAddr)
+
+	guard.lock.Lock()
+	defer guard.lock.Unlock()
+	if guard.connections[ip] <= 1 {
+		delete(guard.connections, ip)
+		return
+	}
+	guard.connections[ip]--
+}
+
+// checkPacket 检查会话的数据包频率, 返回false时丢弃该数据包, 返回的result不为空时断开连接
+// 同一轮超限只记一次违规, 文本消息超限时会话会被禁言一段时间
+func (guard *FloodGuard) checkPacket(session *Session, command ClientCommand) (bool, *Result) {
+	class := classifyCommand(command)
+	state := &session.flood
+	now := time.Now()
+
+	state.lock.Lock()
+	defer state.lock.Unlock()
+
+	if class == packetText && now.Before(state.mutedUntil) {
+		return false, nil
+	}
+	if guard.packetLimiters[class].Allow(session.connId) {
+		state.limited[class] = false
+		return true, nil
+	}
+	if state.limited[class] {
+		return false, nil
+	}
+
+	state.limited[class] = true
+	if now.Sub(state.lastViolation) > guard.config.ViolationWindowDuration {
+		state.violations = 0
+	}
+	state.violations++
+	state.lastViolation = now
+	metrics.FsdFloodViolations.Inc(packetClassNames[class])
+
+	if guard.config.MaxViolations > 0 && state.violations >= guard.config.MaxViolations {
+		guard.logger.WarnF("[%s](%s) %s packet flood, %d violations, disconnecting", session.connId, session.callsign, packetClassNames[class], state.violations)
+		guard.notifySupervisors(fmt.Sprintf("[FLOOD] %s(%s) disconnected after %d flood violations", session.callsign, session.connId, state.violations))
+		return false, ResultError(Custom, true, session.callsign, errors.New("disconnected for packet flood"))
+	}
+
+	if class == packetText && guard.config.MuteDuration > 0 {
+		state.mutedUntil = now.Add(guard.config.MuteDuration)
+		guard.logger.WarnF("[%s](%s) text flood, muted for %s", session.connId, session.callsign, guard.config.MuteDuration)
+		guard.notifySupervisors(fmt.Sprintf("[FLOOD] %s(%s) muted for %s after text flood, violation %d",
+			session.callsign, session.connId, guard.config.MuteDuration, state.violations))
+		if session.client != nil {
+			session.client.SendLine(MakePacket(Message, global.FSDServerName, session.callsign,
+				fmt.Sprintf("You are sending messages too fast and have been muted for %s", guard.config.MuteDuration)))
+		}
+		return false, nil
+	}
+
+	guard.logger.WarnF("[%s](%s) %s packet flood, violation %d", session.connId, session.callsign, packetClassNames[class], state.violations)
+	guard.notifySupervisors(fmt.Sprintf("[FLOOD] %s(%s) exceeded %s packet limit, violation %d",
+		session.callsign, session.connId, packetClassNames[class], state.violations))
+	return false, nil
+}
+
+// notifySupervisors 私聊所有在线监管
+func (guard *FloodGuard) notifySupervisors(message string) {
+	clients := guard.clientManager.GetClientSnapshot()
+	defer guard.clientManager.ReleaseClientSnapshot(clients)
+	for _, client := range clients {
+		if client == nil || client.Disconnected() || !BroadcastToSupClient(client) {
+			continue
+		}
+		client.SendLine(MakePacket(Message, global.FSDServerName, client.Callsign(), message))
+	}
+}
+
+func hostOf(address string) string {
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		return host
+	}
+	return address
+}
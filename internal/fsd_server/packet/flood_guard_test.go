@@ -0,0 +1,248 @@
+package packet
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/config"
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+)
+
+// nopLogger 丢弃全部日志
+type nopLogger struct {
+	log.LoggerInterface
+}
+
+func (nopLogger) Debug(string)                  {}
+func (nopLogger) DebugF(string, ...interface{}) {}
+func (nopLogger) Info(string)                   {}
+func (nopLogger) InfoF(string, ...interface{})  {}
+func (nopLogger) Warn(string)                   {}
+func (nopLogger) WarnF(string, ...interface{})  {}
+func (nopLogger) Error(string)                  {}
+func (nopLogger) ErrorF(string, ...interface{}) {}
+
+// fakeClient 测试用客户端, 记录收到的数据包
+type fakeClient struct {
+	ClientInterface
+	callsign string
+	isAtc    bool
+	rating   Rating
+	lines    []string
+}
+
+func (client *fakeClient) Callsign() string { return client.callsign }
+
+func (client *fakeClient) Disconnected() bool { return false }
+
+func (client *fakeClient) IsAtc() bool { return client.isAtc }
+
+func (client *fakeClient) Rating() Rating { return client.rating }
+
+func (client *fakeClient) SendLine(line []byte) { client.lines = append(client.lines, string(line)) }
+
+func (client *fakeClient) SendError(result *Result) {
+	client.lines = append(client.lines, result.Errno.String())
+}
+
+type fakeClientManager struct {
+	ClientManagerInterface
+	clients []ClientInterface
+}
+
+func (manager *fakeClientManager) GetClientSnapshot() []ClientInterface { return manager.clients }
+
+func (manager *fakeClientManager) ReleaseClientSnapshot([]ClientInterface) {}
+
+func newTestFloodGuard(floodConfig *config.FsdFloodProtectionConfig) (*FloodGuard, *fakeClient) {
+	supervisor := &fakeClient{callsign: "ZSHA_SUP", isAtc: true, rating: Supervisor}
+	pilot := &fakeClient{callsign: "CES101", rating: Observer}
+	clientManager := &fakeClientManager{clients: []ClientInterface{supervisor, pilot}}
+	return NewFloodGuard(nopLogger{}, floodConfig, clientManager), supervisor
+}
+
+func TestClassifyCommand(t *testing.T) {
+	tests := []struct {
+		command  ClientCommand
+		expected packetClass
+	}{
+		{PilotPosition, packetPosition},
+		{AtcPosition, packetPosition},
+		{AtcSubVisPoint, packetPosition},
+		{VisualPilotPeriodic, packetPosition},
+		{VisualPilotPosUpdate, packetPosition},
+		{VisualPilotStop, packetPosition},
+		{Message, packetText},
+		{ClientQuery, packetOther},
+		{AddPilot, packetOther},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		result := classifyCommand(test.command)
+		if result != test.expected {
+			fail++
+			t.Errorf("classifyCommand(%s) = %s; expected %s", test.command, packetClassNames[result], packetClassNames[test.expected])
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestClassifyCommand: %d pass, %d fail", pass, fail)
+}
+
+func TestHostOf(t *testing.T) {
+	tests := []struct {
+		address  string
+		expected string
+	}{
+		{"203.0.113.7:6809", "203.0.113.7"},
+		{"[2001:db8::1]:6809", "2001:db8::1"},
+		{"203.0.113.7", "203.0.113.7"},
+		{"", ""},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		result := hostOf(test.address)
+		if result != test.expected {
+			fail++
+			t.Errorf("hostOf(%q) = %q; expected %q", test.address, result, test.expected)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestHostOf: %d pass, %d fail", pass, fail)
+}
+
+func TestFloodGuardConnection(t *testing.T) {
+	guard, supervisor := newTestFloodGuard(&config.FsdFloodProtectionConfig{
+		Enabled:              true,
+		MaxConnectionsPerIp:  2,
+		ConnectRateLimit:     3,
+		PacketWindowDuration: time.Minute,
+	})
+
+	tests := []struct {
+		name        string
+		address     string
+		release     bool
+		expectedErr error
+	}{
+		{"first", "203.0.113.7:50001", false, nil},
+		{"second", "203.0.113.7:50002", false, nil},
+		// 连接数超限时不计入建连频率
+		{"third", "203.0.113.7:50003", false, ErrTooManyConnections},
+		{"other address", "203.0.113.8:50001", false, nil},
+		{"release", "203.0.113.7:50001", true, nil},
+		{"reconnect", "203.0.113.7:50004", false, nil},
+		{"release again", "203.0.113.7:50002", true, nil},
+		{"too frequent", "203.0.113.7:50005", false, ErrConnectTooFrequent},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		if test.release {
+			guard.ReleaseConnection(test.address)
+			pass++
+			continue
+		}
+		err := guard.AcquireConnection(test.address)
+		if !errors.Is(err, test.expectedErr) {
+			fail++
+			t.Errorf("AcquireConnection(%s) = %v; expected %v", test.name, err, test.expectedErr)
+			continue
+		}
+		pass++
+	}
+
+	// 同一IP每分钟只提醒监管一次
+	if len(supervisor.lines) != 1 {
+		fail++
+		t.Errorf("supervisor received %d notifications; expected 1", len(supervisor.lines))
+	}
+
+	guard.ReleaseConnection("203.0.113.7:50004")
+	guard.ReleaseConnection("203.0.113.8:50001")
+	if len(guard.connections) != 0 {
+		fail++
+		t.Errorf("connections after release = %v; expected empty", guard.connections)
+	}
+	t.Logf("TestFloodGuardConnection: %d pass, %d fail", pass, fail)
+}
+
+func TestFloodGuardCheckPacket(t *testing.T) {
+	guard, supervisor := newTestFloodGuard(&config.FsdFloodProtectionConfig{
+		Enabled:                 true,
+		PacketWindowDuration:    time.Minute,
+		PositionPacketLimit:     3,
+		TextPacketLimit:         2,
+		MuteDuration:            time.Minute,
+		MaxViolations:           3,
+		ViolationWindowDuration: 10 * time.Minute,
+	})
+	client := &fakeClient{callsign: "CES101"}
+	session := &Session{connId: "203.0.113.7:50001", callsign: "CES101", client: client}
+
+	tests := []struct {
+		name               string
+		before             func()
+		command            ClientCommand
+		expectedOk         bool
+		expectedDisconnect bool
+		expectedViolations int
+	}{
+		{"position 1", nil, PilotPosition, true, false, 0},
+		{"position 2", nil, PilotPosition, true, false, 0},
+		{"position 3", nil, AtcPosition, true, false, 0},
+		{"position flood", nil, PilotPosition, false, false, 1},
+		// 同一轮超限只记一次违规
+		{"position still limited", nil, PilotPosition, false, false, 1},
+		{"other unlimited", nil, ClientQuery, true, false, 1},
+		{"text 1", nil, Message, true, false, 1},
+		{"text 2", nil, Message, true, false, 1},
+		{"text flood", nil, Message, false, false, 2},
+		{"text muted", func() { session.flood.limited[packetText] = false }, Message, false, false, 2},
+		{"position new round", func() { session.flood.limited[packetPosition] = false }, PilotPosition, false, true, 3},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		if test.before != nil {
+			test.before()
+		}
+		ok, result := guard.checkPacket(session, test.command)
+		violations := session.flood.violations
+		if ok != test.expectedOk || (result != nil) != test.expectedDisconnect || violations != test.expectedViolations {
+			fail++
+			t.Errorf("checkPacket(%s) = %v, disconnect %v, violations %d; expected %v, disconnect %v, violations %d",
+				test.name, ok, result != nil, violations, test.expectedOk, test.expectedDisconnect, test.expectedViolations)
+			continue
+		}
+		pass++
+	}
+
+	if len(client.lines) != 1 {
+		fail++
+		t.Errorf("client received %d mute notifications; expected 1", len(client.lines))
+	}
+	if len(supervisor.lines) != 3 {
+		fail++
+		t.Errorf("supervisor received %d notifications; expected 3", len(supervisor.lines))
+	}
+
+	// 距上次违规超过统计时间后重新计数
+	other := &Session{connId: "203.0.113.8:50001", callsign: "CES102"}
+	other.flood.violations = 2
+	other.flood.lastViolation = time.Now().Add(-time.Hour)
+	for range 3 {
+		guard.checkPacket(other, PilotPosition)
+	}
+	if ok, result := guard.checkPacket(other, PilotPosition); ok || result != nil || other.flood.violations != 1 {
+		fail++
+		t.Errorf("checkPacket(expired violations) = %v, disconnect %v, violations %d; expected false, disconnect false, violations 1",
+			ok, result != nil, other.flood.violations)
+	}
+	t.Logf("TestFloodGuardCheckPacket: %d pass, %d fail", pass, fail)
+}
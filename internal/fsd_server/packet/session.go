@@ -13,9 +13,13 @@ type Session struct {
 	connId        string
 	callsign      string
 	facilityIdent Facility
+	software      atomic.Pointer[string] // 其他协程(例如监管查询)会读取软件信息
 	user          *operation.User
-	close         atomic.Bool
+	state         atomic.Int32
 	client        ClientInterface
+	record        SessionRecordInterface
+	flood         floodState
+	loginAttempts int
 }
 
 func NewSession(conn net.Conn) *Session {
@@ -25,7 +29,7 @@ func NewSession(conn net.Conn) *Session {
 		callsign: "unknown",
 		client:   nil,
 		user:     nil,
-		close:    atomic.Bool{},
+		state:    atomic.Int32{},
 	}
 }
 
@@ -41,12 +45,48 @@ func (session *Session) ConnId() string { return session.connId }
 
 func (session *Session) Conn() net.Conn { return session.conn }
 
-func (session *Session) SetDisconnected(disconnect bool) { session.close.Store(disconnect) }
+func (session *Session) State() SessionState { return SessionState(session.state.Load()) }
+
+func (session *Session) setState(state SessionState) { session.state.Store(int32(state)) }
+
+func (session *Session) closing() bool { return session.State() == SessionClosing }
+
+// SetDisconnected 标记会话正在断开, 取消标记时根据是否已绑定客户端恢复登录状态
+func (session *Session) SetDisconnected(disconnect bool) {
+	switch {
+	case disconnect:
+		session.setState(SessionClosing)
+	case session.client != nil:
+		session.setState(SessionAuthenticated)
+	default:
+		session.setState(SessionConnected)
+	}
+}
 
 func (session *Session) Client() ClientInterface { return session.client }
 
-func (session *Session) SetClient(client ClientInterface) { session.client = client }
+func (session *Session) SetClient(client ClientInterface) {
+	session.client = client
+	if session.record != nil && client != nil && session.user != nil {
+		session.record.Identify(client.Callsign(), session.user.Cid)
+	}
+}
 
 func (session *Session) FacilityIdent() Facility { return session.facilityIdent }
 
 func (session *Session) SetFacilityIdent(facility Facility) { session.facilityIdent = facility }
+
+func (session *Session) ClientSoftware() string {
+	if software := session.software.Load(); software != nil {
+		return *software
+	}
+	return ""
+}
+
+func (session *Session) SetClientSoftware(software string) { session.software.Store(&software) }
+
+func (session *Session) Record(direction RecordDirection, line []byte) {
+	if session.record != nil {
+		session.record.Record(direction, line)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
 	"github.com/half-nothing/simple-fsd/internal/interfaces/global"
 	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	"github.com/half-nothing/simple-fsd/internal/metrics"
 )
 
 type SessionContent struct {
@@ -18,6 +19,10 @@ type SessionContent struct {
 	clientManager    ClientManagerInterface
 	heartbeatTimeout time.Duration
 	possibleCommands [][]byte
+	recorder         SessionRecorderInterface
+	floodGuard       *FloodGuard
+	loginTimeout     time.Duration
+	maxLoginAttempts int
 }
 
 func NewSessionContent(
@@ -25,12 +30,20 @@ func NewSessionContent(
 	commandHandler CommandHandlerInterface,
 	clientManager ClientManagerInterface,
 	heartbeatTimeout time.Duration,
+	recorder SessionRecorderInterface,
+	floodGuard *FloodGuard,
+	loginTimeout time.Duration,
+	maxLoginAttempts int,
 ) *SessionContent {
 	content := &SessionContent{
 		logger:           log.NewLoggerAdapter(logger, "SessionManager"),
 		commandHandler:   commandHandler,
 		clientManager:    clientManager,
 		heartbeatTimeout: heartbeatTimeout,
+		recorder:         recorder,
+		floodGuard:       floodGuard,
+		loginTimeout:     loginTimeout,
+		maxLoginAttempts: maxLoginAttempts,
 	}
 	content.possibleCommands = commandHandler.GetPossibleCommands()
 	return content
@@ -53,12 +66,14 @@ func (content *SessionContent) SendError(session *Session, result *Result) {
 	}
 
 	packet := MakePacket(Error, global.FSDServerName, session.callsign, fmt.Sprintf("%03d", result.Errno.Index()), result.Env, errString)
+	metrics.CountFsdOutbound(packet)
 	content.logger.DebugF("[%s](%s) <- %s", session.connId, session.callsign, packet[:len(packet)-SplitSignLen])
+	session.Record(RecordOutbound, packet)
 	if session.conn != nil {
 		_, _ = session.conn.Write(packet)
 	}
 	if result.Fatal {
-		session.close.Store(true)
+		session.SetDisconnected(true)
 	}
 }
 
@@ -73,27 +88,88 @@ func (content *SessionContent) handleCommand(session *Session, commandType Clien
 	return res
 }
 
+// isLoginCommand 登录命令, 登录前只处理登录命令和$ID
+func isLoginCommand(command ClientCommand) bool {
+	return command == AddAtc || command == AddPilot
+}
+
+// isRetryableLoginError 允许在同一连接上重新登录的错误, 只有密码错误和呼号无效可以重试
+func isRetryableLoginError(result *Result) bool {
+	return result.Errno == InvalidCidPassword || result.Errno == CallsignInvalid
+}
+
+// handleLogin 处理登录命令, 可重试的错误在失败次数未达到上限时允许在同一连接上重新登录, 其他错误直接断开
+func (content *SessionContent) handleLogin(session *Session, command ClientCommand, data []string, rawLine []byte) *Result {
+	session.setState(SessionAuthenticating)
+	result := content.handleCommand(session, command, data, rawLine)
+	if result.Success && session.client != nil {
+		session.setState(SessionAuthenticated)
+		return result
+	}
+
+	session.setState(SessionConnected)
+	// 已经恢复了断开的客户端时不允许重试, 沿用原本的处理结果
+	if result.Success || session.client != nil {
+		return result
+	}
+
+	session.loginAttempts++
+	retry := *result
+	if isRetryableLoginError(result) && session.loginAttempts < content.maxLoginAttempts {
+		retry.Fatal = false
+		content.logger.InfoF("[%s](%s) login failed, attempt %d/%d", session.connId, session.callsign, session.loginAttempts, content.maxLoginAttempts)
+	} else {
+		retry.Fatal = true
+		content.logger.WarnF("[%s](%s) login failed %d times, disconnecting", session.connId, session.callsign, session.loginAttempts)
+	}
+	return &retry
+}
+
 func (content *SessionContent) handleLine(session *Session, line []byte) {
-	if session.close.Load() {
+	state := session.State()
+	if state == SessionClosing {
 		return
 	}
 	command, data := parserCommandLine(line, content.possibleCommands)
+	metrics.FsdPackets.Inc("in", string(command))
+	if content.floodGuard != nil {
+		if ok, result := content.floodGuard.checkPacket(session, command); !ok {
+			if result != nil {
+				content.SendError(session, result)
+			}
+			return
+		}
+	}
 	if command == Unknown {
 		content.logger.WarnF("[%s](%s) unknown command line %s", session.connId, session.callsign, line)
 		return
 	}
-	result := content.handleCommand(session, command, data, line)
-	if result == nil {
-		content.logger.WarnF("[%s](%s) command handler return a nil result, %s", session.connId, session.callsign, line)
-		return
+
+	var result *Result
+	switch {
+	case isLoginCommand(command) && state == SessionAuthenticated:
+		result = ResultError(AlreadyRegistered, false, session.callsign, errors.New("session already logged in"))
+	case isLoginCommand(command):
+		result = content.handleLogin(session, command, data, line)
+	case state != SessionAuthenticated && command != ClientIdent:
+		result = ResultError(Custom, false, string(command), errors.New("please login first"))
+	default:
+		result = content.handleCommand(session, command, data, line)
 	}
+
 	if !result.Success {
+		metrics.FsdRejectedCommands.Inc(string(command), result.Errno.String())
 		content.logger.ErrorF("[%s](%s) command handle fail, %s, %s, %s", session.connId, session.callsign, result.Errno.String(), result.Err.Error(), line)
 		content.SendError(session, result)
 	}
 }
 
 func (content *SessionContent) HandleConnection(session *Session) {
+	if content.recorder != nil {
+		session.record = content.recorder.NewRecord(session.connId)
+		defer session.record.Close()
+	}
+
 	defer func() {
 		time.AfterFunc(global.FSDDisconnectDelay, func() {
 			content.logger.DebugF("[%s](%s) x Connection closed", session.connId, session.callsign)
@@ -104,34 +180,46 @@ func (content *SessionContent) HandleConnection(session *Session) {
 	}()
 
 	if *global.Vatsim {
-		_, _ = session.conn.Write([]byte("$DISERVER:CLIENT:VATSIM FSD V3.53a:0815b2e12302\r\n"))
+		serverIdent := []byte("$DISERVER:CLIENT:VATSIM FSD V3.53a:0815b2e12302\r\n")
+		session.Record(RecordOutbound, serverIdent)
+		_, _ = session.conn.Write(serverIdent)
 	}
 	scanner := bufio.NewScanner(session.conn)
 	scanner.Split(createSplitFunc(SplitSign))
-	_ = session.conn.SetDeadline(time.Now().Add(content.heartbeatTimeout))
+	// 登录前使用固定的登录期限, 期限内未完成登录的连接会被断开
+	_ = session.conn.SetReadDeadline(time.Now().Add(content.loginTimeout))
 	for scanner.Scan() {
-		_ = session.conn.SetDeadline(time.Now().Add(content.heartbeatTimeout))
 		if scanner.Err() != nil {
 			content.logger.ErrorF("[%s](%s) Error while scanning, %v", session.connId, session.callsign, scanner.Err())
 			break
 		}
 		line := scanner.Bytes()
 		content.logger.DebugF("[%s](%s) -> %s", session.connId, session.callsign, line)
+		session.Record(RecordInbound, line)
 		if session.client == nil || !*global.MutilThread {
 			content.handleLine(session, line)
 		} else {
 			go content.handleLine(session, line)
 		}
-		if session.close.Load() {
+		if session.closing() {
 			break
 		}
+		if session.State() == SessionAuthenticated {
+			_ = session.conn.SetReadDeadline(time.Now().Add(content.heartbeatTimeout))
+		}
+	}
+
+	if session.State() == SessionConnected && isTimeoutError(scanner.Err()) {
+		content.logger.InfoF("[%s](%s) login timeout after %s", session.connId, session.callsign, content.loginTimeout)
+		content.SendError(session, ResultError(Custom, true, session.callsign, errors.New("login timeout")))
 	}
+	session.SetDisconnected(true)
 
 	if session.client != nil {
 		if session.client.IsAtc() {
-			go content.clientManager.BroadcastMessage(MakePacketWithoutSign(RemoveAtc, session.client.Callsign(), fmt.Sprintf("%04d", session.user.Cid)), session.client, BroadcastToClientInRange)
+			go content.clientManager.BroadcastMessageInRange(MakePacketWithoutSign(RemoveAtc, session.client.Callsign(), fmt.Sprintf("%04d", session.user.Cid)), session.client, nil)
 		} else {
-			go content.clientManager.BroadcastMessage(MakePacketWithoutSign(RemovePilot, session.client.Callsign(), fmt.Sprintf("%04d", session.user.Cid)), session.client, BroadcastToClientInRange)
+			go content.clientManager.BroadcastMessageInRange(MakePacketWithoutSign(RemovePilot, session.client.Callsign(), fmt.Sprintf("%04d", session.user.Cid)), session.client, nil)
 		}
 		session.client.MarkedDisconnect(false)
 	}
@@ -0,0 +1,132 @@
+package packet
+
+import (
+	"testing"
+
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+)
+
+// fakeCommandHandler 按顺序返回预设的登录结果, 登录成功时为会话绑定客户端
+type fakeCommandHandler struct {
+	CommandHandlerInterface
+	loginResults []*Result
+	calls        []ClientCommand
+}
+
+func (handler *fakeCommandHandler) GetPossibleCommands() [][]byte {
+	return [][]byte{[]byte(AddPilot), []byte(AddAtc), []byte(ClientIdent), []byte(PilotPosition)}
+}
+
+func (handler *fakeCommandHandler) Call(command ClientCommand, session SessionInterface, _ []string, _ []byte) *Result {
+	handler.calls = append(handler.calls, command)
+	if !isLoginCommand(command) {
+		return ResultSuccess()
+	}
+	result := handler.loginResults[0]
+	handler.loginResults = handler.loginResults[1:]
+	if result.Success {
+		session.SetClient(&fakeClient{callsign: "CES101"})
+	}
+	return result
+}
+
+type loginStep struct {
+	line          string
+	expectedCall  bool
+	expectedState SessionState
+}
+
+func TestSessionLoginGate(t *testing.T) {
+	tests := []struct {
+		name             string
+		maxLoginAttempts int
+		loginResults     []*Result
+		steps            []loginStep
+	}{
+		{"login required", 3, []*Result{ResultSuccess()}, []loginStep{
+			{"@N:CES101:2000:1:31.0:121.0:3000:250:0:0", false, SessionConnected},
+			{"$IDCES101:SERVER:de1e:Simple:1:0:1000:0", true, SessionConnected},
+			{"#APCES101:SERVER:1000:pw:1:9:1:Pilot", true, SessionAuthenticated},
+			{"@N:CES101:2000:1:31.0:121.0:3000:250:0:0", true, SessionAuthenticated},
+			{"#APCES101:SERVER:1000:pw:1:9:1:Pilot", false, SessionAuthenticated},
+		}},
+		{"retry until limit", 3, []*Result{
+			ResultError(InvalidCidPassword, true, "CES101", nil),
+			ResultError(CallsignInvalid, true, "CES101", nil),
+			ResultError(InvalidCidPassword, true, "CES101", nil),
+		}, []loginStep{
+			{"#APCES101:SERVER:1000:bad:1:9:1:Pilot", true, SessionConnected},
+			{"#APCES!01:SERVER:1000:pw:1:9:1:Pilot", true, SessionConnected},
+			{"#APCES101:SERVER:1000:bad:1:9:1:Pilot", true, SessionClosing},
+			{"#APCES101:SERVER:1000:pw:1:9:1:Pilot", false, SessionClosing},
+		}},
+		{"retry then success", 3, []*Result{
+			ResultError(InvalidCidPassword, true, "CES101", nil),
+			ResultSuccess(),
+		}, []loginStep{
+			{"#APCES101:SERVER:1000:bad:1:9:1:Pilot", true, SessionConnected},
+			{"#APCES101:SERVER:1000:pw:1:9:1:Pilot", true, SessionAuthenticated},
+		}},
+		// 只有密码错误和呼号无效可以重试
+		{"not retryable", 3, []*Result{ResultError(CidSuspended, true, "CES101", nil)}, []loginStep{
+			{"#APCES101:SERVER:1000:pw:1:9:1:Pilot", true, SessionClosing},
+		}},
+		{"non fatal not retryable", 3, []*Result{ResultError(RequestLevelTooHigh, false, "CES101", nil)}, []loginStep{
+			{"#APCES101:SERVER:1000:pw:12:9:1:Pilot", true, SessionClosing},
+		}},
+		{"single attempt", 1, []*Result{ResultError(InvalidCidPassword, true, "CES101", nil)}, []loginStep{
+			{"#APCES101:SERVER:1000:bad:1:9:1:Pilot", true, SessionClosing},
+		}},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		handler := &fakeCommandHandler{loginResults: test.loginResults}
+		content := NewSessionContent(nopLogger{}, handler, nil, 0, nil, nil, 0, test.maxLoginAttempts)
+		session := &Session{connId: "203.0.113.7:50001", callsign: "unknown"}
+		matched := true
+		for index, step := range test.steps {
+			calls := len(handler.calls)
+			content.handleLine(session, []byte(step.line))
+			called := len(handler.calls) > calls
+			if state := session.State(); called != step.expectedCall || state != step.expectedState {
+				matched = false
+				t.Errorf("%s step %d = called %v, state %s; expected called %v, state %s",
+					test.name, index, called, state, step.expectedCall, step.expectedState)
+				break
+			}
+		}
+		if !matched {
+			fail++
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestSessionLoginGate: %d pass, %d fail", pass, fail)
+}
+
+func TestSessionStateString(t *testing.T) {
+	tests := []struct {
+		state    SessionState
+		expected string
+	}{
+		{SessionConnected, "connected"},
+		{SessionAuthenticating, "authenticating"},
+		{SessionAuthenticated, "authenticated"},
+		{SessionClosing, "closing"},
+		{SessionState(4), "4"},
+		{SessionState(-1), "-1"},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		result := test.state.String()
+		if result != test.expected {
+			fail++
+			t.Errorf("SessionState(%d).String() = %q; expected %q", int(test.state), result, test.expected)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestSessionStateString: %d pass, %d fail", pass, fail)
+}
@@ -30,6 +30,11 @@ func isNetClosedError(err error) bool {
 	return ok && opErr.Timeout()
 }
 
+func isTimeoutError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
 func createSplitFunc(sep []byte) bufio.SplitFunc {
 	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
 		if atEOF && len(data) == 0 {
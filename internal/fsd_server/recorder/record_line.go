@@ -0,0 +1,75 @@
+// Package recorder
+package recorder
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+)
+
+// RedactedField 录制文件中用于替换密码或令牌的占位符
+const RedactedField = "***"
+
+var ErrInvalidRecordLine = errors.New("invalid record line")
+
+// RecordLine 录制文件中的一行
+// 格式为 "<RFC3339Nano时间> <方向> <原始数据>"
+type RecordLine struct {
+	Time      time.Time
+	Direction RecordDirection
+	Data      []byte
+}
+
+func FormatRecordLine(t time.Time, direction RecordDirection, line []byte) []byte {
+	line = bytes.TrimSuffix(line, SplitSign)
+	buffer := make([]byte, 0, len(time.RFC3339Nano)+len(line)+4)
+	buffer = t.UTC().AppendFormat(buffer, time.RFC3339Nano)
+	buffer = append(buffer, ' ', byte(direction), ' ')
+	buffer = append(buffer, line...)
+	return append(buffer, '\n')
+}
+
+func ParseRecordLine(line []byte) (*RecordLine, error) {
+	timestamp, rest, ok := bytes.Cut(line, []byte{' '})
+	if !ok || len(rest) < 2 || rest[1] != ' ' {
+		return nil, ErrInvalidRecordLine
+	}
+	direction := RecordDirection(rest[0])
+	if direction != RecordInbound && direction != RecordOutbound {
+		return nil, ErrInvalidRecordLine
+	}
+	t, err := time.Parse(time.RFC3339Nano, string(timestamp))
+	if err != nil {
+		return nil, ErrInvalidRecordLine
+	}
+	return &RecordLine{Time: t, Direction: direction, Data: bytes.TrimRight(rest[2:], "\r\n")}, nil
+}
+
+// passwordFieldIndex 登录数据包中密码或令牌所在的字段下标
+var passwordFieldIndex = map[ClientCommand]int{
+	AddAtc:   4,
+	AddPilot: 3,
+}
+
+// RedactLine 将登录数据包中的密码替换为占位符, 其余数据包原样返回
+func RedactLine(line []byte) []byte {
+	return ReplacePassword(line, RedactedField)
+}
+
+// ReplacePassword 替换登录数据包中的密码字段
+func ReplacePassword(line []byte, password string) []byte {
+	for command, index := range passwordFieldIndex {
+		if !bytes.HasPrefix(line, []byte(command)) {
+			continue
+		}
+		fields := bytes.Split(line, []byte{':'})
+		if len(fields) <= index {
+			return line
+		}
+		fields[index] = []byte(password)
+		return bytes.Join(fields, []byte{':'})
+	}
+	return line
+}
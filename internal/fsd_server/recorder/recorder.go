@@ -0,0 +1,168 @@
+// Package recorder
+package recorder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/config"
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/global"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// maxPendingLines 客户端登录前最多缓存的数据行数
+const maxPendingLines = 256
+
+// SessionRecorder 会话录制器, 将每个会话的收发数据按时间写入单独的文件
+type SessionRecorder struct {
+	logger    log.LoggerInterface
+	config    *config.FsdRecorderConfig
+	callsigns []string
+}
+
+func NewSessionRecorder(logger log.LoggerInterface, config *config.FsdRecorderConfig) (*SessionRecorder, error) {
+	if err := os.MkdirAll(config.Path, global.DefaultDirectoryPermission); err != nil {
+		return nil, err
+	}
+	callsigns := make([]string, 0, len(config.Callsigns))
+	for _, callsign := range config.Callsigns {
+		callsigns = append(callsigns, strings.ToUpper(callsign))
+	}
+	return &SessionRecorder{
+		logger:    log.NewLoggerAdapter(logger, "SessionRecorder"),
+		config:    config,
+		callsigns: callsigns,
+	}, nil
+}
+
+func (recorder *SessionRecorder) hasFilter() bool {
+	return len(recorder.callsigns) > 0 || len(recorder.config.Cids) > 0
+}
+
+func (recorder *SessionRecorder) match(callsign string, cid int) bool {
+	if !recorder.hasFilter() {
+		return true
+	}
+	return slices.Contains(recorder.callsigns, strings.ToUpper(callsign)) || slices.Contains(recorder.config.Cids, cid)
+}
+
+func (recorder *SessionRecorder) NewRecord(connId string) SessionRecordInterface {
+	return &sessionRecord{
+		recorder:  recorder,
+		connId:    connId,
+		startTime: time.Now(),
+		pending:   make([][]byte, 0, 16),
+	}
+}
+
+// sessionRecord 单个会话的录制
+// 客户端登录前的数据先缓存在内存中, 登录后根据过滤条件决定写入文件或丢弃
+type sessionRecord struct {
+	lock      sync.Mutex
+	recorder  *SessionRecorder
+	connId    string
+	startTime time.Time
+	pending   [][]byte
+	writer    *lumberjack.Logger
+	discarded bool
+	closed    bool
+}
+
+func (record *sessionRecord) fileName(callsign string, cid int) string {
+	replacer := strings.NewReplacer(":", "-", "[", "", "]", "", "/", "-", "\\", "-")
+	name := fmt.Sprintf("%s_%s_%d_%s.rec", record.startTime.Format("20060102-150405"), callsign, cid, replacer.Replace(record.connId))
+	return filepath.Join(record.recorder.config.Path, name)
+}
+
+// open 打开录制文件并写入缓存的数据, 调用方需持有lock
+func (record *sessionRecord) open(callsign string, cid int) {
+	record.writer = &lumberjack.Logger{
+		Filename:   record.fileName(callsign, cid),
+		MaxSize:    record.recorder.config.MaxFileSize,
+		MaxBackups: record.recorder.config.MaxBackups,
+		LocalTime:  true,
+	}
+	for _, line := range record.pending {
+		record.write(line)
+	}
+	record.pending = nil
+	record.recorder.logger.InfoF("Recording session %s(%d)[%s] to %s", callsign, cid, record.connId, record.writer.Filename)
+}
+
+// write 写入一行数据, 调用方需持有lock
+func (record *sessionRecord) write(line []byte) {
+	if _, err := record.writer.Write(line); err != nil {
+		record.recorder.logger.ErrorF("Fail to write session record %s: %v", record.writer.Filename, err)
+	}
+}
+
+func (record *sessionRecord) Identify(callsign string, cid int) {
+	record.lock.Lock()
+	defer record.lock.Unlock()
+
+	if record.closed || record.discarded || record.writer != nil {
+		return
+	}
+
+	if !record.recorder.match(callsign, cid) {
+		record.discarded = true
+		record.pending = nil
+		return
+	}
+
+	record.open(callsign, cid)
+}
+
+func (record *sessionRecord) Record(direction RecordDirection, line []byte) {
+	data := line
+	if direction == RecordInbound {
+		data = RedactLine(line)
+	}
+	data = FormatRecordLine(time.Now(), direction, data)
+
+	record.lock.Lock()
+	defer record.lock.Unlock()
+
+	if record.closed || record.discarded {
+		return
+	}
+
+	if record.writer != nil {
+		record.write(data)
+		return
+	}
+
+	if len(record.pending) >= maxPendingLines {
+		record.pending = record.pending[1:]
+	}
+	record.pending = append(record.pending, data)
+}
+
+func (record *sessionRecord) Close() {
+	record.lock.Lock()
+	defer record.lock.Unlock()
+
+	if record.closed {
+		return
+	}
+	record.closed = true
+
+	// 未登录的会话只有在没有设置过滤条件时才保存
+	if record.writer == nil && !record.discarded && !record.recorder.hasFilter() && len(record.pending) > 0 {
+		record.open("UNKNOWN", 0)
+	}
+
+	if record.writer != nil {
+		if err := record.writer.Close(); err != nil {
+			record.recorder.logger.ErrorF("Fail to close session record %s: %v", record.writer.Filename, err)
+		}
+	}
+	record.pending = nil
+}
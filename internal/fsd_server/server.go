@@ -2,14 +2,22 @@ package fsd_server
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"net"
 	"time"
 
+	"github.com/half-nothing/simple-fsd/internal/fsd_server/atis"
 	"github.com/half-nothing/simple-fsd/internal/fsd_server/command"
 	"github.com/half-nothing/simple-fsd/internal/fsd_server/packet"
+	"github.com/half-nothing/simple-fsd/internal/fsd_server/recorder"
 	. "github.com/half-nothing/simple-fsd/internal/interfaces"
+	c "github.com/half-nothing/simple-fsd/internal/interfaces/config"
 	"github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
 	"github.com/half-nothing/simple-fsd/internal/interfaces/global"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+	"github.com/half-nothing/simple-fsd/internal/utils"
 )
 
 type ShutdownCallback struct {
@@ -71,9 +79,9 @@ func StartFSDServer(applicationContent *ApplicationContent) {
 	commandHandler := command.NewCommandHandler()
 
 	if *global.VisualPilot {
-		commandHandler.Register(fsd.VisualPilotPeriodic, commandContent.HandleClientIdent, nil)
-		commandHandler.Register(fsd.VisualPilotPosUpdate, commandContent.HandleClientIdent, nil)
-		commandHandler.Register(fsd.VisualPilotStop, commandContent.HandleClientIdent, nil)
+		commandHandler.Register(fsd.VisualPilotPeriodic, commandContent.HandleVisualPilotPosUpdate, &fsd.CommandRequirement{RequireLength: 12, Fatal: false})
+		commandHandler.Register(fsd.VisualPilotPosUpdate, commandContent.HandleVisualPilotPosUpdate, &fsd.CommandRequirement{RequireLength: 12, Fatal: false})
+		commandHandler.Register(fsd.VisualPilotStop, commandContent.HandleVisualPilotPosUpdate, &fsd.CommandRequirement{RequireLength: 6, Fatal: false})
 	}
 	commandHandler.Register(fsd.PilotPosition, commandContent.HandlePilotPosUpdate, &fsd.CommandRequirement{RequireLength: 10, Fatal: false})
 	commandHandler.Register(fsd.AtcPosition, commandContent.HandleAtcPosUpdate, &fsd.CommandRequirement{RequireLength: 8, Fatal: false})
@@ -84,8 +92,8 @@ func StartFSDServer(applicationContent *ApplicationContent) {
 	commandHandler.Register(fsd.WeatherQuery, commandContent.HandleWeatherQuery, &fsd.CommandRequirement{RequireLength: 4, Fatal: false})
 	commandHandler.Register(fsd.Plan, commandContent.HandlePlan, &fsd.CommandRequirement{RequireLength: 17, Fatal: false})
 	commandHandler.Register(fsd.AtcEditPlan, commandContent.HandleAtcEditPlan, &fsd.CommandRequirement{RequireLength: 18, Fatal: false})
-	commandHandler.Register(fsd.RequestHandoff, commandContent.HandleRequest, &fsd.CommandRequirement{RequireLength: 3, Fatal: false})
-	commandHandler.Register(fsd.AcceptHandoff, commandContent.HandleRequest, &fsd.CommandRequirement{RequireLength: 3, Fatal: false})
+	commandHandler.Register(fsd.RequestHandoff, commandContent.HandleRequestHandoff, &fsd.CommandRequirement{RequireLength: 3, Fatal: false})
+	commandHandler.Register(fsd.AcceptHandoff, commandContent.HandleAcceptHandoff, &fsd.CommandRequirement{RequireLength: 3, Fatal: false})
 	commandHandler.Register(fsd.ProController, commandContent.HandleRequest, &fsd.CommandRequirement{RequireLength: 3, Fatal: false})
 	commandHandler.Register(fsd.SquawkBox, commandContent.HandleSquawkBox, &fsd.CommandRequirement{RequireLength: 2, Fatal: false})
 	if *global.Vatsim {
@@ -107,17 +115,99 @@ func StartFSDServer(applicationContent *ApplicationContent) {
 
 	commandHandler.GeneratePossibleCommands()
 
-	sessionContent := packet.NewSessionContent(logger, commandHandler, applicationContent.ClientManager(), config.Server.FSDServer.HeartbeatDuration)
+	var sessionRecorder fsd.SessionRecorderInterface
+	if config.Server.FSDServer.Recorder.Enabled {
+		if r, err := recorder.NewSessionRecorder(logger, config.Server.FSDServer.Recorder); err != nil {
+			logger.ErrorF("Fail to create session recorder, session recording disabled, %v", err)
+		} else {
+			sessionRecorder = r
+			logger.InfoF("Session recorder enabled, records will be saved to %s", config.Server.FSDServer.Recorder.Path)
+		}
+	}
+
+	if config.Server.FSDServer.Atis.Enabled {
+		atis.NewAtisService(applicationContent).Start()
+	}
+
+	var floodGuard *packet.FloodGuard
+	if config.Server.FSDServer.FloodProtection.Enabled {
+		floodGuard = packet.NewFloodGuard(logger, config.Server.FSDServer.FloodProtection, applicationContent.ClientManager())
+	} else {
+		logger.Warn("Flood protection disabled, be aware of possible connection and packet floods")
+	}
+
+	sessionContent := packet.NewSessionContent(logger, commandHandler, applicationContent.ClientManager(), config.Server.FSDServer.HeartbeatDuration, sessionRecorder, floodGuard,
+		config.Server.FSDServer.LoginTimeoutDuration, config.Server.FSDServer.MaxLoginAttempts)
+
+	if config.Server.FSDServer.SSL.Enable {
+		go startTLSListener(logger, config.Server.FSDServer, sessionContent, applicationContent.BanManager(), floodGuard, sem)
+	}
+
+	serveListener(logger, ln, sessionContent, applicationContent.BanManager(), floodGuard, sem)
+}
+
+// startTLSListener 启动TLS监听, 与明文端口共用同一个会话处理与工作线程池
+func startTLSListener(logger log.LoggerInterface, config *c.FSDServerConfig, sessionContent *packet.SessionContent, banManager BanManagerInterface, floodGuard *packet.FloodGuard, sem chan struct{}) {
+	reloader, err := utils.NewCertificateReloader(config.SSL.CertFile, config.SSL.KeyFile, global.FSDCertificateCheckInterval, func(err error) {
+		if err != nil {
+			logger.ErrorF("Fail to reload TLS certificate, keep using the old one, %v", err)
+			return
+		}
+		logger.Info("TLS certificate reloaded")
+	})
+	if err != nil {
+		logger.ErrorF("TLS Server Start error: %v", err)
+		return
+	}
+
+	ln, err := tls.Listen("tcp", config.SSLAddress, &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	})
+	if err != nil {
+		logger.ErrorF("TLS Server Start error: %v", err)
+		return
+	}
+	logger.InfoF("TLS Server Listen On %s", ln.Addr().String())
+
+	defer func() {
+		err := ln.Close()
+		if err != nil {
+			logger.ErrorF("TLS Server close error: %v", err)
+		}
+	}()
 
+	serveListener(logger, ln, sessionContent, banManager, floodGuard, sem)
+}
+
+func serveListener(logger log.LoggerInterface, ln net.Listener, sessionContent *packet.SessionContent, banManager BanManagerInterface, floodGuard *packet.FloodGuard, sem chan struct{}) {
 	for {
 		conn, err := ln.Accept()
 		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
 			logger.ErrorF("Accept connection error: %v", err)
 			continue
 		}
 
 		logger.DebugF("Accepted new connection from %s", conn.RemoteAddr().String())
 
+		// 被封禁的地址在握手前直接断开
+		if ban := banManager.Check(0, conn.RemoteAddr().String(), operation.BanScopeFsd); ban != nil {
+			logger.InfoF("Refused connection from %s, matched ban #%d", conn.RemoteAddr().String(), ban.ID)
+			_ = conn.Close()
+			continue
+		}
+
+		if floodGuard != nil {
+			if err := floodGuard.AcquireConnection(conn.RemoteAddr().String()); err != nil {
+				logger.InfoF("Refused connection from %s, %v", conn.RemoteAddr().String(), err)
+				_ = conn.Close()
+				continue
+			}
+		}
+
 		sem <- struct{}{}
 		go func(c net.Conn) {
 			defer func() {
@@ -125,7 +215,10 @@ func StartFSDServer(applicationContent *ApplicationContent) {
 					logger.ErrorF("Recovered from panic: %v", r)
 				}
 			}()
-			session := packet.NewSession(conn)
+			if floodGuard != nil {
+				defer floodGuard.ReleaseConnection(c.RemoteAddr().String())
+			}
+			session := packet.NewSession(c)
 			sessionContent.HandleConnection(session)
 			<-sem
 		}(conn)
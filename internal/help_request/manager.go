@@ -0,0 +1,222 @@
+// Package help_request
+package help_request
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/config"
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/global"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/queue"
+	"github.com/half-nothing/simple-fsd/internal/utils"
+)
+
+// escalateCheckInterval 检查超时求助的间隔
+const escalateCheckInterval = 30 * time.Second
+
+// HelpRequestManager 持久化机组的求助, 通知在线监管并在超时无人认领时升级
+type HelpRequestManager struct {
+	logger        log.LoggerInterface
+	config        *config.FsdHelpRequestConfig
+	clientManager ClientManagerInterface
+	messageQueue  queue.MessageQueueInterface
+	operation     operation.HelpRequestOperationInterface
+	submitLock    sync.Mutex
+	stopOnce      sync.Once
+	stop          chan struct{}
+	wg            sync.WaitGroup
+}
+
+func NewHelpRequestManager(
+	logger log.LoggerInterface,
+	config *config.FsdHelpRequestConfig,
+	clientManager ClientManagerInterface,
+	messageQueue queue.MessageQueueInterface,
+	helpRequestOperation operation.HelpRequestOperationInterface,
+) *HelpRequestManager {
+	return &HelpRequestManager{
+		logger:        log.NewLoggerAdapter(logger, "HelpRequestManager"),
+		config:        config,
+		clientManager: clientManager,
+		messageQueue:  messageQueue,
+		operation:     helpRequestOperation,
+		stop:          make(chan struct{}),
+	}
+}
+
+func (manager *HelpRequestManager) Start() {
+	manager.wg.Add(1)
+	go manager.run()
+}
+
+func (manager *HelpRequestManager) Stop() {
+	manager.stopOnce.Do(func() {
+		close(manager.stop)
+		manager.wg.Wait()
+	})
+}
+
+func (manager *HelpRequestManager) run() {
+	defer manager.wg.Done()
+
+	ticker := time.NewTicker(escalateCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-manager.stop:
+			return
+		case <-ticker.C:
+			manager.escalate()
+		}
+	}
+}
+
+// Submit 保存求助并通知在线监管, 同一用户同时只能有一个未解决的求助
+// 已有未解决的求助时提醒求助者并返回 operation.ErrHelpRequestOpen
+func (manager *HelpRequestManager) Submit(client ClientInterface, message string) (*operation.HelpRequest, error) {
+	cid := client.User().Cid
+
+	// 检查与保存需要在同一把锁内完成, 避免同一用户并发提交
+	manager.submitLock.Lock()
+	existing, err := manager.operation.GetOpenHelpRequestByCid(cid)
+	if err == nil {
+		manager.submitLock.Unlock()
+		manager.sendMessage(client, fmt.Sprintf("You already have an open help request #%d, please wait for a supervisor", existing.ID))
+		return existing, operation.ErrHelpRequestOpen
+	}
+	if !errors.Is(err, operation.ErrHelpRequestNotFound) {
+		manager.submitLock.Unlock()
+		return nil, err
+	}
+	position := client.Position()[0]
+	request := manager.operation.NewHelpRequest(cid, client.Callsign(), position.Latitude, position.Longitude, message)
+	err = manager.operation.SaveHelpRequest(request)
+	manager.submitLock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	count := manager.notifySupervisors(fmt.Sprintf("[HELP #%d] %s: %s (.claim %d)", request.ID, request.Callsign, message, request.ID))
+	manager.logger.InfoF("Help request #%d created by %s(%04d), %d supervisor(s) notified", request.ID, request.Callsign, request.Cid, count)
+
+	if count == 0 {
+		manager.sendMessage(client, fmt.Sprintf("Help request #%d queued, no supervisor online now, please wait", request.ID))
+	} else {
+		manager.sendMessage(client, fmt.Sprintf("Help request #%d sent to %d supervisor(s)", request.ID, count))
+	}
+	return request, nil
+}
+
+func (manager *HelpRequestManager) Claim(id uint, cid int, callsign string) (*operation.HelpRequest, error) {
+	request, err := manager.operation.GetHelpRequest(id)
+	if err != nil {
+		return nil, err
+	}
+	handler := handlerName(cid, callsign)
+	if err := manager.operation.ClaimHelpRequest(request, cid, handler); err != nil {
+		return nil, err
+	}
+
+	manager.logger.InfoF("Help request #%d claimed by %s(%04d)", request.ID, handler, cid)
+	manager.notifySupervisors(fmt.Sprintf("[HELP #%d] %s claimed by %s", request.ID, request.Callsign, handler))
+	manager.notifyRequester(request, fmt.Sprintf("Your help request #%d has been claimed by %s", request.ID, handler))
+	return request, nil
+}
+
+func (manager *HelpRequestManager) Resolve(id uint, cid int, callsign string, resolution string) (*operation.HelpRequest, error) {
+	request, err := manager.operation.GetHelpRequest(id)
+	if err != nil {
+		return nil, err
+	}
+	handler := handlerName(cid, callsign)
+	if err := manager.operation.ResolveHelpRequest(request, cid, handler, resolution); err != nil {
+		return nil, err
+	}
+
+	manager.logger.InfoF("Help request #%d resolved by %s(%04d)", request.ID, handler, cid)
+	manager.notifySupervisors(fmt.Sprintf("[HELP #%d] %s resolved by %s", request.ID, request.Callsign, handler))
+	if resolution == "" {
+		manager.notifyRequester(request, fmt.Sprintf("Your help request #%d has been resolved by %s", request.ID, handler))
+	} else {
+		manager.notifyRequester(request, fmt.Sprintf("Your help request #%d has been resolved by %s: %s", request.ID, handler, resolution))
+	}
+	return request, nil
+}
+
+func (manager *HelpRequestManager) OpenRequests() ([]*operation.HelpRequest, error) {
+	return manager.operation.GetOpenHelpRequests()
+}
+
+// escalate 将超时未被认领的求助标记为已升级, 发送通知邮件并再次提醒在线监管
+func (manager *HelpRequestManager) escalate() {
+	timeout := manager.config.EscalateTimeoutDuration
+	requests, err := manager.operation.GetHelpRequestsToEscalate(time.Now().Add(-timeout))
+	if err != nil {
+		manager.logger.ErrorF("Fail to query help requests to escalate: %v", err)
+		return
+	}
+	for _, request := range requests {
+		if err := manager.operation.EscalateHelpRequest(request); err != nil {
+			continue
+		}
+		manager.logger.WarnF("Help request #%d from %s unanswered for %s, escalated", request.ID, request.Callsign, timeout)
+		manager.notifySupervisors(fmt.Sprintf("[HELP #%d] %s still waiting for %s: %s (.claim %d)",
+			request.ID, request.Callsign, timeout, request.Message, request.ID))
+		if len(manager.config.EscalateEmails) == 0 {
+			continue
+		}
+		manager.messageQueue.Publish(&queue.Message{
+			Type: queue.SendHelpRequestEscalatedEmail,
+			Data: &interfaces.HelpRequestEscalatedEmailData{
+				Emails:  manager.config.EscalateEmails,
+				Request: request,
+				Timeout: timeout,
+			},
+		})
+	}
+}
+
+// notifySupervisors 私聊所有在线监管, 返回通知到的监管数量
+func (manager *HelpRequestManager) notifySupervisors(message string) int {
+	clients := manager.clientManager.GetClientSnapshot()
+	defer manager.clientManager.ReleaseClientSnapshot(clients)
+	count := 0
+	for _, client := range clients {
+		if client == nil || client.Disconnected() || !BroadcastToSupClient(client) {
+			continue
+		}
+		manager.sendMessage(client, message)
+		count++
+	}
+	return count
+}
+
+// notifyRequester 求助者仍在线时私聊通知处理进度, 呼号被他人占用时不通知
+func (manager *HelpRequestManager) notifyRequester(request *operation.HelpRequest, message string) {
+	if !manager.config.NotifyRequester {
+		return
+	}
+	client, ok := manager.clientManager.GetClient(request.Callsign)
+	if !ok || client.Disconnected() || client.User() == nil || client.User().Cid != request.Cid {
+		return
+	}
+	manager.sendMessage(client, message)
+}
+
+func (manager *HelpRequestManager) sendMessage(client ClientInterface, message string) {
+	client.SendLine(MakePacket(Message, global.FSDServerName, client.Callsign(), message))
+}
+
+func handlerName(cid int, callsign string) string {
+	if callsign == "" {
+		return utils.FormatCid(cid)
+	}
+	return callsign
+}
@@ -0,0 +1,35 @@
+// Package help_request
+package help_request
+
+import (
+	"context"
+	"time"
+)
+
+type ShutdownCallback struct {
+	manager *HelpRequestManager
+}
+
+func NewShutdownCallback(manager *HelpRequestManager) *ShutdownCallback {
+	return &ShutdownCallback{
+		manager: manager,
+	}
+}
+
+func (callback *ShutdownCallback) Invoke(ctx context.Context) error {
+	timeoutCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		callback.manager.Stop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-timeoutCtx.Done():
+		return timeoutCtx.Err()
+	}
+}
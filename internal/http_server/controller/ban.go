@@ -0,0 +1,82 @@
+// Package controller
+package controller
+
+import (
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/http/service"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	"github.com/labstack/echo/v4"
+)
+
+type BanControllerInterface interface {
+	GetBans(ctx echo.Context) error
+	IssueBan(ctx echo.Context) error
+	LiftBan(ctx echo.Context) error
+	ExtendBan(ctx echo.Context) error
+}
+
+type BanController struct {
+	logger     log.LoggerInterface
+	banService BanServiceInterface
+}
+
+func NewBanController(
+	logger log.LoggerInterface,
+	banService BanServiceInterface,
+) *BanController {
+	return &BanController{
+		logger:     log.NewLoggerAdapter(logger, "BanController"),
+		banService: banService,
+	}
+}
+
+func (controller *BanController) GetBans(ctx echo.Context) error {
+	data := &RequestGetBans{}
+	if err := ctx.Bind(data); err != nil {
+		controller.logger.ErrorF("GetBans bind error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	if err := SetJwtInfo(data, ctx); err != nil {
+		controller.logger.ErrorF("GetBans jwt token parse error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	return controller.banService.GetBans(data).Response(ctx)
+}
+
+func (controller *BanController) IssueBan(ctx echo.Context) error {
+	data := &RequestIssueBan{}
+	if err := ctx.Bind(data); err != nil {
+		controller.logger.ErrorF("IssueBan bind error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	if err := SetJwtInfoAndEchoContent(data, ctx); err != nil {
+		controller.logger.ErrorF("IssueBan jwt token parse error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	return controller.banService.IssueBan(data).Response(ctx)
+}
+
+func (controller *BanController) LiftBan(ctx echo.Context) error {
+	data := &RequestLiftBan{}
+	if err := ctx.Bind(data); err != nil {
+		controller.logger.ErrorF("LiftBan bind error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	if err := SetJwtInfoAndEchoContent(data, ctx); err != nil {
+		controller.logger.ErrorF("LiftBan jwt token parse error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	return controller.banService.LiftBan(data).Response(ctx)
+}
+
+func (controller *BanController) ExtendBan(ctx echo.Context) error {
+	data := &RequestExtendBan{}
+	if err := ctx.Bind(data); err != nil {
+		controller.logger.ErrorF("ExtendBan bind error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	if err := SetJwtInfoAndEchoContent(data, ctx); err != nil {
+		controller.logger.ErrorF("ExtendBan jwt token parse error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	return controller.banService.ExtendBan(data).Response(ctx)
+}
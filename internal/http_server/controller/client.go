@@ -15,6 +15,10 @@ type ClientControllerInterface interface {
 	SendMessageToClient(ctx echo.Context) error
 	KillClient(ctx echo.Context) error
 	BroadcastMessage(ctx echo.Context) error
+	GetFlightDataList(ctx echo.Context) error
+	GetFlightData(ctx echo.Context) error
+	AllocateSquawk(ctx echo.Context) error
+	GetResumeToken(ctx echo.Context) error
 }
 
 type ClientController struct {
@@ -80,3 +84,51 @@ func (controller *ClientController) BroadcastMessage(ctx echo.Context) error {
 	}
 	return controller.clientService.SendBroadcastMessage(data).Response(ctx)
 }
+
+func (controller *ClientController) GetFlightDataList(ctx echo.Context) error {
+	data := &RequestFlightDataList{}
+	if err := SetJwtInfo(data, ctx); err != nil {
+		controller.logger.ErrorF("GetFlightDataList jwt token parse error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	return controller.clientService.GetFlightDataList(data).Response(ctx)
+}
+
+func (controller *ClientController) GetFlightData(ctx echo.Context) error {
+	data := &RequestFlightData{}
+	if err := ctx.Bind(data); err != nil {
+		controller.logger.ErrorF("GetFlightData bind error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	if err := SetJwtInfo(data, ctx); err != nil {
+		controller.logger.ErrorF("GetFlightData jwt token parse error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	return controller.clientService.GetFlightData(data).Response(ctx)
+}
+
+func (controller *ClientController) AllocateSquawk(ctx echo.Context) error {
+	data := &RequestAllocateSquawk{}
+	if err := ctx.Bind(data); err != nil {
+		controller.logger.ErrorF("AllocateSquawk bind error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	if err := SetJwtInfo(data, ctx); err != nil {
+		controller.logger.ErrorF("AllocateSquawk jwt token parse error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	return controller.clientService.AllocateSquawk(data).Response(ctx)
+}
+
+func (controller *ClientController) GetResumeToken(ctx echo.Context) error {
+	data := &RequestGetResumeToken{}
+	if err := ctx.Bind(data); err != nil {
+		controller.logger.ErrorF("GetResumeToken bind error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	if err := SetJwtInfo(data, ctx); err != nil {
+		controller.logger.ErrorF("GetResumeToken jwt token parse error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	return controller.clientService.GetResumeToken(data).Response(ctx)
+}
@@ -0,0 +1,55 @@
+// Package controller
+package controller
+
+import (
+	"net/http"
+
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/http/service"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	"github.com/labstack/echo/v4"
+)
+
+type FeedControllerInterface interface {
+	GetFeedStatus(ctx echo.Context) error
+	GetVatsimData(ctx echo.Context) error
+	GetWhazzup(ctx echo.Context) error
+	GetGeoJSON(ctx echo.Context) error
+}
+
+type FeedController struct {
+	logger  log.LoggerInterface
+	service FeedServiceInterface
+}
+
+func NewFeedController(
+	logger log.LoggerInterface,
+	service FeedServiceInterface,
+) *FeedController {
+	return &FeedController{
+		logger:  log.NewLoggerAdapter(logger, "FeedController"),
+		service: service,
+	}
+}
+
+func feedBlob(ctx echo.Context, res *ApiResponse[ResponseGetFeed], contentType string) error {
+	if res.Data == nil {
+		return res.Response(ctx)
+	}
+	return ctx.Blob(http.StatusOK, contentType, *res.Data)
+}
+
+func (controller *FeedController) GetFeedStatus(ctx echo.Context) error {
+	return feedBlob(ctx, controller.service.GetFeedStatus(), echo.MIMEApplicationJSON)
+}
+
+func (controller *FeedController) GetVatsimData(ctx echo.Context) error {
+	return feedBlob(ctx, controller.service.GetVatsimData(), echo.MIMEApplicationJSON)
+}
+
+func (controller *FeedController) GetWhazzup(ctx echo.Context) error {
+	return feedBlob(ctx, controller.service.GetWhazzup(), echo.MIMETextPlainCharsetUTF8)
+}
+
+func (controller *FeedController) GetGeoJSON(ctx echo.Context) error {
+	return feedBlob(ctx, controller.service.GetGeoJSON(), "application/geo+json")
+}
@@ -15,6 +15,8 @@ type FlightPlanControllerInterface interface {
 	DeleteFlightPlan(ctx echo.Context) error
 	LockFlightPlan(ctx echo.Context) error
 	UnlockFlightPlan(ctx echo.Context) error
+	GetSelfFlightPlanRevisions(ctx echo.Context) error
+	GetFlightPlanRevisions(ctx echo.Context) error
 }
 
 type FlightPlanController struct {
@@ -124,3 +126,30 @@ func (controller *FlightPlanController) UnlockFlightPlan(ctx echo.Context) error
 	data.Lock = false
 	return controller.flightPlanService.LockFlightPlan(data).Response(ctx)
 }
+
+func (controller *FlightPlanController) GetSelfFlightPlanRevisions(ctx echo.Context) error {
+	data := &RequestGetFlightPlanRevisions{}
+	if err := ctx.Bind(data); err != nil {
+		controller.logger.ErrorF("GetSelfFlightPlanRevisions bind error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	if err := SetJwtInfo(data, ctx); err != nil {
+		controller.logger.ErrorF("GetSelfFlightPlanRevisions jwt token parse error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	data.TargetCid = 0
+	return controller.flightPlanService.GetFlightPlanRevisions(data).Response(ctx)
+}
+
+func (controller *FlightPlanController) GetFlightPlanRevisions(ctx echo.Context) error {
+	data := &RequestGetFlightPlanRevisions{}
+	if err := ctx.Bind(data); err != nil {
+		controller.logger.ErrorF("GetFlightPlanRevisions bind error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	if err := SetJwtInfo(data, ctx); err != nil {
+		controller.logger.ErrorF("GetFlightPlanRevisions jwt token parse error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	return controller.flightPlanService.GetFlightPlanRevisions(data).Response(ctx)
+}
@@ -0,0 +1,62 @@
+// Package controller
+package controller
+
+import (
+	"fmt"
+	"net/http"
+
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/http/service"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	"github.com/labstack/echo/v4"
+)
+
+type FlightTrackControllerInterface interface {
+	GetFlightTracks(ctx echo.Context) error
+	ExportFlightTrack(ctx echo.Context) error
+}
+
+type FlightTrackController struct {
+	logger  log.LoggerInterface
+	service FlightTrackServiceInterface
+}
+
+func NewFlightTrackController(
+	logger log.LoggerInterface,
+	service FlightTrackServiceInterface,
+) *FlightTrackController {
+	return &FlightTrackController{
+		logger:  log.NewLoggerAdapter(logger, "FlightTrackController"),
+		service: service,
+	}
+}
+
+func (controller *FlightTrackController) GetFlightTracks(ctx echo.Context) error {
+	data := &RequestGetFlightTracks{}
+	if err := ctx.Bind(data); err != nil {
+		controller.logger.ErrorF("GetFlightTracks bind error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	if err := SetJwtInfo(data, ctx); err != nil {
+		controller.logger.ErrorF("GetFlightTracks jwt token parse error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	return controller.service.GetFlightTracks(data).Response(ctx)
+}
+
+func (controller *FlightTrackController) ExportFlightTrack(ctx echo.Context) error {
+	data := &RequestExportFlightTrack{}
+	if err := ctx.Bind(data); err != nil {
+		controller.logger.ErrorF("ExportFlightTrack bind error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	if err := SetJwtInfo(data, ctx); err != nil {
+		controller.logger.ErrorF("ExportFlightTrack jwt token parse error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	res := controller.service.ExportFlightTrack(data)
+	if res.Data == nil {
+		return res.Response(ctx)
+	}
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", res.Data.FileName))
+	return ctx.Blob(http.StatusOK, res.Data.ContentType, res.Data.Content)
+}
@@ -0,0 +1,82 @@
+// Package controller
+package controller
+
+import (
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/http/service"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	"github.com/labstack/echo/v4"
+)
+
+type HelpRequestControllerInterface interface {
+	GetHelpRequests(ctx echo.Context) error
+	ClaimHelpRequest(ctx echo.Context) error
+	ResolveHelpRequest(ctx echo.Context) error
+	GetHelpRequestStatistics(ctx echo.Context) error
+}
+
+type HelpRequestController struct {
+	logger             log.LoggerInterface
+	helpRequestService HelpRequestServiceInterface
+}
+
+func NewHelpRequestController(
+	logger log.LoggerInterface,
+	helpRequestService HelpRequestServiceInterface,
+) *HelpRequestController {
+	return &HelpRequestController{
+		logger:             log.NewLoggerAdapter(logger, "HelpRequestController"),
+		helpRequestService: helpRequestService,
+	}
+}
+
+func (controller *HelpRequestController) GetHelpRequests(ctx echo.Context) error {
+	data := &RequestGetHelpRequests{}
+	if err := ctx.Bind(data); err != nil {
+		controller.logger.ErrorF("GetHelpRequests bind error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	if err := SetJwtInfo(data, ctx); err != nil {
+		controller.logger.ErrorF("GetHelpRequests jwt token parse error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	return controller.helpRequestService.GetHelpRequests(data).Response(ctx)
+}
+
+func (controller *HelpRequestController) ClaimHelpRequest(ctx echo.Context) error {
+	data := &RequestClaimHelpRequest{}
+	if err := ctx.Bind(data); err != nil {
+		controller.logger.ErrorF("ClaimHelpRequest bind error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	if err := SetJwtInfoAndEchoContent(data, ctx); err != nil {
+		controller.logger.ErrorF("ClaimHelpRequest jwt token parse error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	return controller.helpRequestService.ClaimHelpRequest(data).Response(ctx)
+}
+
+func (controller *HelpRequestController) ResolveHelpRequest(ctx echo.Context) error {
+	data := &RequestResolveHelpRequest{}
+	if err := ctx.Bind(data); err != nil {
+		controller.logger.ErrorF("ResolveHelpRequest bind error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	if err := SetJwtInfoAndEchoContent(data, ctx); err != nil {
+		controller.logger.ErrorF("ResolveHelpRequest jwt token parse error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	return controller.helpRequestService.ResolveHelpRequest(data).Response(ctx)
+}
+
+func (controller *HelpRequestController) GetHelpRequestStatistics(ctx echo.Context) error {
+	data := &RequestGetHelpRequestStatistics{}
+	if err := ctx.Bind(data); err != nil {
+		controller.logger.ErrorF("GetHelpRequestStatistics bind error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	if err := SetJwtInfo(data, ctx); err != nil {
+		controller.logger.ErrorF("GetHelpRequestStatistics jwt token parse error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	return controller.helpRequestService.GetHelpRequestStatistics(data).Response(ctx)
+}
@@ -0,0 +1,40 @@
+// Package controller
+package controller
+
+import (
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/http/service"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	"github.com/labstack/echo/v4"
+)
+
+type LogbookControllerInterface interface {
+	GetLogbook(ctx echo.Context) error
+}
+
+type LogbookController struct {
+	logger  log.LoggerInterface
+	service LogbookServiceInterface
+}
+
+func NewLogbookController(
+	logger log.LoggerInterface,
+	service LogbookServiceInterface,
+) *LogbookController {
+	return &LogbookController{
+		logger:  log.NewLoggerAdapter(logger, "LogbookController"),
+		service: service,
+	}
+}
+
+func (controller *LogbookController) GetLogbook(ctx echo.Context) error {
+	data := &RequestGetLogbook{}
+	if err := ctx.Bind(data); err != nil {
+		controller.logger.ErrorF("GetLogbook bind error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	if err := SetJwtInfo(data, ctx); err != nil {
+		controller.logger.ErrorF("GetLogbook jwt token parse error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	return controller.service.GetLogbook(data).Response(ctx)
+}
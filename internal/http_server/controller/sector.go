@@ -0,0 +1,47 @@
+// Package controller
+package controller
+
+import (
+	"net/http"
+
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/http/service"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	"github.com/labstack/echo/v4"
+)
+
+type SectorControllerInterface interface {
+	GetSectors(ctx echo.Context) error
+	GetAircraftOwnership(ctx echo.Context) error
+	GetSectorCoverage(ctx echo.Context) error
+}
+
+type SectorController struct {
+	logger  log.LoggerInterface
+	service SectorServiceInterface
+}
+
+func NewSectorController(
+	logger log.LoggerInterface,
+	service SectorServiceInterface,
+) *SectorController {
+	return &SectorController{
+		logger:  log.NewLoggerAdapter(logger, "SectorController"),
+		service: service,
+	}
+}
+
+func (controller *SectorController) GetSectors(ctx echo.Context) error {
+	return controller.service.GetSectors().Response(ctx)
+}
+
+func (controller *SectorController) GetAircraftOwnership(ctx echo.Context) error {
+	return controller.service.GetAircraftOwnership().Response(ctx)
+}
+
+func (controller *SectorController) GetSectorCoverage(ctx echo.Context) error {
+	res := controller.service.GetSectorCoverage()
+	if res.Data == nil {
+		return res.Response(ctx)
+	}
+	return ctx.Blob(http.StatusOK, "application/geo+json", *res.Data)
+}
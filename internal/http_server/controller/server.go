@@ -11,6 +11,7 @@ type ServerControllerInterface interface {
 	GetServerConfig(ctx echo.Context) error
 	GetServerInfo(ctx echo.Context) error
 	GetServerOnlineTime(ctx echo.Context) error
+	ReloadConfig(ctx echo.Context) error
 }
 
 type ServerController struct {
@@ -36,3 +37,12 @@ func (controller *ServerController) GetServerInfo(ctx echo.Context) error {
 func (controller *ServerController) GetServerOnlineTime(ctx echo.Context) error {
 	return controller.serverService.GetTimeRating().Response(ctx)
 }
+
+func (controller *ServerController) ReloadConfig(ctx echo.Context) error {
+	data := &RequestReloadConfig{}
+	if err := SetJwtInfoAndEchoContent(data, ctx); err != nil {
+		controller.logger.ErrorF("ReloadConfig jwt token parse error: %v", err)
+		return NewErrorResponse(ctx, ErrParseParam)
+	}
+	return controller.serverService.ReloadConfig(data).Response(ctx)
+}
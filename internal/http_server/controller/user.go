@@ -53,6 +53,7 @@ func (controller *UserController) UserLogin(ctx echo.Context) error {
 		controller.logger.ErrorF("UserLogin bind error: %v", err)
 		return NewErrorResponse(ctx, ErrParseParam)
 	}
+	SetEchoContent(data, ctx)
 	return controller.service.UserLogin(data).Response(ctx)
 }
 
@@ -176,5 +177,6 @@ func (controller *UserController) UserFsdLogin(ctx echo.Context) error {
 		controller.logger.ErrorF("UserFsdLogin bind error: %v", err)
 		return NewErrorResponse(ctx, ErrParseParam)
 	}
+	SetEchoContent(data, ctx)
 	return ctx.JSON(http.StatusOK, controller.service.UserFsdLogin(data))
 }
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/metrics"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// MetricsMiddleware 按路由统计请求耗时, 使用路由模板而不是实际路径, 避免产生过多的序列
+func MetricsMiddleware(skipper middleware.Skipper) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if skipper != nil && skipper(c) {
+				return next(c)
+			}
+
+			start := time.Now()
+			err := next(c)
+
+			// 错误交给外层处理, 此时响应尚未写入, 需要根据错误推断状态码
+			status := c.Response().Status
+			if err != nil && !c.Response().Committed {
+				status = http.StatusInternalServerError
+				var httpError *echo.HTTPError
+				if errors.As(err, &httpError) {
+					status = httpError.Code
+				}
+			}
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+			metrics.ObserveSince(metrics.HttpRequestDuration, start, c.Request().Method, route, strconv.Itoa(status))
+			return err
+		}
+	}
+}
@@ -21,9 +21,12 @@ import (
 	"github.com/half-nothing/simple-fsd/internal/http_server/service/store"
 	ws "github.com/half-nothing/simple-fsd/internal/http_server/websocket"
 	. "github.com/half-nothing/simple-fsd/internal/interfaces"
+	c "github.com/half-nothing/simple-fsd/internal/interfaces/config"
 	"github.com/half-nothing/simple-fsd/internal/interfaces/global"
 	"github.com/half-nothing/simple-fsd/internal/interfaces/http/service"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
 	"github.com/half-nothing/simple-fsd/internal/interfaces/queue"
+	"github.com/half-nothing/simple-fsd/internal/metrics"
 	"github.com/half-nothing/simple-fsd/internal/utils"
 	"github.com/labstack/echo-jwt/v4"
 	"github.com/labstack/echo/v4"
@@ -36,12 +39,14 @@ import (
 type ShutdownCallback struct {
 	serverHandler *echo.Echo
 	websocket     *ws.WebSocketServer
+	traffic       *ws.TrafficStreamServer
 }
 
-func NewShutdownCallback(serverHandler *echo.Echo, websocket *ws.WebSocketServer) *ShutdownCallback {
+func NewShutdownCallback(serverHandler *echo.Echo, websocket *ws.WebSocketServer, traffic *ws.TrafficStreamServer) *ShutdownCallback {
 	return &ShutdownCallback{
 		serverHandler: serverHandler,
 		websocket:     websocket,
+		traffic:       traffic,
 	}
 }
 
@@ -50,6 +55,7 @@ func (hc *ShutdownCallback) Invoke(ctx context.Context) error {
 	defer cancel()
 	var eg errgroup.Group
 	eg.Go(func() error { return hc.websocket.Close(timeoutCtx) })
+	eg.Go(func() error { return hc.traffic.Close(timeoutCtx) })
 	eg.Go(func() error { return hc.serverHandler.Shutdown(timeoutCtx) })
 	return eg.Wait()
 }
@@ -67,9 +73,11 @@ func StartHttpServer(applicationContent *ApplicationContent) {
 	messageQueue := applicationContent.MessageQueue()
 
 	websocketServer := ws.NewWebSocketServer(logger, messageQueue, httpConfig)
+	trafficServer := ws.NewTrafficStreamServer(logger, applicationContent.ClientManager(), httpConfig)
 
 	webSocketGroup := e.Group("/ws")
 	webSocketGroup.GET("/fsd", websocketServer.ConnectToFsd)
+	webSocketGroup.GET("/traffic", trafficServer.ConnectToTraffic)
 
 	skipWebSocket := func(c echo.Context) bool {
 		return strings.HasPrefix(c.Path(), "/ws")
@@ -104,6 +112,13 @@ func StartHttpServer(applicationContent *ApplicationContent) {
 		e.Use(middleware.HTTPSRedirect())
 	}
 
+	e.Use(mid.MetricsMiddleware(skipWebSocket))
+	e.GET("/metrics", func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+		c.Response().WriteHeader(http.StatusOK)
+		return metrics.Default.Write(c.Response())
+	})
+
 	e.Use(middleware.TimeoutWithConfig(middleware.TimeoutConfig{
 		Timeout: 30 * time.Second,
 		Skipper: func(c echo.Context) bool {
@@ -132,7 +147,9 @@ func StartHttpServer(applicationContent *ApplicationContent) {
 		HSTSExcludeSubdomains: !httpConfig.SSL.IncludeDomain,
 	}))
 
-	e.Use(middleware.CORS())
+	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: httpConfig.AllowedOrigins,
+	}))
 	if httpConfig.BodyLimit != "" {
 		e.Use(middleware.BodyLimit(httpConfig.BodyLimit))
 	} else {
@@ -143,15 +160,24 @@ func StartHttpServer(applicationContent *ApplicationContent) {
 		Skipper: skipWebSocket,
 	}))
 
+	// 限流器始终创建, 以便配置重载时可以开启或修改限流
+	ipPathLimiter := utils.NewSlidingWindowLimiter(time.Minute, httpConfig.RateLimit)
+	ipPathLimiter.StartCleanup(2 * time.Minute)
+	e.Use(mid.RateLimitMiddleware(ipPathLimiter, mid.CombinedKeyFunc))
 	if httpConfig.RateLimit != 0 {
-		ipPathLimiter := utils.NewSlidingWindowLimiter(time.Minute, httpConfig.RateLimit)
-		ipPathLimiter.StartCleanup(2 * time.Minute)
-		e.Use(mid.RateLimitMiddleware(ipPathLimiter, mid.CombinedKeyFunc))
 		logger.InfoF("Rate limit: %d requests per minute", httpConfig.RateLimit)
 	} else {
 		logger.Warn("No rate limit was set, be aware of possible DDOS attacks")
 	}
 
+	messageQueue.Subscribe(queue.ConfigReloaded, func(message *queue.Message) error {
+		if val, ok := message.Data.(*c.Config); ok {
+			ipPathLimiter.SetMaxRequests(val.Server.HttpServer.RateLimit)
+			return nil
+		}
+		return queue.ErrMessageDataType
+	})
+
 	whazzupUrl, _ := url.JoinPath(httpConfig.ServerAddress, "/api/clients")
 	whazzupContent := fmt.Sprintf("url0=%s", whazzupUrl)
 
@@ -177,16 +203,21 @@ func StartHttpServer(applicationContent *ApplicationContent) {
 	}
 
 	jwtMiddleware := echojwt.WithConfig(jwtConfig)
+	banManager := applicationContent.BanManager()
 
 	jwtVerifyMiddleWare := func(flushToken bool) echo.MiddlewareFunc {
 		return func(next echo.HandlerFunc) echo.HandlerFunc {
 			return func(ctx echo.Context) error {
 				token := ctx.Get("user").(*jwt.Token)
 				claim := token.Claims.(*service.Claims)
-				if flushToken == claim.FlushToken {
-					return next(ctx)
+				if flushToken != claim.FlushToken {
+					return service.NewApiResponse[any](service.ErrInvalidJwtType, nil).Response(ctx)
+				}
+				// 已签发的令牌在封禁生效后立即失效
+				if ban := banManager.Check(claim.Cid, ctx.RealIP(), operation.BanScopeWeb); ban != nil {
+					return service.NewApiResponse[any](service.ErrAccountSuspended, nil).Response(ctx)
 				}
-				return service.NewApiResponse[any](service.ErrInvalidJwtType, nil).Response(ctx)
+				return next(ctx)
 			}
 		}
 	}
@@ -205,7 +236,12 @@ func StartHttpServer(applicationContent *ApplicationContent) {
 	activityOperation := applicationContent.Operations().ActivityOperation()
 	ticketOperation := applicationContent.Operations().TicketOperation()
 	flightPlanOperation := applicationContent.Operations().FlightPlanOperation()
+	flightPlanRevisionOperation := applicationContent.Operations().FlightPlanRevisionOperation()
+	flightTrackOperation := applicationContent.Operations().FlightTrackOperation()
+	logbookOperation := applicationContent.Operations().LogbookOperation()
 	announcementOperation := applicationContent.Operations().AnnouncementOperation()
+	helpRequestOperation := applicationContent.Operations().HelpRequestOperation()
+	banOperation := applicationContent.Operations().BanOperation()
 	metarManager := applicationContent.MetarManager()
 
 	auditLogService := impl.NewAuditService(logger, auditLogOperation)
@@ -231,16 +267,22 @@ func StartHttpServer(applicationContent *ApplicationContent) {
 
 	messageQueue.Subscribe(queue.DeleteVerifyCode, emailService.HandleDeleteVerifyCodeMessage)
 
-	userService := impl.NewUserService(logger, httpConfig, messageQueue, userOperation, historyOperation, auditLogOperation, storeService, emailService)
+	userService := impl.NewUserService(logger, httpConfig, messageQueue, userOperation, historyOperation, auditLogOperation, storeService, emailService, banManager)
 	clientService := impl.NewClientService(logger, httpConfig, userOperation, auditLogOperation, clientManager, messageQueue)
-	serverService := impl.NewServerService(logger, config.Server, userOperation, controllerOperation, activityOperation)
+	serverService := impl.NewServerService(logger, applicationContent.ConfigManager(), messageQueue, userOperation, controllerOperation, activityOperation, auditLogOperation)
 	activityService := impl.NewActivityService(logger, httpConfig, messageQueue, userOperation, activityOperation, auditLogOperation, storeService)
 	controllerService := impl.NewControllerService(logger, httpConfig, messageQueue, userOperation, controllerOperation, controllerRecordOperation, auditLogOperation)
 	controllerApplicationService := impl.NewControllerApplicationService(logger, messageQueue, controllerApplicationOperation, userOperation, auditLogOperation)
 	ticketService := impl.NewTicketService(logger, messageQueue, userOperation, ticketOperation, auditLogOperation)
-	flightPlanService := impl.NewFlightPlanService(logger, messageQueue, userOperation, flightPlanOperation, auditLogOperation)
+	flightPlanService := impl.NewFlightPlanService(logger, messageQueue, userOperation, flightPlanOperation, flightPlanRevisionOperation, auditLogOperation)
+	flightTrackService := impl.NewFlightTrackService(logger, flightTrackOperation)
+	logbookService := impl.NewLogbookService(logger, logbookOperation)
 	announcementService := impl.NewAnnouncementService(logger, messageQueue, announcementOperation, auditLogOperation)
 	metarService := impl.NewMetarService(logger, metarManager)
+	sectorService := impl.NewSectorService(logger, clientManager.SectorManager())
+	feedService := impl.NewFeedService(logger, applicationContent.ConfigManager(), clientManager)
+	helpRequestService := impl.NewHelpRequestService(logger, messageQueue, applicationContent.HelpRequestManager(), helpRequestOperation, auditLogOperation)
+	banService := impl.NewBanService(logger, messageQueue, banManager, banOperation, userOperation, auditLogOperation)
 
 	logger.Info("Controller initializing...")
 
@@ -255,8 +297,14 @@ func StartHttpServer(applicationContent *ApplicationContent) {
 	controllerApplicationController := controller.NewControllerApplicationController(logger, controllerApplicationService)
 	ticketController := controller.NewTicketController(logger, ticketService)
 	flightPlanController := controller.NewFlightPlanController(logger, flightPlanService)
+	flightTrackController := controller.NewFlightTrackController(logger, flightTrackService)
+	logbookController := controller.NewLogbookController(logger, logbookService)
 	announcementController := controller.NewAnnouncementController(logger, announcementService)
 	metarServiceController := controller.NewMetarServiceController(logger, metarService)
+	sectorController := controller.NewSectorController(logger, sectorService)
+	feedController := controller.NewFeedController(logger, feedService)
+	helpRequestController := controller.NewHelpRequestController(logger, helpRequestService)
+	banController := controller.NewBanController(logger, banService)
 
 	logger.Info("Applying router...")
 
@@ -299,14 +347,30 @@ func StartHttpServer(applicationContent *ApplicationContent) {
 	clientGroup.GET("", clientController.GetOnlineClients)
 	clientGroup.GET("/status", func(c echo.Context) error { return c.String(http.StatusOK, whazzupContent) })
 	clientGroup.GET("/paths/:callsign", clientController.GetClientPath, jwtMiddleware, requireNoFlushToken)
+	clientGroup.GET("/flight-data", clientController.GetFlightDataList, jwtMiddleware, requireNoFlushToken)
+	clientGroup.GET("/flight-data/:callsign", clientController.GetFlightData, jwtMiddleware, requireNoFlushToken)
+	clientGroup.POST("/squawk/:callsign", clientController.AllocateSquawk, jwtMiddleware, requireNoFlushToken)
+	clientGroup.GET("/resume-token/:callsign", clientController.GetResumeToken, jwtMiddleware, requireNoFlushToken)
 	clientGroup.POST("/messages", clientController.BroadcastMessage, jwtMiddleware, requireNoFlushToken)
 	clientGroup.POST("/messages/:callsign", clientController.SendMessageToClient, jwtMiddleware, requireNoFlushToken)
 	clientGroup.DELETE("/:callsign", clientController.KillClient, jwtMiddleware, requireNoFlushToken)
 
+	sectorGroup := apiGroup.Group("/sectors")
+	sectorGroup.GET("", sectorController.GetSectors)
+	sectorGroup.GET("/aircraft", sectorController.GetAircraftOwnership)
+	sectorGroup.GET("/coverage", sectorController.GetSectorCoverage)
+
+	feedGroup := apiGroup.Group("/feeds")
+	feedGroup.GET("/status", feedController.GetFeedStatus)
+	feedGroup.GET("/vatsim", feedController.GetVatsimData)
+	feedGroup.GET("/whazzup", feedController.GetWhazzup)
+	feedGroup.GET("/geojson", feedController.GetGeoJSON)
+
 	serverGroup := apiGroup.Group("/server")
 	serverGroup.GET("/config", serverController.GetServerConfig)
 	serverGroup.GET("/info", serverController.GetServerInfo, jwtMiddleware, requireNoFlushToken)
 	serverGroup.GET("/rating", serverController.GetServerOnlineTime, jwtMiddleware, requireNoFlushToken)
+	serverGroup.POST("/config/reload", serverController.ReloadConfig, jwtMiddleware, requireNoFlushToken)
 
 	activityGroup := apiGroup.Group("/activities")
 	activityGroup.GET("", activityController.GetActivities, jwtMiddleware, requireNoFlushToken)
@@ -329,15 +393,36 @@ func StartHttpServer(applicationContent *ApplicationContent) {
 	ticketGroup.PUT("/:tid", ticketController.CloseTicket, jwtMiddleware, requireNoFlushToken)
 	ticketGroup.DELETE("/:tid", ticketController.DeleteTicket, jwtMiddleware, requireNoFlushToken)
 
+	helpRequestGroup := apiGroup.Group("/help-requests")
+	helpRequestGroup.GET("", helpRequestController.GetHelpRequests, jwtMiddleware, requireNoFlushToken)
+	helpRequestGroup.GET("/statistics", helpRequestController.GetHelpRequestStatistics, jwtMiddleware, requireNoFlushToken)
+	helpRequestGroup.PUT("/:hid/claim", helpRequestController.ClaimHelpRequest, jwtMiddleware, requireNoFlushToken)
+	helpRequestGroup.PUT("/:hid/resolve", helpRequestController.ResolveHelpRequest, jwtMiddleware, requireNoFlushToken)
+
+	banGroup := apiGroup.Group("/bans")
+	banGroup.GET("", banController.GetBans, jwtMiddleware, requireNoFlushToken)
+	banGroup.POST("", banController.IssueBan, jwtMiddleware, requireNoFlushToken)
+	banGroup.DELETE("/:bid", banController.LiftBan, jwtMiddleware, requireNoFlushToken)
+	banGroup.PATCH("/:bid", banController.ExtendBan, jwtMiddleware, requireNoFlushToken)
+
 	flightPlanGroup := apiGroup.Group("/plans")
 	flightPlanGroup.POST("", flightPlanController.SubmitFlightPlan, jwtMiddleware, requireNoFlushToken)
 	flightPlanGroup.GET("", flightPlanController.GetFlightPlans, jwtMiddleware, requireNoFlushToken)
 	flightPlanGroup.GET("/self", flightPlanController.GetFlightPlan, jwtMiddleware, requireNoFlushToken)
 	flightPlanGroup.DELETE("/self", flightPlanController.DeleteSelfFlightPlan, jwtMiddleware, requireNoFlushToken)
+	flightPlanGroup.GET("/self/revisions", flightPlanController.GetSelfFlightPlanRevisions, jwtMiddleware, requireNoFlushToken)
+	flightPlanGroup.GET("/:cid/revisions", flightPlanController.GetFlightPlanRevisions, jwtMiddleware, requireNoFlushToken)
 	flightPlanGroup.PUT("/:cid/lock", flightPlanController.LockFlightPlan, jwtMiddleware, requireNoFlushToken)
 	flightPlanGroup.DELETE("/:cid/lock", flightPlanController.UnlockFlightPlan, jwtMiddleware, requireNoFlushToken)
 	flightPlanGroup.DELETE("/:cid", flightPlanController.DeleteFlightPlan, jwtMiddleware, requireNoFlushToken)
 
+	trackGroup := apiGroup.Group("/tracks")
+	trackGroup.GET("", flightTrackController.GetFlightTracks, jwtMiddleware, requireNoFlushToken)
+	trackGroup.GET("/:tid", flightTrackController.ExportFlightTrack, jwtMiddleware, requireNoFlushToken)
+
+	logbookGroup := apiGroup.Group("/logbook")
+	logbookGroup.GET("", logbookController.GetLogbook, jwtMiddleware, requireNoFlushToken)
+
 	announcementGroup := apiGroup.Group("/announcements")
 	announcementGroup.GET("", announcementController.GetAnnouncements, jwtMiddleware, requireNoFlushToken)
 	announcementGroup.GET("/detail", announcementController.GetDetailAnnouncements, jwtMiddleware, requireNoFlushToken)
@@ -370,7 +455,7 @@ func StartHttpServer(applicationContent *ApplicationContent) {
 
 	apiGroup.Use(middleware.Static(httpConfig.Store.LocalStorePath))
 
-	applicationContent.Cleaner().Add(NewShutdownCallback(e, websocketServer))
+	applicationContent.Cleaner().Add(NewShutdownCallback(e, websocketServer, trafficServer))
 
 	protocol := "http"
 	if httpConfig.SSL.Enable {
@@ -0,0 +1,318 @@
+// Package service
+// 存放 BanServiceInterface 的实现
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces"
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/http/service"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/queue"
+)
+
+type BanService struct {
+	logger            log.LoggerInterface
+	messageQueue      queue.MessageQueueInterface
+	banManager        interfaces.BanManagerInterface
+	banOperation      operation.BanOperationInterface
+	userOperation     operation.UserOperationInterface
+	auditLogOperation operation.AuditLogOperationInterface
+}
+
+func NewBanService(
+	logger log.LoggerInterface,
+	messageQueue queue.MessageQueueInterface,
+	banManager interfaces.BanManagerInterface,
+	banOperation operation.BanOperationInterface,
+	userOperation operation.UserOperationInterface,
+	auditLogOperation operation.AuditLogOperationInterface,
+) *BanService {
+	return &BanService{
+		logger:            log.NewLoggerAdapter(logger, "BanService"),
+		messageQueue:      messageQueue,
+		banManager:        banManager,
+		banOperation:      banOperation,
+		userOperation:     userOperation,
+		auditLogOperation: auditLogOperation,
+	}
+}
+
+func (banService *BanService) GetBans(req *RequestGetBans) *ApiResponse[ResponseGetBans] {
+	if req.Page <= 0 || req.PageSize <= 0 {
+		return NewApiResponse[ResponseGetBans](ErrIllegalParam, nil)
+	}
+
+	if res := CheckPermission[ResponseGetBans](req.Permission, operation.BanShowList); res != nil {
+		return res
+	}
+
+	bans, total, err := banService.banOperation.GetBans(req.Page, req.PageSize, req.ActiveOnly)
+	if res := CheckDatabaseError[ResponseGetBans](err); res != nil {
+		return res
+	}
+
+	return NewApiResponse(SuccessGetBans, &ResponseGetBans{
+		Items:    bans,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	})
+}
+
+// parseBanSubject 校验封禁对象, 返回规范化后的对象, CID封禁同时返回被封禁的用户
+func (banService *BanService) parseBanSubject(subjectType operation.BanSubjectType, subject string) (string, *operation.User, error) {
+	switch subjectType {
+	case operation.BanSubjectCid:
+		cid, err := strconv.Atoi(subject)
+		if err != nil || cid <= 0 {
+			return "", nil, operation.ErrInvalidBanSubject
+		}
+		user, err := banService.userOperation.GetUserByCid(cid)
+		if err != nil {
+			return "", nil, err
+		}
+		return strconv.Itoa(cid), user, nil
+	case operation.BanSubjectIp, operation.BanSubjectCidr:
+		subject, err := operation.ParseBanAddress(subjectType, subject)
+		return subject, nil, err
+	default:
+		return "", nil, operation.ErrInvalidBanSubject
+	}
+}
+
+// parseBanDuration 解析封禁时长, 为空时返回0表示永久
+func parseBanDuration(duration string) (time.Duration, bool) {
+	if duration == "" {
+		return 0, true
+	}
+	value, err := time.ParseDuration(duration)
+	if err != nil || value <= 0 {
+		return 0, false
+	}
+	return value, true
+}
+
+func (banService *BanService) IssueBan(req *RequestIssueBan) *ApiResponse[ResponseIssueBan] {
+	subjectType, ok := operation.ParseBanSubjectType(req.SubjectType)
+	if !ok || req.Subject == "" || req.Reason == "" {
+		return NewApiResponse[ResponseIssueBan](ErrIllegalParam, nil)
+	}
+	scope, ok := operation.ParseBanScope(req.Scope)
+	if !ok {
+		return NewApiResponse[ResponseIssueBan](ErrIllegalParam, nil)
+	}
+	duration, ok := parseBanDuration(req.Duration)
+	if !ok {
+		return NewApiResponse[ResponseIssueBan](ErrIllegalParam, nil)
+	}
+
+	operator, res := CheckPermissionFromDatabase[ResponseIssueBan](banService.userOperation, req.Uid, operation.BanIssue)
+	if res != nil {
+		return res
+	}
+
+	subject, target, err := banService.parseBanSubject(subjectType, req.Subject)
+	if errors.Is(err, operation.ErrInvalidBanSubject) {
+		return NewApiResponse[ResponseIssueBan](ErrIllegalParam, nil)
+	}
+	if errors.Is(err, operation.ErrBanRangeTooWide) {
+		return NewApiResponse[ResponseIssueBan](ErrBanRangeTooWide, nil)
+	}
+	if res := CheckDatabaseError[ResponseIssueBan](err); res != nil {
+		return res
+	}
+
+	cid := 0
+	if target != nil {
+		cid = target.Cid
+	}
+	var expiresAt *time.Time
+	if duration > 0 {
+		expires := time.Now().Add(duration)
+		expiresAt = &expires
+	}
+
+	ban := banService.banOperation.NewBan(subjectType, subject, cid, scope, req.Reason, req.Cid, expiresAt)
+
+	// 不能封禁自己或者拥有自己所没有权限的用户
+	if ban.MatchCid(operator.Cid) || ban.MatchIp(net.ParseIP(req.Ip)) {
+		banService.logOverreach(req, subject)
+		return NewApiResponse[ResponseIssueBan](ErrBanSelf, nil)
+	}
+	if target != nil && target.Permission&^operator.Permission != 0 {
+		banService.logOverreach(req, subject)
+		return NewApiResponse[ResponseIssueBan](ErrNoPermission, nil)
+	}
+
+	if res := CallDBFuncWithoutRet[ResponseIssueBan](func() error {
+		return banService.banManager.Issue(ban)
+	}); res != nil {
+		return res
+	}
+
+	if target != nil {
+		banService.messageQueue.Publish(&queue.Message{
+			Type: queue.SendBannedEmail,
+			Data: &interfaces.BannedEmailData{
+				User:     target,
+				Operator: operator,
+				Ban:      ban,
+			},
+		})
+	}
+
+	banService.messageQueue.Publish(&queue.Message{
+		Type: queue.AuditLog,
+		Data: banService.auditLogOperation.NewAuditLog(
+			operation.BanIssued,
+			req.Cid,
+			fmt.Sprintf("%d(%s)", ban.ID, ban.Subject),
+			req.Ip,
+			req.UserAgent,
+			&operation.ChangeDetail{
+				OldValue: operation.ValueNotAvailable,
+				NewValue: fmt.Sprintf("%s, %s", scope.String(), req.Reason),
+			},
+		),
+	})
+
+	data := ResponseIssueBan(ban)
+	return NewApiResponse(SuccessIssueBan, &data)
+}
+
+// logOverreach 记录越权封禁的尝试
+func (banService *BanService) logOverreach(req *RequestIssueBan, subject string) {
+	banService.messageQueue.Publish(&queue.Message{
+		Type: queue.AuditLog,
+		Data: banService.auditLogOperation.NewAuditLog(
+			operation.UnlawfulOverreach,
+			req.Cid,
+			fmt.Sprintf("ban %s", subject),
+			req.Ip,
+			req.UserAgent,
+			nil,
+		),
+	})
+}
+
+func (banService *BanService) LiftBan(req *RequestLiftBan) *ApiResponse[ResponseLiftBan] {
+	if req.BanId <= 0 {
+		return NewApiResponse[ResponseLiftBan](ErrIllegalParam, nil)
+	}
+
+	if res := CheckPermission[ResponseLiftBan](req.Permission, operation.BanEdit); res != nil {
+		return res
+	}
+
+	ban, res := CallDBFunc[*operation.Ban, ResponseLiftBan](func() (*operation.Ban, error) {
+		return banService.banManager.Lift(req.BanId, req.Cid)
+	})
+	if res != nil {
+		return res
+	}
+
+	banService.messageQueue.Publish(&queue.Message{
+		Type: queue.AuditLog,
+		Data: banService.auditLogOperation.NewAuditLog(
+			operation.BanLifted,
+			req.Cid,
+			fmt.Sprintf("%d(%s)", ban.ID, ban.Subject),
+			req.Ip,
+			req.UserAgent,
+			nil,
+		),
+	})
+
+	data := ResponseLiftBan(true)
+	return NewApiResponse(SuccessLiftBan, &data)
+}
+
+func (banService *BanService) ExtendBan(req *RequestExtendBan) *ApiResponse[ResponseExtendBan] {
+	if req.BanId <= 0 {
+		return NewApiResponse[ResponseExtendBan](ErrIllegalParam, nil)
+	}
+	duration, ok := parseBanDuration(req.Duration)
+	if !ok || (duration == 0 && !req.Permanent) {
+		return NewApiResponse[ResponseExtendBan](ErrIllegalParam, nil)
+	}
+
+	operator, res := CheckPermissionFromDatabase[ResponseExtendBan](banService.userOperation, req.Uid, operation.BanEdit)
+	if res != nil {
+		return res
+	}
+
+	ban, res := CallDBFunc[*operation.Ban, ResponseExtendBan](func() (*operation.Ban, error) {
+		return banService.banOperation.GetBan(req.BanId)
+	})
+	if res != nil {
+		return res
+	}
+	if ban.Lifted {
+		return NewApiResponse[ResponseExtendBan](ErrBanAlreadyLifted, nil)
+	}
+
+	oldValue := "permanent"
+	if ban.ExpiresAt != nil {
+		oldValue = ban.ExpiresAt.Format(time.DateTime)
+	}
+
+	// 延长时以当前到期时间为基准, 已到期的封禁从现在开始计算
+	var expiresAt *time.Time
+	newValue := "permanent"
+	if !req.Permanent {
+		if ban.ExpiresAt == nil {
+			return NewApiResponse[ResponseExtendBan](ErrIllegalParam, nil)
+		}
+		base := time.Now()
+		if ban.ExpiresAt.After(base) {
+			base = *ban.ExpiresAt
+		}
+		expires := base.Add(duration)
+		expiresAt = &expires
+		newValue = expires.Format(time.DateTime)
+	}
+
+	ban, res = CallDBFunc[*operation.Ban, ResponseExtendBan](func() (*operation.Ban, error) {
+		return banService.banManager.Extend(req.BanId, expiresAt)
+	})
+	if res != nil {
+		return res
+	}
+
+	if operation.BanSubjectType(ban.SubjectType) == operation.BanSubjectCid {
+		if user, err := banService.userOperation.GetUserByCid(ban.Cid); err == nil {
+			banService.messageQueue.Publish(&queue.Message{
+				Type: queue.SendBannedEmail,
+				Data: &interfaces.BannedEmailData{
+					User:     user,
+					Operator: operator,
+					Ban:      ban,
+				},
+			})
+		}
+	}
+
+	banService.messageQueue.Publish(&queue.Message{
+		Type: queue.AuditLog,
+		Data: banService.auditLogOperation.NewAuditLog(
+			operation.BanExtended,
+			req.Cid,
+			fmt.Sprintf("%d(%s)", ban.ID, ban.Subject),
+			req.Ip,
+			req.UserAgent,
+			&operation.ChangeDetail{
+				OldValue: oldValue,
+				NewValue: newValue,
+			},
+		),
+	})
+
+	data := ResponseExtendBan(ban)
+	return NewApiResponse(SuccessExtendBan, &data)
+}
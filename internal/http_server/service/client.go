@@ -180,3 +180,74 @@ func (clientService *ClientService) SendBroadcastMessage(req *RequestSendBroadca
 	data := ResponseSendBroadcastMessage(true)
 	return NewApiResponse[ResponseSendBroadcastMessage](SuccessSendBroadcastMessage, &data)
 }
+
+func (clientService *ClientService) GetFlightDataList(req *RequestFlightDataList) *ApiResponse[ResponseFlightDataList] {
+	if res := CheckPermission[ResponseFlightDataList](req.Permission, operation.ClientShowFlightData); res != nil {
+		return res
+	}
+
+	data := ResponseFlightDataList(clientService.clientManager.FlightDataStore().GetFlightDataList())
+	return NewApiResponse(SuccessGetFlightData, &data)
+}
+
+func (clientService *ClientService) GetFlightData(req *RequestFlightData) *ApiResponse[ResponseFlightData] {
+	if req.Callsign == "" {
+		return NewApiResponse[ResponseFlightData](ErrIllegalParam, nil)
+	}
+
+	if res := CheckPermission[ResponseFlightData](req.Permission, operation.ClientShowFlightData); res != nil {
+		return res
+	}
+
+	flightData, ok := clientService.clientManager.FlightDataStore().GetFlightData(req.Callsign)
+	if !ok {
+		return NewApiResponse[ResponseFlightData](ErrFlightDataNotFound, nil)
+	}
+
+	data := ResponseFlightData(flightData)
+	return NewApiResponse(SuccessGetFlightData, &data)
+}
+
+func (clientService *ClientService) AllocateSquawk(req *RequestAllocateSquawk) *ApiResponse[ResponseAllocateSquawk] {
+	if req.Callsign == "" {
+		return NewApiResponse[ResponseAllocateSquawk](ErrIllegalParam, nil)
+	}
+
+	if res := CheckPermission[ResponseAllocateSquawk](req.Permission, operation.ClientAllocateSquawk); res != nil {
+		return res
+	}
+
+	code, err := clientService.clientManager.SquawkAllocator().Allocate(req.Callsign)
+	if err != nil {
+		switch {
+		case errors.Is(err, fsd.ErrCallsignNotFound):
+			return NewApiResponse[ResponseAllocateSquawk](ErrClientNotFound, nil)
+		case errors.Is(err, fsd.ErrSquawkDisabled):
+			return NewApiResponse[ResponseAllocateSquawk](ErrSquawkNotEnabled, nil)
+		default:
+			return NewApiResponse[ResponseAllocateSquawk](ErrSquawkUnavailable, nil)
+		}
+	}
+
+	return NewApiResponse(SuccessAllocateSquawk, &ResponseAllocateSquawk{Callsign: req.Callsign, Squawk: code})
+}
+
+// GetResumeToken 只能查询自己名下客户端的恢复令牌
+func (clientService *ClientService) GetResumeToken(req *RequestGetResumeToken) *ApiResponse[ResponseGetResumeToken] {
+	if req.Uid <= 0 || req.Callsign == "" {
+		return NewApiResponse[ResponseGetResumeToken](ErrIllegalParam, nil)
+	}
+
+	client, ok := clientService.clientManager.GetClient(req.Callsign)
+	// 不属于自己的客户端按不存在处理, 避免泄露其他用户的在线状态
+	if !ok || client.User() == nil || client.User().Cid != req.Cid {
+		return NewApiResponse[ResponseGetResumeToken](ErrClientNotFound, nil)
+	}
+
+	token := client.ResumeToken()
+	if token == "" {
+		return NewApiResponse[ResponseGetResumeToken](ErrResumeTokenNotIssued, nil)
+	}
+
+	return NewApiResponse(SuccessGetResumeToken, &ResponseGetResumeToken{Callsign: client.Callsign(), Token: token})
+}
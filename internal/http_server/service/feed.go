@@ -0,0 +1,505 @@
+// Package service
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/http/service"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	"github.com/half-nothing/simple-fsd/internal/utils"
+)
+
+// whazzupReplacer 冒号是whazzup的字段分隔符, 不能出现在字段内容中
+var whazzupReplacer = strings.NewReplacer(":", " ", "\r", "", "\n", " ")
+
+type vatsimGeneral struct {
+	Version          int    `json:"version"`
+	Reload           int    `json:"reload"`
+	Update           string `json:"update"`
+	UpdateTimestamp  string `json:"update_timestamp"`
+	ConnectedClients int    `json:"connected_clients"`
+	UniqueUsers      int    `json:"unique_users"`
+}
+
+type vatsimFlightPlan struct {
+	FlightRules         string `json:"flight_rules"`
+	Aircraft            string `json:"aircraft"`
+	AircraftFaa         string `json:"aircraft_faa"`
+	AircraftShort       string `json:"aircraft_short"`
+	Departure           string `json:"departure"`
+	Arrival             string `json:"arrival"`
+	Alternate           string `json:"alternate"`
+	CruiseTas           string `json:"cruise_tas"`
+	Altitude            string `json:"altitude"`
+	Deptime             string `json:"deptime"`
+	EnrouteTime         string `json:"enroute_time"`
+	FuelTime            string `json:"fuel_time"`
+	Remarks             string `json:"remarks"`
+	Route               string `json:"route"`
+	RevisionId          int    `json:"revision_id"`
+	AssignedTransponder string `json:"assigned_transponder"`
+}
+
+type vatsimPilot struct {
+	Cid            int               `json:"cid"`
+	Name           string            `json:"name"`
+	Callsign       string            `json:"callsign"`
+	Server         string            `json:"server"`
+	PilotRating    int               `json:"pilot_rating"`
+	MilitaryRating int               `json:"military_rating"`
+	Latitude       float64           `json:"latitude"`
+	Longitude      float64           `json:"longitude"`
+	Altitude       int               `json:"altitude"`
+	Groundspeed    int               `json:"groundspeed"`
+	Transponder    string            `json:"transponder"`
+	Heading        int               `json:"heading"`
+	QnhIHg         float64           `json:"qnh_i_hg"`
+	QnhMb          int               `json:"qnh_mb"`
+	FlightPlan     *vatsimFlightPlan `json:"flight_plan"`
+	LogonTime      string            `json:"logon_time"`
+	LastUpdated    string            `json:"last_updated"`
+}
+
+type vatsimController struct {
+	Cid         int      `json:"cid"`
+	Name        string   `json:"name"`
+	Callsign    string   `json:"callsign"`
+	Frequency   string   `json:"frequency"`
+	Facility    int      `json:"facility"`
+	Rating      int      `json:"rating"`
+	Server      string   `json:"server"`
+	VisualRange int      `json:"visual_range"`
+	AtisCode    *string  `json:"atis_code,omitempty"`
+	TextAtis    []string `json:"text_atis"`
+	LastUpdated string   `json:"last_updated"`
+	LogonTime   string   `json:"logon_time"`
+}
+
+type vatsimServer struct {
+	Ident                    string `json:"ident"`
+	HostnameOrIp             string `json:"hostname_or_ip"`
+	Location                 string `json:"location"`
+	Name                     string `json:"name"`
+	ClientsConnectionAllowed int    `json:"clients_connection_allowed"`
+	ClientConnectionsAllowed bool   `json:"client_connections_allowed"`
+	IsSweatbox               bool   `json:"is_sweatbox"`
+}
+
+type vatsimReference struct {
+	Id        int    `json:"id"`
+	Short     string `json:"short"`
+	ShortName string `json:"short_name,omitempty"`
+	Long      string `json:"long"`
+	LongName  string `json:"long_name,omitempty"`
+}
+
+type vatsimData struct {
+	General         vatsimGeneral       `json:"general"`
+	Pilots          []*vatsimPilot      `json:"pilots"`
+	Controllers     []*vatsimController `json:"controllers"`
+	Atis            []*vatsimController `json:"atis"`
+	Servers         []*vatsimServer     `json:"servers"`
+	Prefiles        []any               `json:"prefiles"`
+	Facilities      []*vatsimReference  `json:"facilities"`
+	Ratings         []*vatsimReference  `json:"ratings"`
+	PilotRatings    []*vatsimReference  `json:"pilot_ratings"`
+	MilitaryRatings []*vatsimReference  `json:"military_ratings"`
+}
+
+type feedStatus struct {
+	Data  map[string][]string `json:"data"`
+	Metar []string            `json:"metar"`
+}
+
+type FeedService struct {
+	logger        log.LoggerInterface
+	configManager interfaces.ConfigManagerInterface
+	clientManager fsd.ClientManagerInterface
+	status        *utils.CachedValue[[]byte]
+	vatsimData    *utils.CachedValue[[]byte]
+	whazzup       *utils.CachedValue[[]byte]
+	geoJSON       *utils.CachedValue[[]byte]
+}
+
+func NewFeedService(
+	logger log.LoggerInterface,
+	configManager interfaces.ConfigManagerInterface,
+	clientManager fsd.ClientManagerInterface,
+) *FeedService {
+	service := &FeedService{
+		logger:        log.NewLoggerAdapter(logger, "FeedService"),
+		configManager: configManager,
+		clientManager: clientManager,
+	}
+	cacheDuration := configManager.Config().Server.FSDServer.CacheDuration
+	service.status = utils.NewCachedValue[[]byte](cacheDuration, service.generateStatus)
+	service.vatsimData = utils.NewCachedValue[[]byte](cacheDuration, service.generateVatsimData)
+	service.whazzup = utils.NewCachedValue[[]byte](cacheDuration, service.generateWhazzup)
+	service.geoJSON = utils.NewCachedValue[[]byte](cacheDuration, service.generateGeoJSON)
+	return service
+}
+
+func feedResponse(content *[]byte) *ApiResponse[ResponseGetFeed] {
+	if content == nil {
+		return NewApiResponse[ResponseGetFeed](ErrUnknownServerError, nil)
+	}
+	data := ResponseGetFeed(*content)
+	return NewApiResponse(SuccessGetFeed, &data)
+}
+
+func (service *FeedService) GetFeedStatus() *ApiResponse[ResponseGetFeed] {
+	return feedResponse(service.status.GetValue())
+}
+
+func (service *FeedService) GetVatsimData() *ApiResponse[ResponseGetFeed] {
+	return feedResponse(service.vatsimData.GetValue())
+}
+
+func (service *FeedService) GetWhazzup() *ApiResponse[ResponseGetFeed] {
+	return feedResponse(service.whazzup.GetValue())
+}
+
+func (service *FeedService) GetGeoJSON() *ApiResponse[ResponseGetFeed] {
+	return feedResponse(service.geoJSON.GetValue())
+}
+
+func (service *FeedService) marshal(name string, value any) *[]byte {
+	content, err := json.Marshal(value)
+	if err != nil {
+		service.logger.ErrorF("Fail to marshal %s feed: %v", name, err)
+		return nil
+	}
+	return &content
+}
+
+func (service *FeedService) apiUrl(path string) string {
+	result, _ := url.JoinPath(service.configManager.Config().Server.HttpServer.ServerAddress, "/api", path)
+	return result
+}
+
+func (service *FeedService) serverIdent() string {
+	return strings.ToUpper(strings.ReplaceAll(service.configManager.Config().Server.FSDServer.FSDName, " ", "-"))
+}
+
+func (service *FeedService) serverHost() string {
+	if address, err := url.Parse(service.configManager.Config().Server.HttpServer.ServerAddress); err == nil && address.Hostname() != "" {
+		return address.Hostname()
+	}
+	return service.configManager.Config().Server.FSDServer.Host
+}
+
+// parseLocalTime 在线数据中的时间为服务器本地时间
+func parseLocalTime(value string) time.Time {
+	result, err := time.ParseInLocation(time.DateTime, value, time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return result.UTC()
+}
+
+func formatFrequency(frequency int) string {
+	return fmt.Sprintf("%d.%03d", frequency/1000, frequency%1000)
+}
+
+func padTime(value string) string {
+	if len(value) >= 2 {
+		return value
+	}
+	return strings.Repeat("0", 2-len(value)) + value
+}
+
+// aircraftShort 从"H/B744/L"等格式中提取机型代码
+func aircraftShort(aircraft string) string {
+	for _, part := range strings.Split(aircraft, "/") {
+		if len(part) > 1 {
+			return part
+		}
+	}
+	return aircraft
+}
+
+func isAtisCallsign(callsign string) bool {
+	return strings.HasSuffix(callsign, "_ATIS")
+}
+
+func (service *FeedService) generateStatus() *[]byte {
+	return service.marshal("status", &feedStatus{
+		Data: map[string][]string{
+			"v3":      {service.apiUrl("/feeds/vatsim")},
+			"whazzup": {service.apiUrl("/feeds/whazzup")},
+			"geojson": {service.apiUrl("/feeds/geojson")},
+			"simple":  {service.apiUrl("/clients")},
+		},
+		Metar: []string{service.apiUrl("/metar")},
+	})
+}
+
+func (service *FeedService) generateVatsimData() *[]byte {
+	snapshot := service.clientManager.GetWhazzupContent()
+	updateTime := parseLocalTime(snapshot.General.GenerateTime)
+	lastUpdated := updateTime.Format(time.RFC3339)
+	ident := service.serverIdent()
+
+	data := &vatsimData{
+		General: vatsimGeneral{
+			Version:          3,
+			Reload:           1,
+			Update:           updateTime.Format("20060102150405"),
+			UpdateTimestamp:  lastUpdated,
+			ConnectedClients: snapshot.General.ConnectedClients,
+		},
+		Pilots:      make([]*vatsimPilot, 0, len(snapshot.Pilots)),
+		Controllers: make([]*vatsimController, 0, len(snapshot.Controllers)),
+		Atis:        make([]*vatsimController, 0),
+		Servers: []*vatsimServer{{
+			Ident:                    ident,
+			HostnameOrIp:             service.serverHost(),
+			Name:                     service.configManager.Config().Server.FSDServer.FSDName,
+			ClientsConnectionAllowed: 1,
+			ClientConnectionsAllowed: true,
+		}},
+		Prefiles:        make([]any, 0),
+		Facilities:      make([]*vatsimReference, 0, fsd.CTR.Index()+1),
+		Ratings:         make([]*vatsimReference, 0, len(fsd.Ratings)),
+		PilotRatings:    []*vatsimReference{{Id: 0, ShortName: "NEW", LongName: "Basic Member"}},
+		MilitaryRatings: []*vatsimReference{{Id: 0, ShortName: "M0", LongName: "No Military Rating"}},
+	}
+	for _, facility := range fsd.Facilities[:fsd.CTR.Index()+1] {
+		data.Facilities = append(data.Facilities, &vatsimReference{Id: facility.Id, Short: facility.ShortName, Long: facility.LongName})
+	}
+	for _, rating := range fsd.Ratings {
+		data.Ratings = append(data.Ratings, &vatsimReference{Id: rating.Id, Short: rating.ShortName, Long: rating.LongName})
+	}
+
+	users := make(map[int]struct{})
+	for _, pilot := range snapshot.Pilots {
+		users[pilot.Cid] = struct{}{}
+		// 客户端不上报高度表设定, QNH保持为0
+		item := &vatsimPilot{
+			Cid:         pilot.Cid,
+			Name:        pilot.RealName,
+			Callsign:    pilot.Callsign,
+			Server:      ident,
+			Latitude:    pilot.Latitude,
+			Longitude:   pilot.Longitude,
+			Altitude:    pilot.Altitude,
+			Groundspeed: pilot.GroundSpeed,
+			Transponder: pilot.Transponder,
+			Heading:     pilot.Heading,
+			LogonTime:   parseLocalTime(pilot.LogonTime).Format(time.RFC3339),
+			LastUpdated: lastUpdated,
+		}
+		if plan := pilot.FlightPlan; plan != nil {
+			flightRules := plan.FlightType
+			if len(flightRules) > 1 {
+				flightRules = flightRules[:1]
+			}
+			item.FlightPlan = &vatsimFlightPlan{
+				FlightRules:         flightRules,
+				Aircraft:            plan.AircraftType,
+				AircraftFaa:         plan.AircraftType,
+				AircraftShort:       aircraftShort(plan.AircraftType),
+				Departure:           plan.DepartureAirport,
+				Arrival:             plan.ArrivalAirport,
+				Alternate:           plan.AlternateAirport,
+				CruiseTas:           strconv.Itoa(plan.Tas),
+				Altitude:            plan.CruiseAltitude,
+				Deptime:             fmt.Sprintf("%04d", plan.DepartureTime),
+				EnrouteTime:         padTime(plan.RouteTimeHour) + padTime(plan.RouteTimeMinute),
+				FuelTime:            padTime(plan.FuelTimeHour) + padTime(plan.FuelTimeMinute),
+				Remarks:             plan.Remarks,
+				Route:               plan.Route,
+				AssignedTransponder: pilot.Assigned,
+			}
+		}
+		data.Pilots = append(data.Pilots, item)
+	}
+	for _, controller := range snapshot.Controllers {
+		users[controller.Cid] = struct{}{}
+		item := &vatsimController{
+			Cid:         controller.Cid,
+			Name:        controller.RealName,
+			Callsign:    controller.Callsign,
+			Frequency:   formatFrequency(controller.Frequency),
+			Facility:    controller.Facility,
+			Rating:      controller.Rating,
+			Server:      ident,
+			VisualRange: controller.Range,
+			TextAtis:    controller.AtcInfo,
+			LastUpdated: lastUpdated,
+			LogonTime:   parseLocalTime(controller.LogonTime).Format(time.RFC3339),
+		}
+		if isAtisCallsign(controller.Callsign) {
+			data.Atis = append(data.Atis, item)
+		} else {
+			data.Controllers = append(data.Controllers, item)
+		}
+	}
+	data.General.UniqueUsers = len(users)
+
+	return service.marshal("vatsim", data)
+}
+
+// airportPosition 获取机场坐标, 机场数据不存在时返回空字符串
+func (service *FeedService) airportPosition(icao string) (string, string) {
+	airport := service.configManager.Config().GetAirportData(icao)
+	if airport == nil {
+		return "", ""
+	}
+	return strconv.FormatFloat(airport.Lat, 'f', 5, 64), strconv.FormatFloat(airport.Lon, 'f', 5, 64)
+}
+
+// generateWhazzup 生成传统文本whazzup, 每个客户端一行, 字段顺序见文档
+func (service *FeedService) generateWhazzup() *[]byte {
+	snapshot := service.clientManager.GetWhazzupContent()
+	updateTime := parseLocalTime(snapshot.General.GenerateTime)
+	ident := service.serverIdent()
+
+	builder := strings.Builder{}
+	builder.WriteString("!GENERAL:\r\n")
+	builder.WriteString("VERSION = 8\r\n")
+	builder.WriteString("RELOAD = 1\r\n")
+	builder.WriteString("UPDATE = " + updateTime.Format("20060102150405") + "\r\n")
+	builder.WriteString("CONNECTED CLIENTS = " + strconv.Itoa(snapshot.General.ConnectedClients) + "\r\n")
+	builder.WriteString("CONNECTED SERVERS = 1\r\n")
+	builder.WriteString("!CLIENTS:\r\n")
+
+	writeLine := func(fields []string) {
+		for _, field := range fields {
+			builder.WriteString(whazzupReplacer.Replace(field))
+			builder.WriteByte(':')
+		}
+		builder.WriteString("\r\n")
+	}
+
+	for _, pilot := range snapshot.Pilots {
+		fields := make([]string, 41)
+		fields[0] = pilot.Callsign
+		fields[1] = strconv.Itoa(pilot.Cid)
+		fields[2] = pilot.RealName
+		fields[3] = "PILOT"
+		fields[5] = strconv.FormatFloat(pilot.Latitude, 'f', 5, 64)
+		fields[6] = strconv.FormatFloat(pilot.Longitude, 'f', 5, 64)
+		fields[7] = strconv.Itoa(pilot.Altitude)
+		fields[8] = strconv.Itoa(pilot.GroundSpeed)
+		fields[14] = ident
+		fields[17] = pilot.Transponder
+		fields[37] = parseLocalTime(pilot.LogonTime).Format("20060102150405")
+		fields[38] = strconv.Itoa(pilot.Heading)
+		// 客户端不上报高度表设定, QNH字段留空
+		if plan := pilot.FlightPlan; plan != nil {
+			fields[9] = plan.AircraftType
+			fields[10] = strconv.Itoa(plan.Tas)
+			fields[11] = plan.DepartureAirport
+			fields[12] = plan.CruiseAltitude
+			fields[13] = plan.ArrivalAirport
+			fields[20] = "0"
+			fields[21] = plan.FlightType
+			fields[22] = strconv.Itoa(plan.DepartureTime)
+			fields[23] = strconv.Itoa(plan.AtcDepartureTime)
+			fields[24] = plan.RouteTimeHour
+			fields[25] = plan.RouteTimeMinute
+			fields[26] = plan.FuelTimeHour
+			fields[27] = plan.FuelTimeMinute
+			fields[28] = plan.AlternateAirport
+			fields[29] = plan.Remarks
+			fields[30] = plan.Route
+			fields[31], fields[32] = service.airportPosition(plan.DepartureAirport)
+			fields[33], fields[34] = service.airportPosition(plan.ArrivalAirport)
+		}
+		writeLine(fields)
+	}
+
+	for _, controller := range snapshot.Controllers {
+		fields := make([]string, 41)
+		fields[0] = controller.Callsign
+		fields[1] = strconv.Itoa(controller.Cid)
+		fields[2] = controller.RealName
+		fields[3] = "ATC"
+		fields[4] = formatFrequency(controller.Frequency)
+		fields[5] = strconv.FormatFloat(controller.Latitude, 'f', 5, 64)
+		fields[6] = strconv.FormatFloat(controller.Longitude, 'f', 5, 64)
+		fields[7] = "0"
+		fields[8] = "0"
+		fields[14] = ident
+		fields[16] = strconv.Itoa(controller.Rating)
+		fields[18] = strconv.Itoa(controller.Facility)
+		fields[19] = strconv.Itoa(controller.Range)
+		// ATIS的多行文本使用^§连接
+		fields[35] = strings.Join(controller.AtcInfo, "^§")
+		fields[37] = parseLocalTime(controller.LogonTime).Format("20060102150405")
+		writeLine(fields)
+	}
+
+	builder.WriteString("!SERVERS:\r\n")
+	writeLine([]string{ident, service.serverHost(), "", service.configManager.Config().Server.FSDServer.FSDName, "1"})
+
+	content := []byte(builder.String())
+	return &content
+}
+
+func (service *FeedService) generateGeoJSON() *[]byte {
+	snapshot := service.clientManager.GetWhazzupContent()
+	collection := &geoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: make([]*geoJSONFeature, 0, len(snapshot.Pilots)+len(snapshot.Controllers)),
+	}
+
+	for _, pilot := range snapshot.Pilots {
+		properties := map[string]any{
+			"type":         "pilot",
+			"callsign":     pilot.Callsign,
+			"cid":          pilot.Cid,
+			"name":         pilot.RealName,
+			"altitude":     pilot.Altitude,
+			"ground_speed": pilot.GroundSpeed,
+			"heading":      pilot.Heading,
+			"transponder":  pilot.Transponder,
+			"flight_phase": pilot.FlightPhase,
+			"logon_time":   parseLocalTime(pilot.LogonTime).Format(time.RFC3339),
+		}
+		if plan := pilot.FlightPlan; plan != nil {
+			properties["aircraft"] = plan.AircraftType
+			properties["departure"] = plan.DepartureAirport
+			properties["arrival"] = plan.ArrivalAirport
+			properties["route"] = plan.Route
+		}
+		collection.Features = append(collection.Features, &geoJSONFeature{
+			Type:       "Feature",
+			Geometry:   geoJSONGeometry{Type: "Point", Coordinates: [2]float64{pilot.Longitude, pilot.Latitude}},
+			Properties: properties,
+		})
+	}
+
+	for _, controller := range snapshot.Controllers {
+		clientType := "controller"
+		if isAtisCallsign(controller.Callsign) {
+			clientType = "atis"
+		}
+		collection.Features = append(collection.Features, &geoJSONFeature{
+			Type:     "Feature",
+			Geometry: geoJSONGeometry{Type: "Point", Coordinates: [2]float64{controller.Longitude, controller.Latitude}},
+			Properties: map[string]any{
+				"type":         clientType,
+				"callsign":     controller.Callsign,
+				"cid":          controller.Cid,
+				"name":         controller.RealName,
+				"frequency":    formatFrequency(controller.Frequency),
+				"facility":     controller.Facility,
+				"rating":       controller.Rating,
+				"visual_range": controller.Range,
+				"atis":         controller.AtcInfo,
+				"logon_time":   parseLocalTime(controller.LogonTime).Format(time.RFC3339),
+			},
+		})
+	}
+
+	return service.marshal("geojson", collection)
+}
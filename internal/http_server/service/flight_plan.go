@@ -19,6 +19,7 @@ type FlightPlanService struct {
 	messageQueue        queue.MessageQueueInterface
 	userOperation       operation.UserOperationInterface
 	flightPlanOperation operation.FlightPlanOperationInterface
+	revisionOperation   operation.FlightPlanRevisionOperationInterface
 	auditLogOperation   operation.AuditLogOperationInterface
 }
 
@@ -27,6 +28,7 @@ func NewFlightPlanService(
 	messageQueue queue.MessageQueueInterface,
 	userOperation operation.UserOperationInterface,
 	flightPlanOperation operation.FlightPlanOperationInterface,
+	revisionOperation operation.FlightPlanRevisionOperationInterface,
 	auditLogOperation operation.AuditLogOperationInterface,
 ) *FlightPlanService {
 	return &FlightPlanService{
@@ -34,6 +36,7 @@ func NewFlightPlanService(
 		messageQueue:        messageQueue,
 		userOperation:       userOperation,
 		flightPlanOperation: flightPlanOperation,
+		revisionOperation:   revisionOperation,
 		auditLogOperation:   auditLogOperation,
 	}
 }
@@ -43,6 +46,7 @@ func (flightPlanService *FlightPlanService) SubmitFlightPlan(req *RequestSubmitF
 		return NewApiResponse[ResponseSubmitFlightPlan](ErrIllegalParam, nil)
 	}
 
+	var oldFlightPlan *operation.FlightPlan
 	if flightPlan, err := flightPlanService.flightPlanOperation.GetFlightPlanByCid(req.JwtHeader.Cid); err != nil {
 		if errors.Is(err, operation.ErrFlightPlanNotFound) {
 			req.FlightPlan.ID = 0
@@ -53,6 +57,7 @@ func (flightPlanService *FlightPlanService) SubmitFlightPlan(req *RequestSubmitF
 		if flightPlan.Locked && flightPlan.DepartureAirport == req.DepartureAirport && flightPlan.ArrivalAirport == req.ArrivalAirport {
 			return NewApiResponse[ResponseSubmitFlightPlan](ErrFlightPlanLocked, nil)
 		}
+		oldFlightPlan = flightPlan
 		req.FlightPlan.Locked = false
 		req.FlightPlan.ID = flightPlan.ID
 		req.FlightPlan.CreatedAt = flightPlan.CreatedAt
@@ -67,6 +72,13 @@ func (flightPlanService *FlightPlanService) SubmitFlightPlan(req *RequestSubmitF
 		return res
 	}
 
+	if revision := flightPlanService.revisionOperation.NewRevision(oldFlightPlan, req.FlightPlan, operation.FlightPlanEditByWeb,
+		strconv.Itoa(req.JwtHeader.Cid), req.JwtHeader.Cid); revision != nil {
+		if err := flightPlanService.revisionOperation.SaveRevision(revision); err != nil {
+			flightPlanService.logger.ErrorF("Fail to save flight plan revision of %d: %v", req.JwtHeader.Cid, err)
+		}
+	}
+
 	flightPlanService.messageQueue.Publish(&queue.Message{
 		Type: queue.FlushFlightPlan,
 		Data: &fsd.FlushFlightPlan{
@@ -267,3 +279,31 @@ func (flightPlanService *FlightPlanService) LockFlightPlan(req *RequestLockFligh
 	data := ResponseLockFlightPlan(true)
 	return NewApiResponse(SuccessLockFlightPlan, &data)
 }
+
+func (flightPlanService *FlightPlanService) GetFlightPlanRevisions(req *RequestGetFlightPlanRevisions) *ApiResponse[ResponseGetFlightPlanRevisions] {
+	if req.Page <= 0 || req.PageSize <= 0 || req.TargetCid < 0 {
+		return NewApiResponse[ResponseGetFlightPlanRevisions](ErrIllegalParam, nil)
+	}
+
+	if req.TargetCid == 0 {
+		req.TargetCid = req.Cid
+	}
+
+	if req.TargetCid != req.Cid {
+		if res := CheckPermission[ResponseGetFlightPlanRevisions](req.Permission, operation.FlightPlanShowList); res != nil {
+			return res
+		}
+	}
+
+	revisions, total, err := flightPlanService.revisionOperation.GetRevisions(req.TargetCid, req.Page, req.PageSize)
+	if res := CheckDatabaseError[ResponseGetFlightPlanRevisions](err); res != nil {
+		return res
+	}
+
+	return NewApiResponse(SuccessGetRevisions, &ResponseGetFlightPlanRevisions{
+		Items:    revisions,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	})
+}
@@ -0,0 +1,90 @@
+// Package service
+package service
+
+import (
+	"fmt"
+
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/http/service"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+)
+
+type FlightTrackService struct {
+	logger         log.LoggerInterface
+	trackOperation operation.FlightTrackOperationInterface
+}
+
+func NewFlightTrackService(
+	logger log.LoggerInterface,
+	trackOperation operation.FlightTrackOperationInterface,
+) *FlightTrackService {
+	return &FlightTrackService{
+		logger:         log.NewLoggerAdapter(logger, "FlightTrackService"),
+		trackOperation: trackOperation,
+	}
+}
+
+func (service *FlightTrackService) GetFlightTracks(req *RequestGetFlightTracks) *ApiResponse[ResponseGetFlightTracks] {
+	if req.Page <= 0 || req.PageSize <= 0 {
+		return NewApiResponse[ResponseGetFlightTracks](ErrIllegalParam, nil)
+	}
+
+	tracks, total, err := service.trackOperation.GetFlightTracks(req.Cid, req.Page, req.PageSize)
+	if res := CheckDatabaseError[ResponseGetFlightTracks](err); res != nil {
+		return res
+	}
+
+	data := ResponseGetFlightTracks(&PageResponse[*operation.FlightTrack]{
+		Items:    tracks,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		Total:    total,
+	})
+	return NewApiResponse(SuccessGetFlightTracks, &data)
+}
+
+func (service *FlightTrackService) ExportFlightTrack(req *RequestExportFlightTrack) *ApiResponse[ResponseExportFlightTrack] {
+	if req.Format == "" {
+		req.Format = TrackFormatGeoJSON
+	}
+	if req.TrackId == 0 || !IsValidTrackFormat(req.Format) {
+		return NewApiResponse[ResponseExportFlightTrack](ErrIllegalParam, nil)
+	}
+
+	track, res := CallDBFunc[*operation.FlightTrack, ResponseExportFlightTrack](func() (*operation.FlightTrack, error) {
+		return service.trackOperation.GetFlightTrack(req.TrackId)
+	})
+	if res != nil {
+		return res
+	}
+
+	// 只能导出自己的航迹
+	if track.Cid != req.Cid {
+		return NewApiResponse[ResponseExportFlightTrack](ErrFlightTrackNotFound, nil)
+	}
+
+	points, err := operation.DecodeTrackPoints(track.Data)
+	if res := CheckDatabaseError[ResponseExportFlightTrack](err); res != nil {
+		service.logger.ErrorF("Fail to decode flight track %d: %v", track.ID, err)
+		return res
+	}
+
+	data := &ResponseExportFlightTrack{
+		FileName: fmt.Sprintf("%s_%s.%s", track.Callsign, track.StartTime.UTC().Format("20060102-1504"), req.Format),
+	}
+	switch req.Format {
+	case TrackFormatKML:
+		data.ContentType = "application/vnd.google-earth.kml+xml"
+		data.Content = exportKML(track, points)
+	case TrackFormatGPX:
+		data.ContentType = "application/gpx+xml"
+		data.Content = exportGPX(track, points)
+	case TrackFormatGeoJSON:
+		data.ContentType = "application/geo+json"
+		data.Content, err = exportGeoJSON(track, points)
+		if err != nil {
+			return NewApiResponse[ResponseExportFlightTrack](ErrUnknownServerError, nil)
+		}
+	}
+	return NewApiResponse(SuccessExportTrack, data)
+}
@@ -0,0 +1,203 @@
+// Package service
+// 存放 HelpRequestServiceInterface 的实现
+package service
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces"
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/http/service"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/queue"
+)
+
+// maxStatisticsDays 统计接口允许查询的最大天数
+const maxStatisticsDays = 365
+
+type HelpRequestService struct {
+	logger               log.LoggerInterface
+	messageQueue         queue.MessageQueueInterface
+	helpRequestManager   interfaces.HelpRequestManagerInterface
+	helpRequestOperation operation.HelpRequestOperationInterface
+	auditLogOperation    operation.AuditLogOperationInterface
+}
+
+func NewHelpRequestService(
+	logger log.LoggerInterface,
+	messageQueue queue.MessageQueueInterface,
+	helpRequestManager interfaces.HelpRequestManagerInterface,
+	helpRequestOperation operation.HelpRequestOperationInterface,
+	auditLogOperation operation.AuditLogOperationInterface,
+) *HelpRequestService {
+	return &HelpRequestService{
+		logger:               log.NewLoggerAdapter(logger, "HelpRequestService"),
+		messageQueue:         messageQueue,
+		helpRequestManager:   helpRequestManager,
+		helpRequestOperation: helpRequestOperation,
+		auditLogOperation:    auditLogOperation,
+	}
+}
+
+func (helpRequestService *HelpRequestService) GetHelpRequests(req *RequestGetHelpRequests) *ApiResponse[ResponseGetHelpRequests] {
+	if req.Page <= 0 || req.PageSize <= 0 {
+		return NewApiResponse[ResponseGetHelpRequests](ErrIllegalParam, nil)
+	}
+
+	status := -1
+	if req.Status != "" {
+		requestStatus, ok := operation.ParseHelpRequestStatus(req.Status)
+		if !ok {
+			return NewApiResponse[ResponseGetHelpRequests](ErrIllegalParam, nil)
+		}
+		status = int(requestStatus)
+	}
+
+	if res := CheckPermission[ResponseGetHelpRequests](req.Permission, operation.HelpRequestShowList); res != nil {
+		return res
+	}
+
+	records, total, err := helpRequestService.helpRequestOperation.GetHelpRequests(req.Page, req.PageSize, status)
+	if res := CheckDatabaseError[ResponseGetHelpRequests](err); res != nil {
+		return res
+	}
+
+	return NewApiResponse(SuccessGetHelpRequests, &ResponseGetHelpRequests{
+		Items:    records,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	})
+}
+
+func (helpRequestService *HelpRequestService) ClaimHelpRequest(req *RequestClaimHelpRequest) *ApiResponse[ResponseClaimHelpRequest] {
+	if req.RequestId <= 0 {
+		return NewApiResponse[ResponseClaimHelpRequest](ErrIllegalParam, nil)
+	}
+
+	if res := CheckPermission[ResponseClaimHelpRequest](req.Permission, operation.HelpRequestHandle); res != nil {
+		return res
+	}
+
+	request, res := CallDBFunc[*operation.HelpRequest, ResponseClaimHelpRequest](func() (*operation.HelpRequest, error) {
+		return helpRequestService.helpRequestManager.Claim(req.RequestId, req.Cid, "")
+	})
+	if res != nil {
+		return res
+	}
+
+	helpRequestService.messageQueue.Publish(&queue.Message{
+		Type: queue.AuditLog,
+		Data: helpRequestService.auditLogOperation.NewAuditLog(
+			operation.HelpRequestClaimed,
+			req.Cid,
+			fmt.Sprintf("%d(%s)", request.ID, request.Callsign),
+			req.Ip,
+			req.UserAgent,
+			nil,
+		),
+	})
+
+	data := ResponseClaimHelpRequest(true)
+	return NewApiResponse(SuccessClaimHelpRequest, &data)
+}
+
+func (helpRequestService *HelpRequestService) ResolveHelpRequest(req *RequestResolveHelpRequest) *ApiResponse[ResponseResolveHelpRequest] {
+	if req.RequestId <= 0 {
+		return NewApiResponse[ResponseResolveHelpRequest](ErrIllegalParam, nil)
+	}
+
+	if res := CheckPermission[ResponseResolveHelpRequest](req.Permission, operation.HelpRequestHandle); res != nil {
+		return res
+	}
+
+	request, res := CallDBFunc[*operation.HelpRequest, ResponseResolveHelpRequest](func() (*operation.HelpRequest, error) {
+		return helpRequestService.helpRequestManager.Resolve(req.RequestId, req.Cid, "", req.Resolution)
+	})
+	if res != nil {
+		return res
+	}
+
+	helpRequestService.messageQueue.Publish(&queue.Message{
+		Type: queue.AuditLog,
+		Data: helpRequestService.auditLogOperation.NewAuditLog(
+			operation.HelpRequestResolved,
+			req.Cid,
+			fmt.Sprintf("%d(%s)", request.ID, request.Callsign),
+			req.Ip,
+			req.UserAgent,
+			&operation.ChangeDetail{
+				OldValue: operation.ValueNotAvailable,
+				NewValue: req.Resolution,
+			},
+		),
+	})
+
+	data := ResponseResolveHelpRequest(true)
+	return NewApiResponse(SuccessResolveHelpRequest, &data)
+}
+
+func (helpRequestService *HelpRequestService) GetHelpRequestStatistics(req *RequestGetHelpRequestStatistics) *ApiResponse[ResponseGetHelpRequestStatistics] {
+	if req.Days <= 0 || req.Days > maxStatisticsDays {
+		return NewApiResponse[ResponseGetHelpRequestStatistics](ErrIllegalParam, nil)
+	}
+
+	if res := CheckPermission[ResponseGetHelpRequestStatistics](req.Permission, operation.HelpRequestShowList); res != nil {
+		return res
+	}
+
+	requests, res := CallDBFunc[[]*operation.HelpRequest, ResponseGetHelpRequestStatistics](func() ([]*operation.HelpRequest, error) {
+		return helpRequestService.helpRequestOperation.GetHelpRequestsSince(time.Now().AddDate(0, 0, -req.Days))
+	})
+	if res != nil {
+		return res
+	}
+
+	data := &ResponseGetHelpRequestStatistics{Days: req.Days, Total: len(requests)}
+	claimTimes := make([]float64, 0, len(requests))
+	resolveTimes := make([]float64, 0, len(requests))
+	for _, request := range requests {
+		switch operation.HelpRequestStatus(request.Status) {
+		case operation.HelpRequestStatusPending:
+			data.Pending++
+		case operation.HelpRequestStatusClaimed:
+			data.Claimed++
+		case operation.HelpRequestStatusResolved:
+			data.Resolved++
+		}
+		if request.Escalated {
+			data.Escalated++
+		}
+		if request.ClaimedAt != nil {
+			claimTimes = append(claimTimes, request.ClaimedAt.Sub(request.CreatedAt).Seconds())
+		}
+		if request.ResolvedAt != nil {
+			resolveTimes = append(resolveTimes, request.ResolvedAt.Sub(request.CreatedAt).Seconds())
+		}
+	}
+	data.AverageClaimTime, data.MedianClaimTime = averageAndMedian(claimTimes)
+	data.AverageResolveTime, data.MedianResolveTime = averageAndMedian(resolveTimes)
+
+	return NewApiResponse(SuccessGetHelpRequestStat, data)
+}
+
+func averageAndMedian(values []float64) (average float64, median float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	slices.Sort(values)
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	average = sum / float64(len(values))
+	middle := len(values) / 2
+	if len(values)%2 == 0 {
+		median = (values[middle-1] + values[middle]) / 2
+	} else {
+		median = values[middle]
+	}
+	return
+}
@@ -0,0 +1,42 @@
+// Package service
+package service
+
+import (
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/http/service"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+)
+
+type LogbookService struct {
+	logger           log.LoggerInterface
+	logbookOperation operation.LogbookOperationInterface
+}
+
+func NewLogbookService(
+	logger log.LoggerInterface,
+	logbookOperation operation.LogbookOperationInterface,
+) *LogbookService {
+	return &LogbookService{
+		logger:           log.NewLoggerAdapter(logger, "LogbookService"),
+		logbookOperation: logbookOperation,
+	}
+}
+
+func (service *LogbookService) GetLogbook(req *RequestGetLogbook) *ApiResponse[ResponseGetLogbook] {
+	if req.Page <= 0 || req.PageSize <= 0 {
+		return NewApiResponse[ResponseGetLogbook](ErrIllegalParam, nil)
+	}
+
+	entries, total, err := service.logbookOperation.GetLogbookEntries(req.Cid, req.Page, req.PageSize)
+	if res := CheckDatabaseError[ResponseGetLogbook](err); res != nil {
+		return res
+	}
+
+	data := ResponseGetLogbook(&PageResponse[*operation.LogbookEntry]{
+		Items:    entries,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		Total:    total,
+	})
+	return NewApiResponse(SuccessGetLogbook, &data)
+}
@@ -0,0 +1,82 @@
+// Package service
+package service
+
+import (
+	"encoding/json"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+	. "github.com/half-nothing/simple-fsd/internal/interfaces/http/service"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+)
+
+type geoJSONFeatureCollection struct {
+	Type     string            `json:"type"`
+	Features []*geoJSONFeature `json:"features"`
+}
+
+type SectorService struct {
+	logger        log.LoggerInterface
+	sectorManager fsd.SectorManagerInterface
+}
+
+func NewSectorService(logger log.LoggerInterface, sectorManager fsd.SectorManagerInterface) *SectorService {
+	return &SectorService{
+		logger:        log.NewLoggerAdapter(logger, "SectorService"),
+		sectorManager: sectorManager,
+	}
+}
+
+func (service *SectorService) GetSectors() *ApiResponse[ResponseGetSectors] {
+	if !service.sectorManager.Enabled() {
+		return NewApiResponse[ResponseGetSectors](ErrSectorNotEnabled, nil)
+	}
+	data := ResponseGetSectors(service.sectorManager.GetSectors())
+	return NewApiResponse(SuccessGetSectors, &data)
+}
+
+func (service *SectorService) GetAircraftOwnership() *ApiResponse[ResponseGetAircraftOwnership] {
+	if !service.sectorManager.Enabled() {
+		return NewApiResponse[ResponseGetAircraftOwnership](ErrSectorNotEnabled, nil)
+	}
+	data := ResponseGetAircraftOwnership(service.sectorManager.GetAircraftOwnership())
+	return NewApiResponse(SuccessGetAircraftOwnership, &data)
+}
+
+func (service *SectorService) GetSectorCoverage() *ApiResponse[ResponseGetSectorCoverage] {
+	if !service.sectorManager.Enabled() {
+		return NewApiResponse[ResponseGetSectorCoverage](ErrSectorNotEnabled, nil)
+	}
+
+	sectors := service.sectorManager.GetSectors()
+	collection := &geoJSONFeatureCollection{Type: "FeatureCollection", Features: make([]*geoJSONFeature, 0, len(sectors))}
+	for _, sector := range sectors {
+		// GeoJSON坐标顺序为[经度, 纬度], 且多边形需要首尾闭合
+		ring := make([][2]float64, 0, len(sector.Polygon)+1)
+		for _, point := range sector.Polygon {
+			ring = append(ring, [2]float64{point[1], point[0]})
+		}
+		if ring[0] != ring[len(ring)-1] {
+			ring = append(ring, ring[0])
+		}
+		collection.Features = append(collection.Features, &geoJSONFeature{
+			Type:     "Feature",
+			Geometry: geoJSONGeometry{Type: "Polygon", Coordinates: [][][2]float64{ring}},
+			Properties: map[string]any{
+				"name":       sector.Name,
+				"floor":      sector.Floor,
+				"ceiling":    sector.Ceiling,
+				"owners":     sector.Owners,
+				"controller": sector.Controller,
+				"staffed":    sector.Controller != "",
+			},
+		})
+	}
+
+	content, err := json.Marshal(collection)
+	if err != nil {
+		service.logger.ErrorF("Fail to marshal sector coverage: %v", err)
+		return NewApiResponse[ResponseGetSectorCoverage](ErrUnknownServerError, nil)
+	}
+	data := ResponseGetSectorCoverage(content)
+	return NewApiResponse(SuccessGetSectorCoverage, &data)
+}
@@ -3,20 +3,27 @@
 package service
 
 import (
+	"strings"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces"
 	"github.com/half-nothing/simple-fsd/internal/interfaces/config"
 	"github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
 	. "github.com/half-nothing/simple-fsd/internal/interfaces/http/service"
 	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
 	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/queue"
 	"github.com/half-nothing/simple-fsd/internal/utils"
 )
 
 type ServerService struct {
 	logger              log.LoggerInterface
 	config              *config.ServerConfig
+	configManager       interfaces.ConfigManagerInterface
+	messageQueue        queue.MessageQueueInterface
 	userOperation       operation.UserOperationInterface
 	controllerOperation operation.ControllerOperationInterface
 	activityOperation   operation.ActivityOperationInterface
+	auditLogOperation   operation.AuditLogOperationInterface
 	serverConfig        *utils.CachedValue[ResponseGetServerConfig]
 	serverInfo          *utils.CachedValue[ResponseGetServerInfo]
 	serverOnlineTime    *utils.CachedValue[ResponseGetTimeRating]
@@ -24,17 +31,23 @@ type ServerService struct {
 
 func NewServerService(
 	logger log.LoggerInterface,
-	config *config.ServerConfig,
+	configManager interfaces.ConfigManagerInterface,
+	messageQueue queue.MessageQueueInterface,
 	userOperation operation.UserOperationInterface,
 	controllerOperation operation.ControllerOperationInterface,
 	activityOperation operation.ActivityOperationInterface,
+	auditLogOperation operation.AuditLogOperationInterface,
 ) *ServerService {
+	config := configManager.Config().Server
 	service := &ServerService{
 		logger:              log.NewLoggerAdapter(logger, "ServerService"),
 		config:              config,
+		configManager:       configManager,
+		messageQueue:        messageQueue,
 		userOperation:       userOperation,
 		controllerOperation: controllerOperation,
 		activityOperation:   activityOperation,
+		auditLogOperation:   auditLogOperation,
 	}
 	service.serverConfig = utils.NewCachedValue[ResponseGetServerConfig](0, func() *ResponseGetServerConfig { return service.getServerConfig() })
 	service.serverInfo = utils.NewCachedValue[ResponseGetServerInfo](config.FSDServer.CacheDuration, func() *ResponseGetServerInfo { return service.getServerInfo() })
@@ -119,3 +132,45 @@ func (serverService *ServerService) GetServerInfo() *ApiResponse[ResponseGetServ
 func (serverService *ServerService) GetTimeRating() *ApiResponse[ResponseGetTimeRating] {
 	return NewApiResponse(SuccessGetTimeRating, serverService.serverOnlineTime.GetValue())
 }
+
+func (serverService *ServerService) ReloadConfig(req *RequestReloadConfig) *ApiResponse[ResponseReloadConfig] {
+	if req.Uid <= 0 {
+		return NewApiResponse[ResponseReloadConfig](ErrIllegalParam, nil)
+	}
+
+	if _, res := CheckPermissionFromDatabase[ResponseReloadConfig](serverService.userOperation, req.Uid, operation.ServerConfigReload); res != nil {
+		return res
+	}
+
+	result, err := serverService.configManager.ReloadConfig()
+	if err != nil {
+		serverService.logger.ErrorF("Fail to reload configuration: %v", err)
+		return NewApiResponse[ResponseReloadConfig](ErrReloadConfig, nil)
+	}
+
+	if err := serverService.messageQueue.SyncPublish(&queue.Message{
+		Type: queue.ConfigReloaded,
+		Data: serverService.configManager.Config(),
+	}); err != nil {
+		serverService.logger.ErrorF("Fail to apply reloaded configuration: %v", err)
+		return NewApiResponse[ResponseReloadConfig](ErrApplyConfig, nil)
+	}
+
+	serverService.messageQueue.Publish(&queue.Message{
+		Type: queue.AuditLog,
+		Data: serverService.auditLogOperation.NewAuditLog(
+			operation.ServerConfigReloaded,
+			req.Cid,
+			"config",
+			req.Ip,
+			req.UserAgent,
+			&operation.ChangeDetail{
+				OldValue: operation.ValueNotAvailable,
+				NewValue: strings.Join(result.Applied, ","),
+			},
+		),
+	})
+
+	data := ResponseReloadConfig(*result)
+	return NewApiResponse(SuccessReloadConfig, &data)
+}
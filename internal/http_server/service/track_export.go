@@ -0,0 +1,131 @@
+// Package service
+package service
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+)
+
+// feetToMeter 航迹高度单位为英尺, 导出格式统一使用米
+const feetToMeter = 0.3048
+
+func trackName(track *operation.FlightTrack) string {
+	if track.DepartureAirport == "" || track.ArrivalAirport == "" {
+		return track.Callsign
+	}
+	return fmt.Sprintf("%s %s-%s", track.Callsign, track.DepartureAirport, track.ArrivalAirport)
+}
+
+type kmlDocument struct {
+	XMLName   xml.Name     `xml:"kml"`
+	Namespace string       `xml:"xmlns,attr"`
+	Name      string       `xml:"Document>name"`
+	Placemark kmlPlacemark `xml:"Document>Placemark"`
+}
+
+type kmlPlacemark struct {
+	Name         string `xml:"name"`
+	Description  string `xml:"description"`
+	Extrude      int    `xml:"LineString>extrude"`
+	AltitudeMode string `xml:"LineString>altitudeMode"`
+	Coordinates  string `xml:"LineString>coordinates"`
+}
+
+func exportKML(track *operation.FlightTrack, points []*operation.TrackPoint) []byte {
+	coordinates := strings.Builder{}
+	for i, point := range points {
+		if i > 0 {
+			coordinates.WriteByte(' ')
+		}
+		_, _ = fmt.Fprintf(&coordinates, "%.5f,%.5f,%.0f", point.Longitude, point.Latitude, float64(point.Altitude)*feetToMeter)
+	}
+	name := trackName(track)
+	document := &kmlDocument{
+		Namespace: "http://www.opengis.net/kml/2.2",
+		Name:      name,
+		Placemark: kmlPlacemark{
+			Name: name,
+			Description: fmt.Sprintf("%s - %s", track.StartTime.UTC().Format(time.RFC3339),
+				track.EndTime.UTC().Format(time.RFC3339)),
+			Extrude:      0,
+			AltitudeMode: "absolute",
+			Coordinates:  coordinates.String(),
+		},
+	}
+	data, _ := xml.MarshalIndent(document, "", "  ")
+	return append([]byte(xml.Header), data...)
+}
+
+type gpxDocument struct {
+	XMLName   xml.Name   `xml:"gpx"`
+	Namespace string     `xml:"xmlns,attr"`
+	Version   string     `xml:"version,attr"`
+	Creator   string     `xml:"creator,attr"`
+	Name      string     `xml:"trk>name"`
+	Points    []gpxPoint `xml:"trk>trkseg>trkpt"`
+}
+
+type gpxPoint struct {
+	Latitude  string `xml:"lat,attr"`
+	Longitude string `xml:"lon,attr"`
+	Elevation string `xml:"ele"`
+	Time      string `xml:"time"`
+}
+
+func exportGPX(track *operation.FlightTrack, points []*operation.TrackPoint) []byte {
+	document := &gpxDocument{
+		Namespace: "http://www.topografix.com/GPX/1/1",
+		Version:   "1.1",
+		Creator:   "simple-fsd",
+		Name:      trackName(track),
+		Points:    make([]gpxPoint, 0, len(points)),
+	}
+	for _, point := range points {
+		document.Points = append(document.Points, gpxPoint{
+			Latitude:  fmt.Sprintf("%.5f", point.Latitude),
+			Longitude: fmt.Sprintf("%.5f", point.Longitude),
+			Elevation: fmt.Sprintf("%.0f", float64(point.Altitude)*feetToMeter),
+			Time:      point.Time.UTC().Format(time.RFC3339),
+		})
+	}
+	data, _ := xml.MarshalIndent(document, "", "  ")
+	return append([]byte(xml.Header), data...)
+}
+
+type geoJSONFeature struct {
+	Type       string          `json:"type"`
+	Geometry   geoJSONGeometry `json:"geometry"`
+	Properties map[string]any  `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string `json:"type"`
+	Coordinates any    `json:"coordinates"`
+}
+
+func exportGeoJSON(track *operation.FlightTrack, points []*operation.TrackPoint) ([]byte, error) {
+	coordinates := make([][3]float64, 0, len(points))
+	times := make([]string, 0, len(points))
+	for _, point := range points {
+		coordinates = append(coordinates, [3]float64{point.Longitude, point.Latitude, float64(point.Altitude) * feetToMeter})
+		times = append(times, point.Time.UTC().Format(time.RFC3339))
+	}
+	return json.Marshal(&geoJSONFeature{
+		Type:     "Feature",
+		Geometry: geoJSONGeometry{Type: "LineString", Coordinates: coordinates},
+		Properties: map[string]any{
+			"name":       trackName(track),
+			"callsign":   track.Callsign,
+			"departure":  track.DepartureAirport,
+			"arrival":    track.ArrivalAirport,
+			"start_time": track.StartTime.UTC().Format(time.RFC3339),
+			"end_time":   track.EndTime.UTC().Format(time.RFC3339),
+			"times":      times,
+		},
+	})
+}
@@ -28,6 +28,7 @@ type UserService struct {
 	historyOperation  operation.HistoryOperationInterface
 	storeService      StoreServiceInterface
 	auditLogOperation operation.AuditLogOperationInterface
+	banManager        interfaces.BanManagerInterface
 }
 
 func NewUserService(
@@ -39,6 +40,7 @@ func NewUserService(
 	auditLogOperation operation.AuditLogOperationInterface,
 	storeService StoreServiceInterface,
 	emailService EmailServiceInterface,
+	banManager interfaces.BanManagerInterface,
 ) *UserService {
 	return &UserService{
 		logger:            log.NewLoggerAdapter(logger, "UserService"),
@@ -49,6 +51,7 @@ func NewUserService(
 		historyOperation:  historyOperation,
 		storeService:      storeService,
 		auditLogOperation: auditLogOperation,
+		banManager:        banManager,
 	}
 }
 
@@ -115,6 +118,10 @@ func (userService *UserService) UserLogin(req *RequestUserLogin) *ApiResponse[Re
 		return NewApiResponse[ResponseUserLogin](ErrAccountSuspended, nil)
 	}
 
+	if ban := userService.banManager.Check(user.Cid, req.Ip, operation.BanScopeWeb); ban != nil {
+		return NewApiResponse[ResponseUserLogin](ErrAccountSuspended, nil)
+	}
+
 	if pass := userService.userOperation.VerifyUserPassword(user, req.Password); !pass {
 		return NewApiResponse[ResponseUserLogin](ErrWrongUsernameOrPassword, nil)
 	}
@@ -567,5 +574,9 @@ func (userService *UserService) UserFsdLogin(req *RequestFsdLogin) *ResponseFsdL
 		return &ResponseFsdLogin{Success: false, ErrMsg: "Password is Incorrect"}
 	}
 
+	if ban := userService.banManager.Check(user.Cid, req.Ip, operation.BanScopeFsd); ban != nil {
+		return &ResponseFsdLogin{Success: false, ErrMsg: "You are banned, reason: " + ban.Reason}
+	}
+
 	return &ResponseFsdLogin{Success: true, Token: NewFsdClaims(userService.config.JWT, user).GenerateKey()}
 }
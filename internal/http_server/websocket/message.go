@@ -16,3 +16,10 @@ type ReceiveMessage struct {
 	From string `json:"from"`
 	Data string `json:"data"`
 }
+
+// TrafficSubscription 实时交通流订阅条件, 范围与呼号列表满足其一即推送, 都为空时推送全部客户端
+type TrafficSubscription struct {
+	BoundingBox *[4]float64 `json:"bbox"`      // [最小纬度, 最小经度, 最大纬度, 最大经度]
+	Callsigns   []string    `json:"callsigns"` // 关注的呼号列表
+	Interval    int         `json:"interval"`  // 位置事件推送间隔, 单位毫秒, 不能小于服务器设置的最小间隔
+}
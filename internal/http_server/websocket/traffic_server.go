@@ -0,0 +1,103 @@
+// Package websocket
+// File traffic_server.go
+package websocket
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/config"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/global"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	"github.com/labstack/echo/v4"
+)
+
+// TrafficStreamServer 实时交通流, 订阅者连接后发送订阅条件即可收到增量事件
+type TrafficStreamServer struct {
+	logger        log.LoggerInterface
+	clientManager fsd.ClientManagerInterface
+	subscribers   map[*TrafficSubscriber]struct{}
+	ctx           context.Context
+	cancel        context.CancelFunc
+	lock          sync.Mutex
+	upgrader      *websocket.Upgrader
+}
+
+func NewTrafficStreamServer(
+	logger log.LoggerInterface,
+	clientManager fsd.ClientManagerInterface,
+	config *config.HttpServerConfig,
+) *TrafficStreamServer {
+	server := &TrafficStreamServer{
+		logger:        log.NewLoggerAdapter(logger, "TrafficStreamServer"),
+		clientManager: clientManager,
+		subscribers:   make(map[*TrafficSubscriber]struct{}),
+		upgrader:      newUpgrader(config),
+	}
+	server.ctx, server.cancel = context.WithCancel(context.Background())
+	return server
+}
+
+func (server *TrafficStreamServer) Close(ctx context.Context) error {
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	server.cancel()
+	done := make(chan struct{})
+	go func() {
+		server.logger.Info("Closing traffic stream server")
+		server.lock.Lock()
+		subscribers := make([]*TrafficSubscriber, 0, len(server.subscribers))
+		for subscriber := range server.subscribers {
+			subscribers = append(subscribers, subscriber)
+		}
+		server.lock.Unlock()
+		wg := sync.WaitGroup{}
+		for _, subscriber := range subscribers {
+			wg.Add(1)
+			go func(subscriber *TrafficSubscriber) {
+				defer wg.Done()
+				subscriber.Disconnect()
+			}(subscriber)
+		}
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-timeoutCtx.Done():
+		return timeoutCtx.Err()
+	}
+}
+
+func (server *TrafficStreamServer) ConnectToTraffic(c echo.Context) error {
+	conn, err := server.upgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		return err
+	}
+	subscriber := NewTrafficSubscriber(
+		server.logger,
+		conn,
+		server.clientManager,
+		server.ctx,
+		*global.WebsocketTimeout,
+		*global.WebsocketHeartbeatInterval,
+		*global.TrafficStreamInterval,
+		*global.WebsocketMessageChannelSize,
+		server.removeSubscriber,
+	)
+	server.lock.Lock()
+	server.subscribers[subscriber] = struct{}{}
+	server.lock.Unlock()
+	return nil
+}
+
+func (server *TrafficStreamServer) removeSubscriber(subscriber *TrafficSubscriber) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+	delete(server.subscribers, subscriber)
+}
@@ -0,0 +1,324 @@
+// Package websocket
+// File traffic_subscriber.go
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+)
+
+// trafficFilter 订阅条件
+type trafficFilter struct {
+	boundingBox *[4]float64
+	callsigns   map[string]struct{}
+}
+
+func newTrafficFilter(subscription *TrafficSubscription) *trafficFilter {
+	filter := &trafficFilter{boundingBox: subscription.BoundingBox, callsigns: make(map[string]struct{})}
+	for _, callsign := range subscription.Callsigns {
+		filter.callsigns[strings.ToUpper(callsign)] = struct{}{}
+	}
+	return filter
+}
+
+func (filter *trafficFilter) matchAll() bool {
+	return filter.boundingBox == nil && len(filter.callsigns) == 0
+}
+
+func (filter *trafficFilter) matchCallsign(callsign string) bool {
+	_, ok := filter.callsigns[callsign]
+	return ok
+}
+
+func (filter *trafficFilter) matchPosition(latitude float64, longitude float64) bool {
+	if filter.boundingBox == nil {
+		return false
+	}
+	box := filter.boundingBox
+	return latitude >= box[0] && latitude <= box[2] && longitude >= box[1] && longitude <= box[3]
+}
+
+func (filter *trafficFilter) match(callsign string, latitude float64, longitude float64) bool {
+	return filter.matchAll() || filter.matchCallsign(callsign) || filter.matchPosition(latitude, longitude)
+}
+
+// TrafficSubscriber 实时交通流订阅者
+// 位置事件按订阅的间隔合并推送, 每个客户端只推送间隔内的最后一次位置, 其余事件立即推送
+type TrafficSubscriber struct {
+	logger          log.LoggerInterface
+	conn            *websocket.Conn
+	clientManager   fsd.ClientManagerInterface
+	ctx             context.Context
+	cancelFunc      context.CancelFunc
+	timeoutDuration time.Duration
+	minInterval     time.Duration
+	heartbeatTicker *time.Ticker
+	flushTicker     *time.Ticker
+	events          chan *fsd.TrafficEvent
+	unsubscribe     func()
+	afterDisconnect func(subscriber *TrafficSubscriber)
+	wg              sync.WaitGroup
+	disconnected    atomic.Bool
+
+	lock    sync.Mutex
+	filter  *trafficFilter
+	known   map[string]struct{}
+	pending map[string]*fsd.TrafficEvent
+}
+
+func NewTrafficSubscriber(
+	logger log.LoggerInterface,
+	conn *websocket.Conn,
+	clientManager fsd.ClientManagerInterface,
+	ctx context.Context,
+	timeoutDuration time.Duration,
+	heartbeatDuration time.Duration,
+	minInterval time.Duration,
+	channelSize int,
+	afterDisconnect func(subscriber *TrafficSubscriber),
+) *TrafficSubscriber {
+	subscriber := &TrafficSubscriber{
+		logger:          log.NewLoggerAdapter(logger, "TrafficSubscriber("+conn.RemoteAddr().String()+")"),
+		conn:            conn,
+		clientManager:   clientManager,
+		timeoutDuration: timeoutDuration,
+		minInterval:     minInterval,
+		heartbeatTicker: time.NewTicker(heartbeatDuration),
+		flushTicker:     time.NewTicker(minInterval),
+		events:          make(chan *fsd.TrafficEvent, channelSize),
+		afterDisconnect: afterDisconnect,
+		filter:          newTrafficFilter(&TrafficSubscription{}),
+		known:           make(map[string]struct{}),
+		pending:         make(map[string]*fsd.TrafficEvent),
+	}
+	subscriber.initializeConnection()
+	subscriber.ctx, subscriber.cancelFunc = context.WithCancel(ctx)
+	subscriber.unsubscribe = clientManager.TrafficHub().Subscribe(subscriber.handleEvent)
+	subscriber.wg.Add(2)
+	go subscriber.receiveHandler()
+	go subscriber.sendHandler()
+	subscriber.subscribe(&TrafficSubscription{})
+	return subscriber
+}
+
+func (subscriber *TrafficSubscriber) initializeConnection() {
+	subscriber.conn.SetPongHandler(func(appData string) error {
+		return subscriber.resetReadDeadline()
+	})
+
+	subscriber.conn.SetCloseHandler(func(code int, text string) error {
+		subscriber.logger.InfoF("connection closed by client: %d %s", code, text)
+		go subscriber.Disconnect()
+		return nil
+	})
+
+	_ = subscriber.resetReadDeadline()
+}
+
+func (subscriber *TrafficSubscriber) resetReadDeadline() error {
+	return subscriber.conn.SetReadDeadline(time.Now().Add(subscriber.timeoutDuration))
+}
+
+func (subscriber *TrafficSubscriber) Disconnect() {
+	if !subscriber.disconnected.CompareAndSwap(false, true) {
+		return
+	}
+
+	subscriber.unsubscribe()
+	subscriber.heartbeatTicker.Stop()
+	subscriber.flushTicker.Stop()
+	subscriber.cancelFunc()
+
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "Server disconnected, see you next time")
+	_ = subscriber.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(3*time.Second))
+	_ = subscriber.conn.Close()
+
+	subscriber.wg.Wait()
+
+	if subscriber.afterDisconnect != nil {
+		subscriber.afterDisconnect(subscriber)
+	}
+}
+
+// enqueue 立即推送事件, 缓冲区满时丢弃, 调用方需持有lock
+func (subscriber *TrafficSubscriber) enqueue(event *fsd.TrafficEvent) {
+	select {
+	case subscriber.events <- event:
+	default:
+		subscriber.logger.Warn("event channel full, dropping event")
+	}
+}
+
+// leave 客户端离开订阅范围, 调用方需持有lock
+func (subscriber *TrafficSubscriber) leave(callsign string) {
+	delete(subscriber.known, callsign)
+	delete(subscriber.pending, callsign)
+	subscriber.enqueue(&fsd.TrafficEvent{Type: fsd.TrafficLeave, Callsign: callsign, Time: time.Now()})
+}
+
+// handleEvent 由TrafficHub在发布者协程中调用, 不能阻塞
+func (subscriber *TrafficSubscriber) handleEvent(event *fsd.TrafficEvent) {
+	if subscriber.disconnected.Load() {
+		return
+	}
+
+	subscriber.lock.Lock()
+	defer subscriber.lock.Unlock()
+
+	_, known := subscriber.known[event.Callsign]
+	switch event.Type {
+	case fsd.TrafficConnect, fsd.TrafficPosition:
+		if !subscriber.filter.match(event.Callsign, event.Latitude, event.Longitude) {
+			if known {
+				subscriber.leave(event.Callsign)
+			}
+			return
+		}
+		subscriber.known[event.Callsign] = struct{}{}
+		if event.Type == fsd.TrafficPosition {
+			subscriber.pending[event.Callsign] = event
+			return
+		}
+		subscriber.enqueue(event)
+	case fsd.TrafficDisconnect:
+		if !known {
+			return
+		}
+		delete(subscriber.known, event.Callsign)
+		delete(subscriber.pending, event.Callsign)
+		subscriber.enqueue(event)
+	default:
+		if known {
+			subscriber.enqueue(event)
+		}
+	}
+}
+
+// subscribe 更新订阅条件, 并推送当前满足条件的全部客户端
+func (subscriber *TrafficSubscriber) subscribe(subscription *TrafficSubscription) {
+	interval := time.Duration(subscription.Interval) * time.Millisecond
+	if interval < subscriber.minInterval {
+		interval = subscriber.minInterval
+	}
+	subscriber.flushTicker.Reset(interval)
+
+	clients := subscriber.clientManager.GetClientSnapshot()
+	defer subscriber.clientManager.ReleaseClientSnapshot(clients)
+
+	subscriber.lock.Lock()
+	defer subscriber.lock.Unlock()
+
+	subscriber.filter = newTrafficFilter(subscription)
+	previous := subscriber.known
+	subscriber.known = make(map[string]struct{})
+	subscriber.pending = make(map[string]*fsd.TrafficEvent)
+	for _, client := range clients {
+		if client == nil || client.Disconnected() {
+			continue
+		}
+		position := client.Position()[0]
+		if !subscriber.filter.match(client.Callsign(), position.Latitude, position.Longitude) {
+			continue
+		}
+		subscriber.known[client.Callsign()] = struct{}{}
+		subscriber.enqueue(fsd.NewTrafficEvent(fsd.TrafficConnect, client))
+	}
+	for callsign := range previous {
+		if _, ok := subscriber.known[callsign]; !ok {
+			subscriber.enqueue(&fsd.TrafficEvent{Type: fsd.TrafficLeave, Callsign: callsign, Time: time.Now()})
+		}
+	}
+}
+
+func (subscriber *TrafficSubscriber) receiveHandler() {
+	defer subscriber.wg.Done()
+	for {
+		select {
+		case <-subscriber.ctx.Done():
+			return
+		default:
+			_, msg, err := subscriber.conn.ReadMessage()
+			if err != nil {
+				if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+					subscriber.logger.ErrorF("unexpected close error: %v", err)
+				}
+				go subscriber.Disconnect()
+				return
+			}
+
+			if err := subscriber.resetReadDeadline(); err != nil {
+				subscriber.logger.ErrorF("error setting read deadline: %v", err)
+				go subscriber.Disconnect()
+				return
+			}
+
+			subscription := &TrafficSubscription{}
+			if err := json.Unmarshal(msg, subscription); err != nil {
+				subscriber.logger.ErrorF("error while unmarshalling subscription: %v", err)
+				continue
+			}
+			subscriber.subscribe(subscription)
+		}
+	}
+}
+
+func (subscriber *TrafficSubscriber) write(event *fsd.TrafficEvent) bool {
+	data, err := json.Marshal(event)
+	if err != nil {
+		subscriber.logger.ErrorF("error while marshalling event: %v", err)
+		return true
+	}
+	if err := subscriber.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		subscriber.logger.ErrorF("error while writing event: %v", err)
+		return false
+	}
+	return true
+}
+
+func (subscriber *TrafficSubscriber) flush() bool {
+	subscriber.lock.Lock()
+	pending := subscriber.pending
+	subscriber.pending = make(map[string]*fsd.TrafficEvent, len(pending))
+	subscriber.lock.Unlock()
+
+	for _, event := range pending {
+		if !subscriber.write(event) {
+			return false
+		}
+	}
+	return true
+}
+
+func (subscriber *TrafficSubscriber) sendHandler() {
+	defer subscriber.wg.Done()
+	for {
+		select {
+		case <-subscriber.ctx.Done():
+			return
+		case event := <-subscriber.events:
+			if !subscriber.write(event) {
+				go subscriber.Disconnect()
+				return
+			}
+		case <-subscriber.flushTicker.C:
+			if !subscriber.flush() {
+				go subscriber.Disconnect()
+				return
+			}
+		case <-subscriber.heartbeatTicker.C:
+			if err := subscriber.conn.WriteMessage(websocket.PingMessage, []byte("Ping")); err != nil {
+				subscriber.logger.ErrorF("error while sending ping: %v", err)
+				go subscriber.Disconnect()
+				return
+			}
+		}
+	}
+}
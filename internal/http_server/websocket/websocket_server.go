@@ -6,6 +6,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
 	"strings"
 	"sync"
 	"time"
@@ -44,13 +46,30 @@ func NewWebSocketServer(
 		messageQueue: messageQueue,
 		config:       config,
 		lock:         sync.RWMutex{},
-		upgrader:     &websocket.Upgrader{},
+		upgrader:     newUpgrader(config),
 	}
 	server.ctx, server.cancel = context.WithCancel(context.Background())
 	messageQueue.Subscribe(queue.FsdMessageReceived, server.MessageReceiveHandler)
 	return server
 }
 
+// newUpgrader 创建检查请求来源的升级器, 允许的来源与CORS配置一致
+func newUpgrader(config *config.HttpServerConfig) *websocket.Upgrader {
+	return &websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			// 非浏览器客户端不会携带Origin
+			if origin == "" {
+				return true
+			}
+			if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+				return true
+			}
+			return config.OriginAllowed(origin)
+		},
+	}
+}
+
 func (server *WebSocketServer) Close(ctx context.Context) error {
 	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
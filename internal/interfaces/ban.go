@@ -0,0 +1,22 @@
+// Package interfaces
+package interfaces
+
+import (
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+)
+
+// BanManagerInterface 封禁管理, 缓存生效中的封禁供FSD、语音和Http服务器检查
+type BanManagerInterface interface {
+	// Check 检查CID或者IP在scope范围内是否被封禁, cid小于等于0或ip为空时跳过对应检查, 未被封禁时返回nil
+	Check(cid int, ip string, scope operation.BanScope) (ban *operation.Ban)
+	// Issue 保存封禁并立即生效, 命中封禁的在线FSD客户端会被踢出, 随后调用 AddIssueCallback 注册的回调
+	Issue(ban *operation.Ban) (err error)
+	// AddIssueCallback 注册封禁生效后的回调, 供语音服务器等断开命中封禁的会话
+	AddIssueCallback(callback func(ban *operation.Ban))
+	// Lift 解除封禁
+	Lift(id uint, cid int) (ban *operation.Ban, err error)
+	// Extend 修改封禁到期时间, expiresAt为空时改为永久封禁
+	Extend(id uint, expiresAt *time.Time) (ban *operation.Ban, err error)
+}
@@ -8,4 +8,5 @@ import (
 type ConfigManagerInterface interface {
 	Config() *Config
 	SaveConfig() error
+	ReloadConfig() (*ReloadResult, error)
 }
@@ -30,6 +30,8 @@ type EmailTemplateConfigs struct {
 	ApplicationRejectedEmail   *EmailTemplateConfig `json:"application_rejected_email"`
 	ApplicationProcessingEmail *EmailTemplateConfig `json:"application_processing_email"`
 	TicketReplyEmail           *EmailTemplateConfig `json:"ticket_reply_email"`
+	HelpRequestEscalatedEmail  *EmailTemplateConfig `json:"help_request_escalated_email"`
+	BannedEmail                *EmailTemplateConfig `json:"banned_email"`
 }
 
 func defaultEmailTemplateConfig() *EmailTemplateConfigs {
@@ -84,6 +86,16 @@ func defaultEmailTemplateConfig() *EmailTemplateConfigs {
 			EmailTitle: "工单回复通知",
 			Enable:     true,
 		},
+		HelpRequestEscalatedEmail: &EmailTemplateConfig{
+			FilePath:   "template/help_request_escalated.template",
+			EmailTitle: "求助超时未处理通知",
+			Enable:     true,
+		},
+		BannedEmail: &EmailTemplateConfig{
+			FilePath:   "template/banned.template",
+			EmailTitle: "账号封禁通知",
+			Enable:     true,
+		},
 	}
 }
 
@@ -234,6 +246,28 @@ func (config *EmailTemplateConfigs) checkValid(logger log.LoggerInterface) *Vali
 		)
 	})
 
+	eg.Go(func() error {
+		return validateTemplate(
+			logger,
+			config.HelpRequestEscalatedEmail,
+			global.HelpRequestEscalatedTemplateFilePath,
+			"help_request_escalated",
+			"fail to load help_request_escalated_template",
+			"fail to parse help_request_escalated_template",
+		)
+	})
+
+	eg.Go(func() error {
+		return validateTemplate(
+			logger,
+			config.BannedEmail,
+			global.BannedTemplateFilePath,
+			"banned",
+			"fail to load banned_template",
+			"fail to parse banned_template",
+		)
+	})
+
 	if err := eg.Wait(); err != nil {
 		// 我们这里很确定只会有ValidResult类型的错误
 		// 不可能有其他类型的错误, 代码里根本没有返回其他错误
@@ -0,0 +1,101 @@
+// Package config
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+)
+
+type FederationPeer struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+type FederationConfig struct {
+	Enabled           bool              `json:"enabled"`
+	NodeName          string            `json:"node_name"`
+	Host              string            `json:"host"`
+	Port              uint              `json:"port"`
+	Address           string            `json:"-"`
+	Secret            string            `json:"secret"`
+	Peers             []*FederationPeer `json:"peers"`
+	SyncInterval      string            `json:"sync_interval"`
+	SyncDuration      time.Duration     `json:"-"`
+	ReconnectInterval string            `json:"reconnect_interval"`
+	ReconnectDuration time.Duration     `json:"-"`
+	Timeout           string            `json:"timeout"`
+	TimeoutDuration   time.Duration     `json:"-"`
+}
+
+func defaultFederationConfig() *FederationConfig {
+	return &FederationConfig{
+		Enabled:           false,
+		NodeName:          "",
+		Host:              "0.0.0.0",
+		Port:              6813,
+		Secret:            "",
+		Peers:             make([]*FederationPeer, 0),
+		SyncInterval:      "1s",
+		ReconnectInterval: "10s",
+		Timeout:           "30s",
+	}
+}
+
+func (config *FederationConfig) checkValid(logger log.LoggerInterface) *ValidResult {
+	if !config.Enabled {
+		return ValidPass()
+	}
+
+	if config.NodeName == "" {
+		return ValidFail(errors.New("invalid json field federation.node_name, node_name must not be empty"))
+	}
+
+	if len(config.Secret) < 16 {
+		return ValidFail(errors.New("invalid json field federation.secret, secret must be at least 16 characters"))
+	}
+
+	if result := checkPort(config.Port); result.IsFail() {
+		return result
+	}
+	config.Address = fmt.Sprintf("%s:%d", config.Host, config.Port)
+
+	for _, peer := range config.Peers {
+		if peer.Address == "" {
+			return ValidFail(errors.New("invalid json field federation.peers, peer address must not be empty"))
+		}
+		if peer.Name == config.NodeName {
+			return ValidFail(fmt.Errorf("invalid json field federation.peers, peer %s has the same name as this node", peer.Address))
+		}
+	}
+
+	if len(config.Peers) == 0 {
+		logger.Warn("Federation enabled without any peers, this node will only accept incoming links")
+	}
+
+	if duration, err := time.ParseDuration(config.SyncInterval); err != nil {
+		return ValidFailWith(errors.New("invalid json field federation.sync_interval"), err)
+	} else if duration <= 0 {
+		return ValidFail(errors.New("invalid json field federation.sync_interval, sync_interval must larger than 0"))
+	} else {
+		config.SyncDuration = duration
+	}
+
+	if duration, err := time.ParseDuration(config.ReconnectInterval); err != nil {
+		return ValidFailWith(errors.New("invalid json field federation.reconnect_interval"), err)
+	} else {
+		config.ReconnectDuration = duration
+	}
+
+	if duration, err := time.ParseDuration(config.Timeout); err != nil {
+		return ValidFailWith(errors.New("invalid json field federation.timeout"), err)
+	} else if duration <= config.SyncDuration {
+		return ValidFail(errors.New("invalid json field federation.timeout, timeout must larger than sync_interval"))
+	} else {
+		config.TimeoutDuration = duration
+	}
+
+	return ValidPass()
+}
@@ -0,0 +1,106 @@
+// Package config
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+)
+
+type AtisAirportConfig struct {
+	Icao             string   `json:"icao"`
+	Frequency        string   `json:"frequency"`         // ATIS频率, 例如127.850
+	FrequencyValue   int      `json:"-"`                 // 内部使用字段, FSD协议中的频率值
+	ArrivalRunways   []string `json:"arrival_runways"`   // 落地跑道
+	DepartureRunways []string `json:"departure_runways"` // 起飞跑道
+	Approach         string   `json:"approach"`          // 预期进近方式, 例如ILS
+	TransitionLevel  string   `json:"transition_level"`  // 过渡高度层, 例如FL118
+	Template         []string `json:"template"`          // 为空时使用全局模板
+}
+
+type FsdAtisConfig struct {
+	Enabled                bool                 `json:"enabled"`
+	UpdateInterval         string               `json:"update_interval"` // METAR检查间隔
+	UpdateIntervalDuration time.Duration        `json:"-"`               // 内部使用字段
+	VisualRange            int                  `json:"visual_range"`    // 虚拟ATIS的视程, 单位海里
+	Template               []string             `json:"template"`
+	Airports               []*AtisAirportConfig `json:"airports"`
+}
+
+func defaultFsdAtisConfig() *FsdAtisConfig {
+	return &FsdAtisConfig{
+		Enabled:        false,
+		UpdateInterval: "1m",
+		VisualRange:    50,
+		Template: []string{
+			"{icao} INFORMATION {letter} {time}",
+			"EXPECT {approach} APPROACH, ARRIVAL RUNWAY {arrival_runways}, DEPARTURE RUNWAY {departure_runways}",
+			"WIND {wind}, VISIBILITY {visibility}",
+			"TEMPERATURE {temperature}, DEWPOINT {dewpoint}, QNH {qnh}",
+			"TRANSITION LEVEL {transition_level}",
+			"ADVISE ON INITIAL CONTACT YOU HAVE INFORMATION {letter}",
+		},
+		Airports: make([]*AtisAirportConfig, 0),
+	}
+}
+
+// ParseFrequency 将形如127.850的频率转换为FSD协议中的频率值
+func ParseFrequency(frequency string) (int, error) {
+	value, err := strconv.ParseFloat(frequency, 64)
+	if err != nil {
+		return 0, err
+	}
+	if value < 118 || value >= 137 {
+		return 0, fmt.Errorf("frequency %s out of range [118.000, 136.990]", frequency)
+	}
+	return int(math.Round(value*1000)) - 100000, nil
+}
+
+func (config *FsdAtisConfig) checkValid(logger log.LoggerInterface) *ValidResult {
+	if !config.Enabled {
+		return ValidPass()
+	}
+
+	if duration, err := time.ParseDuration(config.UpdateInterval); err != nil {
+		return ValidFail(fmt.Errorf("invalid json field atis.update_interval, duration parse error, %v", err))
+	} else if duration < time.Second {
+		return ValidFail(fmt.Errorf("atis.update_interval must not less than 1s, got %s", config.UpdateInterval))
+	} else {
+		config.UpdateIntervalDuration = duration
+	}
+
+	if config.VisualRange <= 0 {
+		logger.WarnF("Invalid atis visual_range %d, using default 50", config.VisualRange)
+		config.VisualRange = 50
+	}
+
+	if len(config.Airports) == 0 {
+		logger.Warn("ATIS generator enabled without any airport")
+	}
+
+	icaos := make(map[string]bool)
+	for _, airport := range config.Airports {
+		airport.Icao = strings.ToUpper(airport.Icao)
+		if len(airport.Icao) != 4 {
+			return ValidFail(fmt.Errorf("invalid atis airport icao %s", airport.Icao))
+		}
+		if icaos[airport.Icao] {
+			return ValidFail(fmt.Errorf("duplicate atis airport %s", airport.Icao))
+		}
+		icaos[airport.Icao] = true
+		frequency, err := ParseFrequency(airport.Frequency)
+		if err != nil {
+			return ValidFailWith(fmt.Errorf("invalid atis frequency of %s", airport.Icao), err)
+		}
+		airport.FrequencyValue = frequency
+		if len(airport.Template) == 0 && len(config.Template) == 0 {
+			return ValidFail(fmt.Errorf("atis airport %s has no template", airport.Icao))
+		}
+	}
+
+	return ValidPass()
+}
@@ -0,0 +1,83 @@
+// Package config
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+)
+
+// FsdConnectionLimit 同一CID各类连接的最大数量, -1为不限制
+type FsdConnectionLimit struct {
+	Pilot    int `json:"pilot"`
+	Atc      int `json:"atc"`
+	Atis     int `json:"atis"`
+	Observer int `json:"observer"`
+}
+
+func (limit *FsdConnectionLimit) checkValid(name string) *ValidResult {
+	if limit.Pilot < -1 || limit.Atc < -1 || limit.Atis < -1 || limit.Observer < -1 {
+		return ValidFail(fmt.Errorf("connection_policy.%s limits must not less than -1", name))
+	}
+	return ValidPass()
+}
+
+// FsdConnectionPolicyRule 用户管制权限或登录席位命中其中任意一项时使用该规则
+type FsdConnectionPolicyRule struct {
+	Ratings    []int               `json:"ratings"`    // 匹配的用户管制权限
+	Facilities []string            `json:"facilities"` // 匹配的登录席位, 如SUP, CTR, Pilot
+	Exempt     bool                `json:"exempt"`     // 为true时不限制连接数
+	Limit      *FsdConnectionLimit `json:"limit"`
+}
+
+type FsdConnectionPolicyConfig struct {
+	Enabled bool                       `json:"enabled"`
+	Default *FsdConnectionLimit        `json:"default"` // 没有规则匹配时使用的限制
+	Rules   []*FsdConnectionPolicyRule `json:"rules"`   // 按顺序匹配, 使用第一条匹配的规则
+}
+
+func defaultFsdConnectionPolicyConfig() *FsdConnectionPolicyConfig {
+	return &FsdConnectionPolicyConfig{
+		Enabled: false,
+		Default: &FsdConnectionLimit{
+			Pilot:    1,
+			Atc:      1,
+			Atis:     1,
+			Observer: -1,
+		},
+		Rules: []*FsdConnectionPolicyRule{
+			// 监管与管理员不受限制
+			{Ratings: []int{11, 12}, Facilities: make([]string, 0), Exempt: true},
+		},
+	}
+}
+
+func (config *FsdConnectionPolicyConfig) checkValid(_ log.LoggerInterface) *ValidResult {
+	if config.Default == nil {
+		return ValidFail(fmt.Errorf("connection_policy.default is required"))
+	}
+	if result := config.Default.checkValid("default"); result.IsFail() {
+		return result
+	}
+
+	for index, rule := range config.Rules {
+		if len(rule.Ratings) == 0 && len(rule.Facilities) == 0 {
+			return ValidFail(fmt.Errorf("connection_policy.rules[%d] must match at least one rating or facility", index))
+		}
+		for i, facility := range rule.Facilities {
+			rule.Facilities[i] = strings.ToUpper(facility)
+		}
+		if rule.Exempt {
+			continue
+		}
+		if rule.Limit == nil {
+			return ValidFail(fmt.Errorf("connection_policy.rules[%d].limit is required when exempt is false", index))
+		}
+		if result := rule.Limit.checkValid(fmt.Sprintf("rules[%d].limit", index)); result.IsFail() {
+			return result
+		}
+	}
+
+	return ValidPass()
+}
@@ -0,0 +1,80 @@
+// Package config
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+)
+
+type FsdFloodProtectionConfig struct {
+	Enabled                 bool          `json:"enabled"`
+	MaxConnectionsPerIp     int           `json:"max_connections_per_ip"` // 单个IP同时保持的最大连接数, 0为不限制
+	ConnectRateLimit        int           `json:"connect_rate_limit"`     // 单个IP每分钟最多新建的连接数, 0为不限制
+	PacketWindow            string        `json:"packet_window"`          // 数据包限流的统计窗口
+	PacketWindowDuration    time.Duration `json:"-"`                      // 内部使用字段
+	PositionPacketLimit     int           `json:"position_packet_limit"`  // 窗口内允许的位置数据包数量, 0为不限制
+	TextPacketLimit         int           `json:"text_packet_limit"`      // 窗口内允许的文本消息数量, 0为不限制
+	OtherPacketLimit        int           `json:"other_packet_limit"`     // 窗口内允许的其他数据包数量, 0为不限制
+	MuteTime                string        `json:"mute_time"`              // 文本刷屏后的禁言时间
+	MuteDuration            time.Duration `json:"-"`                      // 内部使用字段
+	MaxViolations           int           `json:"max_violations"`         // 统计时间内超限达到该次数时断开连接
+	ViolationWindow         string        `json:"violation_window"`       // 超限次数的统计时间
+	ViolationWindowDuration time.Duration `json:"-"`                      // 内部使用字段
+}
+
+func defaultFsdFloodProtectionConfig() *FsdFloodProtectionConfig {
+	return &FsdFloodProtectionConfig{
+		Enabled:             false,
+		MaxConnectionsPerIp: 8,
+		ConnectRateLimit:    30,
+		PacketWindow:        "10s",
+		PositionPacketLimit: 300,
+		TextPacketLimit:     10,
+		OtherPacketLimit:    600,
+		MuteTime:            "1m",
+		MaxViolations:       5,
+		ViolationWindow:     "10m",
+	}
+}
+
+func (config *FsdFloodProtectionConfig) checkValid(_ log.LoggerInterface) *ValidResult {
+	if config.MaxConnectionsPerIp < 0 || config.ConnectRateLimit < 0 {
+		return ValidFail(fmt.Errorf("flood_protection.max_connections_per_ip and flood_protection.connect_rate_limit must not less than 0"))
+	}
+
+	if config.PositionPacketLimit < 0 || config.TextPacketLimit < 0 || config.OtherPacketLimit < 0 {
+		return ValidFail(fmt.Errorf("flood_protection packet limits must not less than 0"))
+	}
+
+	if config.MaxViolations < 0 {
+		return ValidFail(fmt.Errorf("flood_protection.max_violations must not less than 0, got %d", config.MaxViolations))
+	}
+
+	if duration, err := time.ParseDuration(config.PacketWindow); err != nil {
+		return ValidFail(fmt.Errorf("invalid json field flood_protection.packet_window, duration parse error, %v", err))
+	} else if duration < time.Second {
+		return ValidFail(fmt.Errorf("flood_protection.packet_window must not less than 1s, got %s", config.PacketWindow))
+	} else {
+		config.PacketWindowDuration = duration
+	}
+
+	if duration, err := time.ParseDuration(config.MuteTime); err != nil {
+		return ValidFail(fmt.Errorf("invalid json field flood_protection.mute_time, duration parse error, %v", err))
+	} else if duration < 0 {
+		return ValidFail(fmt.Errorf("flood_protection.mute_time must not less than 0, got %s", config.MuteTime))
+	} else {
+		config.MuteDuration = duration
+	}
+
+	if duration, err := time.ParseDuration(config.ViolationWindow); err != nil {
+		return ValidFail(fmt.Errorf("invalid json field flood_protection.violation_window, duration parse error, %v", err))
+	} else if duration <= 0 {
+		return ValidFail(fmt.Errorf("flood_protection.violation_window must larger than 0, got %s", config.ViolationWindow))
+	} else {
+		config.ViolationWindowDuration = duration
+	}
+
+	return ValidPass()
+}
@@ -0,0 +1,43 @@
+// Package config
+package config
+
+import (
+	"fmt"
+	"net/mail"
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+)
+
+type FsdHelpRequestConfig struct {
+	EscalateTimeout         string        `json:"escalate_timeout"` // 求助未被认领时升级的超时时间
+	EscalateTimeoutDuration time.Duration `json:"-"`                // 内部使用字段
+	EscalateEmails          []string      `json:"escalate_emails"`  // 升级时接收通知邮件的地址
+	NotifyRequester         bool          `json:"notify_requester"` // 是否将处理进度私聊通知求助者
+}
+
+func defaultFsdHelpRequestConfig() *FsdHelpRequestConfig {
+	return &FsdHelpRequestConfig{
+		EscalateTimeout: "10m",
+		EscalateEmails:  make([]string, 0),
+		NotifyRequester: true,
+	}
+}
+
+func (config *FsdHelpRequestConfig) checkValid(_ log.LoggerInterface) *ValidResult {
+	if duration, err := time.ParseDuration(config.EscalateTimeout); err != nil {
+		return ValidFail(fmt.Errorf("invalid json field help_request.escalate_timeout, duration parse error, %v", err))
+	} else if duration < time.Minute {
+		return ValidFail(fmt.Errorf("help_request.escalate_timeout must not less than 1m, got %s", config.EscalateTimeout))
+	} else {
+		config.EscalateTimeoutDuration = duration
+	}
+
+	for _, email := range config.EscalateEmails {
+		if _, err := mail.ParseAddress(email); err != nil {
+			return ValidFailWith(fmt.Errorf("invalid email address %s in help_request.escalate_emails", email), err)
+		}
+	}
+
+	return ValidPass()
+}
@@ -0,0 +1,54 @@
+// Package config
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+)
+
+type OverflowPolicy string
+
+const (
+	OverflowDrop       OverflowPolicy = "drop"       // 丢弃新数据包
+	OverflowDisconnect OverflowPolicy = "disconnect" // 断开客户端连接
+)
+
+type FsdOutboundQueueConfig struct {
+	QueueSize            int            `json:"queue_size"`      // 每个客户端发送队列的最大长度
+	WriteTimeout         string         `json:"write_timeout"`   // 单次写入超时时间
+	WriteTimeoutDuration time.Duration  `json:"-"`               // 内部使用字段
+	OverflowPolicy       OverflowPolicy `json:"overflow_policy"` // 队列溢出时的处理策略
+}
+
+func defaultFsdOutboundQueueConfig() *FsdOutboundQueueConfig {
+	return &FsdOutboundQueueConfig{
+		QueueSize:      512,
+		WriteTimeout:   "10s",
+		OverflowPolicy: OverflowDrop,
+	}
+}
+
+func (config *FsdOutboundQueueConfig) checkValid(logger log.LoggerInterface) *ValidResult {
+	if config.QueueSize <= 0 {
+		logger.WarnF("Invalid outbound queue_size %d, using default 512", config.QueueSize)
+		config.QueueSize = 512
+	}
+
+	if duration, err := time.ParseDuration(config.WriteTimeout); err != nil {
+		return ValidFail(fmt.Errorf("invalid json field outbound_queue.write_timeout, duration parse error, %v", err))
+	} else if duration <= 0 {
+		return ValidFail(fmt.Errorf("outbound_queue.write_timeout must larger than 0, got %s", config.WriteTimeout))
+	} else {
+		config.WriteTimeoutDuration = duration
+	}
+
+	switch config.OverflowPolicy {
+	case OverflowDrop, OverflowDisconnect:
+	default:
+		return ValidFail(fmt.Errorf("invalid json field outbound_queue.overflow_policy, must be %s or %s, got %s", OverflowDrop, OverflowDisconnect, config.OverflowPolicy))
+	}
+
+	return ValidPass()
+}
@@ -0,0 +1,53 @@
+// Package config
+package config
+
+import (
+	"errors"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+)
+
+type FsdRecorderConfig struct {
+	Enabled     bool     `json:"enabled"`
+	Path        string   `json:"path"`
+	MaxFileSize int      `json:"max_file_size"` // 单个录制文件最大尺寸, 单位MB
+	MaxBackups  int      `json:"max_backups"`   // 单个会话保留的最大旧文件数量
+	Callsigns   []string `json:"callsigns"`
+	Cids        []int    `json:"cids"`
+}
+
+func defaultFsdRecorderConfig() *FsdRecorderConfig {
+	return &FsdRecorderConfig{
+		Enabled:     false,
+		Path:        "records",
+		MaxFileSize: 10,
+		MaxBackups:  5,
+		Callsigns:   make([]string, 0),
+		Cids:        make([]int, 0),
+	}
+}
+
+func (config *FsdRecorderConfig) checkValid(logger log.LoggerInterface) *ValidResult {
+	if !config.Enabled {
+		return ValidPass()
+	}
+
+	if config.Path == "" {
+		return ValidFail(errors.New("invalid json field recorder.path, path must not be empty"))
+	}
+
+	if config.MaxFileSize <= 0 {
+		logger.WarnF("Invalid recorder max_file_size %d, using default 10", config.MaxFileSize)
+		config.MaxFileSize = 10
+	}
+
+	if config.MaxBackups < 0 {
+		config.MaxBackups = 0
+	}
+
+	if len(config.Callsigns) == 0 && len(config.Cids) == 0 {
+		logger.Warn("Session recorder enabled without any filter, all sessions will be recorded")
+	}
+
+	return ValidPass()
+}
@@ -0,0 +1,107 @@
+// Package config
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+)
+
+type SectorData struct {
+	Name    string       `json:"name"`
+	Floor   int          `json:"floor"`   // 扇区下限, 单位英尺
+	Ceiling int          `json:"ceiling"` // 扇区上限, 单位英尺, 0表示无上限
+	Owners  []string     `json:"owners"`  // 负责席位呼号, 支持*通配符, 按顺序回退
+	Polygon [][2]float64 `json:"polygon"` // 扇区边界顶点, 格式为[纬度, 经度]
+}
+
+func (config *SectorData) checkValid(_ log.LoggerInterface) *ValidResult {
+	if config.Name == "" {
+		return ValidFail(errors.New("sector name must not be empty"))
+	}
+	if len(config.Polygon) < 3 {
+		return ValidFail(fmt.Errorf("sector %s polygon must have at least 3 points", config.Name))
+	}
+	for _, point := range config.Polygon {
+		if point[0] < -90 || point[0] > 90 || point[1] < -180 || point[1] > 180 {
+			return ValidFail(fmt.Errorf("sector %s has invalid point %v", config.Name, point))
+		}
+	}
+	if config.Floor < 0 || (config.Ceiling != 0 && config.Ceiling <= config.Floor) {
+		return ValidFail(fmt.Errorf("sector %s has invalid vertical limits %d-%d", config.Name, config.Floor, config.Ceiling))
+	}
+	if len(config.Owners) == 0 {
+		return ValidFail(fmt.Errorf("sector %s has no owner", config.Name))
+	}
+	for _, owner := range config.Owners {
+		if _, err := path.Match(owner, ""); err != nil {
+			return ValidFailWith(fmt.Errorf("sector %s has invalid owner pattern %s", config.Name, owner), err)
+		}
+	}
+	return ValidPass()
+}
+
+// InVerticalLimits 高度是否在扇区的垂直范围内
+func (config *SectorData) InVerticalLimits(altitude int) bool {
+	return altitude >= config.Floor && (config.Ceiling == 0 || altitude < config.Ceiling)
+}
+
+// MatchOwner 呼号是否为扇区的负责席位之一, 返回在回退顺序中的位置
+func (config *SectorData) MatchOwner(callsign string) (int, bool) {
+	for index, owner := range config.Owners {
+		if ok, _ := path.Match(owner, callsign); ok {
+			return index, true
+		}
+	}
+	return 0, false
+}
+
+type FsdSectorConfig struct {
+	Enabled         bool          `json:"enabled"`
+	SectorFile      string        `json:"sector_file"`      // 扇区定义文件路径
+	FrequencyFilter bool          `json:"frequency_filter"` // 频率消息只发送给该频率管制员负责的机组
+	Sectors         []*SectorData `json:"-"`                // 内部使用字段, 按文件顺序匹配
+}
+
+func defaultFsdSectorConfig() *FsdSectorConfig {
+	return &FsdSectorConfig{
+		Enabled:         false,
+		SectorFile:      "data/sectors.json",
+		FrequencyFilter: false,
+		Sectors:         make([]*SectorData, 0),
+	}
+}
+
+func (config *FsdSectorConfig) checkValid(logger log.LoggerInterface) *ValidResult {
+	if !config.Enabled {
+		return ValidPass()
+	}
+
+	data, err := os.ReadFile(config.SectorFile)
+	if err != nil {
+		return ValidFailWith(fmt.Errorf("fail to read sector file %s", config.SectorFile), err)
+	}
+	sectors := make([]*SectorData, 0)
+	if err := json.Unmarshal(data, &sectors); err != nil {
+		return ValidFail(fmt.Errorf("invalid json file %s, %v", config.SectorFile, err))
+	}
+
+	names := make(map[string]bool, len(sectors))
+	for _, sector := range sectors {
+		if result := sector.checkValid(logger); result.IsFail() {
+			return result
+		}
+		if names[sector.Name] {
+			return ValidFail(fmt.Errorf("duplicate sector %s", sector.Name))
+		}
+		names[sector.Name] = true
+	}
+	config.Sectors = sectors
+
+	logger.InfoF("Sector data loaded, found %d sectors", len(sectors))
+	return ValidPass()
+}
@@ -0,0 +1,68 @@
+// Package config
+package config
+
+import "testing"
+
+func TestSectorMatchOwner(t *testing.T) {
+	sector := &SectorData{
+		Name:   "ZSHA",
+		Owners: []string{"ZSHA_CTR", "ZSHA_*_CTR", "ZSSS_APP"},
+	}
+
+	tests := []struct {
+		callsign      string
+		expectedOrder int
+		expectedOk    bool
+	}{
+		{"ZSHA_CTR", 0, true},
+		{"ZSHA_1_CTR", 1, true},
+		{"ZSHA_N_CTR", 1, true},
+		{"ZSSS_APP", 2, true},
+		{"ZSSS_1_APP", 0, false},
+		{"ZSHA_CTR1", 0, false},
+		{"ZSHA__CTR", 1, true},
+		{"ZSPD_APP", 0, false},
+		{"", 0, false},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		order, ok := sector.MatchOwner(test.callsign)
+		if order != test.expectedOrder || ok != test.expectedOk {
+			fail++
+			t.Errorf("MatchOwner(%q) = %d, %v; expected %d, %v", test.callsign, order, ok, test.expectedOrder, test.expectedOk)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestSectorMatchOwner: %d pass, %d fail", pass, fail)
+}
+
+func TestSectorInVerticalLimits(t *testing.T) {
+	tests := []struct {
+		floor    int
+		ceiling  int
+		altitude int
+		expected bool
+	}{
+		{0, 0, 0, true},
+		{0, 0, 45000, true},
+		{0, 24500, 24499, true},
+		{0, 24500, 24500, false},
+		{24500, 0, 24500, true},
+		{24500, 0, 24499, false},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		sector := &SectorData{Floor: test.floor, Ceiling: test.ceiling}
+		result := sector.InVerticalLimits(test.altitude)
+		if result != test.expected {
+			fail++
+			t.Errorf("InVerticalLimits(%d-%d, %d) = %v; expected %v", test.floor, test.ceiling, test.altitude, result, test.expected)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestSectorInVerticalLimits: %d pass, %d fail", pass, fail)
+}
@@ -14,25 +14,40 @@ import (
 )
 
 type FSDServerConfig struct {
-	FSDName              string                  `json:"fsd_name"` // FSD名称
-	Host                 string                  `json:"host"`
-	Port                 uint                    `json:"port"`
-	Address              string                  `json:"-"`
-	AirportDataFile      string                  `json:"airport_data_file"`
-	AirportData          map[string]*AirportData `json:"-"`
-	PosUpdatePoints      int                     `json:"pos_update_points"`
-	HeartbeatInterval    string                  `json:"heartbeat_interval"`
-	HeartbeatDuration    time.Duration           `json:"-"`
-	CacheTime            string                  `json:"whazzup_cache_time"`
-	CacheDuration        time.Duration           `json:"-"`
-	SessionCleanTime     string                  `json:"session_clean_time"`    // 会话保留时间
-	SessionCleanDuration time.Duration           `json:"-"`                     // 内部使用字段
-	MaxWorkers           int                     `json:"max_workers"`           // 并发线程数
-	MaxBroadcastWorkers  int                     `json:"max_broadcast_workers"` // 广播并发线程数
-	RangeLimit           *FsdRangeLimit          `json:"range_limit"`
-	FirstMotdLine        string                  `json:"first_motd_line"`
-	Motd                 []string                `json:"motd"`
-	CurrentMotd          []string                `json:"-"`
+	FSDName              string                     `json:"fsd_name"` // FSD名称
+	Host                 string                     `json:"host"`
+	Port                 uint                       `json:"port"`
+	Address              string                     `json:"-"`
+	SSLPort              uint                       `json:"ssl_port"`
+	SSLAddress           string                     `json:"-"`
+	SSL                  *SSLConfig                 `json:"ssl"`
+	AirportDataFile      string                     `json:"airport_data_file"`
+	AirportData          map[string]*AirportData    `json:"-"`
+	PosUpdatePoints      int                        `json:"pos_update_points"`
+	HeartbeatInterval    string                     `json:"heartbeat_interval"`
+	HeartbeatDuration    time.Duration              `json:"-"`
+	CacheTime            string                     `json:"whazzup_cache_time"`
+	CacheDuration        time.Duration              `json:"-"`
+	SessionCleanTime     string                     `json:"session_clean_time"`    // 会话保留时间
+	SessionCleanDuration time.Duration              `json:"-"`                     // 内部使用字段
+	ResumeToken          bool                       `json:"resume_token"`          // 恢复会话时是否需要服务器下发的恢复令牌
+	LoginTimeout         string                     `json:"login_timeout"`         // 建立连接后必须在该时间内完成登录
+	LoginTimeoutDuration time.Duration              `json:"-"`                     // 内部使用字段
+	MaxLoginAttempts     int                        `json:"max_login_attempts"`    // 同一连接允许的登录失败次数
+	MaxWorkers           int                        `json:"max_workers"`           // 并发线程数
+	MaxBroadcastWorkers  int                        `json:"max_broadcast_workers"` // 广播并发线程数
+	RangeLimit           *FsdRangeLimit             `json:"range_limit"`
+	Recorder             *FsdRecorderConfig         `json:"recorder"`
+	OutboundQueue        *FsdOutboundQueueConfig    `json:"outbound_queue"`
+	Squawk               *FsdSquawkConfig           `json:"squawk"`
+	Atis                 *FsdAtisConfig             `json:"atis"`
+	Sector               *FsdSectorConfig           `json:"sector"`
+	HelpRequest          *FsdHelpRequestConfig      `json:"help_request"`
+	FloodProtection      *FsdFloodProtectionConfig  `json:"flood_protection"`
+	ConnectionPolicy     *FsdConnectionPolicyConfig `json:"connection_policy"`
+	FirstMotdLine        string                     `json:"first_motd_line"`
+	Motd                 []string                   `json:"motd"`
+	CurrentMotd          []string                   `json:"-"`
 }
 
 func defaultFSDServerConfig() *FSDServerConfig {
@@ -40,14 +55,27 @@ func defaultFSDServerConfig() *FSDServerConfig {
 		FSDName:             "Simple-Fsd",
 		Host:                "0.0.0.0",
 		Port:                6809,
+		SSLPort:             6812,
+		SSL:                 defaultSSLConfig(),
 		AirportDataFile:     "data/airport.json",
 		PosUpdatePoints:     1,
 		CacheTime:           "15s",
 		HeartbeatInterval:   "40s",
 		SessionCleanTime:    "40s",
+		ResumeToken:         false,
+		LoginTimeout:        "30s",
+		MaxLoginAttempts:    1,
 		MaxWorkers:          128,
 		MaxBroadcastWorkers: 128,
 		RangeLimit:          defaultFsdRangeLimitConfig(),
+		Recorder:            defaultFsdRecorderConfig(),
+		OutboundQueue:       defaultFsdOutboundQueueConfig(),
+		Squawk:              defaultFsdSquawkConfig(),
+		Atis:                defaultFsdAtisConfig(),
+		Sector:              defaultFsdSectorConfig(),
+		HelpRequest:         defaultFsdHelpRequestConfig(),
+		FloodProtection:     defaultFsdFloodProtectionConfig(),
+		ConnectionPolicy:    defaultFsdConnectionPolicyConfig(),
 		FirstMotdLine:       "Welcome to use %[1]s v%[2]s",
 		Motd:                make([]string, 0),
 		CurrentMotd:         make([]string, 0),
@@ -63,10 +91,56 @@ func (config *FSDServerConfig) checkValid(logger log.LoggerInterface) *ValidResu
 		return result
 	}
 
+	if result := config.Recorder.checkValid(logger); result.IsFail() {
+		return result
+	}
+
+	if result := config.OutboundQueue.checkValid(logger); result.IsFail() {
+		return result
+	}
+
+	if result := config.Squawk.checkValid(logger); result.IsFail() {
+		return result
+	}
+
+	if result := config.Atis.checkValid(logger); result.IsFail() {
+		return result
+	}
+
+	if result := config.Sector.checkValid(logger); result.IsFail() {
+		return result
+	}
+
+	if result := config.HelpRequest.checkValid(logger); result.IsFail() {
+		return result
+	}
+
+	if result := config.FloodProtection.checkValid(logger); result.IsFail() {
+		return result
+	}
+
+	if result := config.ConnectionPolicy.checkValid(logger); result.IsFail() {
+		return result
+	}
+
 	if result := checkPort(config.Port); result.IsFail() {
 		return result
 	}
 
+	if result := config.SSL.checkValid(logger); result.IsFail() {
+		return result
+	}
+
+	if config.SSL.Enable {
+		if result := checkPort(config.SSLPort); result.IsFail() {
+			return result
+		}
+		if config.SSLPort == config.Port {
+			return ValidFail(errors.New("invalid json field ssl_port, ssl_port must be different from port"))
+		}
+		config.SSLAddress = fmt.Sprintf("%s:%d", config.Host, config.SSLPort)
+	}
+
 	data := make([]string, 0, 1+len(config.Motd))
 	data = append(data, fmt.Sprintf(config.FirstMotdLine, config.FSDName, AppVersion.String()))
 	data = append(data, config.Motd...)
@@ -100,6 +174,18 @@ func (config *FSDServerConfig) checkValid(logger log.LoggerInterface) *ValidResu
 		config.SessionCleanDuration = duration
 	}
 
+	if duration, err := time.ParseDuration(config.LoginTimeout); err != nil {
+		return ValidFail(fmt.Errorf("invalid json field login_timeout, duration parse error, %v", err))
+	} else if duration <= 0 {
+		return ValidFail(fmt.Errorf("login_timeout must larger than 0, got %s", config.LoginTimeout))
+	} else {
+		config.LoginTimeoutDuration = duration
+	}
+
+	if config.MaxLoginAttempts <= 0 {
+		return ValidFail(fmt.Errorf("max_login_attempts must larger than 0, got %d", config.MaxLoginAttempts))
+	}
+
 	if duration, err := time.ParseDuration(config.HeartbeatInterval); err != nil {
 		return ValidFail(fmt.Errorf("invalid json field heartbead_interval, duration parse error, %v", err))
 	} else if duration <= 25*time.Second {
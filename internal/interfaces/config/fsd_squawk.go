@@ -0,0 +1,143 @@
+// Package config
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+)
+
+type SquawkRange struct {
+	Name      string   `json:"name"`     // 区域名称, 例如情报区或机场代码
+	Prefixes  []string `json:"prefixes"` // 起飞机场ICAO前缀, 例如ZB或ZBAA
+	Sectors   []string `json:"sectors"`  // 扇区名称, 起飞机场没有匹配时按机组所在的扇区匹配
+	Start     string   `json:"start"`
+	End       string   `json:"end"`
+	StartCode int      `json:"-"`
+	EndCode   int      `json:"-"`
+}
+
+type FsdSquawkConfig struct {
+	Enabled       bool           `json:"enabled"`
+	ConflictRange float64        `json:"conflict_range"` // 冲突检测范围, 单位海里, 0表示检查全部在线机组
+	ReservedCodes []string       `json:"reserved_codes"` // 不参与分配的编码
+	Ranges        []*SquawkRange `json:"ranges"`
+	DefaultRange  *SquawkRange   `json:"default_range"` // 没有匹配的区域时使用的编码范围
+}
+
+func defaultFsdSquawkConfig() *FsdSquawkConfig {
+	return &FsdSquawkConfig{
+		Enabled:       false,
+		ConflictRange: 0,
+		ReservedCodes: []string{"0000", "1200", "2000", "2200", "7000", "7500", "7600", "7700"},
+		Ranges:        make([]*SquawkRange, 0),
+		DefaultRange: &SquawkRange{
+			Name:     "DEFAULT",
+			Prefixes: make([]string, 0),
+			Sectors:  make([]string, 0),
+			Start:    "0101",
+			End:      "7677",
+		},
+	}
+}
+
+// ParseSquawk 解析四位八进制应答机编码
+func ParseSquawk(code string) (int, error) {
+	if len(code) != 4 {
+		return 0, fmt.Errorf("squawk code %s must be 4 digits", code)
+	}
+	value, err := strconv.ParseInt(code, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("squawk code %s must be octal", code)
+	}
+	return int(value), nil
+}
+
+func FormatSquawk(code int) string {
+	return fmt.Sprintf("%04o", code)
+}
+
+func (config *SquawkRange) checkValid(_ log.LoggerInterface) *ValidResult {
+	start, err := ParseSquawk(config.Start)
+	if err != nil {
+		return ValidFailWith(fmt.Errorf("invalid squawk range %s start", config.Name), err)
+	}
+	end, err := ParseSquawk(config.End)
+	if err != nil {
+		return ValidFailWith(fmt.Errorf("invalid squawk range %s end", config.Name), err)
+	}
+	if start > end {
+		return ValidFail(fmt.Errorf("invalid squawk range %s, start %s larger than end %s", config.Name, config.Start, config.End))
+	}
+	config.StartCode = start
+	config.EndCode = end
+	for i, prefix := range config.Prefixes {
+		config.Prefixes[i] = strings.ToUpper(prefix)
+	}
+	return ValidPass()
+}
+
+func (config *FsdSquawkConfig) checkValid(logger log.LoggerInterface) *ValidResult {
+	if !config.Enabled {
+		return ValidPass()
+	}
+
+	if config.ConflictRange < 0 {
+		config.ConflictRange = 0
+	}
+
+	for _, code := range config.ReservedCodes {
+		if _, err := ParseSquawk(code); err != nil {
+			return ValidFailWith(errors.New("invalid json field squawk.reserved_codes"), err)
+		}
+	}
+
+	for _, squawkRange := range config.Ranges {
+		if len(squawkRange.Prefixes) == 0 && len(squawkRange.Sectors) == 0 {
+			return ValidFail(fmt.Errorf("squawk range %s must have at least one prefix or sector", squawkRange.Name))
+		}
+		if result := squawkRange.checkValid(logger); result.IsFail() {
+			return result
+		}
+	}
+
+	if config.DefaultRange == nil {
+		logger.Warn("Squawk default_range not set, aircraft outside configured ranges will not get a code")
+		return ValidPass()
+	}
+
+	return config.DefaultRange.checkValid(logger)
+}
+
+// MatchRange 匹配编码范围, 起飞机场前缀最长的范围优先
+// 没有飞行计划或起飞机场没有匹配时, 按机组当前所在的扇区匹配, 最后使用默认范围
+func (config *FsdSquawkConfig) MatchRange(departure string, sectors []string) *SquawkRange {
+	departure = strings.ToUpper(departure)
+	var matched *SquawkRange
+	matchedLen := 0
+	if departure != "" {
+		for _, squawkRange := range config.Ranges {
+			for _, prefix := range squawkRange.Prefixes {
+				if len(prefix) > matchedLen && strings.HasPrefix(departure, prefix) {
+					matched = squawkRange
+					matchedLen = len(prefix)
+				}
+			}
+		}
+	}
+	if matched != nil {
+		return matched
+	}
+	for _, squawkRange := range config.Ranges {
+		for _, sector := range squawkRange.Sectors {
+			if slices.Contains(sectors, sector) {
+				return squawkRange
+			}
+		}
+	}
+	return config.DefaultRange
+}
@@ -0,0 +1,59 @@
+// Package config
+package config
+
+import "testing"
+
+func newTestSquawkRange(name string, prefixes []string, sectors []string, start, end int) *SquawkRange {
+	return &SquawkRange{
+		Name:      name,
+		Prefixes:  prefixes,
+		Sectors:   sectors,
+		Start:     FormatSquawk(start),
+		End:       FormatSquawk(end),
+		StartCode: start,
+		EndCode:   end,
+	}
+}
+
+func TestMatchSquawkRange(t *testing.T) {
+	config := &FsdSquawkConfig{
+		Ranges: []*SquawkRange{
+			newTestSquawkRange("ZBPE", []string{"ZB"}, []string{"ZBPE"}, 0o101, 0o177),
+			newTestSquawkRange("ZBAA", []string{"ZBAA"}, nil, 0o201, 0o277),
+			newTestSquawkRange("ZSHA", []string{"ZS"}, []string{"ZSHA", "ZSSS_APP"}, 0o301, 0o377),
+			newTestSquawkRange("ZGZU", nil, []string{"ZGZU"}, 0o401, 0o477),
+		},
+		DefaultRange: newTestSquawkRange("DEFAULT", nil, nil, 0o501, 0o577),
+	}
+
+	tests := []struct {
+		departure string
+		sectors   []string
+		expected  string
+	}{
+		{"ZBTJ", nil, "ZBPE"},
+		{"zbtj", nil, "ZBPE"},
+		{"ZBAA", nil, "ZBAA"},
+		{"ZBAA", []string{"ZSHA"}, "ZBAA"},
+		{"ZSPD", nil, "ZSHA"},
+		{"", []string{"ZSSS_APP"}, "ZSHA"},
+		{"", []string{"ZGZU"}, "ZGZU"},
+		{"VHHH", []string{"ZGZU"}, "ZGZU"},
+		{"", []string{"ZYSH", "ZBPE"}, "ZBPE"},
+		{"VHHH", nil, "DEFAULT"},
+		{"", []string{"ZYSH"}, "DEFAULT"},
+		{"", nil, "DEFAULT"},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		result := config.MatchRange(test.departure, test.sectors)
+		if result == nil || result.Name != test.expected {
+			fail++
+			t.Errorf("MatchRange(%q, %v) = %v; expected %s", test.departure, test.sectors, result, test.expected)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestMatchSquawkRange: %d pass, %d fail", pass, fail)
+}
@@ -4,6 +4,8 @@ package config
 import (
 	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
 )
@@ -19,12 +21,15 @@ type HttpServerConfig struct {
 	ProxyType      int              `json:"proxy_type"`
 	TrustedIpRange []string         `json:"trusted_ip_range"`
 	BodyLimit      string           `json:"body_limit"`
+	AllowedOrigins []string         `json:"allowed_origins"` // 允许跨域访问的来源, 同时用于CORS与WebSocket
 	Store          *HttpServerStore `json:"store"`
 	RateLimit      int              `json:"rateLimit"`
 	Email          *EmailConfig     `json:"email"`
 	JWT            *JWTConfig       `json:"jwt"`
 	SSL            *SSLConfig       `json:"ssl"`
 	Navigraph      *NavigraphConfig `json:"navigraph"`
+
+	originPatterns []*regexp.Regexp
 }
 
 func defaultHttpServerConfig() *HttpServerConfig {
@@ -38,6 +43,7 @@ func defaultHttpServerConfig() *HttpServerConfig {
 		ProxyType:      0,
 		TrustedIpRange: make([]string, 0),
 		BodyLimit:      "10MB",
+		AllowedOrigins: []string{"*"},
 		RateLimit:      15,
 		Store:          defaultHttpServerStore(),
 		Email:          defaultEmailConfig(),
@@ -51,6 +57,17 @@ func (config *HttpServerConfig) FormatCallsign(cid int) string {
 	return fmt.Sprintf("%s%04d%s", config.ClientPrefix, cid, config.ClientSuffix)
 }
 
+// OriginAllowed 检查请求来源是否在允许列表中
+func (config *HttpServerConfig) OriginAllowed(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, pattern := range config.originPatterns {
+		if pattern.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
 func (config *HttpServerConfig) checkValid(logger log.LoggerInterface) *ValidResult {
 	if config.Enabled {
 		if result := checkPort(config.Port); result.IsFail() {
@@ -67,6 +84,23 @@ func (config *HttpServerConfig) checkValid(logger log.LoggerInterface) *ValidRes
 			return ValidFail(errors.New("client_prefix and client_suffix can't be empty at the same time"))
 		}
 
+		if len(config.AllowedOrigins) == 0 {
+			logger.WarnF("allowed_origins is empty, allowing all origins")
+			config.AllowedOrigins = []string{"*"}
+		}
+		config.originPatterns = make([]*regexp.Regexp, 0, len(config.AllowedOrigins))
+		for _, origin := range config.AllowedOrigins {
+			// 与echo的CORS中间件保持一致, *匹配任意字符, ?匹配单个字符
+			pattern := regexp.QuoteMeta(strings.ToLower(origin))
+			pattern = strings.ReplaceAll(pattern, "\\*", ".*")
+			pattern = strings.ReplaceAll(pattern, "\\?", ".")
+			re, err := regexp.Compile("^" + pattern + "$")
+			if err != nil {
+				return ValidFailWith(fmt.Errorf("invalid allowed origin %s", origin), err)
+			}
+			config.originPatterns = append(config.originPatterns, re)
+		}
+
 		if config.RateLimit < 0 {
 			logger.WarnF("Invalid rate limit value %d, using default 15", config.RateLimit)
 			config.RateLimit = 15
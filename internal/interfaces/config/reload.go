@@ -0,0 +1,120 @@
+// Package config
+package config
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// hotReloadFields 可以在运行时直接生效的配置项, 按json路径前缀匹配
+var hotReloadFields = []string{
+	"server.fsd_server.fsd_name",
+	"server.fsd_server.first_motd_line",
+	"server.fsd_server.motd",
+	"server.fsd_server.range_limit",
+	"server.fsd_server.max_broadcast_workers",
+	"server.http_server.rateLimit",
+	"metar_source",
+	"rating",
+	"facility",
+}
+
+// ReloadResult 配置重载结果
+type ReloadResult struct {
+	Applied        []string `json:"applied"`         // 已经生效的配置项
+	RequireRestart []string `json:"require_restart"` // 已修改但需要重启才能生效的配置项
+}
+
+func isHotReloadField(path string) bool {
+	return slices.ContainsFunc(hotReloadFields, func(field string) bool {
+		return path == field || strings.HasPrefix(path, field+".")
+	})
+}
+
+// ApplyReload 对比新旧配置, 返回应用了可热重载配置项的新配置快照
+// 当前配置对象不会被修改, 修改过的结构体均为复制后的新对象, 由调用方原子地发布新快照
+func (c *Config) ApplyReload(newConfig *Config) (*Config, *ReloadResult) {
+	result := &ReloadResult{
+		Applied:        make([]string, 0),
+		RequireRestart: make([]string, 0),
+	}
+
+	changed := make([]string, 0)
+	diffFields(reflect.ValueOf(c).Elem(), reflect.ValueOf(newConfig).Elem(), "", &changed)
+	if len(changed) == 0 {
+		return c, result
+	}
+
+	for _, path := range changed {
+		if isHotReloadField(path) {
+			result.Applied = append(result.Applied, path)
+		} else {
+			result.RequireRestart = append(result.RequireRestart, path)
+		}
+	}
+
+	if len(result.Applied) == 0 {
+		return c, result
+	}
+
+	fsdServer, newFsdServer := *c.Server.FSDServer, newConfig.Server.FSDServer
+	fsdServer.FSDName = newFsdServer.FSDName
+	fsdServer.FirstMotdLine = newFsdServer.FirstMotdLine
+	fsdServer.Motd = newFsdServer.Motd
+	fsdServer.CurrentMotd = newFsdServer.CurrentMotd
+	fsdServer.MaxBroadcastWorkers = newFsdServer.MaxBroadcastWorkers
+	fsdServer.RangeLimit = newFsdServer.RangeLimit
+
+	httpServer := *c.Server.HttpServer
+	httpServer.RateLimit = newConfig.Server.HttpServer.RateLimit
+
+	server := *c.Server
+	server.FSDServer = &fsdServer
+	server.HttpServer = &httpServer
+
+	snapshot := *c
+	snapshot.Server = &server
+	snapshot.MetarSource = newConfig.MetarSource
+	snapshot.Rating = newConfig.Rating
+	snapshot.Facility = newConfig.Facility
+
+	return &snapshot, result
+}
+
+// diffFields 递归比较两个结构体, 记录发生变化的字段的json路径
+// 切片和map作为整体比较, 不展开到元素
+func diffFields(oldValue, newValue reflect.Value, prefix string, changed *[]string) {
+	for i := 0; i < oldValue.NumField(); i++ {
+		field := oldValue.Type().Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		oldField, newField := oldValue.Field(i), newValue.Field(i)
+		if oldField.Kind() == reflect.Pointer && oldField.Type().Elem().Kind() == reflect.Struct {
+			if oldField.IsNil() || newField.IsNil() {
+				if oldField.IsNil() != newField.IsNil() {
+					*changed = append(*changed, path)
+				}
+				continue
+			}
+			oldField, newField = oldField.Elem(), newField.Elem()
+		}
+
+		if oldField.Kind() == reflect.Struct {
+			diffFields(oldField, newField, path, changed)
+			continue
+		}
+
+		if !reflect.DeepEqual(oldField.Interface(), newField.Interface()) {
+			*changed = append(*changed, path)
+		}
+	}
+}
@@ -9,6 +9,7 @@ type ServerConfig struct {
 	HttpServer  *HttpServerConfig  `json:"http_server"`
 	VoiceServer *VoiceServerConfig `json:"voice_server"`
 	GRPCServer  *GRPCServerConfig  `json:"grpc_server"`
+	Federation  *FederationConfig  `json:"federation"`
 }
 
 func defaultServerConfig() *ServerConfig {
@@ -18,6 +19,7 @@ func defaultServerConfig() *ServerConfig {
 		HttpServer:  defaultHttpServerConfig(),
 		VoiceServer: defaultVoiceServerConfig(),
 		GRPCServer:  defaultGRPCServerConfig(),
+		Federation:  defaultFederationConfig(),
 	}
 }
 
@@ -37,5 +39,8 @@ func (config *ServerConfig) checkValid(logger log.LoggerInterface) *ValidResult
 	if result := config.GRPCServer.checkValid(logger); result.IsFail() {
 		return result
 	}
+	if result := config.Federation.checkValid(logger); result.IsFail() {
+		return result
+	}
 	return ValidPass()
 }
@@ -28,7 +28,7 @@ func defaultSSLConfig() *SSLConfig {
 func (config *SSLConfig) checkValid(logger log.LoggerInterface) *ValidResult {
 	if config.Enable {
 		if config.CertFile == "" || config.KeyFile == "" {
-			logger.WarnF("HTTPS server requires both cert and key files. Cert: %s, Key: %s. Falling back to HTTP", config.CertFile, config.KeyFile)
+			logger.WarnF("SSL requires both cert and key files. Cert: %s, Key: %s. SSL disabled", config.CertFile, config.KeyFile)
 			config.Enable = false
 		}
 	}
@@ -9,35 +9,41 @@ import (
 )
 
 type ApplicationContent struct {
-	configManager     ConfigManagerInterface
-	cleaner           CleanerInterface
-	clientManager     fsd.ClientManagerInterface
-	connectionManager fsd.ConnectionManagerInterface
-	logger            *log.Loggers
-	messageQueue      queue.MessageQueueInterface
-	metarManager      MetarManagerInterface
-	operations        *operation.DatabaseOperations
+	banManager         BanManagerInterface
+	configManager      ConfigManagerInterface
+	cleaner            CleanerInterface
+	clientManager      fsd.ClientManagerInterface
+	connectionManager  fsd.ConnectionManagerInterface
+	helpRequestManager HelpRequestManagerInterface
+	logger             *log.Loggers
+	messageQueue       queue.MessageQueueInterface
+	metarManager       MetarManagerInterface
+	operations         *operation.DatabaseOperations
 }
 
 func NewApplicationContent(
 	logger *log.Loggers,
+	banManager BanManagerInterface,
 	cleaner CleanerInterface,
 	configManager ConfigManagerInterface,
 	clientManager fsd.ClientManagerInterface,
 	connectionManager fsd.ConnectionManagerInterface,
+	helpRequestManager HelpRequestManagerInterface,
 	messageQueue queue.MessageQueueInterface,
 	metarManager MetarManagerInterface,
 	db *operation.DatabaseOperations,
 ) *ApplicationContent {
 	return &ApplicationContent{
-		configManager:     configManager,
-		cleaner:           cleaner,
-		clientManager:     clientManager,
-		connectionManager: connectionManager,
-		logger:            logger,
-		messageQueue:      messageQueue,
-		metarManager:      metarManager,
-		operations:        db,
+		banManager:         banManager,
+		configManager:      configManager,
+		cleaner:            cleaner,
+		clientManager:      clientManager,
+		connectionManager:  connectionManager,
+		helpRequestManager: helpRequestManager,
+		logger:             logger,
+		messageQueue:       messageQueue,
+		metarManager:       metarManager,
+		operations:         db,
 	}
 }
 
@@ -45,6 +51,8 @@ func (app *ApplicationContent) ConfigManager() ConfigManagerInterface {
 	return app.configManager
 }
 
+func (app *ApplicationContent) BanManager() BanManagerInterface { return app.banManager }
+
 func (app *ApplicationContent) Cleaner() CleanerInterface { return app.cleaner }
 
 func (app *ApplicationContent) ClientManager() fsd.ClientManagerInterface { return app.clientManager }
@@ -53,6 +61,10 @@ func (app *ApplicationContent) ConnectionManager() fsd.ConnectionManagerInterfac
 	return app.connectionManager
 }
 
+func (app *ApplicationContent) HelpRequestManager() HelpRequestManagerInterface {
+	return app.helpRequestManager
+}
+
 func (app *ApplicationContent) Logger() *log.Loggers { return app.logger }
 
 func (app *ApplicationContent) MessageQueue() queue.MessageQueueInterface { return app.messageQueue }
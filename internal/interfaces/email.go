@@ -25,6 +25,8 @@ type EmailSenderInterface interface {
 	SendPasswordResetEmail(data *PasswordResetEmailData) error
 	SendPermissionChangeEmail(data *PermissionChangeEmailData) error
 	SendTicketReplyEmail(data *TicketReplyEmailData) error
+	SendHelpRequestEscalatedEmail(data *HelpRequestEscalatedEmailData) error
+	SendBannedEmail(data *BannedEmailData) error
 }
 
 type EmailMessageHandlerInterface interface {
@@ -38,6 +40,8 @@ type EmailMessageHandlerInterface interface {
 	HandleSendPasswordResetEmailMessage(message *queue.Message) error
 	HandleSendPermissionChangeEmailMessage(message *queue.Message) error
 	HandleSendTicketReplyEmailMessage(message *queue.Message) error
+	HandleSendHelpRequestEscalatedEmailMessage(message *queue.Message) error
+	HandleSendBannedEmailMessage(message *queue.Message) error
 }
 
 type ApplicationPassedEmailData struct {
@@ -181,3 +185,37 @@ type TicketReplyEmail struct {
 	Title string // 工单标题
 	Reply string // 工单回复内容
 }
+
+type HelpRequestEscalatedEmailData struct {
+	Emails  []string
+	Request *operation.HelpRequest
+	Timeout time.Duration
+}
+
+// HelpRequestEscalatedEmail 求助超时未处理通知
+type HelpRequestEscalatedEmail struct {
+	Id       uint   // 求助编号
+	Cid      string // 求助者CID
+	Callsign string // 求助者呼号
+	Position string // 求助时的位置
+	Message  string // 求助内容
+	Time     string // 求助时间
+	Timeout  string // 超时时间
+}
+
+type BannedEmailData struct {
+	User     *operation.User
+	Operator *operation.User
+	Ban      *operation.Ban
+}
+
+// BannedEmail 账号封禁通知
+type BannedEmail struct {
+	Cid      string // 用户CID
+	Time     string // 封禁开始时间
+	Operator string // 操作者CID
+	Scope    string // 封禁范围
+	Expires  string // 到期时间
+	Reason   string // 理由
+	Contact  string // 操作者邮箱
+}
@@ -69,6 +69,11 @@ func BroadcastToSup(toClient, _ ClientInterface) bool {
 	return toClient.Rating() >= Supervisor
 }
 
+// BroadcastToVisualClient 只发送给支持VisualPilot快速位置更新的客户端
+func BroadcastToVisualClient(toClient, _ ClientInterface) bool {
+	return toClient.CheckCapacity(VisualPilot)
+}
+
 func BroadcastToClientInRangeWithThreshold(toClient, fromClient ClientInterface, threshold float64) bool {
 	if fromClient == nil {
 		return true
@@ -3,6 +3,7 @@ package fsd
 
 import (
 	"errors"
+	"time"
 
 	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
 )
@@ -10,24 +11,38 @@ import (
 type Callback func()
 
 type PilotPath struct {
-	Latitude  float64 `json:"latitude"`
-	Longitude float64 `json:"longitude"`
-	Altitude  int     `json:"altitude"`
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	Altitude  int       `json:"altitude"`
+	Time      time.Time `json:"time"`
 }
 
 var (
 	ErrClientDisconnected = errors.New("client disconnected")
 	ErrClientSocketWrite  = errors.New("client socket write error")
+	ErrClientQueueFull    = errors.New("client outbound queue full")
 )
 
 type ClientInterface interface {
 	Disconnected() bool
 	Delete()
 	Reconnect(socket SessionInterface) bool
+	// IssueResumeToken 生成新的恢复令牌, 之前下发的令牌失效
+	IssueResumeToken() string
+	// CheckResumeToken 检查恢复会话时提交的令牌
+	CheckResumeToken(token string) bool
+	// ResumeToken 当前有效的恢复令牌, 未下发时返回空字符串
+	ResumeToken() string
 	MarkedDisconnect(immediate bool)
+	// Discard 丢弃未能加入客户端管理器的客户端, 只释放发送队列, 不保存记录也不影响同呼号的其他客户端
+	Discard()
 	UpsertFlightPlan(flightPlanData []string) error
 	SetPosition(index int, lat float64, lon float64) error
 	UpdatePilotPos(transponder int, lat float64, lon float64, alt int, groundSpeed int, pbh uint32)
+	UpdateVisualPos(position *VisualPosition)
+	VisualPosition() *VisualPosition
+	// SetFastUpdate 记录是否已要求客户端发送快速位置更新, 返回状态是否发生变化
+	SetFastUpdate(enabled bool) bool
 	UpdateAtcPos(frequency int, facility Facility, visualRange float64, lat float64, lon float64)
 	UpdateAtcVisPoint(visIndex int, lat float64, lon float64) error
 	ClearAtcAtisInfo()
@@ -36,13 +51,22 @@ type ClientInterface interface {
 	SendLineWithoutLog(line []byte) error
 	SendLine(line []byte)
 	SendMotd()
+	ResetMotd()
 	UpdateCapacities(capacities []string)
 	CheckCapacity(capacity string) bool
 	CheckFacility(facility Facility) bool
 	CheckRating(rating []Rating) bool
 	IsAtc() bool
 	IsAtis() bool
+	// IsRemote 是否为联邦节点上的远程客户端
+	IsRemote() bool
+	// IsVirtual 是否为服务器生成的虚拟客户端, 例如自动ATIS, 没有对应的连接和用户
+	IsVirtual() bool
 	Callsign() string
+	// RemoteAddr 客户端的远程地址, 本节点以外的客户端返回所在节点
+	RemoteAddr() string
+	// ClientSoftware 客户端软件描述, 来自$ID或者登录时的协议信息
+	ClientSoftware() string
 	Rating() Rating
 	Facility() Facility
 	RealName() string
@@ -57,6 +81,8 @@ type ClientInterface interface {
 	History() *operation.History
 	Transponder() string
 	Altitude() int
+	// FlightPhase 机组当前飞行阶段, 管制员返回空字符串
+	FlightPhase() FlightPhase
 	GroundSpeed() int
 	Heading() int
 	Paths() []*PilotPath
@@ -17,7 +17,10 @@ var (
 type ClientManagerInterface interface {
 	GetWhazzupContent() *OnlineClients
 	Shutdown(ctx context.Context) error
+	// GetClientSnapshot 获取全部客户端的快照, 切片来自对象池, 使用完毕后需要调用 ReleaseClientSnapshot
 	GetClientSnapshot() []ClientInterface
+	// ReleaseClientSnapshot 将快照切片放回对象池, 调用后不能再使用该切片
+	ReleaseClientSnapshot(clients []ClientInterface)
 	AddClient(client ClientInterface) error
 	GetClient(callsign string) (ClientInterface, bool)
 	DeleteClient(callsign string) bool
@@ -27,6 +30,16 @@ type ClientManagerInterface interface {
 	KickClientFromServer(callsign string, reason string) (ClientInterface, error)
 	SendMessageTo(callsign string, message []byte) error
 	BroadcastMessage(message []byte, fromClient ClientInterface, filter BroadcastFilter)
+	// BroadcastMessageInRange 向fromClient范围内满足过滤条件的客户端广播, 通过空间索引筛选候选客户端
+	BroadcastMessageInRange(message []byte, fromClient ClientInterface, filter BroadcastFilter)
+	// HasClientInRange 是否存在除fromClient以外在范围内且满足过滤条件的在线客户端
+	HasClientInRange(fromClient ClientInterface, filter BroadcastFilter) bool
+	// UpdateClientPosition 客户端位置或视程变化后调用, 更新空间索引
+	UpdateClientPosition(client ClientInterface)
+	FlightDataStore() FlightDataStoreInterface
+	SquawkAllocator() SquawkAllocatorInterface
+	SectorManager() SectorManagerInterface
+	TrafficHub() TrafficHubInterface
 }
 
 type BroadcastMessageData struct {
@@ -73,9 +86,11 @@ type OnlinePilot struct {
 	Latitude    float64               `json:"latitude"`
 	Longitude   float64               `json:"longitude"`
 	Transponder string                `json:"transponder"`
+	Assigned    string                `json:"assigned_transponder,omitempty"` // 应答机编码分配器为该机组预留的编码
 	Heading     int                   `json:"heading"`
 	Altitude    int                   `json:"altitude"`
 	GroundSpeed int                   `json:"ground_speed"`
+	FlightPhase FlightPhase           `json:"flight_phase"`
 	FlightPlan  *operation.FlightPlan `json:"flight_plan"`
 	LogonTime   string                `json:"logon_time"`
 }
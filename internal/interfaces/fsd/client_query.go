@@ -15,6 +15,12 @@ const (
 	IHoleTag         = "IH"
 	ITakeTag         = "IT"
 	DropTag          = "DR"
+	HandoffTransfer  = "HT"
+	ScratchPad       = "SC"
+	TempAltitude     = "TA"
+	BeaconCode       = "BC"
+	AssignSquawk     = "SQ"
+	ClientRealName   = "RN"
 )
 
 const (
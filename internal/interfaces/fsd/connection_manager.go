@@ -1,15 +1,53 @@
 // Package fsd
 package fsd
 
-import "errors"
+import (
+	"errors"
+	"strings"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+)
 
 var (
-	ErrCidNotFound        = errors.New("target cid not found")
-	ErrConnectionNotFound = errors.New("connection not found")
+	ErrCidNotFound            = errors.New("target cid not found")
+	ErrConnectionNotFound     = errors.New("connection not found")
+	ErrConnectionLimitReached = errors.New("connection limit reached")
 )
 
+type ConnectionKind string
+
+const (
+	ConnectionPilot    ConnectionKind = "pilot"
+	ConnectionAtc      ConnectionKind = "atc"
+	ConnectionAtis     ConnectionKind = "atis"
+	ConnectionObserver ConnectionKind = "observer"
+)
+
+// GetConnectionKind 通过呼号后缀判断连接类型
+func GetConnectionKind(callsign string, isAtc bool) ConnectionKind {
+	if !isAtc {
+		return ConnectionPilot
+	}
+	if strings.HasSuffix(callsign, "ATIS") {
+		return ConnectionAtis
+	}
+	if index := strings.LastIndex(callsign, "_"); index != -1 {
+		if facility, ok := LookupFacility(callsign[index+1:]); ok && facility == OBS {
+			return ConnectionObserver
+		}
+	}
+	return ConnectionAtc
+}
+
 type ConnectionManagerInterface interface {
-	AddConnection(client ClientInterface)
+	// AddConnection 在同一把锁内检查连接数限制并记录连接, 超出限制时返回 ErrConnectionLimitReached
+	AddConnection(client ClientInterface) error
 	RemoveConnection(client ClientInterface) error
 	GetConnections(cid int) ([]ClientInterface, error)
+	// CheckConnectionPolicy 检查用户能否以该呼号新建连接, 超出限制时返回 ErrConnectionLimitReached
+	// 只用于创建客户端前提前拒绝, 最终以 AddConnection 的检查为准
+	CheckConnectionPolicy(user *operation.User, callsign string, isAtc bool) error
+	// ResumeConnection 检查用户能否恢复已断开的连接, 被恢复的连接本身不计入, 超出限制时返回 ErrConnectionLimitReached
+	// 检查通过后调用 resume 恢复连接, 返回 resume 的结果, 恢复期间该连接计入连接数
+	ResumeConnection(user *operation.User, client ClientInterface, resume func() bool) (bool, error)
 }
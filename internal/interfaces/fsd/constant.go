@@ -1,5 +1,7 @@
 package fsd
 
+import "maps"
+
 type Enum interface {
 	String() string
 	Index() int
@@ -11,7 +13,8 @@ const AllowAtcFacility = DEL | GND | TWR | APP | CTR | FSS
 
 var AllowKillRating = []Rating{Supervisor, Administrator}
 
-var RatingFacilityMap = map[Rating]Facility{
+// defaultRatingFacilityMap 内置的权限席位映射, 配置文件中的rating在此基础上覆盖
+var defaultRatingFacilityMap = map[Rating]Facility{
 	Ban:           0,
 	Normal:        Pilot,
 	Observer:      Pilot | OBS,
@@ -28,7 +31,8 @@ var RatingFacilityMap = map[Rating]Facility{
 	Administrator: Pilot | OBS | DEL | GND | RMP | TWR | APP | CTR | FSS | SUP | ADM,
 }
 
-var FacilityMap = map[string]Facility{
+// defaultFacilityMap 内置的呼号后缀席位映射, 配置文件中的facility在此基础上覆盖
+var defaultFacilityMap = map[string]Facility{
 	"ADM":  ADM,
 	"SUP":  SUP,
 	"OBS":  OBS,
@@ -41,3 +45,7 @@ var FacilityMap = map[string]Facility{
 	"FSS":  FSS,
 	"ATIS": TWR,
 }
+
+var RatingFacilityMap = maps.Clone(defaultRatingFacilityMap)
+
+var FacilityMap = maps.Clone(defaultFacilityMap)
@@ -40,3 +40,43 @@ func FindNearestDistance(groupA, groupB [4]Position) (minDistance float64) {
 	}
 	return
 }
+
+// UnwrapLongitudes 展开跨越180度经线的多边形, 使相邻顶点的经度差不超过180度
+// 展开后的经度可能超出[-180, 180], 返回新的切片, 不修改原多边形
+func UnwrapLongitudes(polygon []Position) []Position {
+	result := make([]Position, len(polygon))
+	copy(result, polygon)
+	for i := 1; i < len(result); i++ {
+		for result[i].Longitude-result[i-1].Longitude > 180 {
+			result[i].Longitude -= 360
+		}
+		for result[i].Longitude-result[i-1].Longitude < -180 {
+			result[i].Longitude += 360
+		}
+	}
+	return result
+}
+
+// PointInPolygon 使用射线法判断点是否在多边形内
+// 跨越180度经线的多边形需要先经过 UnwrapLongitudes 展开, 点的经度会依次尝试加减360度
+// 边界上的点按半开区间处理, 相邻多边形的公共边和公共顶点只属于其中一个多边形
+func PointInPolygon(point Position, polygon []Position) bool {
+	for _, offset := range [3]float64{0, 360, -360} {
+		if pointInRing(Position{Latitude: point.Latitude, Longitude: point.Longitude + offset}, polygon) {
+			return true
+		}
+	}
+	return false
+}
+
+func pointInRing(point Position, polygon []Position) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.Latitude > point.Latitude) != (b.Latitude > point.Latitude) &&
+			point.Longitude < (b.Longitude-a.Longitude)*(point.Latitude-a.Latitude)/(b.Latitude-a.Latitude)+a.Longitude {
+			inside = !inside
+		}
+	}
+	return inside
+}
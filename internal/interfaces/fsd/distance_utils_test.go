@@ -0,0 +1,137 @@
+package fsd
+
+import "testing"
+
+func square(minLat, minLon, maxLat, maxLon float64) []Position {
+	return []Position{
+		{Latitude: minLat, Longitude: minLon},
+		{Latitude: minLat, Longitude: maxLon},
+		{Latitude: maxLat, Longitude: maxLon},
+		{Latitude: maxLat, Longitude: minLon},
+	}
+}
+
+func TestPointInPolygon(t *testing.T) {
+	concave := []Position{
+		{Latitude: 0, Longitude: 0},
+		{Latitude: 0, Longitude: 10},
+		{Latitude: 10, Longitude: 10},
+		{Latitude: 10, Longitude: 6},
+		{Latitude: 4, Longitude: 6},
+		{Latitude: 4, Longitude: 4},
+		{Latitude: 10, Longitude: 4},
+		{Latitude: 10, Longitude: 0},
+	}
+	antimeridian := UnwrapLongitudes([]Position{
+		{Latitude: 50, Longitude: 170},
+		{Latitude: 50, Longitude: -170},
+		{Latitude: 60, Longitude: -170},
+		{Latitude: 60, Longitude: 170},
+	})
+
+	tests := []struct {
+		name     string
+		point    Position
+		polygon  []Position
+		expected bool
+	}{
+		{"inside", Position{Latitude: 5, Longitude: 5}, square(0, 0, 10, 10), true},
+		{"outside", Position{Latitude: 15, Longitude: 5}, square(0, 0, 10, 10), false},
+		{"negative inside", Position{Latitude: -33.9, Longitude: 151.2}, square(-34, 151, -33, 152), true},
+		{"concave arm", Position{Latitude: 8, Longitude: 2}, concave, true},
+		{"concave notch", Position{Latitude: 8, Longitude: 5}, concave, false},
+		{"concave base", Position{Latitude: 2, Longitude: 5}, concave, true},
+		{"lower edge", Position{Latitude: 0, Longitude: 5}, square(0, 0, 10, 10), true},
+		{"upper edge", Position{Latitude: 10, Longitude: 5}, square(0, 0, 10, 10), false},
+		{"left edge", Position{Latitude: 5, Longitude: 0}, square(0, 0, 10, 10), true},
+		{"right edge", Position{Latitude: 5, Longitude: 10}, square(0, 0, 10, 10), false},
+		{"lower left vertex", Position{Latitude: 0, Longitude: 0}, square(0, 0, 10, 10), true},
+		{"upper right vertex", Position{Latitude: 10, Longitude: 10}, square(0, 0, 10, 10), false},
+		{"antimeridian east", Position{Latitude: 55, Longitude: 175}, antimeridian, true},
+		{"antimeridian west", Position{Latitude: 55, Longitude: -175}, antimeridian, true},
+		{"antimeridian 180", Position{Latitude: 55, Longitude: 180}, antimeridian, true},
+		{"antimeridian -180", Position{Latitude: 55, Longitude: -180}, antimeridian, true},
+		{"antimeridian outside east", Position{Latitude: 55, Longitude: -160}, antimeridian, false},
+		{"antimeridian outside west", Position{Latitude: 55, Longitude: 160}, antimeridian, false},
+		{"antimeridian outside north", Position{Latitude: 65, Longitude: 179}, antimeridian, false},
+		{"degenerate", Position{Latitude: 0, Longitude: 0}, []Position{}, false},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		result := PointInPolygon(test.point, test.polygon)
+		if result != test.expected {
+			fail++
+			t.Errorf("PointInPolygon(%s %+v) = %v; expected %v", test.name, test.point, result, test.expected)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestPointInPolygon: %d pass, %d fail", pass, fail)
+}
+
+func TestPointInPolygonSharedBoundary(t *testing.T) {
+	// 四个相邻扇区的公共边和公共顶点上的点只能属于其中一个扇区
+	sectors := [][]Position{
+		square(0, 0, 10, 10),
+		square(0, 10, 10, 20),
+		square(10, 0, 20, 10),
+		square(10, 10, 20, 20),
+	}
+	tests := []Position{
+		{Latitude: 5, Longitude: 10},
+		{Latitude: 15, Longitude: 10},
+		{Latitude: 10, Longitude: 5},
+		{Latitude: 10, Longitude: 15},
+		{Latitude: 10, Longitude: 10},
+	}
+	pass := 0
+	fail := 0
+	for _, point := range tests {
+		count := 0
+		for _, sector := range sectors {
+			if PointInPolygon(point, sector) {
+				count++
+			}
+		}
+		if count != 1 {
+			fail++
+			t.Errorf("point %+v is inside %d sectors; expected 1", point, count)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestPointInPolygonSharedBoundary: %d pass, %d fail", pass, fail)
+}
+
+func TestUnwrapLongitudes(t *testing.T) {
+	tests := []struct {
+		input    []float64
+		expected []float64
+	}{
+		{[]float64{10, 20, 30}, []float64{10, 20, 30}},
+		{[]float64{170, -170, -170, 170}, []float64{170, 190, 190, 170}},
+		{[]float64{-170, 170, 170, -170}, []float64{-170, -190, -190, -170}},
+		{[]float64{}, []float64{}},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		polygon := make([]Position, 0, len(test.input))
+		for _, longitude := range test.input {
+			polygon = append(polygon, Position{Latitude: 0, Longitude: longitude})
+		}
+		result := UnwrapLongitudes(polygon)
+		matched := len(result) == len(test.expected)
+		for i := 0; matched && i < len(result); i++ {
+			matched = result[i].Longitude == test.expected[i]
+		}
+		if !matched || (len(polygon) > 1 && polygon[1].Longitude != test.input[1]) {
+			fail++
+			t.Errorf("UnwrapLongitudes(%v) = %+v; expected %v", test.input, result, test.expected)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestUnwrapLongitudes: %d pass, %d fail", pass, fail)
+}
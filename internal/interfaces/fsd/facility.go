@@ -3,7 +3,9 @@ package fsd
 
 import (
 	"fmt"
+	"maps"
 	"strings"
+	"sync"
 
 	"github.com/half-nothing/simple-fsd/internal/interfaces/config"
 	"github.com/half-nothing/simple-fsd/internal/utils"
@@ -47,6 +49,9 @@ var Facilities = []*FacilityModel{
 
 var facilitiesIndex = map[Facility]int{OBS: 0, FSS: 1, DEL: 2, GND: 3, TWR: 4, APP: 5, CTR: 6, Pilot: 7, RMP: 8, SUP: 9, ADM: 10}
 
+// mappingLock 保护可以在配置重载时修改的权限席位映射和视程限制
+var mappingLock sync.RWMutex
+
 var facilityRangeLimit = map[Facility]int{Pilot: 50, OBS: 300, DEL: 20, GND: 20, TWR: 50, APP: 150, CTR: 600, FSS: 600, RMP: 20, SUP: 300, ADM: 300}
 
 func (f Facility) String() string {
@@ -62,42 +67,59 @@ func (f Facility) CheckFacility(facility Facility) bool {
 }
 
 func (f Facility) GetRangeLimit() int {
+	mappingLock.RLock()
+	defer mappingLock.RUnlock()
 	return facilityRangeLimit[f]
 }
 
 func (r Rating) CheckRatingFacility(facility Facility) bool {
+	mappingLock.RLock()
+	defer mappingLock.RUnlock()
 	return RatingFacilityMap[r].CheckFacility(facility)
 }
 
+// LookupFacility 根据呼号后缀查找对应的席位
+func LookupFacility(ident string) (Facility, bool) {
+	mappingLock.RLock()
+	defer mappingLock.RUnlock()
+	facility, ok := FacilityMap[ident]
+	return facility, ok
+}
+
+// SyncRatingConfig 以内置映射为基础重建权限席位映射, 配置中删除的项会恢复为默认值
 func SyncRatingConfig(config *config.Config) error {
-	if len(config.Rating) == 0 {
-		return nil
-	}
+	ratings := maps.Clone(defaultRatingFacilityMap)
 	for rating, facility := range config.Rating {
 		r := utils.StrToInt(rating, int(Ban)-1)
 		if !IsValidRating(r) {
 			return fmt.Errorf("illegal permission value %s", rating)
 		}
-		RatingFacilityMap[Rating(r)] = Facility(facility)
+		ratings[Rating(r)] = Facility(facility)
 	}
+	mappingLock.Lock()
+	defer mappingLock.Unlock()
+	RatingFacilityMap = ratings
 	return nil
 }
 
+// SyncFacilityConfig 以内置映射为基础重建呼号后缀席位映射, 配置中删除的项不再生效
 func SyncFacilityConfig(config *config.Config) error {
-	if len(config.Facility) == 0 {
-		return nil
-	}
+	facilities := maps.Clone(defaultFacilityMap)
 	for ident, facility := range config.Facility {
 		if facility < 0 {
 			return fmt.Errorf("illegal facility ident value %d", facility)
 		}
-		ident = strings.ToUpper(ident)
-		FacilityMap[ident] = Facility(facility)
+		facilities[strings.ToUpper(ident)] = Facility(facility)
 	}
+	mappingLock.Lock()
+	defer mappingLock.Unlock()
+	FacilityMap = facilities
 	return nil
 }
 
 func SyncRangeLimit(config *config.FsdRangeLimit) {
+	mappingLock.Lock()
+	defer mappingLock.Unlock()
 	facilityRangeLimit[OBS] = config.Observer
 	facilityRangeLimit[DEL] = config.Delivery
 	facilityRangeLimit[GND] = config.Ground
@@ -0,0 +1,90 @@
+package fsd
+
+import (
+	"testing"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/config"
+)
+
+func TestSyncFacilityReload(t *testing.T) {
+	t.Cleanup(func() {
+		_ = SyncRatingConfig(&config.Config{})
+		_ = SyncFacilityConfig(&config.Config{})
+	})
+
+	custom := &config.Config{
+		Rating:   map[string]int{"1": int(Pilot | OBS | DEL)},
+		Facility: map[string]int{"dep": int(DEL), "ATIS": int(GND)},
+	}
+	tests := []struct {
+		name             string
+		config           *config.Config
+		expectedObsDel   bool
+		expectedDep      bool
+		expectedAtis     Facility
+		expectedSupValid bool
+	}{
+		{"default", &config.Config{}, false, false, TWR, true},
+		{"custom", custom, true, true, GND, true},
+		{"removed", &config.Config{}, false, false, TWR, true},
+		{"partial", &config.Config{Facility: map[string]int{"DEP": int(DEL)}}, false, true, TWR, true},
+		{"removed again", &config.Config{Rating: map[string]int{}, Facility: map[string]int{}}, false, false, TWR, true},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		if err := SyncRatingConfig(test.config); err != nil {
+			fail++
+			t.Errorf("SyncRatingConfig(%s) = %v; expected nil", test.name, err)
+			continue
+		}
+		if err := SyncFacilityConfig(test.config); err != nil {
+			fail++
+			t.Errorf("SyncFacilityConfig(%s) = %v; expected nil", test.name, err)
+			continue
+		}
+		obsDel := Observer.CheckRatingFacility(DEL)
+		_, dep := LookupFacility("DEP")
+		atis, _ := LookupFacility("ATIS")
+		_, sup := LookupFacility("SUP")
+		if obsDel != test.expectedObsDel || dep != test.expectedDep || atis != test.expectedAtis || sup != test.expectedSupValid {
+			fail++
+			t.Errorf("sync(%s) = %v, %v, %s, %v; expected %v, %v, %s, %v",
+				test.name, obsDel, dep, atis, sup, test.expectedObsDel, test.expectedDep, test.expectedAtis, test.expectedSupValid)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestSyncFacilityReload: %d pass, %d fail", pass, fail)
+}
+
+func TestSyncFacilityInvalid(t *testing.T) {
+	t.Cleanup(func() {
+		_ = SyncRatingConfig(&config.Config{})
+		_ = SyncFacilityConfig(&config.Config{})
+	})
+	_ = SyncFacilityConfig(&config.Config{Facility: map[string]int{"DEP": int(DEL)}})
+
+	tests := []struct {
+		name   string
+		config *config.Config
+		sync   func(*config.Config) error
+	}{
+		{"illegal rating", &config.Config{Rating: map[string]int{"99": int(Pilot)}}, SyncRatingConfig},
+		{"illegal facility", &config.Config{Facility: map[string]int{"XXX": -1}}, SyncFacilityConfig},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		err := test.sync(test.config)
+		// 校验失败时保留原有映射
+		_, dep := LookupFacility("DEP")
+		if err == nil || !dep || Observer.CheckRatingFacility(DEL) {
+			fail++
+			t.Errorf("sync(%s) = %v, DEP kept %v; expected error and DEP kept", test.name, err, dep)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestSyncFacilityInvalid: %d pass, %d fail", pass, fail)
+}
@@ -0,0 +1,34 @@
+// Package fsd
+package fsd
+
+import "time"
+
+// AssignedValue 管制员为航空器指定的数据, 例如临时高度, 应答机编码, 草稿板
+type AssignedValue struct {
+	Value     string    `json:"value"`
+	SetBy     string    `json:"set_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// FlightData 服务器保存的航空器协调数据, 包括标牌归属, 指定数据与移交状态
+type FlightData struct {
+	Callsign      string                    `json:"callsign"`
+	Owner         string                    `json:"owner"`
+	HandoffFrom   string                    `json:"handoff_from"`
+	HandoffTarget string                    `json:"handoff_target"`
+	Assigned      map[string]*AssignedValue `json:"assigned"` // 键为EuroScope协调子命令, 例如SC, TA, BC
+	UpdatedAt     time.Time                 `json:"updated_at"`
+}
+
+type FlightDataStoreInterface interface {
+	// HandleCoordination 处理发往@94835的协调子命令, args[0]为目标航空器呼号
+	HandleCoordination(from string, subQuery string, args []string)
+	RequestHandoff(from string, to string, callsign string)
+	AcceptHandoff(from string, to string, callsign string)
+	// ReplayTo 将当前的协调数据发送给刚登录或重连的管制员
+	ReplayTo(client ClientInterface)
+	// RemoveClient 客户端被删除时清理相关的协调数据
+	RemoveClient(client ClientInterface)
+	GetFlightData(callsign string) (*FlightData, bool)
+	GetFlightDataList() []*FlightData
+}
@@ -0,0 +1,16 @@
+// Package fsd
+package fsd
+
+// FlightPhase 机组飞行阶段
+type FlightPhase string
+
+const (
+	PhasePreflight FlightPhase = "preflight" // 停机位准备
+	PhaseTaxiOut   FlightPhase = "taxi_out"  // 推出滑行
+	PhaseAirborne  FlightPhase = "airborne"  // 起飞爬升
+	PhaseEnRoute   FlightPhase = "en_route"  // 航路
+	PhaseApproach  FlightPhase = "approach"  // 进近
+	PhaseLanded    FlightPhase = "landed"    // 落地减速
+	PhaseTaxiIn    FlightPhase = "taxi_in"   // 落地滑行
+	PhaseArrived   FlightPhase = "arrived"   // 到达停机位
+)
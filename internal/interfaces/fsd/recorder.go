@@ -0,0 +1,22 @@
+// Package fsd
+package fsd
+
+type RecordDirection byte
+
+const (
+	RecordInbound  RecordDirection = '>' // 客户端发往服务器
+	RecordOutbound RecordDirection = '<' // 服务器发往客户端
+)
+
+// SessionRecorderInterface 会话录制器, 为每个连接创建一份录制
+type SessionRecorderInterface interface {
+	NewRecord(connId string) SessionRecordInterface
+}
+
+// SessionRecordInterface 单个会话的录制
+type SessionRecordInterface interface {
+	// Identify 客户端登录后调用, 录制器据此决定是否保留该会话的录制
+	Identify(callsign string, cid int)
+	Record(direction RecordDirection, line []byte)
+	Close()
+}
@@ -0,0 +1,34 @@
+// Package fsd
+package fsd
+
+import "errors"
+
+var ErrSectorDisabled = errors.New("sector ownership disabled")
+
+// SectorStatus 扇区及当前负责的在线管制员
+type SectorStatus struct {
+	Name       string       `json:"name"`
+	Floor      int          `json:"floor"`
+	Ceiling    int          `json:"ceiling"`
+	Owners     []string     `json:"owners"`
+	Polygon    [][2]float64 `json:"polygon"`
+	Controller string       `json:"controller"` // 为空表示无人值守
+}
+
+// AircraftOwnership 航空器所在扇区及负责的管制员
+type AircraftOwnership struct {
+	Callsign   string `json:"callsign"`
+	Altitude   int    `json:"altitude"`
+	Sector     string `json:"sector"`     // 为空表示不在任何扇区内
+	Controller string `json:"controller"` // 为空表示所在扇区无人值守
+}
+
+type SectorManagerInterface interface {
+	Enabled() bool
+	// Responsible 查找负责指定位置和高度的扇区与在线管制员, 扇区无人值守时管制员为nil
+	Responsible(position Position, altitude int) (sector string, controller ClientInterface)
+	GetSectors() []*SectorStatus
+	GetAircraftOwnership() []*AircraftOwnership
+	// FrequencyFilter 生成频率消息的接收过滤器, 未启用或频率上没有扇区管制员时返回nil
+	FrequencyFilter(frequency int) BroadcastFilter
+}
@@ -3,10 +3,29 @@ package fsd
 
 import (
 	"net"
+	"strconv"
 
 	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
 )
 
+type SessionState int32
+
+const (
+	SessionConnected      SessionState = iota // 已连接, 等待登录
+	SessionAuthenticating                     // 正在处理登录命令
+	SessionAuthenticated                      // 已登录
+	SessionClosing                            // 正在断开
+)
+
+var sessionStateNames = []string{"connected", "authenticating", "authenticated", "closing"}
+
+func (state SessionState) String() string {
+	if state < 0 || int(state) >= len(sessionStateNames) {
+		return strconv.Itoa(int(state))
+	}
+	return sessionStateNames[state]
+}
+
 type SessionInterface interface {
 	Callsign() string
 	SetCallsign(callsign string)
@@ -15,8 +34,15 @@ type SessionInterface interface {
 	ConnId() string
 	Conn() net.Conn
 	SetDisconnected(disconnect bool)
+	// State 会话当前的登录状态
+	State() SessionState
 	Client() ClientInterface
 	SetClient(client ClientInterface)
 	FacilityIdent() Facility
+	// ClientSoftware 客户端通过$ID上报的软件名称和版本
+	ClientSoftware() string
+	SetClientSoftware(software string)
 	SetFacilityIdent(facility Facility)
+	// Record 录制该会话收发的数据, 未启用录制时为空操作
+	Record(direction RecordDirection, line []byte)
 }
@@ -0,0 +1,18 @@
+// Package fsd
+package fsd
+
+import "errors"
+
+var (
+	ErrSquawkDisabled  = errors.New("squawk allocator disabled")
+	ErrSquawkNoRange   = errors.New("no squawk range matched")
+	ErrSquawkExhausted = errors.New("squawk range exhausted")
+)
+
+type SquawkAllocatorInterface interface {
+	// Allocate 为指定呼号分配应答机编码, 已有预留且仍然可用时返回原编码
+	Allocate(callsign string) (string, error)
+	// Release 释放指定呼号预留的编码
+	Release(callsign string)
+	GetReservation(callsign string) (string, bool)
+}
@@ -0,0 +1,96 @@
+// Package fsd
+package fsd
+
+import (
+	"time"
+
+	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+)
+
+type TrafficEventType string
+
+const (
+	TrafficConnect    TrafficEventType = "connect"
+	TrafficDisconnect TrafficEventType = "disconnect"
+	TrafficPosition   TrafficEventType = "position"
+	TrafficFlightPlan TrafficEventType = "flight_plan"
+	TrafficAtis       TrafficEventType = "atis"
+	// TrafficLeave 客户端离开订阅范围, 只由订阅者生成
+	TrafficLeave TrafficEventType = "leave"
+)
+
+// TrafficEvent 实时交通事件, 不同类型的事件只填充相关字段
+type TrafficEvent struct {
+	Type        TrafficEventType      `json:"type"`
+	Callsign    string                `json:"callsign"`
+	Cid         int                   `json:"cid,omitempty"`
+	IsAtc       bool                  `json:"is_atc"`
+	RealName    string                `json:"real_name,omitempty"`
+	Latitude    float64               `json:"latitude,omitempty"`
+	Longitude   float64               `json:"longitude,omitempty"`
+	Altitude    int                   `json:"altitude,omitempty"`
+	GroundSpeed int                   `json:"ground_speed,omitempty"`
+	Heading     int                   `json:"heading,omitempty"`
+	Transponder string                `json:"transponder,omitempty"`
+	FlightPhase FlightPhase           `json:"flight_phase,omitempty"`
+	Frequency   int                   `json:"frequency,omitempty"`
+	Facility    int                   `json:"facility,omitempty"`
+	VisualRange int                   `json:"visual_range,omitempty"`
+	FlightPlan  *operation.FlightPlan `json:"flight_plan,omitempty"`
+	AtisInfo    []string              `json:"atis_info,omitempty"`
+	Time        time.Time             `json:"time"`
+}
+
+func (event *TrafficEvent) setPosition(client ClientInterface) {
+	position := client.Position()[0]
+	event.Latitude = position.Latitude
+	event.Longitude = position.Longitude
+	if client.IsAtc() {
+		event.Frequency = client.Frequency() + 100000
+		event.Facility = client.Facility().Index()
+		event.VisualRange = int(client.VisualRange())
+		return
+	}
+	event.Altitude = client.Altitude()
+	event.GroundSpeed = client.GroundSpeed()
+	event.Heading = client.Heading()
+	event.Transponder = client.Transponder()
+	event.FlightPhase = client.FlightPhase()
+}
+
+// NewTrafficEvent 根据客户端当前状态生成事件
+func NewTrafficEvent(eventType TrafficEventType, client ClientInterface) *TrafficEvent {
+	event := &TrafficEvent{
+		Type:     eventType,
+		Callsign: client.Callsign(),
+		IsAtc:    client.IsAtc(),
+		Time:     time.Now(),
+	}
+	switch eventType {
+	case TrafficConnect:
+		if user := client.User(); user != nil {
+			event.Cid = user.Cid
+		}
+		event.RealName = client.RealName()
+		event.setPosition(client)
+		event.FlightPlan = client.FlightPlan()
+		event.AtisInfo = client.AtisInfo()
+	case TrafficPosition:
+		event.setPosition(client)
+	case TrafficFlightPlan:
+		event.FlightPlan = client.FlightPlan()
+	case TrafficAtis:
+		event.AtisInfo = client.AtisInfo()
+	default:
+	}
+	return event
+}
+
+type TrafficHandler func(event *TrafficEvent)
+
+type TrafficHubInterface interface {
+	// Publish 发布客户端事件, 没有订阅者时直接返回, 不会生成事件
+	Publish(eventType TrafficEventType, client ClientInterface)
+	// Subscribe 订阅所有事件, handler在发布者的协程中同步调用, 不能阻塞
+	Subscribe(handler TrafficHandler) (unsubscribe func())
+}
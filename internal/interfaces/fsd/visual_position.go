@@ -0,0 +1,19 @@
+// Package fsd
+package fsd
+
+// VisualPosition VisualPilot快速位置更新(^, #SL, #ST)携带的数据
+type VisualPosition struct {
+	Latitude        float64
+	Longitude       float64
+	AltitudeTrue    float64
+	AltitudeAgl     float64
+	Pbh             uint32
+	VelocityX       float64 // 经度方向速度, 单位m/s
+	VelocityY       float64 // 高度方向速度, 单位m/s
+	VelocityZ       float64 // 纬度方向速度, 单位m/s
+	VelocityPitch   float64 // 俯仰角速度, 单位rad/s
+	VelocityHeading float64 // 航向角速度, 单位rad/s
+	VelocityBank    float64 // 滚转角速度, 单位rad/s
+	NoseGearAngle   float64
+	Stopped         bool
+}
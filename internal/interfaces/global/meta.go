@@ -24,6 +24,7 @@ var (
 	WebsocketHeartbeatInterval  = flag.Duration("websocket_heartbeat_interval", 30*time.Second, "Websocket heartbeat interval")
 	WebsocketTimeout            = flag.Duration("websocket_timeout", 60*time.Second, "Websocket timeout")
 	WebsocketMessageChannelSize = flag.Int("websocket_message_channel_size", 128, "Websocket message channel size")
+	TrafficStreamInterval       = flag.Duration("traffic_stream_interval", time.Second, "Minimum interval of position events sent to traffic stream subscribers")
 )
 
 const (
@@ -49,6 +50,7 @@ const (
 	EnvWebsocketHeartbeatInterval  = "WEBSOCKET_HEART_INTERVAL"
 	EnvWebsocketTimeout            = "WEBSOCKET_TIMEOUT"
 	EnvWebsocketMessageChannelSize = "WEBSOCKET_MESSAGE_CHANNEL_SIZE"
+	EnvTrafficStreamInterval       = "TRAFFIC_STREAM_INTERVAL"
 
 	LogFilePath  = "logs"
 	MainLogName  = "main"
@@ -73,10 +75,14 @@ const (
 	ApplicationRejectedTemplateFilePath   = "/template/application_rejected.template"
 	ApplicationProcessingTemplateFilePath = "/template/application_processing.template"
 	TicketReplyTemplateFilePath           = "/template/ticket_reply.template"
+	HelpRequestEscalatedTemplateFilePath  = "/template/help_request_escalated.template"
+	BannedTemplateFilePath                = "/template/banned.template"
 
 	DefaultFilePermissions     = 0644
 	DefaultDirectoryPermission = 0755
 
 	FSDServerName      = "SERVER"
 	FSDDisconnectDelay = 100 * time.Millisecond
+
+	FSDCertificateCheckInterval = 30 * time.Second
 )
@@ -0,0 +1,19 @@
+// Package interfaces
+package interfaces
+
+import (
+	"github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+)
+
+// HelpRequestManagerInterface 求助队列管理, FSD命令与HTTP接口共用
+type HelpRequestManagerInterface interface {
+	// Submit 创建求助并通知在线监管, 没有监管在线时求助保留在队列中
+	Submit(client fsd.ClientInterface, message string) (request *operation.HelpRequest, err error)
+	// Claim 认领求助, callsign为空时使用CID作为处理人名称
+	Claim(id uint, cid int, callsign string) (request *operation.HelpRequest, err error)
+	// Resolve 解决求助
+	Resolve(id uint, cid int, callsign string, resolution string) (request *operation.HelpRequest, err error)
+	// OpenRequests 获取所有未解决的求助
+	OpenRequests() (requests []*operation.HelpRequest, err error)
+}
@@ -0,0 +1,66 @@
+// Package service
+package service
+
+import "github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+
+var (
+	ErrBanNotFound      = NewApiStatus("BAN_NOT_FOUND", "封禁记录不存在", NotFound)
+	ErrBanAlreadyLifted = NewApiStatus("BAN_ALREADY_LIFTED", "封禁已解除", Conflict)
+	ErrBanSelf          = NewApiStatus("BAN_SELF", "不能封禁自己", BadRequest)
+	ErrBanRangeTooWide  = NewApiStatus("BAN_RANGE_TOO_WIDE", "封禁IP段范围过大", BadRequest)
+	SuccessGetBans      = NewApiStatus("GET_BANS", "成功获取封禁记录", Ok)
+	SuccessIssueBan     = NewApiStatus("ISSUE_BAN", "成功添加封禁", Ok)
+	SuccessLiftBan      = NewApiStatus("LIFT_BAN", "成功解除封禁", Ok)
+	SuccessExtendBan    = NewApiStatus("EXTEND_BAN", "成功修改封禁期限", Ok)
+)
+
+type BanServiceInterface interface {
+	GetBans(req *RequestGetBans) *ApiResponse[ResponseGetBans]
+	IssueBan(req *RequestIssueBan) *ApiResponse[ResponseIssueBan]
+	LiftBan(req *RequestLiftBan) *ApiResponse[ResponseLiftBan]
+	ExtendBan(req *RequestExtendBan) *ApiResponse[ResponseExtendBan]
+}
+
+type RequestGetBans struct {
+	JwtHeader
+	Page       int  `query:"page_number"`
+	PageSize   int  `query:"page_size"`
+	ActiveOnly bool `query:"active"`
+}
+
+type ResponseGetBans struct {
+	Items    []*operation.Ban `json:"items"`
+	Page     int              `json:"page"`
+	PageSize int              `json:"page_size"`
+	Total    int64            `json:"total"`
+}
+
+type RequestIssueBan struct {
+	JwtHeader
+	EchoContentHeader
+	SubjectType string `json:"subject_type"` // cid, ip, cidr
+	Subject     string `json:"subject"`
+	Scope       string `json:"scope"` // fsd, voice, web, all
+	Reason      string `json:"reason"`
+	Duration    string `json:"duration"` // 为空时永久封禁
+}
+
+type ResponseIssueBan *operation.Ban
+
+type RequestLiftBan struct {
+	JwtHeader
+	EchoContentHeader
+	BanId uint `param:"bid"`
+}
+
+type ResponseLiftBan bool
+
+type RequestExtendBan struct {
+	JwtHeader
+	EchoContentHeader
+	BanId     uint   `param:"bid"`
+	Duration  string `json:"duration"` // 在当前到期时间的基础上延长
+	Permanent bool   `json:"permanent"`
+}
+
+type ResponseExtendBan *operation.Ban
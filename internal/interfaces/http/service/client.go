@@ -12,6 +12,13 @@ var (
 	SuccessKillClient           = NewApiStatus("KILL_CLIENT", "成功踢出客户端", Ok)
 	SuccessGetClientPath        = NewApiStatus("GET_CLIENT_PATH", "获取客户端飞行路径", Ok)
 	SuccessSendBroadcastMessage = NewApiStatus("SEND_BROADCAST_MESSAGE", "获取客户端飞行路径", Ok)
+	ErrFlightDataNotFound       = NewApiStatus("FLIGHT_DATA_NOT_FOUND", "指定航空器没有协调数据", NotFound)
+	SuccessGetFlightData        = NewApiStatus("GET_FLIGHT_DATA", "获取协调数据成功", Ok)
+	ErrSquawkNotEnabled         = NewApiStatus("SQUAWK_DISABLED", "应答机编码分配未启用", NotFound)
+	ErrSquawkUnavailable        = NewApiStatus("SQUAWK_UNAVAILABLE", "没有可用的应答机编码", Conflict)
+	SuccessAllocateSquawk       = NewApiStatus("ALLOCATE_SQUAWK", "分配应答机编码成功", Ok)
+	ErrResumeTokenNotIssued     = NewApiStatus("RESUME_TOKEN_NOT_ISSUED", "该客户端没有恢复令牌", NotFound)
+	SuccessGetResumeToken       = NewApiStatus("GET_RESUME_TOKEN", "获取恢复令牌成功", Ok)
 )
 
 type ClientServiceInterface interface {
@@ -20,6 +27,10 @@ type ClientServiceInterface interface {
 	KillClient(req *RequestKillClient) *ApiResponse[ResponseKillClient]
 	GetClientFlightPath(req *RequestClientPath) *ApiResponse[ResponseClientPath]
 	SendBroadcastMessage(req *RequestSendBroadcastMessage) *ApiResponse[ResponseSendBroadcastMessage]
+	GetFlightDataList(req *RequestFlightDataList) *ApiResponse[ResponseFlightDataList]
+	GetFlightData(req *RequestFlightData) *ApiResponse[ResponseFlightData]
+	AllocateSquawk(req *RequestAllocateSquawk) *ApiResponse[ResponseAllocateSquawk]
+	GetResumeToken(req *RequestGetResumeToken) *ApiResponse[ResponseGetResumeToken]
 }
 
 type RequestSendMessageToClient struct {
@@ -54,3 +65,36 @@ type RequestSendBroadcastMessage struct {
 }
 
 type ResponseSendBroadcastMessage bool
+
+type RequestFlightDataList struct {
+	JwtHeader
+}
+
+type ResponseFlightDataList []*fsd.FlightData
+
+type RequestFlightData struct {
+	JwtHeader
+	Callsign string `param:"callsign"`
+}
+
+type ResponseFlightData *fsd.FlightData
+
+type RequestAllocateSquawk struct {
+	JwtHeader
+	Callsign string `param:"callsign"`
+}
+
+type ResponseAllocateSquawk struct {
+	Callsign string `json:"callsign"`
+	Squawk   string `json:"squawk"`
+}
+
+type RequestGetResumeToken struct {
+	JwtHeader
+	Callsign string `param:"callsign"`
+}
+
+type ResponseGetResumeToken struct {
+	Callsign string `json:"callsign"`
+	Token    string `json:"token"`
+}
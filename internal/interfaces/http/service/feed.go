@@ -0,0 +1,20 @@
+// Package service
+package service
+
+var (
+	SuccessGetFeed = NewApiStatus("GET_FEED", "成功获取在线数据", Ok)
+)
+
+// FeedServiceInterface 兼容第三方工具的在线数据, 所有格式都由同一份whazzup快照生成, 并分别缓存
+type FeedServiceInterface interface {
+	// GetFeedStatus 列出所有可用数据源的status.json
+	GetFeedStatus() *ApiResponse[ResponseGetFeed]
+	// GetVatsimData VATSIM data v3格式
+	GetVatsimData() *ApiResponse[ResponseGetFeed]
+	// GetWhazzup 传统文本whazzup格式
+	GetWhazzup() *ApiResponse[ResponseGetFeed]
+	// GetGeoJSON GeoJSON FeatureCollection格式
+	GetGeoJSON() *ApiResponse[ResponseGetFeed]
+}
+
+type ResponseGetFeed []byte
@@ -13,6 +13,7 @@ var (
 	SuccessDeleteSelfFlightPlan = NewApiStatus("DELETE_SELF_FLIGHT_PLAN", "成功删除自己的飞行计划", Ok)
 	SuccessDeleteFlightPlan     = NewApiStatus("DELETE_FLIGHT_PLAN", "成功删除飞行计划", Ok)
 	SuccessLockFlightPlan       = NewApiStatus("LOCK_FLIGHT_PLAN", "成功修改计划锁定状态", Ok)
+	SuccessGetRevisions         = NewApiStatus("GET_FLIGHT_PLAN_REVISIONS", "成功获取计划修订记录", Ok)
 )
 
 type FlightPlanServiceInterface interface {
@@ -22,6 +23,7 @@ type FlightPlanServiceInterface interface {
 	DeleteSelfFlightPlan(req *RequestDeleteSelfFlightPlan) *ApiResponse[ResponseDeleteSelfFlightPlan]
 	DeleteFlightPlan(req *RequestDeleteFlightPlan) *ApiResponse[ResponseDeleteFlightPlan]
 	LockFlightPlan(req *RequestLockFlightPlan) *ApiResponse[ResponseLockFlightPlan]
+	GetFlightPlanRevisions(req *RequestGetFlightPlanRevisions) *ApiResponse[ResponseGetFlightPlanRevisions]
 }
 
 type RequestSubmitFlightPlan struct {
@@ -75,3 +77,17 @@ type RequestLockFlightPlan struct {
 }
 
 type ResponseLockFlightPlan bool
+
+type RequestGetFlightPlanRevisions struct {
+	JwtHeader
+	TargetCid int `param:"cid"` // 为0时查询自己的修订记录
+	Page      int `query:"page_number"`
+	PageSize  int `query:"page_size"`
+}
+
+type ResponseGetFlightPlanRevisions struct {
+	Items    []*operation.FlightPlanRevision `json:"items"`
+	Page     int                             `json:"page"`
+	PageSize int                             `json:"page_size"`
+	Total    int64                           `json:"total"`
+}
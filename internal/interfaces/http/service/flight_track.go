@@ -0,0 +1,47 @@
+// Package service
+package service
+
+import "github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+
+type TrackFormat string
+
+const (
+	TrackFormatKML     TrackFormat = "kml"
+	TrackFormatGPX     TrackFormat = "gpx"
+	TrackFormatGeoJSON TrackFormat = "geojson"
+)
+
+func IsValidTrackFormat(format TrackFormat) bool {
+	return format == TrackFormatKML || format == TrackFormatGPX || format == TrackFormatGeoJSON
+}
+
+var (
+	ErrFlightTrackNotFound  = NewApiStatus("FLIGHT_TRACK_NOT_FOUND", "航迹不存在", NotFound)
+	ErrFlightTrackCorrupted = NewApiStatus("FLIGHT_TRACK_CORRUPTED", "航迹数据损坏", ServerInternalError)
+	SuccessGetFlightTracks  = NewApiStatus("GET_FLIGHT_TRACKS", "成功获取航迹列表", Ok)
+	SuccessExportTrack      = NewApiStatus("EXPORT_FLIGHT_TRACK", "成功导出航迹", Ok)
+)
+
+type FlightTrackServiceInterface interface {
+	GetFlightTracks(req *RequestGetFlightTracks) *ApiResponse[ResponseGetFlightTracks]
+	ExportFlightTrack(req *RequestExportFlightTrack) *ApiResponse[ResponseExportFlightTrack]
+}
+
+type RequestGetFlightTracks struct {
+	JwtHeader
+	PageArguments
+}
+
+type ResponseGetFlightTracks *PageResponse[*operation.FlightTrack]
+
+type RequestExportFlightTrack struct {
+	JwtHeader
+	TrackId uint        `param:"tid"`
+	Format  TrackFormat `query:"format"`
+}
+
+type ResponseExportFlightTrack struct {
+	FileName    string
+	ContentType string
+	Content     []byte
+}
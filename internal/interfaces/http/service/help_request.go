@@ -0,0 +1,71 @@
+// Package service
+package service
+
+import "github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+
+var (
+	ErrHelpRequestNotFound    = NewApiStatus("HELP_REQUEST_NOT_FOUND", "求助不存在", NotFound)
+	ErrHelpRequestClaimed     = NewApiStatus("HELP_REQUEST_CLAIMED", "求助已被其他监管认领", Conflict)
+	ErrHelpRequestResolved    = NewApiStatus("HELP_REQUEST_RESOLVED", "求助已解决", Conflict)
+	SuccessGetHelpRequests    = NewApiStatus("GET_HELP_REQUESTS", "成功获取求助数据", Ok)
+	SuccessClaimHelpRequest   = NewApiStatus("CLAIM_HELP_REQUEST", "成功认领求助", Ok)
+	SuccessResolveHelpRequest = NewApiStatus("RESOLVE_HELP_REQUEST", "成功解决求助", Ok)
+	SuccessGetHelpRequestStat = NewApiStatus("GET_HELP_REQUEST_STATISTICS", "成功获取求助统计数据", Ok)
+)
+
+type HelpRequestServiceInterface interface {
+	GetHelpRequests(req *RequestGetHelpRequests) *ApiResponse[ResponseGetHelpRequests]
+	ClaimHelpRequest(req *RequestClaimHelpRequest) *ApiResponse[ResponseClaimHelpRequest]
+	ResolveHelpRequest(req *RequestResolveHelpRequest) *ApiResponse[ResponseResolveHelpRequest]
+	GetHelpRequestStatistics(req *RequestGetHelpRequestStatistics) *ApiResponse[ResponseGetHelpRequestStatistics]
+}
+
+type RequestGetHelpRequests struct {
+	JwtHeader
+	Page     int    `query:"page_number"`
+	PageSize int    `query:"page_size"`
+	Status   string `query:"status"` // pending, claimed, resolved, 为空时获取全部状态
+}
+
+type ResponseGetHelpRequests struct {
+	Items    []*operation.HelpRequest `json:"items"`
+	Page     int                      `json:"page"`
+	PageSize int                      `json:"page_size"`
+	Total    int64                    `json:"total"`
+}
+
+type RequestClaimHelpRequest struct {
+	JwtHeader
+	EchoContentHeader
+	RequestId uint `param:"hid"`
+}
+
+type ResponseClaimHelpRequest bool
+
+type RequestResolveHelpRequest struct {
+	JwtHeader
+	EchoContentHeader
+	RequestId  uint   `param:"hid"`
+	Resolution string `json:"resolution"`
+}
+
+type ResponseResolveHelpRequest bool
+
+type RequestGetHelpRequestStatistics struct {
+	JwtHeader
+	Days int `query:"days"`
+}
+
+// ResponseGetHelpRequestStatistics 响应时间单位为秒, 只统计已认领或已解决的求助
+type ResponseGetHelpRequestStatistics struct {
+	Days               int     `json:"days"`
+	Total              int     `json:"total"`
+	Pending            int     `json:"pending"`
+	Claimed            int     `json:"claimed"`
+	Resolved           int     `json:"resolved"`
+	Escalated          int     `json:"escalated"`
+	AverageClaimTime   float64 `json:"average_claim_time"`
+	MedianClaimTime    float64 `json:"median_claim_time"`
+	AverageResolveTime float64 `json:"average_resolve_time"`
+	MedianResolveTime  float64 `json:"median_resolve_time"`
+}
@@ -0,0 +1,19 @@
+// Package service
+package service
+
+import "github.com/half-nothing/simple-fsd/internal/interfaces/operation"
+
+var (
+	SuccessGetLogbook = NewApiStatus("GET_LOGBOOK", "成功获取飞行日志", Ok)
+)
+
+type LogbookServiceInterface interface {
+	GetLogbook(req *RequestGetLogbook) *ApiResponse[ResponseGetLogbook]
+}
+
+type RequestGetLogbook struct {
+	JwtHeader
+	PageArguments
+}
+
+type ResponseGetLogbook *PageResponse[*operation.LogbookEntry]
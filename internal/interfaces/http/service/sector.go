@@ -0,0 +1,24 @@
+// Package service
+package service
+
+import "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
+
+var (
+	ErrSectorNotEnabled         = NewApiStatus("SECTOR_DISABLED", "扇区归属未启用", NotFound)
+	SuccessGetSectors           = NewApiStatus("GET_SECTORS", "成功获取扇区信息", Ok)
+	SuccessGetAircraftOwnership = NewApiStatus("GET_AIRCRAFT_OWNERSHIP", "成功获取航空器归属", Ok)
+	SuccessGetSectorCoverage    = NewApiStatus("GET_SECTOR_COVERAGE", "成功获取扇区覆盖图", Ok)
+)
+
+type SectorServiceInterface interface {
+	GetSectors() *ApiResponse[ResponseGetSectors]
+	GetAircraftOwnership() *ApiResponse[ResponseGetAircraftOwnership]
+	GetSectorCoverage() *ApiResponse[ResponseGetSectorCoverage]
+}
+
+type ResponseGetSectors []*fsd.SectorStatus
+
+type ResponseGetAircraftOwnership []*fsd.AircraftOwnership
+
+// ResponseGetSectorCoverage GeoJSON格式的扇区覆盖图
+type ResponseGetSectorCoverage []byte
@@ -2,6 +2,7 @@
 package service
 
 import (
+	"github.com/half-nothing/simple-fsd/internal/interfaces/config"
 	. "github.com/half-nothing/simple-fsd/internal/interfaces/fsd"
 )
 
@@ -9,12 +10,16 @@ var (
 	SuccessGetServerConfig = NewApiStatus("GET_SERVER_CONFIG", "成功获取服务器配置", Ok)
 	SuccessGetServerInfo   = NewApiStatus("GET_SERVER_INFO", "成功获取服务器信息", Ok)
 	SuccessGetTimeRating   = NewApiStatus("GET_TIME_RATING", "成功获取服务器排行榜", Ok)
+	SuccessReloadConfig    = NewApiStatus("RELOAD_CONFIG", "成功重载服务器配置", Ok)
+	ErrReloadConfig        = NewApiStatus("RELOAD_CONFIG_FAIL", "配置文件读取或校验失败, 已保留当前配置", BadRequest)
+	ErrApplyConfig         = NewApiStatus("APPLY_CONFIG_FAIL", "部分配置应用失败", ServerInternalError)
 )
 
 type ServerServiceInterface interface {
 	GetServerConfig() *ApiResponse[ResponseGetServerConfig]
 	GetServerInfo() *ApiResponse[ResponseGetServerInfo]
 	GetTimeRating() *ApiResponse[ResponseGetTimeRating]
+	ReloadConfig(req *RequestReloadConfig) *ApiResponse[ResponseReloadConfig]
 }
 
 type FileLimit struct {
@@ -46,3 +51,10 @@ type ResponseGetTimeRating struct {
 	Pilots      []*OnlineTime `json:"pilots"`
 	Controllers []*OnlineTime `json:"controllers"`
 }
+
+type RequestReloadConfig struct {
+	JwtHeader
+	EchoContentHeader
+}
+
+type ResponseReloadConfig config.ReloadResult
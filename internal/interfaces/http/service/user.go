@@ -64,6 +64,7 @@ type RequestUserRegister struct {
 type ResponseUserRegister bool
 
 type RequestUserLogin struct {
+	EchoContentHeader
 	Username string `json:"username"`
 	Password string `json:"password"`
 }
@@ -172,6 +173,7 @@ type RequestResetUserPassword struct {
 type ResponseResetUserPassword bool
 
 type RequestFsdLogin struct {
+	EchoContentHeader
 	Cid        string `json:"cid"`
 	Password   string `json:"password"`
 	IsSweatbox bool   `json:"is_sweatbox"`
@@ -221,6 +221,20 @@ func CheckDatabaseError[T any](err error) *ApiResponse[T] {
 		return NewApiResponse[T](ErrApplicationAlreadyExists, nil)
 	case errors.Is(err, operation.ErrAnnouncementNotFound):
 		return NewApiResponse[T](ErrAnnouncementNotFound, nil)
+	case errors.Is(err, operation.ErrFlightTrackNotFound):
+		return NewApiResponse[T](ErrFlightTrackNotFound, nil)
+	case errors.Is(err, operation.ErrTrackDataCorrupted):
+		return NewApiResponse[T](ErrFlightTrackCorrupted, nil)
+	case errors.Is(err, operation.ErrHelpRequestNotFound):
+		return NewApiResponse[T](ErrHelpRequestNotFound, nil)
+	case errors.Is(err, operation.ErrHelpRequestClaimed):
+		return NewApiResponse[T](ErrHelpRequestClaimed, nil)
+	case errors.Is(err, operation.ErrHelpRequestResolved):
+		return NewApiResponse[T](ErrHelpRequestResolved, nil)
+	case errors.Is(err, operation.ErrBanNotFound):
+		return NewApiResponse[T](ErrBanNotFound, nil)
+	case errors.Is(err, operation.ErrBanAlreadyLifted):
+		return NewApiResponse[T](ErrBanAlreadyLifted, nil)
 	case err != nil:
 		return NewApiResponse[T](ErrDatabaseFail, nil)
 	default:
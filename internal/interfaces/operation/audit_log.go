@@ -24,6 +24,7 @@ const ValueNotAvailable = "NOT AVAILABLE"
 type AuditEventType string
 
 const (
+	UserCreated                     AuditEventType = "UserCreated"
 	UserInformationEdit             AuditEventType = "UserInformationEdit"
 	UserPermissionGrant             AuditEventType = "UserPermissionGrant"
 	UserPermissionRevoke            AuditEventType = "UserPermissionRevoke"
@@ -34,6 +35,7 @@ const (
 	ClientKicked                    AuditEventType = "ClientKickedFromWeb"
 	ClientMessage                   AuditEventType = "ClientMessage"
 	ClientBroadcastMessage          AuditEventType = "ClientBroadcastMessage"
+	SupervisorCommand               AuditEventType = "SupervisorCommand"
 	UnlawfulOverreach               AuditEventType = "UnlawfulOverreach"
 	TicketOpen                      AuditEventType = "TicketOpen"
 	TicketClose                     AuditEventType = "TicketClose"
@@ -54,6 +56,12 @@ const (
 	AnnouncementPublished           AuditEventType = "AnnouncementPublished"
 	AnnouncementUpdated             AuditEventType = "AnnouncementUpdated"
 	AnnouncementDeleted             AuditEventType = "AnnouncementDeleted"
+	ServerConfigReloaded            AuditEventType = "ServerConfigReloaded"
+	HelpRequestClaimed              AuditEventType = "HelpRequestClaimed"
+	HelpRequestResolved             AuditEventType = "HelpRequestResolved"
+	BanIssued                       AuditEventType = "BanIssued"
+	BanLifted                       AuditEventType = "BanLifted"
+	BanExtended                     AuditEventType = "BanExtended"
 )
 
 type AuditLogOperationInterface interface {
@@ -0,0 +1,173 @@
+// Package operation
+package operation
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"time"
+)
+
+type BanSubjectType int
+
+const (
+	BanSubjectCid  BanSubjectType = iota // 封禁用户CID
+	BanSubjectIp                         // 封禁单个IP地址
+	BanSubjectCidr                       // 封禁IP地址段
+)
+
+var banSubjectTypeNames = map[string]BanSubjectType{
+	"cid":  BanSubjectCid,
+	"ip":   BanSubjectIp,
+	"cidr": BanSubjectCidr,
+}
+
+// ParseBanSubjectType 将封禁对象类型名称转换为封禁对象类型
+func ParseBanSubjectType(name string) (BanSubjectType, bool) {
+	subjectType, ok := banSubjectTypeNames[name]
+	return subjectType, ok
+}
+
+type BanScope int
+
+const (
+	BanScopeFsd   BanScope = 1 << iota // FSD服务器
+	BanScopeVoice                      // 语音服务器
+	BanScopeWeb                        // Http服务器
+	BanScopeAll   = BanScopeFsd | BanScopeVoice | BanScopeWeb
+)
+
+var banScopeNames = map[string]BanScope{
+	"fsd":   BanScopeFsd,
+	"voice": BanScopeVoice,
+	"web":   BanScopeWeb,
+	"all":   BanScopeAll,
+}
+
+// ParseBanScope 将封禁范围名称转换为封禁范围
+func ParseBanScope(name string) (BanScope, bool) {
+	scope, ok := banScopeNames[name]
+	return scope, ok
+}
+
+func (scope BanScope) String() string {
+	switch scope {
+	case BanScopeFsd:
+		return "fsd"
+	case BanScopeVoice:
+		return "voice"
+	case BanScopeWeb:
+		return "web"
+	case BanScopeAll:
+		return "all"
+	default:
+		return strconv.Itoa(int(scope))
+	}
+}
+
+// Ban 封禁记录, ExpiresAt为空时为永久封禁
+type Ban struct {
+	ID          uint       `gorm:"primarykey" json:"id"`
+	SubjectType int        `gorm:"index:idx_ban_subject;not null" json:"subject_type"`
+	Subject     string     `gorm:"size:64;index:idx_ban_subject;not null" json:"subject"`
+	Cid         int        `gorm:"index;default:0;not null" json:"cid"`
+	Scope       int        `gorm:"not null" json:"scope"`
+	Reason      string     `gorm:"type:text;not null" json:"reason"`
+	IssuerCid   int        `gorm:"not null" json:"issuer_cid"`
+	StartAt     time.Time  `gorm:"not null" json:"start_at"`
+	ExpiresAt   *time.Time `gorm:"index" json:"expires_at"`
+	Lifted      bool       `gorm:"index;default:false;not null" json:"lifted"`
+	LiftedBy    int        `gorm:"default:0;not null" json:"lifted_by"`
+	LiftedAt    *time.Time `json:"lifted_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"-"`
+}
+
+// Active 封禁在now时刻是否生效
+func (ban *Ban) Active(now time.Time) bool {
+	if ban.Lifted || now.Before(ban.StartAt) {
+		return false
+	}
+	return ban.ExpiresAt == nil || now.Before(*ban.ExpiresAt)
+}
+
+// Covers 封禁范围是否包含scope
+func (ban *Ban) Covers(scope BanScope) bool {
+	return BanScope(ban.Scope)&scope != 0
+}
+
+// MatchCid 封禁是否命中该CID, IP和IP段封禁永远不命中
+func (ban *Ban) MatchCid(cid int) bool {
+	return BanSubjectType(ban.SubjectType) == BanSubjectCid && cid > 0 && ban.Cid == cid
+}
+
+// MatchIp 封禁是否命中该IP, CID封禁永远不命中
+func (ban *Ban) MatchIp(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	switch BanSubjectType(ban.SubjectType) {
+	case BanSubjectIp:
+		return ip.Equal(net.ParseIP(ban.Subject))
+	case BanSubjectCidr:
+		_, network, err := net.ParseCIDR(ban.Subject)
+		return err == nil && network.Contains(ip)
+	default:
+		return false
+	}
+}
+
+// 允许封禁的最大IP段, 防止误封大量用户
+const (
+	MinIpv4BanPrefix = 16
+	MinIpv6BanPrefix = 48
+)
+
+// ParseBanAddress 校验IP或IP段封禁对象, 返回规范化后的对象
+func ParseBanAddress(subjectType BanSubjectType, subject string) (string, error) {
+	switch subjectType {
+	case BanSubjectIp:
+		ip := net.ParseIP(subject)
+		if ip == nil {
+			return "", ErrInvalidBanSubject
+		}
+		return ip.String(), nil
+	case BanSubjectCidr:
+		_, network, err := net.ParseCIDR(subject)
+		if err != nil {
+			return "", ErrInvalidBanSubject
+		}
+		ones, bits := network.Mask.Size()
+		if (bits == 8*net.IPv4len && ones < MinIpv4BanPrefix) || (bits == 8*net.IPv6len && ones < MinIpv6BanPrefix) {
+			return "", ErrBanRangeTooWide
+		}
+		return network.String(), nil
+	default:
+		return "", ErrInvalidBanSubject
+	}
+}
+
+var (
+	ErrBanNotFound       = errors.New("ban not found")
+	ErrBanAlreadyLifted  = errors.New("ban already lifted")
+	ErrInvalidBanSubject = errors.New("invalid ban subject")
+	ErrBanRangeTooWide   = errors.New("ban range too wide")
+)
+
+// BanOperationInterface 封禁操作接口定义
+type BanOperationInterface interface {
+	// NewBan 创建封禁记录(不写入数据库), expiresAt为空时为永久封禁
+	NewBan(subjectType BanSubjectType, subject string, cid int, scope BanScope, reason string, issuerCid int, expiresAt *time.Time) (ban *Ban)
+	// SaveBan 保存封禁记录, 当err为nil时保存成功
+	SaveBan(ban *Ban) (err error)
+	// GetBan 通过ID获取封禁记录, 当err为nil时返回值ban有效
+	GetBan(id uint) (ban *Ban, err error)
+	// GetBans 分页获取封禁记录, activeOnly为true时只获取生效中的封禁
+	GetBans(page, pageSize int, activeOnly bool) (bans []*Ban, total int64, err error)
+	// GetActiveBans 获取所有生效中的封禁
+	GetActiveBans() (bans []*Ban, err error)
+	// LiftBan 解除封禁, 已解除的封禁返回 ErrBanAlreadyLifted
+	LiftBan(ban *Ban, cid int) (err error)
+	// ExtendBan 修改封禁到期时间, expiresAt为空时改为永久封禁
+	ExtendBan(ban *Ban, expiresAt *time.Time) (err error)
+}
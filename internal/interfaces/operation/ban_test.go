@@ -0,0 +1,150 @@
+// Package operation
+package operation
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestBanActive(t *testing.T) {
+	now := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		ban      *Ban
+		expected bool
+	}{
+		{"permanent", &Ban{StartAt: past}, true},
+		{"not expired", &Ban{StartAt: past, ExpiresAt: &future}, true},
+		{"expired", &Ban{StartAt: past, ExpiresAt: &past}, false},
+		{"expires now", &Ban{StartAt: past, ExpiresAt: &now}, false},
+		{"not started", &Ban{StartAt: future}, false},
+		{"starts now", &Ban{StartAt: now}, true},
+		{"lifted", &Ban{StartAt: past, Lifted: true}, false},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		result := test.ban.Active(now)
+		if result != test.expected {
+			fail++
+			t.Errorf("Active(%s) = %v; expected %v", test.name, result, test.expected)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestBanActive: %d pass, %d fail", pass, fail)
+}
+
+func TestBanCovers(t *testing.T) {
+	tests := []struct {
+		scope    BanScope
+		check    BanScope
+		expected bool
+	}{
+		{BanScopeFsd, BanScopeFsd, true},
+		{BanScopeFsd, BanScopeVoice, false},
+		{BanScopeFsd, BanScopeWeb, false},
+		{BanScopeVoice, BanScopeVoice, true},
+		{BanScopeWeb, BanScopeFsd, false},
+		{BanScopeAll, BanScopeFsd, true},
+		{BanScopeAll, BanScopeVoice, true},
+		{BanScopeAll, BanScopeWeb, true},
+		{BanScopeFsd | BanScopeWeb, BanScopeWeb, true},
+		{BanScopeFsd | BanScopeWeb, BanScopeVoice, false},
+		{0, BanScopeFsd, false},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		ban := &Ban{Scope: int(test.scope)}
+		result := ban.Covers(test.check)
+		if result != test.expected {
+			fail++
+			t.Errorf("Covers(%s, %s) = %v; expected %v", test.scope, test.check, result, test.expected)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestBanCovers: %d pass, %d fail", pass, fail)
+}
+
+func TestBanMatch(t *testing.T) {
+	cidBan := &Ban{SubjectType: int(BanSubjectCid), Subject: "2352", Cid: 2352}
+	ipBan := &Ban{SubjectType: int(BanSubjectIp), Subject: "203.0.113.7"}
+	cidrBan := &Ban{SubjectType: int(BanSubjectCidr), Subject: "203.0.113.0/24"}
+	ipv6Ban := &Ban{SubjectType: int(BanSubjectCidr), Subject: "2001:db8:1::/48"}
+	brokenBan := &Ban{SubjectType: int(BanSubjectCidr), Subject: "203.0.113.0"}
+
+	tests := []struct {
+		name     string
+		ban      *Ban
+		cid      int
+		ip       string
+		expected bool
+	}{
+		{"cid match", cidBan, 2352, "", true},
+		{"cid mismatch", cidBan, 2353, "", false},
+		{"cid ignores ip", cidBan, 0, "203.0.113.7", false},
+		{"ip match", ipBan, 0, "203.0.113.7", true},
+		{"ip mapped", ipBan, 0, "::ffff:203.0.113.7", true},
+		{"ip mismatch", ipBan, 0, "203.0.113.8", false},
+		{"ip ignores cid", ipBan, 2352, "", false},
+		{"cidr match", cidrBan, 0, "203.0.113.200", true},
+		{"cidr mismatch", cidrBan, 0, "203.0.114.1", false},
+		{"ipv6 cidr match", ipv6Ban, 0, "2001:db8:1:ffff::1", true},
+		{"ipv6 cidr mismatch", ipv6Ban, 0, "2001:db8:2::1", false},
+		{"broken subject", brokenBan, 0, "203.0.113.0", false},
+		{"no ip", cidrBan, 0, "", false},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		result := test.ban.MatchCid(test.cid) || test.ban.MatchIp(net.ParseIP(test.ip))
+		if result != test.expected {
+			fail++
+			t.Errorf("Match(%s, %d, %q) = %v; expected %v", test.name, test.cid, test.ip, result, test.expected)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestBanMatch: %d pass, %d fail", pass, fail)
+}
+
+func TestParseBanAddress(t *testing.T) {
+	tests := []struct {
+		subjectType BanSubjectType
+		subject     string
+		expected    string
+		expectedErr error
+	}{
+		{BanSubjectIp, "203.0.113.7", "203.0.113.7", nil},
+		{BanSubjectIp, "2001:0db8::0001", "2001:db8::1", nil},
+		{BanSubjectIp, "203.0.113.0/24", "", ErrInvalidBanSubject},
+		{BanSubjectIp, "localhost", "", ErrInvalidBanSubject},
+		{BanSubjectCidr, "203.0.113.7/24", "203.0.113.0/24", nil},
+		{BanSubjectCidr, "10.1.0.0/16", "10.1.0.0/16", nil},
+		{BanSubjectCidr, "10.0.0.0/15", "", ErrBanRangeTooWide},
+		{BanSubjectCidr, "0.0.0.0/0", "", ErrBanRangeTooWide},
+		{BanSubjectCidr, "2001:db8:1::/48", "2001:db8:1::/48", nil},
+		{BanSubjectCidr, "2001:db8::/32", "", ErrBanRangeTooWide},
+		{BanSubjectCidr, "203.0.113.7", "", ErrInvalidBanSubject},
+		{BanSubjectCid, "2352", "", ErrInvalidBanSubject},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		result, err := ParseBanAddress(test.subjectType, test.subject)
+		if result != test.expected || !errors.Is(err, test.expectedErr) {
+			fail++
+			t.Errorf("ParseBanAddress(%d, %q) = %q, %v; expected %q, %v", test.subjectType, test.subject, result, err, test.expected, test.expectedErr)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestParseBanAddress: %d pass, %d fail", pass, fail)
+}
@@ -51,6 +51,8 @@ type FlightPlanOperationInterface interface {
 	UpdateFlightPlan(flightPlan *FlightPlan, flightPlanData []string, atcEdit bool) (err error)
 	SaveFlightPlan(flightPlan *FlightPlan) (err error)
 	GetFlightPlans(page, pageSize int) (flightPlans []*FlightPlan, total int64, err error)
+	// GetStaleFlightPlans 获取before之前最后更新的飞行计划, includeLocked为false时跳过已锁定的计划
+	GetStaleFlightPlans(before time.Time, includeLocked bool) (flightPlans []*FlightPlan, err error)
 	LockFlightPlan(flightPlan *FlightPlan) (err error)
 	UnlockFlightPlan(flightPlan *FlightPlan) (err error)
 	DeleteSelfFlightPlan(flightPlan *FlightPlan) (err error)
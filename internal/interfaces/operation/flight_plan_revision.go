@@ -0,0 +1,74 @@
+// Package operation
+package operation
+
+import (
+	"strconv"
+	"time"
+)
+
+type FlightPlanRevisionSource string
+
+const (
+	FlightPlanEditByPilot FlightPlanRevisionSource = "pilot" // 机组通过$FP提交
+	FlightPlanEditByAtc   FlightPlanRevisionSource = "atc"   // 管制员通过$AM或EuroScope修改
+	FlightPlanEditByWeb   FlightPlanRevisionSource = "web"   // 通过网页提交
+)
+
+type FlightPlanFieldChange struct {
+	Field    string `json:"field"`
+	OldValue string `json:"old_value"`
+	NewValue string `json:"new_value"`
+}
+
+// FlightPlanRevision 飞行计划修订记录, 每次修改飞行计划都会生成一条
+type FlightPlanRevision struct {
+	ID        uint                     `gorm:"primarykey" json:"id"`
+	Cid       int                      `gorm:"uniqueIndex:idx_revision_cid_revision,priority:1;not null" json:"cid"`
+	Callsign  string                   `gorm:"size:16;not null" json:"callsign"`
+	Revision  int                      `gorm:"uniqueIndex:idx_revision_cid_revision,priority:2;not null" json:"revision"`
+	Source    FlightPlanRevisionSource `gorm:"size:8;not null" json:"source"`
+	Editor    string                   `gorm:"size:16;not null" json:"editor"`
+	EditorCid int                      `gorm:"not null" json:"editor_cid"`
+	Changes   []*FlightPlanFieldChange `gorm:"type:text;serializer:json" json:"changes"`
+	CreatedAt time.Time                `json:"created_at"`
+}
+
+// DiffFlightPlan 对比两个飞行计划的字段, oldPlan为nil时视为新建计划
+func DiffFlightPlan(oldPlan, newPlan *FlightPlan) []*FlightPlanFieldChange {
+	if oldPlan == nil {
+		oldPlan = &FlightPlan{}
+	}
+	fields := []struct {
+		name     string
+		oldValue string
+		newValue string
+	}{
+		{"flight_rules", oldPlan.FlightType, newPlan.FlightType},
+		{"aircraft", oldPlan.AircraftType, newPlan.AircraftType},
+		{"cruise_tas", strconv.Itoa(oldPlan.Tas), strconv.Itoa(newPlan.Tas)},
+		{"departure", oldPlan.DepartureAirport, newPlan.DepartureAirport},
+		{"departure_time", strconv.Itoa(oldPlan.DepartureTime), strconv.Itoa(newPlan.DepartureTime)},
+		{"altitude", oldPlan.CruiseAltitude, newPlan.CruiseAltitude},
+		{"arrival", oldPlan.ArrivalAirport, newPlan.ArrivalAirport},
+		{"route_time", oldPlan.RouteTimeHour + ":" + oldPlan.RouteTimeMinute, newPlan.RouteTimeHour + ":" + newPlan.RouteTimeMinute},
+		{"fuel_time", oldPlan.FuelTimeHour + ":" + oldPlan.FuelTimeMinute, newPlan.FuelTimeHour + ":" + newPlan.FuelTimeMinute},
+		{"alternate", oldPlan.AlternateAirport, newPlan.AlternateAirport},
+		{"remarks", oldPlan.Remarks, newPlan.Remarks},
+		{"route", oldPlan.Route, newPlan.Route},
+	}
+	changes := make([]*FlightPlanFieldChange, 0)
+	for _, field := range fields {
+		if field.oldValue != field.newValue {
+			changes = append(changes, &FlightPlanFieldChange{Field: field.name, OldValue: field.oldValue, NewValue: field.newValue})
+		}
+	}
+	return changes
+}
+
+type FlightPlanRevisionOperationInterface interface {
+	// NewRevision 对比新旧计划生成修订记录, 没有变化时返回nil
+	NewRevision(oldPlan, newPlan *FlightPlan, source FlightPlanRevisionSource, editor string, editorCid int) (revision *FlightPlanRevision)
+	// SaveRevision 保存修订记录, 修订号在保存时自动递增
+	SaveRevision(revision *FlightPlanRevision) (err error)
+	GetRevisions(cid, page, pageSize int) (revisions []*FlightPlanRevision, total int64, err error)
+}
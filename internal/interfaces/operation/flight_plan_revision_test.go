@@ -0,0 +1,76 @@
+// Package operation
+package operation
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func newTestFlightPlan() *FlightPlan {
+	return &FlightPlan{
+		Callsign:         "CES2352",
+		FlightType:       "I",
+		AircraftType:     "A320/L",
+		Tas:              450,
+		DepartureAirport: "ZSSS",
+		DepartureTime:    1200,
+		CruiseAltitude:   "FL331",
+		ArrivalAirport:   "ZBAA",
+		RouteTimeHour:    "2",
+		RouteTimeMinute:  "10",
+		FuelTimeHour:     "4",
+		FuelTimeMinute:   "0",
+		AlternateAirport: "ZBTJ",
+		Remarks:          "/V/",
+		Route:            "PIKAS G330 PIMOL",
+	}
+}
+
+func TestDiffFlightPlan(t *testing.T) {
+	tests := []struct {
+		name     string
+		oldPlan  *FlightPlan
+		modify   func(plan *FlightPlan)
+		expected []string
+	}{
+		{"unchanged", newTestFlightPlan(), func(*FlightPlan) {}, []string{}},
+		{"altitude", newTestFlightPlan(), func(plan *FlightPlan) { plan.CruiseAltitude = "FL351" }, []string{"altitude:FL331->FL351"}},
+		{"tas", newTestFlightPlan(), func(plan *FlightPlan) { plan.Tas = 460 }, []string{"cruise_tas:450->460"}},
+		{"route time", newTestFlightPlan(), func(plan *FlightPlan) { plan.RouteTimeMinute = "15" }, []string{"route_time:2:10->2:15"}},
+		{"ambiguous route time", newTestFlightPlan(), func(plan *FlightPlan) {
+			plan.RouteTimeHour = "21"
+			plan.RouteTimeMinute = "0"
+		}, []string{"route_time:2:10->21:0"}},
+		{"field order", newTestFlightPlan(), func(plan *FlightPlan) {
+			plan.Route = "PIKAS W142 PIMOL"
+			plan.FlightType = "V"
+			plan.ArrivalAirport = "ZBAD"
+		}, []string{"flight_rules:I->V", "arrival:ZBAA->ZBAD", "route:PIKAS G330 PIMOL->PIKAS W142 PIMOL"}},
+		{"ignore other fields", newTestFlightPlan(), func(plan *FlightPlan) {
+			plan.Locked = true
+			plan.AtcDepartureTime = 1230
+		}, []string{}},
+		{"new plan", nil, func(plan *FlightPlan) {
+			*plan = FlightPlan{DepartureAirport: "ZSSS", ArrivalAirport: "ZBAA", Tas: 450, RouteTimeHour: "2", RouteTimeMinute: "10"}
+		}, []string{"cruise_tas:0->450", "departure:->ZSSS", "arrival:->ZBAA", "route_time::->2:10"}},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		newPlan := newTestFlightPlan()
+		test.modify(newPlan)
+		changes := DiffFlightPlan(test.oldPlan, newPlan)
+		result := make([]string, 0, len(changes))
+		for _, change := range changes {
+			result = append(result, fmt.Sprintf("%s:%s->%s", change.Field, change.OldValue, change.NewValue))
+		}
+		if !slices.Equal(result, test.expected) {
+			fail++
+			t.Errorf("DiffFlightPlan(%s) = %v; expected %v", test.name, result, test.expected)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestDiffFlightPlan: %d pass, %d fail", pass, fail)
+}
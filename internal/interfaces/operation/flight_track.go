@@ -0,0 +1,124 @@
+// Package operation
+package operation
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"time"
+)
+
+// TrackPoint 航迹点
+type TrackPoint struct {
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	Altitude  int       `json:"altitude"`
+	Time      time.Time `json:"time"`
+}
+
+// FlightTrack 机组单次连线的航迹, 与联飞记录一一对应
+type FlightTrack struct {
+	ID               uint      `gorm:"primarykey" json:"id"`
+	HistoryId        uint      `gorm:"uniqueIndex;not null" json:"history_id"`
+	Cid              int       `gorm:"index;not null" json:"cid"`
+	Callsign         string    `gorm:"size:16;not null" json:"callsign"`
+	DepartureAirport string    `gorm:"size:4;not null" json:"departure"`
+	ArrivalAirport   string    `gorm:"size:4;not null" json:"arrival"`
+	PointCount       int       `gorm:"not null" json:"point_count"`
+	StartTime        time.Time `gorm:"not null" json:"start_time"`
+	EndTime          time.Time `gorm:"not null" json:"end_time"`
+	Data             []byte    `gorm:"not null" json:"-"`
+	CreatedAt        time.Time `json:"-"`
+}
+
+var (
+	ErrFlightTrackNotFound = errors.New("flight track not found")
+	ErrTrackDataCorrupted  = errors.New("flight track data corrupted")
+)
+
+const (
+	trackDataVersion = 1
+	// trackCoordinateScale 经纬度精度为1e-5度, 约1米
+	trackCoordinateScale = 1e5
+)
+
+// EncodeTrackPoints 将航迹点压缩编码
+// 每个点相对前一个点的经纬度、高度和秒级时间差以变长整数保存, 再整体进行deflate压缩
+func EncodeTrackPoints(points []*TrackPoint) ([]byte, error) {
+	raw := make([]byte, 0, len(points)*8+binary.MaxVarintLen64)
+	raw = binary.AppendUvarint(raw, uint64(len(points)))
+	var lastLat, lastLon, lastAlt, lastTime int64
+	for _, point := range points {
+		lat := int64(math.Round(point.Latitude * trackCoordinateScale))
+		lon := int64(math.Round(point.Longitude * trackCoordinateScale))
+		alt := int64(point.Altitude)
+		t := point.Time.Unix()
+		raw = binary.AppendVarint(raw, lat-lastLat)
+		raw = binary.AppendVarint(raw, lon-lastLon)
+		raw = binary.AppendVarint(raw, alt-lastAlt)
+		raw = binary.AppendVarint(raw, t-lastTime)
+		lastLat, lastLon, lastAlt, lastTime = lat, lon, alt, t
+	}
+
+	buffer := bytes.NewBuffer(make([]byte, 0, len(raw)/2+1))
+	buffer.WriteByte(trackDataVersion)
+	writer, err := flate.NewWriter(buffer, flate.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(raw); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// DecodeTrackPoints 解码EncodeTrackPoints生成的数据
+func DecodeTrackPoints(data []byte) ([]*TrackPoint, error) {
+	if len(data) == 0 || data[0] != trackDataVersion {
+		return nil, ErrTrackDataCorrupted
+	}
+	raw, err := io.ReadAll(flate.NewReader(bytes.NewReader(data[1:])))
+	if err != nil {
+		return nil, ErrTrackDataCorrupted
+	}
+
+	reader := bytes.NewReader(raw)
+	count, err := binary.ReadUvarint(reader)
+	if err != nil || count > uint64(len(raw)) {
+		return nil, ErrTrackDataCorrupted
+	}
+	points := make([]*TrackPoint, 0, count)
+	var values [4]int64
+	for i := uint64(0); i < count; i++ {
+		for j := range values {
+			delta, err := binary.ReadVarint(reader)
+			if err != nil {
+				return nil, ErrTrackDataCorrupted
+			}
+			values[j] += delta
+		}
+		points = append(points, &TrackPoint{
+			Latitude:  float64(values[0]) / trackCoordinateScale,
+			Longitude: float64(values[1]) / trackCoordinateScale,
+			Altitude:  int(values[2]),
+			Time:      time.Unix(values[3], 0),
+		})
+	}
+	return points, nil
+}
+
+// FlightTrackOperationInterface 航迹操作接口定义
+type FlightTrackOperationInterface interface {
+	// NewFlightTrack 根据联飞记录和航迹点创建航迹, 当err为nil时返回值track有效
+	NewFlightTrack(history *History, departure, arrival string, points []*TrackPoint) (track *FlightTrack, err error)
+	SaveFlightTrack(track *FlightTrack) (err error)
+	GetFlightTracks(cid, page, pageSize int) (tracks []*FlightTrack, total int64, err error)
+	// GetFlightTrack 获取航迹, 当err为nil时返回值track有效
+	GetFlightTrack(id uint) (track *FlightTrack, err error)
+}
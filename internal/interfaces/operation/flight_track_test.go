@@ -0,0 +1,141 @@
+// Package operation
+package operation
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func encodeRawTrack(t *testing.T, raw []byte) []byte {
+	buffer := bytes.NewBuffer([]byte{trackDataVersion})
+	writer, err := flate.NewWriter(buffer, flate.BestSpeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = writer.Write(raw)
+	_ = writer.Close()
+	return buffer.Bytes()
+}
+
+func TestTrackPointsRoundTrip(t *testing.T) {
+	start := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		points []*TrackPoint
+	}{
+		{"empty", []*TrackPoint{}},
+		{"single", []*TrackPoint{{31.14341, 121.80525, 13, start}}},
+		{"climb", []*TrackPoint{
+			{31.14341, 121.80525, 13, start},
+			{31.16012, 121.78120, 3500, start.Add(15 * time.Second)},
+			{31.20877, 121.70331, 8900, start.Add(45 * time.Second)},
+		}},
+		{"negative", []*TrackPoint{
+			{-33.94610, 151.17720, 21, start},
+			{-33.90000, 151.10000, 5000, start.Add(time.Minute)},
+		}},
+		{"antimeridian", []*TrackPoint{
+			{52.0, 179.99999, 35000, start},
+			{52.1, -179.99999, 35000, start.Add(time.Minute)},
+		}},
+		{"descend and time gap", []*TrackPoint{
+			{40.07250, 116.59750, 12000, start},
+			{40.07000, 116.60000, 0, start.Add(2 * time.Hour)},
+		}},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		data, err := EncodeTrackPoints(test.points)
+		if err != nil {
+			fail++
+			t.Errorf("EncodeTrackPoints(%s) error %v", test.name, err)
+			continue
+		}
+		points, err := DecodeTrackPoints(data)
+		if err != nil || len(points) != len(test.points) {
+			fail++
+			t.Errorf("DecodeTrackPoints(%s) = %d points, %v; expected %d points", test.name, len(points), err, len(test.points))
+			continue
+		}
+		matched := true
+		for i, point := range points {
+			expected := test.points[i]
+			if math.Abs(point.Latitude-expected.Latitude) > 1e-6 || math.Abs(point.Longitude-expected.Longitude) > 1e-6 ||
+				point.Altitude != expected.Altitude || !point.Time.Equal(expected.Time) {
+				matched = false
+				t.Errorf("DecodeTrackPoints(%s)[%d] = %+v; expected %+v", test.name, i, point, expected)
+			}
+		}
+		if !matched {
+			fail++
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestTrackPointsRoundTrip: %d pass, %d fail", pass, fail)
+}
+
+func TestTrackPointsPrecision(t *testing.T) {
+	tests := []struct {
+		latitude  float64
+		longitude float64
+		expectLat float64
+		expectLon float64
+	}{
+		{31.123454, 121.123456, 31.12345, 121.12346},
+		{-0.000004, 0.000006, 0, 0.00001},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		data, _ := EncodeTrackPoints([]*TrackPoint{{test.latitude, test.longitude, 0, time.Unix(0, 0)}})
+		points, err := DecodeTrackPoints(data)
+		if err != nil || math.Abs(points[0].Latitude-test.expectLat) > 1e-9 || math.Abs(points[0].Longitude-test.expectLon) > 1e-9 {
+			fail++
+			t.Errorf("round trip (%v, %v) = %+v, %v; expected (%v, %v)", test.latitude, test.longitude, points, err, test.expectLat, test.expectLon)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestTrackPointsPrecision: %d pass, %d fail", pass, fail)
+}
+
+func TestDecodeTrackPointsCorrupted(t *testing.T) {
+	valid, _ := EncodeTrackPoints([]*TrackPoint{{31.1, 121.8, 100, time.Unix(1735718400, 0)}})
+	// 声明了两个点但只包含一个点的数据
+	truncated := binary.AppendUvarint(nil, 2)
+	for _, value := range []int64{3110000, 12180000, 100, 1735718400} {
+		truncated = binary.AppendVarint(truncated, value)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"nil", nil},
+		{"empty", []byte{}},
+		{"wrong version", append([]byte{trackDataVersion + 1}, valid[1:]...)},
+		{"not deflate", []byte{trackDataVersion, 0xff, 0xff, 0xff}},
+		{"cut deflate", valid[:len(valid)/2]},
+		{"missing point", encodeRawTrack(t, truncated)},
+		{"count too large", encodeRawTrack(t, binary.AppendUvarint(nil, 1<<40))},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		points, err := DecodeTrackPoints(test.data)
+		if !errors.Is(err, ErrTrackDataCorrupted) {
+			fail++
+			t.Errorf("DecodeTrackPoints(%s) = %v, %v; expected %v", test.name, points, err, ErrTrackDataCorrupted)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestDecodeTrackPointsCorrupted: %d pass, %d fail", pass, fail)
+}
@@ -0,0 +1,80 @@
+// Package operation
+package operation
+
+import (
+	"errors"
+	"time"
+)
+
+type HelpRequestStatus int
+
+const (
+	HelpRequestStatusPending  HelpRequestStatus = iota // 等待处理
+	HelpRequestStatusClaimed                           // 已被监管认领
+	HelpRequestStatusResolved                          // 已解决
+)
+
+var helpRequestStatusNames = map[string]HelpRequestStatus{
+	"pending":  HelpRequestStatusPending,
+	"claimed":  HelpRequestStatusClaimed,
+	"resolved": HelpRequestStatusResolved,
+}
+
+// ParseHelpRequestStatus 将状态名称转换为求助状态
+func ParseHelpRequestStatus(name string) (HelpRequestStatus, bool) {
+	status, ok := helpRequestStatusNames[name]
+	return status, ok
+}
+
+// HelpRequest 机组通过*S(wallop)发起的求助
+type HelpRequest struct {
+	ID              uint       `gorm:"primarykey" json:"id"`
+	Cid             int        `gorm:"index;not null" json:"cid"`
+	Callsign        string     `gorm:"size:16;not null" json:"callsign"`
+	Latitude        float64    `gorm:"not null" json:"latitude"`
+	Longitude       float64    `gorm:"not null" json:"longitude"`
+	Message         string     `gorm:"type:text;not null" json:"message"`
+	Status          int        `gorm:"index;default:0;not null" json:"status"`
+	HandlerCid      int        `gorm:"default:0;not null" json:"handler_cid"`
+	HandlerCallsign string     `gorm:"size:16;default:'';not null" json:"handler_callsign"`
+	Resolution      string     `gorm:"type:text" json:"resolution"`
+	Escalated       bool       `gorm:"default:false;not null" json:"escalated"`
+	ClaimedAt       *time.Time `json:"claimed_at"`
+	ResolvedAt      *time.Time `json:"resolved_at"`
+	EscalatedAt     *time.Time `json:"escalated_at"`
+	CreatedAt       time.Time  `gorm:"index" json:"created_at"`
+	UpdatedAt       time.Time  `json:"-"`
+}
+
+var (
+	ErrHelpRequestNotFound = errors.New("help request not found")
+	ErrHelpRequestClaimed  = errors.New("help request already claimed by another supervisor")
+	ErrHelpRequestResolved = errors.New("help request already resolved")
+	ErrHelpRequestOpen     = errors.New("an open help request already exists")
+)
+
+// HelpRequestOperationInterface 求助操作接口定义
+type HelpRequestOperationInterface interface {
+	// NewHelpRequest 创建求助(不写入数据库)
+	NewHelpRequest(cid int, callsign string, latitude, longitude float64, message string) (request *HelpRequest)
+	// SaveHelpRequest 保存求助, 当err为nil时保存成功
+	SaveHelpRequest(request *HelpRequest) (err error)
+	// GetHelpRequest 通过ID获取求助, 当err为nil时返回值request有效
+	GetHelpRequest(id uint) (request *HelpRequest, err error)
+	// GetHelpRequests 分页获取求助, status小于0时获取全部状态
+	GetHelpRequests(page, pageSize int, status int) (requests []*HelpRequest, total int64, err error)
+	// GetOpenHelpRequests 获取所有未解决的求助
+	GetOpenHelpRequests() (requests []*HelpRequest, err error)
+	// GetOpenHelpRequestByCid 获取用户尚未解决的求助, 不存在时返回 ErrHelpRequestNotFound
+	GetOpenHelpRequestByCid(cid int) (request *HelpRequest, err error)
+	// GetHelpRequestsSince 获取since之后创建的求助, 用于统计响应时间
+	GetHelpRequestsSince(since time.Time) (requests []*HelpRequest, err error)
+	// GetHelpRequestsToEscalate 获取before之前创建, 仍未被认领且未升级的求助
+	GetHelpRequestsToEscalate(before time.Time) (requests []*HelpRequest, err error)
+	// ClaimHelpRequest 认领求助, 已被他人认领时返回 ErrHelpRequestClaimed
+	ClaimHelpRequest(request *HelpRequest, cid int, callsign string) (err error)
+	// ResolveHelpRequest 解决求助, 未认领的求助会同时记录处理人
+	ResolveHelpRequest(request *HelpRequest, cid int, callsign string, resolution string) (err error)
+	// EscalateHelpRequest 标记求助已升级, 求助已被认领或已升级时返回 ErrHelpRequestClaimed
+	EscalateHelpRequest(request *HelpRequest) (err error)
+}
@@ -0,0 +1,29 @@
+// Package operation
+package operation
+
+import "time"
+
+// LogbookEntry 飞行日志, 由服务器根据机组位置自动记录
+type LogbookEntry struct {
+	ID               uint       `gorm:"primarykey" json:"id"`
+	Cid              int        `gorm:"index;not null" json:"cid"`
+	Callsign         string     `gorm:"size:16;not null" json:"callsign"`
+	AircraftType     string     `gorm:"size:128;not null" json:"aircraft"`
+	PlannedDeparture string     `gorm:"size:4;not null" json:"planned_departure"`
+	PlannedArrival   string     `gorm:"size:4;not null" json:"planned_arrival"`
+	DepartureAirport string     `gorm:"size:4;not null" json:"departure"`
+	ArrivalAirport   string     `gorm:"size:4;not null" json:"arrival"`
+	OffBlockTime     *time.Time `json:"off_block_time"`
+	TakeoffTime      *time.Time `json:"takeoff_time"`
+	LandingTime      *time.Time `json:"landing_time"`
+	OnBlockTime      *time.Time `json:"on_block_time"`
+	TouchdownRate    int        `gorm:"default:0;not null" json:"touchdown_rate"` // 接地垂直速度, 单位ft/min
+	Completed        bool       `gorm:"default:0;not null" json:"completed"`      // 是否完整记录到到达停机位
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// LogbookOperationInterface 飞行日志操作接口定义
+type LogbookOperationInterface interface {
+	SaveLogbookEntry(entry *LogbookEntry) (err error)
+	GetLogbookEntries(cid, page, pageSize int) (entries []*LogbookEntry, total int64, err error)
+}
@@ -12,6 +12,11 @@ type DatabaseOperations struct {
 	controllerApplicationOperation ControllerApplicationOperationInterface // 管制员申请操作
 	ticketOperation                TicketOperationInterface                // 工单操作
 	announcementOperation          AnnouncementOperationInterface          // 公告操作
+	flightPlanRevisionOperation    FlightPlanRevisionOperationInterface    // 飞行计划修订记录操作
+	flightTrackOperation           FlightTrackOperationInterface           // 航迹操作
+	logbookOperation               LogbookOperationInterface               // 飞行日志操作
+	helpRequestOperation           HelpRequestOperationInterface           // 求助操作
+	banOperation                   BanOperationInterface                   // 封禁操作
 }
 
 func NewDatabaseOperations(
@@ -25,6 +30,11 @@ func NewDatabaseOperations(
 	controllerApplicationOperation ControllerApplicationOperationInterface,
 	tickerOperation TicketOperationInterface,
 	announcementOperation AnnouncementOperationInterface,
+	flightPlanRevisionOperation FlightPlanRevisionOperationInterface,
+	flightTrackOperation FlightTrackOperationInterface,
+	logbookOperation LogbookOperationInterface,
+	helpRequestOperation HelpRequestOperationInterface,
+	banOperation BanOperationInterface,
 ) *DatabaseOperations {
 	return &DatabaseOperations{
 		userOperation:                  userOperation,
@@ -37,6 +47,11 @@ func NewDatabaseOperations(
 		controllerApplicationOperation: controllerApplicationOperation,
 		ticketOperation:                tickerOperation,
 		announcementOperation:          announcementOperation,
+		flightPlanRevisionOperation:    flightPlanRevisionOperation,
+		flightTrackOperation:           flightTrackOperation,
+		logbookOperation:               logbookOperation,
+		helpRequestOperation:           helpRequestOperation,
+		banOperation:                   banOperation,
 	}
 }
 
@@ -77,3 +92,23 @@ func (db *DatabaseOperations) TicketOperation() TicketOperationInterface { retur
 func (db *DatabaseOperations) AnnouncementOperation() AnnouncementOperationInterface {
 	return db.announcementOperation
 }
+
+func (db *DatabaseOperations) FlightPlanRevisionOperation() FlightPlanRevisionOperationInterface {
+	return db.flightPlanRevisionOperation
+}
+
+func (db *DatabaseOperations) FlightTrackOperation() FlightTrackOperationInterface {
+	return db.flightTrackOperation
+}
+
+func (db *DatabaseOperations) LogbookOperation() LogbookOperationInterface {
+	return db.logbookOperation
+}
+
+func (db *DatabaseOperations) HelpRequestOperation() HelpRequestOperationInterface {
+	return db.helpRequestOperation
+}
+
+func (db *DatabaseOperations) BanOperation() BanOperationInterface {
+	return db.banOperation
+}
@@ -46,6 +46,14 @@ const (
 	AnnouncementPublish
 	AnnouncementEdit
 	AnnouncementDelete
+	ServerConfigReload
+	ClientShowFlightData
+	ClientAllocateSquawk
+	HelpRequestShowList
+	HelpRequestHandle
+	BanShowList
+	BanIssue
+	BanEdit
 )
 
 var PermissionMap = map[string]Permission{
@@ -90,6 +98,14 @@ var PermissionMap = map[string]Permission{
 	"AnnouncementPublish":           AnnouncementPublish,
 	"AnnouncementEdit":              AnnouncementEdit,
 	"AnnouncementDelete":            AnnouncementDelete,
+	"ServerConfigReload":            ServerConfigReload,
+	"ClientShowFlightData":          ClientShowFlightData,
+	"ClientAllocateSquawk":          ClientAllocateSquawk,
+	"HelpRequestShowList":           HelpRequestShowList,
+	"HelpRequestHandle":             HelpRequestHandle,
+	"BanShowList":                   BanShowList,
+	"BanIssue":                      BanIssue,
+	"BanEdit":                       BanEdit,
 }
 
 func (p *Permission) HasPermission(perm Permission) bool {
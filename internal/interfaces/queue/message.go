@@ -20,6 +20,8 @@ const (
 	SendPasswordResetEmail
 	SendPermissionChangeEmail
 	SendTicketReplyEmail
+	SendHelpRequestEscalatedEmail
+	SendBannedEmail
 	SendMessageToClient
 	DeleteVerifyCode
 	KickClientFromServer
@@ -29,6 +31,7 @@ const (
 	AuditLog
 	AuditLogs
 	FsdMessageReceived
+	ConfigReloaded
 )
 
 var messageTypes = []string{
@@ -42,6 +45,8 @@ var messageTypes = []string{
 	"SendPasswordResetEmail",
 	"SendPermissionChangeEmail",
 	"SendTicketReplyEmail",
+	"SendHelpRequestEscalatedEmail",
+	"SendBannedEmail",
 	"SendMessageToClient",
 	"DeleteVerifyCode",
 	"KickClientFromServer",
@@ -51,6 +56,7 @@ var messageTypes = []string{
 	"AuditLog",
 	"AuditLogs",
 	"FsdMessageReceived",
+	"ConfigReloaded",
 }
 
 func (messageType MessageType) String() string {
@@ -10,6 +10,7 @@ import (
 	"github.com/half-nothing/simple-fsd/internal/interfaces/global"
 	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
 	"github.com/half-nothing/simple-fsd/internal/interfaces/queue"
+	"github.com/half-nothing/simple-fsd/internal/metrics"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -64,6 +65,10 @@ func NewAsyncMessageQueue(
 
 	asyncMessageQueue.shutdownCallback = NewShutdownCallback(asyncMessageQueue)
 
+	metrics.MessageQueueDepth.SetCollector(func(emit func(value float64, labelValues ...string)) {
+		emit(float64(len(asyncMessageQueue.messageCh)))
+	})
+
 	asyncMessageQueue.Start()
 
 	return asyncMessageQueue
@@ -77,11 +82,13 @@ func (asyncMessageQueue *AsyncMessageQueue) handleMessage(message *queue.Message
 		asyncMessageQueue.logger.WarnF("No subscribers for message type %s", message.Type.String())
 		return fmt.Errorf("no subscribers for message type %s", message.Type.String())
 	}
+	metrics.MessageQueueMessages.Inc(message.Type.String())
 	var eg errgroup.Group
 	for _, subscriber := range subscribers {
 		eg.Go(func() error { return subscriber(message) })
 	}
 	if err := eg.Wait(); err != nil {
+		metrics.MessageQueueErrors.Inc(message.Type.String())
 		asyncMessageQueue.logger.ErrorF("Error in handling message type %s: %s", message.Type.String(), err.Error())
 		return err
 	}
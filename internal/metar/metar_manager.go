@@ -2,6 +2,8 @@
 package metar
 
 import (
+	"net/url"
+	"strconv"
 	"sync"
 	"time"
 
@@ -9,6 +11,8 @@ import (
 	"github.com/half-nothing/simple-fsd/internal/interfaces/config"
 	"github.com/half-nothing/simple-fsd/internal/interfaces/global"
 	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/queue"
+	"github.com/half-nothing/simple-fsd/internal/metrics"
 	"golang.org/x/sync/singleflight"
 )
 
@@ -16,6 +20,7 @@ type MetarManager struct {
 	logger       log.LoggerInterface
 	config       config.MetarSources
 	getters      []MetarGetterInterface
+	gettersLock  sync.RWMutex
 	metarCache   CacheInterface[*string]
 	requestGroup singleflight.Group
 }
@@ -28,15 +33,32 @@ func NewMetarManager(
 	manager := &MetarManager{
 		logger:     log.NewLoggerAdapter(logger, "MetarManager"),
 		config:     config,
-		getters:    make([]MetarGetterInterface, 0, len(config)),
 		metarCache: cache,
 	}
-	for _, metarSource := range config {
-		manager.getters = append(manager.getters, NewMetarGetter(manager.logger, metarSource))
-	}
+	manager.getters = manager.newGetters(config)
 	return manager
 }
 
+func (metarManager *MetarManager) newGetters(sources config.MetarSources) []MetarGetterInterface {
+	getters := make([]MetarGetterInterface, 0, len(sources))
+	for _, metarSource := range sources {
+		getters = append(getters, NewMetarGetter(metarManager.logger, metarSource))
+	}
+	return getters
+}
+
+func (metarManager *MetarManager) HandleConfigReloadedMessage(message *queue.Message) error {
+	if val, ok := message.Data.(*config.Config); ok {
+		getters := metarManager.newGetters(val.MetarSource)
+		metarManager.gettersLock.Lock()
+		defer metarManager.gettersLock.Unlock()
+		metarManager.config = val.MetarSource
+		metarManager.getters = getters
+		return nil
+	}
+	return queue.ErrMessageDataType
+}
+
 func (metarManager *MetarManager) cacheMetar(icao string, metar *string) {
 	currentTime := time.Now()
 	minute := currentTime.Minute()
@@ -64,12 +86,14 @@ func (metarManager *MetarManager) QueryMetar(icao string) (metar string, err err
 	}
 
 	if cachedMetar, ok := metarManager.metarCache.Get(icao); ok {
+		metrics.MetarCacheRequests.Inc("hit")
 		if cachedMetar == nil {
 			return "", ErrMetarNotFound
 		}
 		return *cachedMetar, nil
 	}
 
+	metrics.MetarCacheRequests.Inc("miss")
 	result, err, _ := metarManager.requestGroup.Do(icao, func() (interface{}, error) {
 		defer func() {
 			if r := recover(); r != nil {
@@ -77,9 +101,15 @@ func (metarManager *MetarManager) QueryMetar(icao string) (metar string, err err
 			}
 		}()
 
-		for _, getter := range metarManager.getters {
+		metarManager.gettersLock.RLock()
+		getters := metarManager.getters
+		sources := metarManager.config
+		metarManager.gettersLock.RUnlock()
+
+		for index, getter := range getters {
 			metar, err := getter.GetMetar(icao)
 			if err != nil {
+				metrics.MetarSourceFailures.Inc(sourceName(sources, index))
 				continue
 			}
 			metarManager.cacheMetar(icao, &metar)
@@ -124,3 +154,14 @@ func (metarManager *MetarManager) QueryMetars(icaos []string) (metars []string)
 	wg.Wait()
 	return
 }
+
+// sourceName 使用数据源地址的主机名区分数据源, 避免查询参数产生过多的序列
+func sourceName(sources config.MetarSources, index int) string {
+	if index >= len(sources) {
+		return strconv.Itoa(index)
+	}
+	if address, err := url.Parse(sources[index].Url); err == nil && address.Host != "" {
+		return address.Host
+	}
+	return strconv.Itoa(index)
+}
@@ -0,0 +1,80 @@
+// Package metrics
+package metrics
+
+import (
+	"bytes"
+	"time"
+)
+
+const namespace = "simplefsd_"
+
+// Default 服务器所有指标注册在这里, 由HTTP服务器的/metrics输出
+var Default = NewRegistry()
+
+var (
+	OnlineClients = NewGaugeFunc(Default, namespace+"online_clients",
+		"Number of online clients by type and facility.", "type", "facility")
+	FsdPackets = NewCounterVec(Default, namespace+"fsd_packets_total",
+		"FSD packets handled by direction and command.", "direction", "command")
+	FsdRejectedCommands = NewCounterVec(Default, namespace+"fsd_rejected_commands_total",
+		"FSD commands rejected by the server by command and result error.", "command", "result")
+	FsdFloodViolations = NewCounterVec(Default, namespace+"fsd_flood_violations_total",
+		"FSD flood protection violations per kind, refused connections or packet floods.", "kind")
+	BroadcastDuration = NewHistogramVec(Default, namespace+"broadcast_duration_seconds",
+		"Time spent fanning out a broadcast packet to all target clients.", DefaultBuckets)
+
+	MessageQueueDepth = NewGaugeFunc(Default, namespace+"message_queue_depth",
+		"Messages waiting in the async message queue.")
+	MessageQueueMessages = NewCounterVec(Default, namespace+"message_queue_messages_total",
+		"Messages handled by the message queue per message type.", "type")
+	MessageQueueErrors = NewCounterVec(Default, namespace+"message_queue_handler_errors_total",
+		"Message queue handler errors per message type.", "type")
+
+	MetarCacheRequests = NewCounterVec(Default, namespace+"metar_cache_requests_total",
+		"METAR queries answered from cache (hit) or fetched from sources (miss).", "result")
+	MetarSourceFailures = NewCounterVec(Default, namespace+"metar_source_failures_total",
+		"Failed METAR fetches per source.", "source")
+
+	VoiceChannels = NewGaugeFunc(Default, namespace+"voice_channels",
+		"Active voice channels.")
+	VoiceTransmitters = NewGaugeFunc(Default, namespace+"voice_transmitters",
+		"Transmitters joined to a voice channel.")
+	VoiceUdpPackets = NewCounterVec(Default, namespace+"voice_udp_packets_total",
+		"Voice UDP packets relayed to receivers or dropped by reason.", "result", "reason")
+
+	HttpRequestDuration = NewHistogramVec(Default, namespace+"http_request_duration_seconds",
+		"HTTP request latency per route.", DefaultBuckets, "method", "route", "status")
+)
+
+// ObserveSince 记录从start到现在经过的秒数
+func ObserveSince(histogram *HistogramVec, start time.Time, labelValues ...string) {
+	histogram.Observe(time.Since(start).Seconds(), labelValues...)
+}
+
+// packetCommand FSD命令为1个字符(@, %等)或3个字符(#AA, $CQ等)
+func packetCommand(packet []byte) string {
+	if len(packet) == 0 {
+		return ""
+	}
+	if (packet[0] == '#' || packet[0] == '$') && len(packet) >= 3 {
+		return string(packet[:3])
+	}
+	return string(packet[:1])
+}
+
+// CountFsdOutbound 统计发送的FSD数据包, data中可能包含多个以\r\n结尾的数据包
+func CountFsdOutbound(data []byte) {
+	for len(data) > 0 {
+		end := bytes.Index(data, []byte("\r\n"))
+		if end < 0 {
+			end = len(data)
+		}
+		if end > 0 {
+			FsdPackets.Inc("out", packetCommand(data[:end]))
+		}
+		if end+2 >= len(data) {
+			return
+		}
+		data = data[end+2:]
+	}
+}
@@ -0,0 +1,298 @@
+// Package metrics
+// 轻量的Prometheus文本格式指标实现, 只包含服务器需要的计数器、仪表和直方图
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// collector 可以输出到/metrics的指标
+type collector interface {
+	write(writer *bufio.Writer)
+}
+
+type Registry struct {
+	lock       sync.RWMutex
+	collectors []collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{collectors: make([]collector, 0)}
+}
+
+func (registry *Registry) register(c collector) {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	registry.collectors = append(registry.collectors, c)
+}
+
+// Write 以Prometheus文本格式输出所有指标
+func (registry *Registry) Write(w io.Writer) error {
+	registry.lock.RLock()
+	collectors := registry.collectors
+	registry.lock.RUnlock()
+
+	writer := bufio.NewWriter(w)
+	for _, c := range collectors {
+		c.write(writer)
+	}
+	return writer.Flush()
+}
+
+var (
+	labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	// helpReplacer HELP行只需要转义反斜杠和换行
+	helpReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+type metricDesc struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+func (desc *metricDesc) writeHeader(writer *bufio.Writer) {
+	writer.WriteString("# HELP " + desc.name + " " + helpReplacer.Replace(desc.help) + "\n")
+	writer.WriteString("# TYPE " + desc.name + " " + desc.kind + "\n")
+}
+
+// writeSample 输出一行样本, extraName/extraValue用于直方图的le标签
+func (desc *metricDesc) writeSample(writer *bufio.Writer, name string, labelValues []string, extraName string, extraValue string, value float64) {
+	writer.WriteString(name)
+	if len(desc.labels) > 0 || extraName != "" {
+		writer.WriteByte('{')
+		for index, label := range desc.labels {
+			if index > 0 {
+				writer.WriteByte(',')
+			}
+			writer.WriteString(label + `="` + labelReplacer.Replace(labelValues[index]) + `"`)
+		}
+		if extraName != "" {
+			if len(desc.labels) > 0 {
+				writer.WriteByte(',')
+			}
+			writer.WriteString(extraName + `="` + extraValue + `"`)
+		}
+		writer.WriteByte('}')
+	}
+	writer.WriteByte(' ')
+	writer.WriteString(formatFloat(value))
+	writer.WriteByte('\n')
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// seriesKey 标签值拼接为序列的键, 单个标签时直接使用标签值避免分配
+func seriesKey(labelValues []string) string {
+	if len(labelValues) == 1 {
+		return labelValues[0]
+	}
+	return strings.Join(labelValues, "\xff")
+}
+
+// atomicFloat 使用CAS实现的原子浮点数
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (value *atomicFloat) add(delta float64) {
+	for {
+		old := value.bits.Load()
+		if value.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+delta)) {
+			return
+		}
+	}
+}
+
+func (value *atomicFloat) load() float64 {
+	return math.Float64frombits(value.bits.Load())
+}
+
+// seriesSet 按标签值保存序列, 输出时按键排序
+type seriesSet[T any] struct {
+	series sync.Map
+	create func(labelValues []string) *T
+}
+
+type seriesEntry[T any] struct {
+	labelValues []string
+	value       *T
+}
+
+func (set *seriesSet[T]) get(labelValues []string) *T {
+	key := seriesKey(labelValues)
+	if entry, ok := set.series.Load(key); ok {
+		return entry.(*seriesEntry[T]).value
+	}
+	entry, _ := set.series.LoadOrStore(key, &seriesEntry[T]{
+		labelValues: append([]string(nil), labelValues...),
+		value:       set.create(labelValues),
+	})
+	return entry.(*seriesEntry[T]).value
+}
+
+func (set *seriesSet[T]) sorted() []*seriesEntry[T] {
+	keys := make([]string, 0)
+	entries := make(map[string]*seriesEntry[T])
+	set.series.Range(func(key, value any) bool {
+		keys = append(keys, key.(string))
+		entries[key.(string)] = value.(*seriesEntry[T])
+		return true
+	})
+	sort.Strings(keys)
+	result := make([]*seriesEntry[T], 0, len(keys))
+	for _, key := range keys {
+		result = append(result, entries[key])
+	}
+	return result
+}
+
+// CounterVec 带标签的计数器
+type CounterVec struct {
+	desc   metricDesc
+	values seriesSet[atomicFloat]
+}
+
+func NewCounterVec(registry *Registry, name string, help string, labels ...string) *CounterVec {
+	counter := &CounterVec{desc: metricDesc{name: name, help: help, kind: "counter", labels: labels}}
+	counter.values.create = func([]string) *atomicFloat { return &atomicFloat{} }
+	registry.register(counter)
+	return counter
+}
+
+func (counter *CounterVec) Inc(labelValues ...string) {
+	counter.Add(1, labelValues...)
+}
+
+func (counter *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 || len(labelValues) != len(counter.desc.labels) {
+		return
+	}
+	counter.values.get(labelValues).add(delta)
+}
+
+func (counter *CounterVec) write(writer *bufio.Writer) {
+	counter.desc.writeHeader(writer)
+	for _, entry := range counter.values.sorted() {
+		counter.desc.writeSample(writer, counter.desc.name, entry.labelValues, "", "", entry.value.load())
+	}
+}
+
+// GaugeFunc 在抓取时才计算数值的仪表, 适合在线人数、队列长度等可以直接读取的状态
+type GaugeFunc struct {
+	desc    metricDesc
+	lock    sync.RWMutex
+	collect func(emit func(value float64, labelValues ...string))
+}
+
+func NewGaugeFunc(registry *Registry, name string, help string, labels ...string) *GaugeFunc {
+	gauge := &GaugeFunc{desc: metricDesc{name: name, help: help, kind: "gauge", labels: labels}}
+	registry.register(gauge)
+	return gauge
+}
+
+// SetCollector 设置抓取时调用的回调, 回调中对每个序列调用一次emit
+func (gauge *GaugeFunc) SetCollector(collect func(emit func(value float64, labelValues ...string))) {
+	gauge.lock.Lock()
+	defer gauge.lock.Unlock()
+	gauge.collect = collect
+}
+
+func (gauge *GaugeFunc) write(writer *bufio.Writer) {
+	gauge.lock.RLock()
+	collect := gauge.collect
+	gauge.lock.RUnlock()
+
+	gauge.desc.writeHeader(writer)
+	if collect == nil {
+		return
+	}
+	collect(func(value float64, labelValues ...string) {
+		if len(labelValues) != len(gauge.desc.labels) {
+			return
+		}
+		gauge.desc.writeSample(writer, gauge.desc.name, labelValues, "", "", value)
+	})
+}
+
+type histogramSeries struct {
+	buckets []atomic.Uint64
+	count   atomic.Uint64
+	sum     atomicFloat
+}
+
+// HistogramVec 带标签的直方图
+type HistogramVec struct {
+	desc    metricDesc
+	bounds  []float64
+	series  seriesSet[histogramSeries]
+	boundLe []string
+}
+
+// DefaultBuckets 默认的延迟分桶, 单位秒
+var DefaultBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+func NewHistogramVec(registry *Registry, name string, help string, buckets []float64, labels ...string) *HistogramVec {
+	bounds := append([]float64(nil), buckets...)
+	sort.Float64s(bounds)
+	histogram := &HistogramVec{
+		desc:    metricDesc{name: name, help: help, kind: "histogram", labels: labels},
+		bounds:  bounds,
+		boundLe: make([]string, 0, len(bounds)),
+	}
+	for _, bound := range bounds {
+		histogram.boundLe = append(histogram.boundLe, formatFloat(bound))
+	}
+	histogram.series.create = func([]string) *histogramSeries {
+		return &histogramSeries{buckets: make([]atomic.Uint64, len(bounds))}
+	}
+	registry.register(histogram)
+	return histogram
+}
+
+func (histogram *HistogramVec) Observe(value float64, labelValues ...string) {
+	if len(labelValues) != len(histogram.desc.labels) {
+		return
+	}
+	series := histogram.series.get(labelValues)
+	// 只记录所在的桶, 输出时再累加
+	if index := sort.SearchFloat64s(histogram.bounds, value); index < len(histogram.bounds) {
+		series.buckets[index].Add(1)
+	}
+	series.count.Add(1)
+	series.sum.add(value)
+}
+
+func (histogram *HistogramVec) write(writer *bufio.Writer) {
+	histogram.desc.writeHeader(writer)
+	name := histogram.desc.name
+	for _, entry := range histogram.series.sorted() {
+		var cumulative uint64
+		for index := range histogram.bounds {
+			cumulative += entry.value.buckets[index].Load()
+			histogram.desc.writeSample(writer, name+"_bucket", entry.labelValues, "le", histogram.boundLe[index], float64(cumulative))
+		}
+		count := entry.value.count.Load()
+		histogram.desc.writeSample(writer, name+"_bucket", entry.labelValues, "le", "+Inf", float64(count))
+		histogram.desc.writeSample(writer, name+"_sum", entry.labelValues, "", "", entry.value.sum.load())
+		histogram.desc.writeSample(writer, name+"_count", entry.labelValues, "", "", float64(count))
+	}
+}
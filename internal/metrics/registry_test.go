@@ -0,0 +1,136 @@
+// Package metrics
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistryWrite(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(registry *Registry)
+		expected string
+	}{
+		{"counter", func(registry *Registry) {
+			counter := NewCounterVec(registry, "fsd_packets_total", "Total packets", "command", "direction")
+			counter.Inc("#TM", "in")
+			counter.Add(2.5, "#TM", "in")
+			counter.Inc("@N", "out")
+			// 负数和标签数量不匹配时忽略
+			counter.Add(-1, "@N", "out")
+			counter.Inc("@N")
+		}, `# HELP fsd_packets_total Total packets
+# TYPE fsd_packets_total counter
+fsd_packets_total{command="#TM",direction="in"} 3.5
+fsd_packets_total{command="@N",direction="out"} 1
+`},
+		{"counter without labels", func(registry *Registry) {
+			NewCounterVec(registry, "fsd_empty_total", "Never incremented")
+			NewCounterVec(registry, "fsd_reloads_total", "Reloads").Inc()
+		}, `# HELP fsd_empty_total Never incremented
+# TYPE fsd_empty_total counter
+# HELP fsd_reloads_total Reloads
+# TYPE fsd_reloads_total counter
+fsd_reloads_total 1
+`},
+		{"label escaping", func(registry *Registry) {
+			counter := NewCounterVec(registry, "fsd_errors_total", "Errors with \\ and\nnewline", "message")
+			counter.Inc("say \"hi\"")
+			counter.Inc("C:\\path")
+			counter.Inc("line1\nline2")
+		}, `# HELP fsd_errors_total Errors with \\ and\nnewline
+# TYPE fsd_errors_total counter
+fsd_errors_total{message="C:\\path"} 1
+fsd_errors_total{message="line1\nline2"} 1
+fsd_errors_total{message="say \"hi\""} 1
+`},
+		{"gauge", func(registry *Registry) {
+			NewGaugeFunc(registry, "fsd_uncollected", "No collector", "kind")
+			gauge := NewGaugeFunc(registry, "fsd_online_clients", "Online clients", "kind")
+			gauge.SetCollector(func(emit func(value float64, labelValues ...string)) {
+				emit(12, "pilot")
+				emit(3, "atc")
+				emit(1)
+				emit(0.5, "quote\"")
+			})
+		}, `# HELP fsd_uncollected No collector
+# TYPE fsd_uncollected gauge
+# HELP fsd_online_clients Online clients
+# TYPE fsd_online_clients gauge
+fsd_online_clients{kind="pilot"} 12
+fsd_online_clients{kind="atc"} 3
+fsd_online_clients{kind="quote\""} 0.5
+`},
+		{"histogram", func(registry *Registry) {
+			histogram := NewHistogramVec(registry, "fsd_latency_seconds", "Latency", []float64{1, 0.1, 0.5}, "path")
+			histogram.Observe(0.05, "/api")
+			histogram.Observe(0.1, "/api")
+			histogram.Observe(0.3, "/api")
+			histogram.Observe(2, "/api")
+			histogram.Observe(0.2, "a\\b")
+		}, `# HELP fsd_latency_seconds Latency
+# TYPE fsd_latency_seconds histogram
+fsd_latency_seconds_bucket{path="/api",le="0.1"} 2
+fsd_latency_seconds_bucket{path="/api",le="0.5"} 3
+fsd_latency_seconds_bucket{path="/api",le="1"} 3
+fsd_latency_seconds_bucket{path="/api",le="+Inf"} 4
+fsd_latency_seconds_sum{path="/api"} 2.45
+fsd_latency_seconds_count{path="/api"} 4
+fsd_latency_seconds_bucket{path="a\\b",le="0.1"} 0
+fsd_latency_seconds_bucket{path="a\\b",le="0.5"} 1
+fsd_latency_seconds_bucket{path="a\\b",le="1"} 1
+fsd_latency_seconds_bucket{path="a\\b",le="+Inf"} 1
+fsd_latency_seconds_sum{path="a\\b"} 0.2
+fsd_latency_seconds_count{path="a\\b"} 1
+`},
+		{"histogram without labels", func(registry *Registry) {
+			NewHistogramVec(registry, "fsd_tick_seconds", "Tick", []float64{0.01}).Observe(0.001)
+		}, `# HELP fsd_tick_seconds Tick
+# TYPE fsd_tick_seconds histogram
+fsd_tick_seconds_bucket{le="0.01"} 1
+fsd_tick_seconds_bucket{le="+Inf"} 1
+fsd_tick_seconds_sum 0.001
+fsd_tick_seconds_count 1
+`},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		registry := NewRegistry()
+		test.setup(registry)
+		builder := &strings.Builder{}
+		if err := registry.Write(builder); err != nil || builder.String() != test.expected {
+			fail++
+			t.Errorf("Write(%s) = %v\n%s\nexpected\n%s", test.name, err, builder.String(), test.expected)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestRegistryWrite: %d pass, %d fail", pass, fail)
+}
+
+func TestFormatFloat(t *testing.T) {
+	tests := []struct {
+		value    float64
+		expected string
+	}{
+		{0, "0"},
+		{1, "1"},
+		{0.0005, "0.0005"},
+		{2.5, "2.5"},
+		{1e21, "1e+21"},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		result := formatFloat(test.value)
+		if result != test.expected {
+			fail++
+			t.Errorf("formatFloat(%v) = %q; expected %q", test.value, result, test.expected)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestFormatFloat: %d pass, %d fail", pass, fail)
+}
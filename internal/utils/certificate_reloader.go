@@ -0,0 +1,112 @@
+// Package utils
+package utils
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// CertificateReloader 可热重载的证书, 证书文件修改后会在下一次握手时重新加载
+type CertificateReloader struct {
+	certFile      string
+	keyFile       string
+	checkInterval time.Duration
+	mu            sync.RWMutex
+	certificate   *tls.Certificate
+	certModTime   time.Time
+	keyModTime    time.Time
+	lastCheck     time.Time
+	onReload      func(err error)
+}
+
+func NewCertificateReloader(certFile, keyFile string, checkInterval time.Duration, onReload func(err error)) (*CertificateReloader, error) {
+	reloader := &CertificateReloader{
+		certFile:      certFile,
+		keyFile:       keyFile,
+		checkInterval: checkInterval,
+		onReload:      onReload,
+	}
+	if err := reloader.reload(); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+func (reloader *CertificateReloader) modTimes() (time.Time, time.Time, error) {
+	certInfo, err := os.Stat(reloader.certFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	keyInfo, err := os.Stat(reloader.keyFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return certInfo.ModTime(), keyInfo.ModTime(), nil
+}
+
+func (reloader *CertificateReloader) reload() error {
+	certModTime, keyModTime, err := reloader.modTimes()
+	if err != nil {
+		return err
+	}
+	certificate, err := tls.LoadX509KeyPair(reloader.certFile, reloader.keyFile)
+	if err != nil {
+		return fmt.Errorf("fail to load certificate %s, %v", reloader.certFile, err)
+	}
+	reloader.certificate = &certificate
+	reloader.certModTime = certModTime
+	reloader.keyModTime = keyModTime
+	reloader.lastCheck = time.Now()
+	return nil
+}
+
+// Reload 强制重新加载证书, 加载失败时继续使用旧证书
+func (reloader *CertificateReloader) Reload() error {
+	reloader.mu.Lock()
+	defer reloader.mu.Unlock()
+	return reloader.reload()
+}
+
+func (reloader *CertificateReloader) checkUpdate() {
+	reloader.mu.RLock()
+	if time.Since(reloader.lastCheck) < reloader.checkInterval {
+		reloader.mu.RUnlock()
+		return
+	}
+	reloader.mu.RUnlock()
+
+	reloader.mu.Lock()
+	defer reloader.mu.Unlock()
+
+	if time.Since(reloader.lastCheck) < reloader.checkInterval {
+		return
+	}
+	reloader.lastCheck = time.Now()
+
+	certModTime, keyModTime, err := reloader.modTimes()
+	if err != nil {
+		if reloader.onReload != nil {
+			reloader.onReload(err)
+		}
+		return
+	}
+	if certModTime.Equal(reloader.certModTime) && keyModTime.Equal(reloader.keyModTime) {
+		return
+	}
+
+	err = reloader.reload()
+	if reloader.onReload != nil {
+		reloader.onReload(err)
+	}
+}
+
+// GetCertificate 用于 tls.Config 的 GetCertificate 字段
+func (reloader *CertificateReloader) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	reloader.checkUpdate()
+	reloader.mu.RLock()
+	defer reloader.mu.RUnlock()
+	return reloader.certificate, nil
+}
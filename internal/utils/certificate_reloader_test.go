@@ -0,0 +1,197 @@
+// Package utils
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestCertificate 生成自签名证书并写入文件, 修改时间设置为 modTime
+func writeTestCertificate(t *testing.T, certFile, keyFile, commonName string, modTime time.Time) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	writeTestFile(t, certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), modTime)
+	writeTestFile(t, keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), modTime)
+}
+
+func writeTestFile(t *testing.T, file string, data []byte, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(file, data, 0600); err != nil {
+		t.Fatalf("write %s: %v", file, err)
+	}
+	if err := os.Chtimes(file, modTime, modTime); err != nil {
+		t.Fatalf("chtimes %s: %v", file, err)
+	}
+}
+
+func commonNameOf(t *testing.T, certificate *tls.Certificate) string {
+	t.Helper()
+	if certificate == nil || len(certificate.Certificate) == 0 {
+		return ""
+	}
+	parsed, err := x509.ParseCertificate(certificate.Certificate[0])
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	return parsed.Subject.CommonName
+}
+
+func TestNewCertificateReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	writeTestCertificate(t, certFile, keyFile, "initial", time.Now())
+	otherKeyFile := filepath.Join(dir, "other_key.pem")
+	writeTestCertificate(t, filepath.Join(dir, "other_cert.pem"), otherKeyFile, "other", time.Now())
+
+	tests := []struct {
+		name       string
+		certFile   string
+		keyFile    string
+		expectedOk bool
+		expectedCn string
+	}{
+		{"valid pair", certFile, keyFile, true, "initial"},
+		{"missing cert", filepath.Join(dir, "missing.pem"), keyFile, false, ""},
+		{"missing key", certFile, filepath.Join(dir, "missing.pem"), false, ""},
+		{"mismatched key", certFile, otherKeyFile, false, ""},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		reloader, err := NewCertificateReloader(test.certFile, test.keyFile, time.Minute, nil)
+		if (err == nil) != test.expectedOk {
+			fail++
+			t.Errorf("NewCertificateReloader(%s) error = %v; expected ok %v", test.name, err, test.expectedOk)
+			continue
+		}
+		if reloader != nil {
+			certificate, _ := reloader.GetCertificate(nil)
+			if cn := commonNameOf(t, certificate); cn != test.expectedCn {
+				fail++
+				t.Errorf("NewCertificateReloader(%s) certificate = %q; expected %q", test.name, cn, test.expectedCn)
+				continue
+			}
+		}
+		pass++
+	}
+	t.Logf("TestNewCertificateReloader: %d pass, %d fail", pass, fail)
+}
+
+func TestCertificateReloaderCheckUpdate(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	start := time.Now().Add(-time.Hour)
+	writeTestCertificate(t, certFile, keyFile, "initial", start)
+
+	reloadErrors := make([]error, 0)
+	reloader, err := NewCertificateReloader(certFile, keyFile, 0, func(err error) { reloadErrors = append(reloadErrors, err) })
+	if err != nil {
+		t.Fatalf("NewCertificateReloader: %v", err)
+	}
+
+	tests := []struct {
+		name             string
+		action           func()
+		expectedCn       string
+		expectedReloads  int
+		expectedFailures int
+	}{
+		{"unchanged", func() {}, "initial", 0, 0},
+		{"rewritten with same mtime", func() { writeTestCertificate(t, certFile, keyFile, "same mtime", start) }, "initial", 0, 0},
+		{"renewed", func() { writeTestCertificate(t, certFile, keyFile, "renewed", start.Add(time.Minute)) }, "renewed", 1, 0},
+		{"invalid cert", func() {
+			writeTestFile(t, certFile, []byte("not a certificate"), start.Add(2*time.Minute))
+		}, "renewed", 2, 1},
+		{"mismatched key", func() {
+			writeTestCertificate(t, certFile, filepath.Join(dir, "unused_key.pem"), "mismatched", start.Add(3*time.Minute))
+		}, "renewed", 3, 2},
+		{"missing key", func() { _ = os.Remove(keyFile) }, "renewed", 4, 3},
+		{"restored", func() { writeTestCertificate(t, certFile, keyFile, "restored", start.Add(4*time.Minute)) }, "restored", 5, 3},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		test.action()
+		certificate, err := reloader.GetCertificate(nil)
+		failures := 0
+		for _, reloadErr := range reloadErrors {
+			if reloadErr != nil {
+				failures++
+			}
+		}
+		cn := commonNameOf(t, certificate)
+		if err != nil || cn != test.expectedCn || len(reloadErrors) != test.expectedReloads || failures != test.expectedFailures {
+			fail++
+			t.Errorf("GetCertificate(%s) = %q, %v, %d reloads, %d failures; expected %q, nil, %d reloads, %d failures",
+				test.name, cn, err, len(reloadErrors), failures, test.expectedCn, test.expectedReloads, test.expectedFailures)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestCertificateReloaderCheckUpdate: %d pass, %d fail", pass, fail)
+}
+
+func TestCertificateReloaderCheckInterval(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	start := time.Now().Add(-time.Hour)
+	writeTestCertificate(t, certFile, keyFile, "initial", start)
+
+	reloader, err := NewCertificateReloader(certFile, keyFile, time.Hour, nil)
+	if err != nil {
+		t.Fatalf("NewCertificateReloader: %v", err)
+	}
+	writeTestCertificate(t, certFile, keyFile, "renewed", start.Add(time.Minute))
+
+	tests := []struct {
+		name       string
+		action     func()
+		expectedCn string
+	}{
+		{"within interval", func() {}, "initial"},
+		{"forced reload", func() { _ = reloader.Reload() }, "renewed"},
+	}
+	pass := 0
+	fail := 0
+	for _, test := range tests {
+		test.action()
+		certificate, _ := reloader.GetCertificate(nil)
+		if cn := commonNameOf(t, certificate); cn != test.expectedCn {
+			fail++
+			t.Errorf("GetCertificate(%s) = %q; expected %q", test.name, cn, test.expectedCn)
+			continue
+		}
+		pass++
+	}
+	t.Logf("TestCertificateReloaderCheckInterval: %d pass, %d fail", pass, fail)
+}
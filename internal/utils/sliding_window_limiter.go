@@ -23,11 +23,22 @@ func NewSlidingWindowLimiter(windowSize time.Duration, maxRequests int) *Sliding
 	}
 }
 
+// SetMaxRequests 修改窗口内允许的最大请求数, 小于等于0表示不限制
+func (l *SlidingWindowLimiter) SetMaxRequests(maxRequests int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.maxRequests = maxRequests
+}
+
 // Allow 检查是否允许请求
 func (l *SlidingWindowLimiter) Allow(key string) bool {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
+	if l.maxRequests <= 0 {
+		return true
+	}
+
 	now := time.Now()
 
 	if _, exists := l.requestRecords[key]; !exists {
@@ -22,8 +22,10 @@ import (
 	"github.com/half-nothing/simple-fsd/internal/interfaces/global"
 	"github.com/half-nothing/simple-fsd/internal/interfaces/http/service"
 	"github.com/half-nothing/simple-fsd/internal/interfaces/log"
+	"github.com/half-nothing/simple-fsd/internal/interfaces/operation"
 	"github.com/half-nothing/simple-fsd/internal/interfaces/queue"
 	. "github.com/half-nothing/simple-fsd/internal/interfaces/voice"
+	"github.com/half-nothing/simple-fsd/internal/metrics"
 	"github.com/half-nothing/simple-fsd/internal/utils"
 )
 
@@ -42,6 +44,7 @@ type VoiceServer struct {
 
 	messageQueue      queue.MessageQueueInterface
 	connectionManager fsd.ConnectionManagerInterface
+	banManager        interfaces.BanManagerInterface
 
 	tcpLimiter       *utils.SlidingWindowLimiter
 	udpLimiter       *utils.SlidingWindowLimiter
@@ -64,6 +67,7 @@ func NewVoiceServer(
 		channels:          make(map[ChannelFrequency]*Channel),
 		messageQueue:      application.MessageQueue(),
 		connectionManager: application.ConnectionManager(),
+		banManager:        application.BanManager(),
 		addressSlicePool: sync.Pool{
 			New: func() interface{} { return make([]*net.UDPAddr, 0, 128) },
 		},
@@ -74,10 +78,36 @@ func NewVoiceServer(
 	server.tcpLimiter = utils.NewSlidingWindowLimiter(time.Minute, server.config.TCPPacketLimit)
 	server.tcpLimiter.StartCleanup(2 * time.Minute)
 	server.ctx, server.cancel = context.WithCancel(context.Background())
+	metrics.VoiceChannels.SetCollector(server.collectChannels)
+	metrics.VoiceTransmitters.SetCollector(server.collectTransmitters)
 	application.Cleaner().Add(NewShutdownCallback(server))
+	server.banManager.AddIssueCallback(server.kickBannedClients)
 	return server
 }
 
+func (s *VoiceServer) collectChannels(emit func(value float64, labelValues ...string)) {
+	s.channelsMutex.RLock()
+	defer s.channelsMutex.RUnlock()
+	emit(float64(len(s.channels)))
+}
+
+func (s *VoiceServer) collectTransmitters(emit func(value float64, labelValues ...string)) {
+	s.channelsMutex.RLock()
+	defer s.channelsMutex.RUnlock()
+	count := 0
+	for _, channel := range s.channels {
+		channel.ClientsMutex.RLock()
+		count += len(channel.Clients)
+		channel.ClientsMutex.RUnlock()
+	}
+	emit(float64(count))
+}
+
+// dropPacket 统计被丢弃的UDP语音包
+func dropPacket(reason string) {
+	metrics.VoiceUdpPackets.Inc("dropped", reason)
+}
+
 func (s *VoiceServer) Start() error {
 	tcpListener, err := net.Listen("tcp", s.config.TCPAddress)
 	if err != nil {
@@ -167,11 +197,12 @@ func (s *VoiceServer) handleTCPConnection(conn net.Conn) {
 		return
 	}
 
-	jwtToken = strings.TrimRight(jwtToken, "\n")
+	// 认证行为JWT令牌, 同一CID有多个FSD连接时可以在令牌后用空格指定呼号
+	jwtToken, callsign, _ := strings.Cut(strings.TrimSpace(jwtToken), " ")
 
-	logger.DebugF("Jwt token received: %s", jwtToken)
+	logger.DebugF("Jwt token received: %s, callsign: %s", jwtToken, callsign)
 
-	clientInfo, connection, err := s.authenticateClient(jwtToken)
+	clientInfo, connection, err := s.authenticateClient(jwtToken, strings.TrimSpace(callsign), conn.RemoteAddr().String())
 	if err != nil {
 		logger.ErrorF("Failed to authenticate client: %s", err.Error())
 		s.sendError(conn, "Authentication failed: "+err.Error())
@@ -249,7 +280,7 @@ func (s *VoiceServer) validateControlMessage(msg *ControlMessage) error {
 	return nil
 }
 
-func (s *VoiceServer) authenticateClient(tokenString string) (*ClientInfo, fsd.ClientInterface, error) {
+func (s *VoiceServer) authenticateClient(tokenString string, callsign string, remoteAddr string) (*ClientInfo, fsd.ClientInterface, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &service.Claims{}, func(token *jwt.Token) (interface{}, error) { return s.jwtSecret, nil })
 
 	if err != nil || !token.Valid {
@@ -261,6 +292,10 @@ func (s *VoiceServer) authenticateClient(tokenString string) (*ClientInfo, fsd.C
 		return nil, nil, fmt.Errorf("invalid token claims")
 	}
 
+	if ban := s.banManager.Check(claims.Cid, remoteAddr, operation.BanScopeVoice); ban != nil {
+		return nil, nil, fmt.Errorf("you are banned, reason: %s", ban.Reason)
+	}
+
 	_, ok = s.clients[claims.Cid]
 	if ok {
 		return nil, nil, fmt.Errorf("client already login")
@@ -275,23 +310,54 @@ func (s *VoiceServer) authenticateClient(tokenString string) (*ClientInfo, fsd.C
 		return nil, nil, errors.New("unknown server error")
 	}
 
-	connections = utils.Filter(connections, func(connection fsd.ClientInterface) bool {
-		return !connection.IsAtc() || !connection.IsAtis()
-	})
-
-	if len(connections) > 1 {
-		s.logger.ErrorF("too many fsd connections found, %d connections", len(connections))
-		return nil, nil, errors.New("found more than one connection, please disconnect some of them until only one remains")
+	connection, err := selectConnection(connections, callsign)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	connection := connections[0]
-
 	return &ClientInfo{
 		Cid:      claims.Cid,
 		Callsign: connection.Callsign(),
 	}, connection, nil
 }
 
+// selectConnection 从同一CID的FSD连接中选出语音使用的连接
+// 指定呼号时使用该连接, 否则忽略ATIS连接, 多个连接时优先使用非观察者连接
+func selectConnection(connections []fsd.ClientInterface, callsign string) (fsd.ClientInterface, error) {
+	connections = utils.Filter(connections, func(connection fsd.ClientInterface) bool {
+		return !connection.Disconnected() && !connection.IsAtis()
+	})
+
+	if callsign != "" {
+		for _, connection := range connections {
+			if strings.EqualFold(connection.Callsign(), callsign) {
+				return connection, nil
+			}
+		}
+		return nil, fmt.Errorf("no fsd connection found with callsign %s", callsign)
+	}
+
+	if len(connections) == 0 {
+		return nil, errors.New("no fsd connection found")
+	}
+	if len(connections) == 1 {
+		return connections[0], nil
+	}
+
+	controllers := utils.Filter(connections, func(connection fsd.ClientInterface) bool {
+		return fsd.GetConnectionKind(connection.Callsign(), connection.IsAtc()) != fsd.ConnectionObserver
+	})
+	if len(controllers) == 1 {
+		return controllers[0], nil
+	}
+
+	callsigns := make([]string, 0, len(connections))
+	for _, connection := range connections {
+		callsigns = append(callsigns, connection.Callsign())
+	}
+	return nil, fmt.Errorf("found %d fsd connections (%s), please specify the callsign after the token", len(connections), strings.Join(callsigns, ", "))
+}
+
 func (s *VoiceServer) handleControlMessage(client *ClientInfo, msg *ControlMessage) {
 	switch msg.Type {
 	case Switch:
@@ -445,21 +511,25 @@ func (s *VoiceServer) handleUDPConnections() {
 
 			if !s.udpLimiter.Allow(addr.String()) {
 				s.logger.WarnF("Drop UDP data due to rate limit exceeded for %s", addr)
+				dropPacket("rate_limit")
 				continue
 			}
 
 			if n == 0 {
 				s.logger.DebugF("Zero packet received from udp://%s", addr)
+				dropPacket("malformed")
 				continue
 			}
 
 			if n > 65507 {
 				s.logger.WarnF("Oversized UDP packet from udp://%s", addr)
+				dropPacket("malformed")
 				continue
 			}
 
 			if !bytes.HasSuffix(buffer[:n], []byte("\n")) {
 				s.logger.WarnF("Receive incomplete voice data from udp://%s", addr)
+				dropPacket("malformed")
 				continue
 			}
 
@@ -467,6 +537,7 @@ func (s *VoiceServer) handleUDPConnections() {
 
 			if len(data) < 9 {
 				s.logger.WarnF("Packet too short from udp://%s: %d bytes", addr, len(data))
+				dropPacket("malformed")
 				continue
 			}
 
@@ -475,18 +546,21 @@ func (s *VoiceServer) handleUDPConnections() {
 			var cid int32
 			if err := binary.Read(reader, binary.LittleEndian, &cid); err != nil {
 				s.logger.WarnF("Failed to read CID from udp://%s: %v", addr, err)
+				dropPacket("malformed")
 				continue
 			}
 
 			var transmitter int8
 			if err := binary.Read(reader, binary.LittleEndian, &transmitter); err != nil {
 				s.logger.WarnF("Failed to read Transmitter from udp://%s: %v", addr, err)
+				dropPacket("malformed")
 				continue
 			}
 
 			var frequency int32
 			if err := binary.Read(reader, binary.LittleEndian, &frequency); err != nil {
 				s.logger.WarnF("Failed to read Frequency from udp://%s: %v", addr, err)
+				dropPacket("malformed")
 				continue
 			}
 
@@ -494,6 +568,7 @@ func (s *VoiceServer) handleUDPConnections() {
 			callsignLength := int8(data[callsignStart])
 			if callsignLength < 0 {
 				s.logger.WarnF("Invalid callsign length from udp://%s: %d", addr, callsignLength)
+				dropPacket("malformed")
 				continue
 			}
 			callsignEnd := callsignStart + 1 + int(callsignLength)
@@ -501,6 +576,7 @@ func (s *VoiceServer) handleUDPConnections() {
 			if n-1 < callsignEnd {
 				s.logger.WarnF("Not enough data for callsign from udp://%s: need %d, have %d",
 					addr, callsignEnd, len(data))
+				dropPacket("malformed")
 				continue
 			}
 
@@ -509,6 +585,7 @@ func (s *VoiceServer) handleUDPConnections() {
 
 			if cid <= 0 || frequency <= 0 || transmitter < 0 {
 				s.logger.WarnF("Invalid voice packet fields from %s: CID=%d, Frequency=%d, Transmitter=%d", addr, cid, frequency, transmitter)
+				dropPacket("malformed")
 				continue
 			}
 
@@ -543,20 +620,24 @@ func (s *VoiceServer) handleUpdateUDPAddress(packet *VoicePacket, addr *net.UDPA
 func (s *VoiceServer) broadcastVoicePacket(packet *VoicePacket, fromAddr *net.UDPAddr, rawData []byte) {
 	client, transmitter := s.handleUpdateUDPAddress(packet, fromAddr)
 	if client == nil || transmitter == nil {
+		dropPacket("unknown_client")
 		return
 	}
 
+	// 不含语音数据的包只用于更新UDP地址
 	if len(packet.Data) == 0 {
 		return
 	}
 
 	if client.Callsign != packet.Callsign {
 		client.Logger.WarnF("Invalid callsign from %s, expected %s, got %s", fromAddr, client.Callsign, packet.Callsign)
+		dropPacket("callsign_mismatch")
 		return
 	}
 
 	if int(transmitter.Frequency) != packet.Frequency {
 		client.Logger.WarnF("frequency mismatch, drop UDP packet, expected %d, got %d", packet.Frequency, transmitter.Frequency)
+		dropPacket("frequency_mismatch")
 		return
 	}
 
@@ -566,6 +647,7 @@ func (s *VoiceServer) broadcastVoicePacket(packet *VoicePacket, fromAddr *net.UD
 
 	if !exists {
 		client.Logger.ErrorF("Channel %d not found from %s", transmitter.Frequency, client.Callsign)
+		dropPacket("channel_not_found")
 		return
 	}
 
@@ -633,6 +715,33 @@ func (s *VoiceServer) cleanupClient(client *ClientInfo) {
 	client.Disconnected.Store(true)
 }
 
+// kickBannedClients 断开命中语音封禁的已认证会话
+func (s *VoiceServer) kickBannedClients(ban *operation.Ban) {
+	if !ban.Covers(operation.BanScopeVoice) {
+		return
+	}
+
+	s.clientsMutex.RLock()
+	clients := make([]*ClientInfo, 0)
+	for _, client := range s.clients {
+		var ip net.IP
+		if address, ok := client.TCPConn.RemoteAddr().(*net.TCPAddr); ok {
+			ip = address.IP
+		}
+		if ban.MatchCid(client.Cid) || ban.MatchIp(ip) {
+			clients = append(clients, client)
+		}
+	}
+	s.clientsMutex.RUnlock()
+
+	for _, client := range clients {
+		s.logger.InfoF("Disconnecting %s(%04d) for ban #%d", client.Callsign, client.Cid, ban.ID)
+		_ = client.SendError(fmt.Sprintf("you are banned, reason: %s", ban.Reason))
+		s.cleanupClient(client)
+		_ = client.TCPConn.Close()
+	}
+}
+
 // 频道管理
 
 func (s *VoiceServer) getOrCreateTransmitter(client *ClientInfo, transmitterID int) *Transmitter {
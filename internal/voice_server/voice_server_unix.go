@@ -6,6 +6,7 @@ import (
 	"net"
 
 	"github.com/half-nothing/simple-fsd/internal/interfaces/voice"
+	"github.com/half-nothing/simple-fsd/internal/metrics"
 	"golang.org/x/net/ipv4"
 )
 
@@ -21,9 +22,15 @@ func (s *VoiceServer) broadcastToTargets(targets []*net.UDPAddr, rawData []byte,
 	}
 
 	n, err := packetConn.WriteBatch(messages, 0)
+	if n > 0 {
+		metrics.VoiceUdpPackets.Add(float64(n), "relayed", "")
+	}
 	if err != nil {
 		client.Logger.ErrorF("Failed to batch send voice data: %v", err)
 	} else if n < len(messages) {
 		client.Logger.WarnF("Partial batch send: %d/%d", n, len(messages))
 	}
+	if n < len(messages) {
+		metrics.VoiceUdpPackets.Add(float64(len(messages)-max(n, 0)), "dropped", "send_failed")
+	}
 }
@@ -7,6 +7,7 @@ import (
 	"sync"
 
 	"github.com/half-nothing/simple-fsd/internal/interfaces/voice"
+	"github.com/half-nothing/simple-fsd/internal/metrics"
 )
 
 func (s *VoiceServer) broadcastToTargets(targets []*net.UDPAddr, rawData []byte, client *voice.ClientInfo) {
@@ -26,7 +27,10 @@ func (s *VoiceServer) broadcastToTargets(targets []*net.UDPAddr, rawData []byte,
 			_, err := s.udpConn.WriteToUDP(rawData, targetAddr)
 			if err != nil {
 				client.Logger.DebugF("Failed to send to %s: %v", targetAddr, err)
+				metrics.VoiceUdpPackets.Inc("dropped", "send_failed")
+				return
 			}
+			metrics.VoiceUdpPackets.Inc("relayed", "")
 		}(addr)
 	}
 